@@ -0,0 +1,128 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// This file implements the StdAbbreviations option, which prints
+// instances of standard library templates in the shorter form that
+// a programmer would write.
+
+// stdDefaultArgs maps the name of a standard library template to its
+// default template arguments, which follow the required arguments.
+// In a default argument $0 is replaced by the first template argument
+// and $1 by the second.
+var stdDefaultArgs = map[string][]string{
+	"vector":             {"std::allocator<$0>"},
+	"deque":              {"std::allocator<$0>"},
+	"list":               {"std::allocator<$0>"},
+	"forward_list":       {"std::allocator<$0>"},
+	"set":                {"std::less<$0>", "std::allocator<$0>"},
+	"multiset":           {"std::less<$0>", "std::allocator<$0>"},
+	"map":                {"std::less<$0>", "std::allocator<std::pair<$0 const, $1> >"},
+	"multimap":           {"std::less<$0>", "std::allocator<std::pair<$0 const, $1> >"},
+	"unordered_set":      {"std::hash<$0>", "std::equal_to<$0>", "std::allocator<$0>"},
+	"unordered_multiset": {"std::hash<$0>", "std::equal_to<$0>", "std::allocator<$0>"},
+	"unordered_map":      {"std::hash<$0>", "std::equal_to<$0>", "std::allocator<std::pair<$0 const, $1> >"},
+	"unordered_multimap": {"std::hash<$0>", "std::equal_to<$0>", "std::allocator<std::pair<$0 const, $1> >"},
+	"basic_string":       {"std::char_traits<$0>", "std::allocator<$0>"},
+	"basic_string_view":  {"std::char_traits<$0>"},
+	"unique_ptr":         {"std::default_delete<$0>"},
+	"priority_queue":     {"std::vector<$0, std::allocator<$0> >", "std::less<$0>"},
+	"queue":              {"std::deque<$0, std::allocator<$0> >"},
+	"stack":              {"std::deque<$0, std::allocator<$0> >"},
+}
+
+// stdAbbreviation returns the abbreviated form of a standard library
+// template instance, or nil if it can not be abbreviated.
+func stdAbbreviation(t *Template) AST {
+	name := stdTemplateName(t)
+	if name == "" {
+		return nil
+	}
+
+	args := make([]string, len(t.Args))
+	for i, arg := range t.Args {
+		args[i] = ASTToString(arg, NoStdInlineNamespaces)
+	}
+
+	full := "std::" + name + "<" + strings.Join(args, ", ")
+	if strings.HasSuffix(full, ">") {
+		full += " "
+	}
+	full += ">"
+	if short, ok := stdTypedefs[full]; ok {
+		return &Qualified{Scope: &Name{Name: "std"}, Name: &Name{Name: short}}
+	}
+
+	defaults := stdDefaultArgs[name]
+	required := len(t.Args) - len(defaults)
+	if len(defaults) == 0 || required < 1 {
+		return nil
+	}
+	n := len(t.Args)
+	for n > required {
+		if args[n-1] != stdExpandDefault(defaults[n-required-1], args[:required]) {
+			break
+		}
+		n--
+	}
+	if n == len(t.Args) {
+		return nil
+	}
+	return &Template{Name: t.Name, Args: t.Args[:n]}
+}
+
+// stdExpandDefault replaces $0 and $1 in a default template argument
+// with the corresponding template arguments. It returns "" if the
+// default refers to an argument that is not present.
+func stdExpandDefault(def string, args []string) string {
+	var b strings.Builder
+	for i := 0; i < len(def); i++ {
+		if def[i] != '$' || i+1 >= len(def) {
+			b.WriteByte(def[i])
+			continue
+		}
+		i++
+		n := int(def[i] - '0')
+		if n >= len(args) {
+			return ""
+		}
+		b.WriteString(args[n])
+		if strings.HasSuffix(args[n], ">") && i+1 < len(def) && def[i+1] == '>' {
+			// Match the way that ASTToString prints
+			// closing brackets.
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// stdTemplateName returns the unqualified name of a template defined
+// in the std namespace, possibly in an inline namespace. It returns
+// "" if the template is not in the std namespace.
+func stdTemplateName(t *Template) string {
+	q, ok := t.Name.(*Qualified)
+	if !ok {
+		return ""
+	}
+	n, ok := q.Name.(*Name)
+	if !ok {
+		return ""
+	}
+	switch scope := q.Scope.(type) {
+	case *Name:
+		if scope.Name != "std" {
+			return ""
+		}
+	case *Qualified:
+		if !isStdInlineNamespace(scope) {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return n.Name
+}
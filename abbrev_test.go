@@ -0,0 +1,41 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestStdAbbreviations(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEE6appendEPKcm", nil, "std::string::append(char const*, unsigned long)"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", nil, "std::__1::vector<int>::push_back(int const&)"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", []Option{NoStdInlineNamespaces}, "std::vector<int>::push_back(int const&)"},
+		{"_Z1fSt3mapIiSsSt4lessIiESaISt4pairIKiSsEEE", nil, "f(std::map<int, std::string>)"},
+		{"_Z1fSt3mapIiiSt7greaterIiESaISt4pairIKiiEEE", nil, "f(std::map<int, int, std::greater<int> >)"},
+		{"_Z1fSt3mapIiiSt7greaterIiESaISt4pairIKiiEEE", []Option{NoAngleSpace}, "f(std::map<int, int, std::greater<int>>)"},
+		{"_Z1fSt6vectorISsSaISsEE", nil, "f(std::vector<std::string>)"},
+		{"_Z1fSt6vectorIS_IiSaIiEESaIS1_EE", nil, "f(std::vector<std::vector<int> >)"},
+		{"_Z1fNSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEE", nil, "f(std::string)"},
+		{"_Z1fNSt7__cxx1112basic_stringIwSt11char_traitsIwESaIwEEE", nil, "f(std::wstring)"},
+		{"_Z1fSt10unique_ptrIiSt14default_deleteIiEE", nil, "f(std::unique_ptr<int>)"},
+		{"_Z1fSt10unique_ptrIiPFvPiEE", nil, "f(std::unique_ptr<int, void (*)(int*)>)"},
+		{"_Z1fSt13basic_ostreamIcSt11char_traitsIcEE", nil, "f(std::ostream)"},
+		{"_Z1fSt13unordered_mapIPciSt4hashIS0_ESt8equal_toIS0_ESaISt4pairIKS0_iEEE", nil, "f(std::unordered_map<char*, int>)"},
+		{"_Z1fSt6vectorIiSaIiEE", []Option{NoTemplateParams}, "f(std::vector)"},
+		{"_Z1fN3foo6vectorIiSaIiEEE", nil, "f(foo::vector<int, std::allocator<int> >)"},
+	}
+
+	for _, test := range tests {
+		options := append([]Option{StdAbbreviations}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, options, got, test.want)
+		}
+	}
+}
@@ -6,12 +6,19 @@ package demangle
 
 import (
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // AST is an abstract syntax tree representing a C++ declaration.
 // This is sufficient for the demangler but is by no means a general C++ AST.
-// This abstract syntax tree is only used for C++ symbols, not Rust symbols.
+// Rust symbols that use the v0 mangling scheme are represented using
+// the node types whose names start with Rust, defined in rustast.go.
 type AST interface {
 	// Internal method to convert to demangled string.
 	print(*printState)
@@ -37,44 +44,479 @@ type AST interface {
 
 // ASTToString returns the demangled name of the AST.
 func ASTToString(a AST, options ...Option) string {
+	s, _ := astToString(a, false, options)
+	return s
+}
+
+// astToString implements ASTToString and ASTToStringWithSpans.
+// If spans is true it also returns the spans of the string.
+func astToString(a AST, spans bool, options []Option) (string, []Span) {
+	return new(printState).astToString(a, spans, false, options, nil)
+}
+
+// printSizeEstimate returns an estimate of the length of the
+// demangled form of a mangled C++ name, so that the printer can
+// allocate its buffer once rather than growing it repeatedly. A
+// demangled name is typically about one and a half times as long as
+// the mangled name, plus the components that each substitution or
+// template parameter refers to. The estimate only looks at the
+// characters of the name, so it is cheap. Overestimating wastes as
+// much memory as underestimating, which costs a second chunk; these
+// factors use the least memory for the names in cases_test.go.
+func printSizeEstimate(name string) int {
+	refs := 0
+	for i := 0; i+1 < len(name); i++ {
+		if (name[i] == 'S' || name[i] == 'T') && (name[i+1] == '_' || isDigit(name[i+1]) || isUpper(name[i+1])) {
+			refs++
+		}
+	}
+	return len(name) + len(name)/2 + 8*refs
+}
+
+// astToString implements the astToString function using ps,
+// so that a Demangler can reuse the memory that ps holds.
+// If nodes is true it records the spans of the nodes in
+// ps.nodeSpans. If ex is not nil it holds the settings of a
+// DemangleOptions that have no Option.
+func (ps *printState) astToString(a AST, spans, nodes bool, options []Option, ex *extraOptions) (string, []Span) {
+	tmax, marker, mopts := markerOptions(options, ex)
+	if tmax > 0 {
+		options = mopts
+	}
+
+	hint := ps.sizeHint
+	a = ps.init(a, options, ex)
+	if a == nil {
+		return "", nil
+	}
+	if ps.max > 0 && hint > ps.max {
+		hint = ps.max
+	}
+	ps.buf.Grow(hint)
+	ps.recordSpans = spans
+	if spans {
+		ps.spanName = baseName(a)
+		if s, ok := ps.spanName.(*Special); ok {
+			ps.spanName = s.Val
+		}
+	}
+	ps.recordNodes = nodes
+	a.print(ps)
+	if nodes {
+		ps.addNodeSpan(a, 0)
+	}
+	s := ps.buf.String()
+	max := ps.max
+	if tmax > 0 && len(s) > tmax {
+		s = truncateAtToken(s, tmax, marker)
+		max = len(s)
+		if len(marker) < tmax {
+			// The spans stop before the marker.
+			max -= len(marker)
+		}
+		if max == 0 {
+			ps.nodeSpans = nil
+			return s, nil
+		}
+	} else if max > 0 && len(s) > max {
+		s = s[:max]
+	} else {
+		max = 0
+	}
+	if nodes {
+		ps.nodeSpans = finishNodeSpans(ps.nodeSpans, max)
+	}
+	if !spans {
+		return s, nil
+	}
+	return s, finishSpans(ps.spans, max)
+}
+
+// newPrintState returns a printState set up for the options,
+// and the AST to print, which is nil if there is nothing to print.
+func newPrintState(a AST, options []Option) (AST, *printState) {
+	ps := new(printState)
+	if a = ps.init(a, options, nil); a == nil {
+		return nil, nil
+	}
+	return a, ps
+}
+
+// init sets up ps for the options and ex, which may be nil,
+// keeping any memory that ps has already allocated. It returns the AST to print, which is nil
+// if there is nothing to print.
+func (ps *printState) init(a AST, options []Option, ex *extraOptions) AST {
 	tparams := true
+	elideTParams := false
 	enclosingParams := true
-	llvmStyle := false
+	llvmExprs := false
+	llvmTypes := false
+	llvmLambdas := false
+	llvmLiterals := false
+	llvmClones := false
+	llvmSpecials := false
+	noVendorQuals := false
+	llvmUnnamed := false
+	noAngleSpace := false
+	westConst := false
+	noStdInline := false
+	stdAbbrev := false
+	noReturnType := false
+	noABITags := false
+	baseOnly := false
+	localOnly := false
+	stableLambdas := false
+	elideConstraints := false
+	thunkOffsets := false
+	tparamNames := false
+	scopeOnly := false
+	anonStyle := 0
+	noMethodQuals := false
+	canonicalSpace := false
+	literalStyle := 0
+	maxTArgLen := 0
+	var namer Namer
+	var hook PrintHook
+	if ex != nil {
+		namer = ex.namer
+		hook = ex.hook
+	}
 	max := 0
+	depth := 2 * defaultMaxDepth
 	for _, o := range options {
 		switch {
 		case o == NoTemplateParams:
 			tparams = false
+		case o == ElideTemplateParams:
+			tparams = false
+			elideTParams = true
 		case o == NoEnclosingParams:
 			enclosingParams = false
 		case o == LLVMStyle:
-			llvmStyle = true
+			llvmExprs = true
+			llvmTypes = true
+			llvmLambdas = true
+			llvmLiterals = true
+			llvmClones = true
+			llvmSpecials = true
+			noAngleSpace = true
+		case o == LLVMExpressions:
+			llvmExprs = true
+		case o == LLVMTypes:
+			llvmTypes = true
+		case o == LLVMLambdas:
+			llvmLambdas = true
+		case o == LLVMLiterals:
+			llvmLiterals = true
+		case o == LLVMClones:
+			llvmClones = true
+		case o == LLVMSpecialNames:
+			llvmSpecials = true
+		case o == NoVendorQualifiers:
+			noVendorQuals = true
+		case o == LLVMUnnamed:
+			llvmUnnamed = true
+		case o == NoAngleSpace:
+			noAngleSpace = true
+		case o == WestConst:
+			westConst = true
+		case o == NoStdInlineNamespaces:
+			noStdInline = true
+		case o == StdAbbreviations:
+			stdAbbrev = true
+		case o == NoReturnType:
+			noReturnType = true
+		case o == NoABITags:
+			noABITags = true
+		case o == BaseNameOnly:
+			baseOnly = true
+		case o == LocalNameOnly:
+			localOnly = true
+		case o == StableLambdaIDs:
+			stableLambdas = true
+		case o == ElideConstraints:
+			elideConstraints = true
+		case o == ThunkOffsets:
+			thunkOffsets = true
+		case o == TemplateParamNames:
+			tparamNames = true
+		case o == ScopeOnly:
+			scopeOnly = true
+		case o == ShortAnonymousNamespace:
+			anonStyle = anonShort
+		case o == NoAnonymousNamespace:
+			anonStyle = anonOmit
+		case o == NoMethodQualifiers:
+			noMethodQuals = true
+		case o == CanonicalSpacing:
+			canonicalSpace = true
+			noAngleSpace = true
+		case o == NoLiteralSuffixes:
+			literalStyle |= literalNoSuffix
+		case o == HexLiterals:
+			literalStyle |= literalHex
+		case o == FunctionalCastLiterals:
+			literalStyle |= literalFunctionalCast
+		case o == BoolLiterals:
+			literalStyle |= literalBool
+		case o == EnumLiteralValues:
+			literalStyle |= literalEnumValue
+		case o == ShortEnumLiterals:
+			literalStyle |= literalEnumShort
+		case o == CharLiterals:
+			literalStyle |= literalChar
+		case isTemplateArgLength(o):
+			maxTArgLen = optionValue(o)
 		case isMaxLength(o):
 			max = maxLength(o)
+		case isMaxDepth(o):
+			depth = 2 * optionValue(o)
 		}
 	}
 
-	ps := printState{
-		tparams:         tparams,
-		enclosingParams: enclosingParams,
-		llvmStyle:       llvmStyle,
-		max:             max,
-		scopes:          1,
+	if localOnly {
+		if l, ok := innermostLocal(a); ok {
+			a = l
+		}
 	}
-	a.print(&ps)
-	s := ps.buf.String()
-	if max > 0 && len(s) > max {
-		s = s[:max]
+	if scopeOnly {
+		a = enclosingScope(a)
+		if a == nil {
+			return nil
+		}
+	}
+	if baseOnly {
+		a = baseName(a)
+	}
+	var paramTmpls map[*Template]string
+	if tparamNames {
+		paramTmpls = paramTemplates(a)
+	}
+
+	buf := ps.buf
+	buf.Reset()
+	*ps = printState{
+		tparams:          tparams,
+		elideTParams:     elideTParams,
+		enclosingParams:  enclosingParams,
+		llvmExprs:        llvmExprs,
+		llvmTypes:        llvmTypes,
+		llvmLambdas:      llvmLambdas,
+		stableLambdas:    stableLambdas,
+		llvmLiterals:     llvmLiterals,
+		llvmClones:       llvmClones,
+		llvmSpecials:     llvmSpecials,
+		noVendorQuals:    noVendorQuals,
+		llvmUnnamed:      llvmUnnamed,
+		noAngleSpace:     noAngleSpace,
+		westConst:        westConst,
+		noStdInline:      noStdInline,
+		stdAbbrev:        stdAbbrev,
+		noReturnType:     noReturnType,
+		noABITags:        noABITags,
+		anonStyle:        anonStyle,
+		noMethodQuals:    noMethodQuals,
+		canonicalSpace:   canonicalSpace,
+		literalStyle:     literalStyle,
+		elideConstraints: elideConstraints,
+		thunkOffsets:     thunkOffsets,
+		tparamNames:      paramTmpls,
+		maxTArgLen:       maxTArgLen,
+		namer:            namer,
+		hook:             hook,
+		max:              max,
+		maxDepth:         depth,
+		scopes:           1,
+		buf:              buf,
+		inner:            ps.inner[:0],
+		printing:         ps.printing[:0],
+	}
+	return a
+}
+
+// baseName returns the unqualified name of the entity that a names,
+// without template arguments or a type, for the BaseNameOnly option.
+func baseName(a AST) AST {
+	for {
+		switch n := a.(type) {
+		case *Typed:
+			a = n.Name
+		case *Qualified:
+			a = n.Name
+		case *Template:
+			a = n.Name
+		case *TaggedName:
+			a = n.Name
+		case *Clone:
+			a = n.Base
+		case *ModuleEntity:
+			a = n.Name
+		case *Friend:
+			a = n.Name
+		case *Special:
+			return &Special{Prefix: n.Prefix, Val: baseName(n.Val), Offsets: n.Offsets}
+		default:
+			return a
+		}
+	}
+}
+
+// enclosingScope returns the scope of the entity that a names,
+// for the ScopeOnly option. It returns nil if the entity is not
+// in a scope.
+func enclosingScope(a AST) AST {
+	for {
+		switch n := a.(type) {
+		case *Typed:
+			a = n.Name
+		case *Qualified:
+			return n.Scope
+		case *Template:
+			a = n.Name
+		case *TaggedName:
+			a = n.Name
+		case *Clone:
+			a = n.Base
+		case *ModuleEntity:
+			a = n.Name
+		case *Friend:
+			a = n.Name
+		case *Special:
+			a = n.Val
+		default:
+			return nil
+		}
 	}
-	return s
+}
+
+// innermostLocal returns a without the enclosing function of the
+// innermost local name, for the LocalNameOnly option. The second
+// result reports whether a includes a local name.
+func innermostLocal(a AST) (AST, bool) {
+	switch n := a.(type) {
+	case *Typed:
+		if name, ok := innermostLocal(n.Name); ok {
+			return &Typed{Name: name, Type: n.Type}, true
+		}
+	case *Qualified:
+		if n.LocalName {
+			return n.Name, true
+		}
+		if scope, ok := innermostLocal(n.Scope); ok {
+			return &Qualified{Scope: scope, Name: n.Name}, true
+		}
+	case *Template:
+		if name, ok := innermostLocal(n.Name); ok {
+			return &Template{Name: name, Args: n.Args}, true
+		}
+	case *TaggedName:
+		if name, ok := innermostLocal(n.Name); ok {
+			return &TaggedName{Name: name, Tag: n.Tag}, true
+		}
+	case *Clone:
+		if base, ok := innermostLocal(n.Base); ok {
+			return &Clone{Base: base, Suffix: n.Suffix}, true
+		}
+	case *Special:
+		if val, ok := innermostLocal(n.Val); ok {
+			return &Special{Prefix: n.Prefix, Val: val, Offsets: n.Offsets}, true
+		}
+	}
+	return a, false
+}
+
+// paramTemplates returns the templates whose arguments are printed
+// as parameter names for the TemplateParamNames option: the templates
+// whose parameters are used in a, and the template of the entity that
+// a names. The map values are filled in by tparamName.
+func paramTemplates(a AST) map[*Template]string {
+	ret := make(map[*Template]string)
+	seen := make(map[AST]bool)
+	a.Traverse(func(a AST) bool {
+		if seen[a] {
+			return false
+		}
+		seen[a] = true
+		if tp, ok := a.(*TemplateParam); ok && tp.Template != nil {
+			ret[tp.Template] = ""
+		}
+		return true
+	})
+
+	for {
+		switch n := a.(type) {
+		case *Typed:
+			a = n.Name
+		case *Qualified:
+			a = n.Name
+		case *TaggedName:
+			a = n.Name
+		case *Clone:
+			a = n.Base
+		case *Special:
+			a = n.Val
+		case *Template:
+			ret[n] = ""
+			return ret
+		default:
+			return ret
+		}
+	}
+}
+
+// tparamName returns the name of template parameter i of t,
+// for the TemplateParamNames option.
+func (ps *printState) tparamName(t *Template, i int) string {
+	prefix := ps.tparamNames[t]
+	if prefix == "" {
+		n := 0
+		for _, p := range ps.tparamNames {
+			if p != "" {
+				n++
+			}
+		}
+		prefix = strings.Repeat(string("TUVWXYZ"[n%7]), n/7+1)
+		ps.tparamNames[t] = prefix
+	}
+	if i == 0 {
+		return prefix
+	}
+	return prefix + strconv.Itoa(i)
 }
 
 // The printState type holds information needed to print an AST.
 type printState struct {
-	tparams         bool // whether to print template parameters
-	enclosingParams bool // whether to print enclosing parameters
-	llvmStyle       bool
-	max             int // maximum output length
+	tparams          bool // whether to print template parameters
+	elideTParams     bool // whether to print omitted template parameters as <...>
+	enclosingParams  bool // whether to print enclosing parameters
+	llvmExprs        bool
+	llvmTypes        bool
+	llvmLambdas      bool                 // whether to print lambdas as 'lambda'
+	stableLambdas    bool                 // whether to identify lambdas by a hash
+	llvmLiterals     bool                 // whether to print nullptr literals as nullptr
+	llvmClones       bool                 // whether to print clone suffixes as (.suffix)
+	llvmSpecials     bool                 // whether to use LLVM names for special symbols
+	noVendorQuals    bool                 // whether to omit vendor qualifiers
+	llvmUnnamed      bool                 // whether to use __unnamed_N and $_N
+	noAngleSpace     bool                 // whether to print >> rather than > >
+	westConst        bool                 // whether to print const before the type
+	noStdInline      bool                 // whether to omit std inline namespaces
+	stdAbbrev        bool                 // whether to abbreviate std templates
+	noReturnType     bool                 // whether to omit function return types
+	noABITags        bool                 // whether to omit ABI tags
+	anonStyle        int                  // how to print anonymous namespaces
+	noMethodQuals    bool                 // whether to omit member function qualifiers
+	canonicalSpace   bool                 // whether to avoid redundant spaces
+	literalStyle     int                  // how to print integer literals
+	elideConstraints bool                 // whether to print requires clauses as "requires …"
+	thunkOffsets     bool                 // whether to print the adjustments made by thunks
+	tparamNames      map[*Template]string // templates printed with parameter names
+	maxTArgLen       int                  // maximum template argument length
+	namer            Namer                // names unnamed types and lambdas
+	hook             PrintHook            // overrides printing nodes
+	max              int                  // maximum output length
+	sizeHint         int                  // expected output length, cleared by init; see printSizeEstimate
+	maxDepth         int                  // maximum nesting of printed nodes
 
 	// The scopes field is used to avoid unnecessary parentheses
 	// around expressions that use > (or >>). It is incremented if
@@ -87,9 +529,17 @@ type printState struct {
 	// inside some other set of parentheses.
 	scopes int
 
-	buf  strings.Builder
+	buf  chunkBuffer
 	last byte // Last byte written to buffer.
 
+	// If the out field is not nil, the output is periodically
+	// moved from buf to out, for ToWriter. The flushed field is
+	// the number of bytes moved, and outErr is the first error
+	// returned by out.
+	out     io.Writer
+	outErr  error
+	flushed int
+
 	// The inner field is a list of items to print for a type
 	// name.  This is used by types to implement the inside-out
 	// C++ declaration syntax.
@@ -99,20 +549,54 @@ type printState struct {
 	// printing.  This avoids endless recursion if a substitution
 	// reference creates a cycle in the graph.
 	printing []AST
+
+	// The spans field records the spans of the output if
+	// recordSpans is set. The spanName field is the name of the
+	// entity, and inScope is set while printing a scope, as
+	// nested scopes are not recorded separately.
+	recordSpans bool
+	spans       []Span
+	spanName    AST
+	inScope     bool
+
+	// The nodeSpans field records the output printed for each
+	// node if recordNodes is set.
+	recordNodes bool
+	nodeSpans   []NodeSpan
+
+	// The namerScope field is the scope of the name being
+	// printed, to pass to namer.
+	namerScope AST
+
+	// The hookSkip field is a node to print without calling hook,
+	// for HookPrinter.PrintDefault.
+	hookSkip AST
 }
 
 // writeByte adds a byte to the string being printed.
 func (ps *printState) writeByte(b byte) {
+	if b == ' ' && ps.canonicalSpace && (ps.last == ' ' || ps.buf.Len()+ps.flushed == 0) {
+		return
+	}
 	ps.last = b
 	ps.buf.WriteByte(b)
+	if ps.out != nil && ps.buf.Len() >= flushSize {
+		ps.flush()
+	}
 }
 
 // writeString adds a string to the string being printed.
 func (ps *printState) writeString(s string) {
+	if ps.canonicalSpace && len(s) > 0 && s[0] == ' ' && (ps.last == ' ' || ps.buf.Len()+ps.flushed == 0) {
+		s = s[1:]
+	}
 	if len(s) > 0 {
 		ps.last = s[len(s)-1]
 	}
 	ps.buf.WriteString(s)
+	if ps.out != nil && ps.buf.Len() >= flushSize {
+		ps.flush()
+	}
 }
 
 // Print an AST.
@@ -120,6 +604,18 @@ func (ps *printState) print(a AST) {
 	if ps.max > 0 && ps.buf.Len() > ps.max {
 		return
 	}
+	if ps.outErr != nil {
+		// Nothing more will be written.
+		return
+	}
+
+	if len(ps.printing) >= ps.maxDepth {
+		// The AST is nested too deeply to print. The
+		// demangler doesn't build such an AST, but one
+		// built by hand might be.
+		ps.writeString("...")
+		return
+	}
 
 	c := 0
 	for _, v := range ps.printing {
@@ -137,13 +633,61 @@ func (ps *printState) print(a AST) {
 			}
 		}
 	}
+
 	ps.printing = append(ps.printing, a)
 
-	a.print(ps)
+	start := ps.buf.Len()
+	if ps.hook != nil && ps.callHook(a) {
+		// The hook printed a.
+	} else if ps.recordSpans && a == ps.spanName {
+		// Only record the first appearance of the name.
+		ps.spanName = nil
+		a.print(ps)
+		ps.addSpan(SpanName, start)
+	} else {
+		a.print(ps)
+	}
+
+	if ps.recordNodes {
+		ps.addNodeSpan(a, start)
+	}
 
 	ps.printing = ps.printing[:len(ps.printing)-1]
 }
 
+// callHook calls ps.hook for a, and reports whether it printed a.
+func (ps *printState) callHook(a AST) bool {
+	if a == ps.hookSkip {
+		ps.hookSkip = nil
+		return false
+	}
+	return ps.hook(a, &HookPrinter{ps: ps})
+}
+
+// A HookPrinter is passed to a PrintHook to print a node.
+type HookPrinter struct {
+	ps *printState
+}
+
+// WriteString writes a string to the output.
+func (hp *HookPrinter) WriteString(s string) {
+	hp.ps.writeString(s)
+}
+
+// Print prints a node, calling the hook for it and its children.
+func (hp *HookPrinter) Print(a AST) {
+	hp.ps.print(a)
+}
+
+// PrintDefault prints a node as it is normally printed, without
+// calling the hook for the node itself. The hook is still called
+// for its children.
+func (hp *HookPrinter) PrintDefault(a AST) {
+	hp.ps.hookSkip = a
+	hp.ps.print(a)
+	hp.ps.hookSkip = nil
+}
+
 // printList prints a list of AST values separated by commas,
 // optionally skipping some.
 func (ps *printState) printList(args []AST, skip func(AST) bool) {
@@ -152,12 +696,16 @@ func (ps *printState) printList(args []AST, skip func(AST) bool) {
 		if skip != nil && skip(a) {
 			continue
 		}
+		if el, ok := a.(*ExprList); ok && len(el.Exprs) == 0 {
+			// The expansion of an empty argument pack.
+			continue
+		}
 		if !first {
 			ps.writeString(", ")
 		}
 
 		needsParen := false
-		if ps.llvmStyle {
+		if ps.llvmExprs {
 			if p, ok := a.(hasPrec); ok {
 				if p.prec() >= precComma {
 					needsParen = true
@@ -228,9 +776,29 @@ type hasPrec interface {
 // Name is an unqualified name.
 type Name struct {
 	Name string
+
+	// The Internal field is true if the name is mangled as a
+	// name with internal linkage, such as a static function.
+	Internal bool
 }
 
+// anonymousNamespace is the name we use for an anonymous namespace.
+const anonymousNamespace = "(anonymous namespace)"
+
+// Values for the anonStyle field of printState.
+const (
+	anonLong  = iota // (anonymous namespace)
+	anonShort        // {anonymous}
+	anonOmit         // omit the namespace
+)
+
 func (n *Name) print(ps *printState) {
+	if ps.anonStyle != anonLong && n.Name == anonymousNamespace {
+		if ps.anonStyle == anonShort {
+			ps.writeString("{anonymous}")
+		}
+		return
+	}
 	ps.writeString(n.Name)
 }
 
@@ -269,8 +837,18 @@ func (t *Typed) print(ps *printState) {
 	holdInner := ps.inner
 	defer func() { ps.inner = holdInner }()
 
+	typ := t.Type
+	if ps.noMethodQuals {
+		if mwq, ok := typ.(*MethodWithQualifiers); ok {
+			typ = mwq.Method
+		}
+	}
+	if ps.noReturnType {
+		typ = withoutReturnType(typ)
+	}
+
 	ps.inner = []AST{t}
-	ps.print(t.Type)
+	ps.print(typ)
 	if len(ps.inner) > 0 {
 		// The type did not print the name; print it now in
 		// the default location.
@@ -279,6 +857,22 @@ func (t *Typed) print(ps *printState) {
 	}
 }
 
+// withoutReturnType returns a function type without its return type,
+// for the NoReturnType option. Other types are returned unchanged.
+func withoutReturnType(a AST) AST {
+	switch a := a.(type) {
+	case *FunctionType:
+		if a.Return != nil {
+			return &FunctionType{Args: a.Args, ForLocalName: a.ForLocalName}
+		}
+	case *MethodWithQualifiers:
+		if m := withoutReturnType(a.Method); m != a.Method {
+			return &MethodWithQualifiers{Method: m, Qualifiers: a.Qualifiers, RefQualifier: a.RefQualifier}
+		}
+	}
+	return a
+}
+
 func (t *Typed) printInner(ps *printState) {
 	ps.print(t.Name)
 }
@@ -333,12 +927,46 @@ type Qualified struct {
 	// demangler prints a local name slightly differently.  We
 	// keep track of this for compatibility.
 	LocalName bool // A full local name encoding
+
+	// The Discriminator field is set for a local name that is
+	// not the first entity with the same name in the function.
+	// It is the number of earlier entities with that name.
+	Discriminator int
 }
 
 func (q *Qualified) print(ps *printState) {
-	ps.print(q.Scope)
+	if ps.noStdInline && isStdInlineNamespace(q) {
+		ps.writeString("std")
+		return
+	}
+	if ps.anonStyle == anonOmit {
+		if n, ok := q.Scope.(*Name); ok && n.Name == anonymousNamespace {
+			ps.print(q.Name)
+			return
+		}
+		if n, ok := q.Name.(*Name); ok && n.Name == anonymousNamespace {
+			ps.print(q.Scope)
+			return
+		}
+	}
+	if ps.inScope {
+		ps.print(q.Scope)
+	} else {
+		ps.inScope = true
+		start := ps.buf.Len()
+		ps.print(q.Scope)
+		ps.inScope = false
+		ps.addSpan(SpanScope, start)
+	}
 	ps.writeString("::")
-	ps.print(q.Name)
+	if ps.namer != nil {
+		scope := ps.namerScope
+		ps.namerScope = q.Scope
+		ps.print(q.Name)
+		ps.namerScope = scope
+	} else {
+		ps.print(q.Name)
+	}
 }
 
 func (q *Qualified) Traverse(fn func(AST) bool) {
@@ -363,7 +991,7 @@ func (q *Qualified) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if name == nil {
 		name = q.Name
 	}
-	q = &Qualified{Scope: scope, Name: name, LocalName: q.LocalName}
+	q = &Qualified{Scope: scope, Name: name, LocalName: q.LocalName, Discriminator: q.Discriminator}
 	if r := fn(q); r != nil {
 		return r
 	}
@@ -379,6 +1007,9 @@ func (q *Qualified) goString(indent int, field string) string {
 	if q.LocalName {
 		s = " LocalName: true"
 	}
+	if q.Discriminator > 0 {
+		s += fmt.Sprintf(" Discriminator: %d", q.Discriminator)
+	}
 	return fmt.Sprintf("%*s%sQualified:%s\n%s\n%s", indent, "", field,
 		s, q.Scope.goString(indent+2, "Scope: "),
 		q.Name.goString(indent+2, "Name: "))
@@ -388,6 +1019,29 @@ func (q *Qualified) prec() precedence {
 	return precPrimary
 }
 
+// stdInlineNamespaces is the set of inline namespaces used by
+// implementations of the C++ standard library. libstdc++ uses
+// __cxx11, libc++ uses __1 and __2 by default, Android uses __ndk1,
+// and Chromium uses __u and Cr.
+var stdInlineNamespaces = map[string]bool{
+	"__cxx11": true,
+	"__1":     true,
+	"__2":     true,
+	"__ndk1":  true,
+	"__u":     true,
+	"Cr":      true,
+}
+
+// isStdInlineNamespace reports whether q is one of the
+// stdInlineNamespaces.
+func isStdInlineNamespace(q *Qualified) bool {
+	if s, ok := q.Scope.(*Name); !ok || s.Name != "std" {
+		return false
+	}
+	n, ok := q.Name.(*Name)
+	return ok && stdInlineNamespaces[n.Name]
+}
+
 // Template is a template with arguments.
 type Template struct {
 	Name AST
@@ -395,6 +1049,13 @@ type Template struct {
 }
 
 func (t *Template) print(ps *printState) {
+	if ps.stdAbbrev && ps.tparams {
+		if a := stdAbbreviation(t); a != nil {
+			ps.print(a)
+			return
+		}
+	}
+
 	// Inner types apply to the template as a whole, they don't
 	// cross over into the template.
 	holdInner := ps.inner
@@ -403,7 +1064,7 @@ func (t *Template) print(ps *printState) {
 	ps.inner = nil
 	ps.print(t.Name)
 
-	if !ps.tparams {
+	if !ps.tparams && !ps.elideTParams {
 		// Do not print template parameters.
 		return
 	}
@@ -411,21 +1072,83 @@ func (t *Template) print(ps *printState) {
 	if ps.last == '<' {
 		ps.writeByte(' ')
 	}
+	if !ps.tparams {
+		start := ps.buf.Len()
+		ps.writeString("<...>")
+		ps.addSpan(SpanTemplateArgs, start)
+		return
+	}
 
 	scopes := ps.scopes
 	ps.scopes = 0
 
+	inScope := ps.inScope
+	ps.inScope = false
+	start := ps.buf.Len()
+
 	ps.writeByte('<')
-	ps.printList(t.Args, ps.isEmpty)
-	if ps.last == '>' && !ps.llvmStyle {
+	args := t.Args
+	if _, ok := ps.tparamNames[t]; ok {
+		args = make([]AST, len(t.Args))
+		for i, arg := range t.Args {
+			name := ps.tparamName(t, i)
+			if _, ok := arg.(*ArgumentPack); ok {
+				name += "..."
+			}
+			args[i] = &Name{Name: name}
+		}
+	}
+	if ps.maxTArgLen > 0 {
+		args = ps.capTemplateArgs(args)
+	}
+	ps.printList(args, ps.isEmpty)
+	if ps.last == '>' && !ps.noAngleSpace {
 		// Avoid syntactic ambiguity in old versions of C++.
 		ps.writeByte(' ')
 	}
 	ps.writeByte('>')
 
+	ps.addSpan(SpanTemplateArgs, start)
+	ps.inScope = inScope
+
 	ps.scopes = scopes
 }
 
+// capTemplateArgs returns args with each argument that is longer
+// than ps.maxTArgLen when printed replaced by "…".
+func (ps *printState) capTemplateArgs(args []AST) []AST {
+	var ret []AST
+	for i, a := range args {
+		// Print the argument into a separate buffer,
+		// stopping once it is too long.
+		sub := *ps
+		sub.buf = chunkBuffer{}
+		sub.last = 0
+		sub.inner = nil
+		sub.recordSpans = false
+		sub.spans = nil
+		sub.recordNodes = false
+		sub.nodeSpans = nil
+		sub.maxTArgLen = 0
+		sub.out = nil
+		sub.flushed = 0
+		sub.max = ps.maxTArgLen
+		sub.print(a)
+		if sub.buf.Len() <= ps.maxTArgLen {
+			continue
+		}
+
+		if ret == nil {
+			ret = append([]AST(nil), args...)
+		}
+		ret[i] = &Name{Name: "…"}
+	}
+	if ret == nil {
+		return args
+	}
+	return ret
+}
+
 func (t *Template) Traverse(fn func(AST) bool) {
 	if fn(t) {
 		t.Name.Traverse(fn)
@@ -486,7 +1209,7 @@ func (t *Template) goString(indent int, field string) string {
 // TemplateParam is a template parameter.  The Template field is
 // filled in while parsing the demangled string.  We don't normally
 // see these while printing--they are replaced by the simplify
-// function.
+// function, except with the TemplateParamNames option.
 type TemplateParam struct {
 	Index    int
 	Template *Template
@@ -499,6 +1222,10 @@ func (tp *TemplateParam) print(ps *printState) {
 	if tp.Index >= len(tp.Template.Args) {
 		panic("TemplateParam Index out of bounds")
 	}
+	if _, ok := ps.tparamNames[tp.Template]; ok {
+		ps.writeString(ps.tparamName(tp.Template, tp.Index))
+		return
+	}
 	ps.print(tp.Template.Args[tp.Index])
 }
 
@@ -530,7 +1257,7 @@ type LambdaAuto struct {
 func (la *LambdaAuto) print(ps *printState) {
 	// We print the index plus 1 because that is what the standard
 	// demangler does.
-	if ps.llvmStyle {
+	if ps.llvmLambdas {
 		ps.writeString("auto")
 	} else {
 		fmt.Fprintf(&ps.buf, "auto:%d", la.Index+1)
@@ -619,6 +1346,24 @@ type Qualifiers struct {
 func (qs *Qualifiers) print(ps *printState) {
 	first := true
 	for _, q := range qs.Qualifiers {
+		if ps.llvmTypes && isExceptionSpec(q) {
+			// LLVM prints the exception specification
+			// last, as it is written in C++.
+			continue
+		}
+		if !first {
+			ps.writeByte(' ')
+		}
+		q.print(ps)
+		first = false
+	}
+	if !ps.llvmTypes {
+		return
+	}
+	for _, q := range qs.Qualifiers {
+		if !isExceptionSpec(q) {
+			continue
+		}
 		if !first {
 			ps.writeByte(' ')
 		}
@@ -627,6 +1372,13 @@ func (qs *Qualifiers) print(ps *printState) {
 	}
 }
 
+// isExceptionSpec reports whether a qualifier is an exception
+// specification.
+func isExceptionSpec(a AST) bool {
+	q, ok := a.(*Qualifier)
+	return ok && (q.Name == "noexcept" || q.Name == "throw")
+}
+
 func (qs *Qualifiers) Traverse(fn func(AST) bool) {
 	if fn(qs) {
 		for _, q := range qs.Qualifiers {
@@ -753,6 +1505,23 @@ type TypeWithQualifiers struct {
 }
 
 func (twq *TypeWithQualifiers) print(ps *printState) {
+	if ps.westConst && isCVQualifiers(twq.Qualifiers) {
+		if at, ok := twq.Base.(*ArrayType); ok {
+			// The qualifiers apply to the element type.
+			ps.print(&ArrayType{
+				Dimension: at.Dimension,
+				Element:   &TypeWithQualifiers{Base: at.Element, Qualifiers: twq.Qualifiers},
+			})
+			return
+		}
+		if isWestConstBase(twq.Base) {
+			ps.print(twq.Qualifiers)
+			ps.writeByte(' ')
+			ps.print(twq.Base)
+			return
+		}
+	}
+
 	// Give the base type a chance to print the inner types.
 	ps.inner = append(ps.inner, twq)
 	ps.print(twq.Base)
@@ -764,6 +1533,37 @@ func (twq *TypeWithQualifiers) print(ps *printState) {
 	}
 }
 
+// isWestConstBase reports whether the qualifiers of a type may be
+// printed before it, which is the case if the type is printed as a
+// single name rather than as a declarator.
+func isWestConstBase(a AST) bool {
+	switch a := a.(type) {
+	case *BuiltinType, *Name, *Qualified, *Template, *TaggedName, *ElaboratedType, *Decltype, *FixedType, *BinaryFP, *BitIntType, *ComplexType, *ImaginaryType:
+		return true
+	case *TemplateParam:
+		if a.Template != nil && a.Index < len(a.Template.Args) {
+			return isWestConstBase(a.Template.Args[a.Index])
+		}
+	}
+	return false
+}
+
+// isCVQualifiers reports whether a is a list of const and volatile
+// qualifiers, with no vendor qualifiers.
+func isCVQualifiers(a AST) bool {
+	qs, ok := a.(*Qualifiers)
+	if !ok {
+		return false
+	}
+	for _, q := range qs.Qualifiers {
+		q, ok := q.(*Qualifier)
+		if !ok || (q.Name != "const" && q.Name != "volatile") || len(q.Exprs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Print qualifiers as an inner type by just printing the qualifiers.
 func (twq *TypeWithQualifiers) printInner(ps *printState) {
 	ps.writeByte(' ')
@@ -820,27 +1620,30 @@ func (mwq *MethodWithQualifiers) print(ps *printState) {
 	ps.inner = append(ps.inner, mwq)
 	ps.print(mwq.Method)
 	if len(ps.inner) > 0 {
-		if mwq.Qualifiers != nil {
-			ps.writeByte(' ')
-			ps.print(mwq.Qualifiers)
-		}
-		if mwq.RefQualifier != "" {
-			ps.writeByte(' ')
-			ps.writeString(mwq.RefQualifier)
-		}
+		mwq.printInner(ps)
 		ps.inner = ps.inner[:len(ps.inner)-1]
 	}
 }
 
 func (mwq *MethodWithQualifiers) printInner(ps *printState) {
+	start := -1
 	if mwq.Qualifiers != nil {
 		ps.writeByte(' ')
+		start = ps.buf.Len()
 		ps.print(mwq.Qualifiers)
 	}
 	if mwq.RefQualifier != "" {
-		ps.writeByte(' ')
+		if !ps.canonicalSpace {
+			ps.writeByte(' ')
+		}
+		if start < 0 {
+			start = ps.buf.Len()
+		}
 		ps.writeString(mwq.RefQualifier)
 	}
+	if start >= 0 {
+		ps.addSpan(SpanQualifiers, start)
+	}
 }
 
 func (mwq *MethodWithQualifiers) Traverse(fn func(AST) bool) {
@@ -900,7 +1703,7 @@ type BuiltinType struct {
 
 func (bt *BuiltinType) print(ps *printState) {
 	name := bt.Name
-	if ps.llvmStyle && name == "decltype(nullptr)" {
+	if ps.llvmLiterals && name == "decltype(nullptr)" {
 		name = "std::nullptr_t"
 	}
 	ps.writeString(name)
@@ -1250,7 +2053,11 @@ type VendorQualifier struct {
 }
 
 func (vq *VendorQualifier) print(ps *printState) {
-	if ps.llvmStyle {
+	if ps.noVendorQuals {
+		ps.print(vq.Type)
+		return
+	}
+	if ps.llvmTypes {
 		ps.print(vq.Type)
 		vq.printInner(ps)
 	} else {
@@ -1289,7 +2096,7 @@ func (vq *VendorQualifier) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if typ == nil {
 		typ = vq.Type
 	}
-	vq = &VendorQualifier{Qualifier: qualifier, Type: vq.Type}
+	vq = &VendorQualifier{Qualifier: qualifier, Type: typ}
 	if r := fn(vq); r != nil {
 		return r
 	}
@@ -1409,18 +2216,20 @@ type FunctionType struct {
 
 func (ft *FunctionType) print(ps *printState) {
 	retType := ft.Return
-	if ft.ForLocalName && (!ps.enclosingParams || !ps.llvmStyle) {
+	if ft.ForLocalName && (!ps.enclosingParams || !ps.llvmTypes) {
 		retType = nil
 	}
 	if retType != nil {
 		// Pass the return type as an inner type in order to
 		// print the arguments in the right location.
 		ps.inner = append(ps.inner, ft)
+		start := ps.buf.Len()
 		ps.print(retType)
 		if len(ps.inner) == 0 {
 			// Everything was printed.
 			return
 		}
+		ps.addSpan(SpanReturnType, start)
 		ps.inner = ps.inner[:len(ps.inner)-1]
 		ps.writeByte(' ')
 	}
@@ -1465,6 +2274,7 @@ func (ft *FunctionType) printArgs(ps *printState) {
 		ps.endScope(')')
 	}
 
+	start := ps.buf.Len()
 	ps.startScope('(')
 	if !ft.ForLocalName || ps.enclosingParams {
 		first := true
@@ -1480,6 +2290,7 @@ func (ft *FunctionType) printArgs(ps *printState) {
 		}
 	}
 	ps.endScope(')')
+	ps.addSpan(SpanParams, start)
 
 	ps.inner = save
 	ps.printInner(false)
@@ -1572,7 +2383,7 @@ type FunctionParam struct {
 func (fp *FunctionParam) print(ps *printState) {
 	if fp.Index == 0 {
 		ps.writeString("this")
-	} else if ps.llvmStyle {
+	} else if ps.llvmExprs {
 		if fp.Index == 1 {
 			ps.writeString("fp")
 		} else {
@@ -1813,7 +2624,7 @@ func (vt *VectorType) print(ps *printState) {
 
 func (vt *VectorType) printInner(ps *printState) {
 	end := byte(')')
-	if ps.llvmStyle {
+	if ps.llvmTypes {
 		ps.writeString(" vector[")
 		end = ']'
 	} else {
@@ -1911,7 +2722,7 @@ type Decltype struct {
 
 func (dt *Decltype) print(ps *printState) {
 	ps.writeString("decltype")
-	if !ps.llvmStyle {
+	if !ps.llvmExprs {
 		ps.writeString(" ")
 	}
 	ps.startScope('(')
@@ -1956,6 +2767,7 @@ type Operator struct {
 }
 
 func (op *Operator) print(ps *printState) {
+	start := ps.buf.Len()
 	ps.writeString("operator")
 	if isLower(op.Name[0]) {
 		ps.writeByte(' ')
@@ -1963,6 +2775,7 @@ func (op *Operator) print(ps *printState) {
 	n := op.Name
 	n = strings.TrimSuffix(n, " ")
 	ps.writeString(n)
+	ps.addSpan(SpanOperator, start)
 }
 
 func (op *Operator) Traverse(fn func(AST) bool) {
@@ -1991,7 +2804,8 @@ func (op *Operator) prec() precedence {
 // Constructor is a constructor.
 type Constructor struct {
 	Name AST
-	Base AST // base class of inheriting constructor
+	Base AST      // base class of inheriting constructor
+	Kind CtorKind // which variant of the constructor
 }
 
 func (c *Constructor) print(ps *printState) {
@@ -2026,7 +2840,7 @@ func (c *Constructor) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if base == nil {
 		base = c.Base
 	}
-	c = &Constructor{Name: name, Base: base}
+	c = &Constructor{Name: name, Base: base, Kind: c.Kind}
 	if r := fn(c); r != nil {
 		return r
 	}
@@ -2050,6 +2864,7 @@ func (c *Constructor) goString(indent int, field string) string {
 // Destructor is a destructor.
 type Destructor struct {
 	Name AST
+	Kind DtorKind // which variant of the destructor
 }
 
 func (d *Destructor) print(ps *printState) {
@@ -2071,7 +2886,7 @@ func (d *Destructor) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if name == nil {
 		return fn(d)
 	}
-	d = &Destructor{Name: name}
+	d = &Destructor{Name: name, Kind: d.Kind}
 	if r := fn(d); r != nil {
 		return r
 	}
@@ -2141,6 +2956,9 @@ type TaggedName struct {
 
 func (t *TaggedName) print(ps *printState) {
 	ps.print(t.Name)
+	if ps.noABITags {
+		return
+	}
 	ps.writeString("[abi:")
 	ps.print(t.Tag)
 	ps.writeByte(']')
@@ -2193,9 +3011,17 @@ type PackExpansion struct {
 
 func (pe *PackExpansion) print(ps *printState) {
 	// We normally only get here if the simplify function was
-	// unable to locate and expand the pack.
-	if pe.Pack == nil {
-		if ps.llvmStyle {
+	// unable to locate and expand the pack, or for the
+	// TemplateParamNames option.
+	if ps.tparamNames != nil {
+		if _, ok := pe.Base.(*TemplateParam); ok {
+			ps.print(pe.Base)
+		} else {
+			parenthesize(ps, pe.Base)
+		}
+		ps.writeString("...")
+	} else if pe.Pack == nil {
+		if ps.llvmExprs {
 			ps.print(pe.Base)
 		} else {
 			parenthesize(ps, pe.Base)
@@ -2306,7 +3132,7 @@ type SizeofPack struct {
 }
 
 func (sp *SizeofPack) print(ps *printState) {
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		ps.writeString("sizeof...")
 		ps.startScope('(')
 		ps.print(sp.Pack)
@@ -2573,7 +3399,7 @@ func (ttp *TemplateTemplateParam) print(ps *printState) {
 
 	if ttp.Constraint != nil {
 		ps.writeString(" requires ")
-		ps.print(ttp.Constraint)
+		ps.printConstraint(ttp.Constraint)
 	}
 }
 
@@ -2786,8 +3612,10 @@ type Cast struct {
 }
 
 func (c *Cast) print(ps *printState) {
+	start := ps.buf.Len()
 	ps.writeString("operator ")
 	ps.print(c.To)
+	ps.addSpan(SpanOperator, start)
 }
 
 func (c *Cast) Traverse(fn func(AST) bool) {
@@ -2831,7 +3659,7 @@ func parenthesize(ps *printState, val AST) {
 	switch v := val.(type) {
 	case *Name, *InitializerList:
 	case *FunctionParam:
-		if ps.llvmStyle {
+		if ps.llvmExprs {
 			paren = true
 		}
 	case *Qualified:
@@ -2908,7 +3736,7 @@ func (u *Unary) print(ps *printState) {
 
 	// Don't print the argument list when taking the address of a
 	// function.
-	if !ps.llvmStyle {
+	if !ps.llvmExprs {
 		if op != nil && op.Name == "&" {
 			if t, ok := expr.(*Typed); ok {
 				if _, ok := t.Type.(*FunctionType); ok {
@@ -2919,7 +3747,7 @@ func (u *Unary) print(ps *printState) {
 	}
 
 	if u.Suffix {
-		if ps.llvmStyle {
+		if ps.llvmExprs {
 			wantParens := true
 			opPrec := precUnary
 			if op != nil {
@@ -2944,7 +3772,7 @@ func (u *Unary) print(ps *printState) {
 
 	if op != nil {
 		ps.writeString(op.Name)
-		if ps.llvmStyle && op.Name == "noexcept" {
+		if ps.llvmExprs && op.Name == "noexcept" {
 			ps.writeByte(' ')
 		}
 	} else if c, ok := u.Op.(*Cast); ok {
@@ -2970,7 +3798,7 @@ func (u *Unary) print(ps *printState) {
 			ps.startScope('(')
 			ps.print(expr)
 			ps.endScope(')')
-		} else if ps.llvmStyle {
+		} else if ps.llvmExprs {
 			var wantParens bool
 			switch {
 			case op == nil:
@@ -3129,7 +3957,7 @@ func (b *Binary) print(ps *printState) {
 			// initializer chains.
 			ps.print(b.Right)
 		} else {
-			if ps.llvmStyle {
+			if ps.llvmExprs {
 				ps.writeString(" = ")
 				ps.print(b.Right)
 			} else {
@@ -3144,7 +3972,7 @@ func (b *Binary) print(ps *printState) {
 	// uses the greater-than operator, so that it does not get
 	// confused with the '>' that ends template parameters.
 	needsOuterParen := op != nil && (op.Name == ">" || op.Name == ">>")
-	if ps.llvmStyle && ps.scopes > 0 {
+	if ps.llvmExprs && ps.scopes > 0 {
 		needsOuterParen = false
 	}
 	if needsOuterParen {
@@ -3154,8 +3982,8 @@ func (b *Binary) print(ps *printState) {
 	left := b.Left
 
 	skipParens := false
-	addSpaces := ps.llvmStyle
-	if ps.llvmStyle && op != nil {
+	addSpaces := ps.llvmExprs
+	if ps.llvmExprs && op != nil {
 		switch op.Name {
 		case ".", "->", "->*":
 			addSpaces = false
@@ -3176,14 +4004,14 @@ func (b *Binary) print(ps *printState) {
 				left = ty.Name
 			}
 		}
-		if ps.llvmStyle {
+		if ps.llvmExprs {
 			skipParens = true
 		}
 	}
 
 	if skipParens {
 		ps.print(left)
-	} else if ps.llvmStyle {
+	} else if ps.llvmExprs {
 		prec := precPrimary
 		if p, ok := left.(hasPrec); ok {
 			prec = p.prec()
@@ -3226,7 +4054,7 @@ func (b *Binary) print(ps *printState) {
 		ps.print(b.Op)
 	}
 
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		prec := precPrimary
 		if p, ok := b.Right.(hasPrec); ok {
 			prec = p.prec()
@@ -3325,7 +4153,7 @@ func (t *Trinary) print(ps *printState) {
 			// initializer chains.
 			ps.print(t.Third)
 		} else {
-			if ps.llvmStyle {
+			if ps.llvmExprs {
 				ps.writeString(" = ")
 				ps.print(t.Third)
 			} else {
@@ -3336,7 +4164,7 @@ func (t *Trinary) print(ps *printState) {
 		return
 	}
 
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		wantParens := true
 		opPrec := precPrimary
 		if op, ok := t.Op.(*Operator); ok {
@@ -3358,13 +4186,13 @@ func (t *Trinary) print(ps *printState) {
 		parenthesize(ps, t.First)
 	}
 
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		ps.writeString(" ? ")
 	} else {
 		ps.writeByte('?')
 	}
 
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		wantParens := true
 		if p, ok := t.Second.(hasPrec); ok {
 			if p.prec() < precDefault {
@@ -3384,7 +4212,7 @@ func (t *Trinary) print(ps *printState) {
 
 	ps.writeString(" : ")
 
-	if ps.llvmStyle {
+	if ps.llvmExprs {
 		wantParens := true
 		if p, ok := t.Third.(hasPrec); ok {
 			if p.prec() < precAssign {
@@ -3466,11 +4294,11 @@ func (f *Fold) print(ps *printState) {
 	op, _ := f.Op.(*Operator)
 	printOp := func() {
 		if op != nil {
-			if ps.llvmStyle {
+			if ps.llvmExprs {
 				ps.writeByte(' ')
 			}
 			ps.writeString(op.Name)
-			if ps.llvmStyle {
+			if ps.llvmExprs {
 				ps.writeByte(' ')
 			}
 		} else {
@@ -3478,7 +4306,7 @@ func (f *Fold) print(ps *printState) {
 		}
 	}
 	foldParenthesize := func(a AST) {
-		if ps.llvmStyle {
+		if ps.llvmExprs {
 			prec := precDefault
 			if p, ok := a.(hasPrec); ok {
 				prec = p.prec()
@@ -3726,7 +4554,7 @@ type New struct {
 }
 
 func (n *New) print(ps *printState) {
-	if !ps.llvmStyle {
+	if !ps.llvmExprs {
 		// Op doesn't really matter for printing--we always print "new".
 		ps.writeString("new ")
 	} else {
@@ -3847,15 +4675,75 @@ var builtinTypeFloat = map[string]bool{
 	"half":        true,
 }
 
+// Bits for the literalStyle field of printState.
+const (
+	literalNoSuffix       = 1 << iota // omit integer suffixes
+	literalHex                        // print integers in hex
+	literalFunctionalCast             // print T(1) rather than (T)1
+	literalBool                       // print any bool as true or false
+	literalEnumValue                  // print only the value of an enum
+	literalEnumShort                  // print E(1) rather than (N::E)1
+	literalChar                       // print characters as 'A'
+)
+
+// charLiteralPrefix maps character types to the prefix of a
+// character literal of that type.
+var charLiteralPrefix = map[string]string{
+	"char":          "",
+	"signed char":   "",
+	"unsigned char": "",
+	"wchar_t":       "L",
+	"char8_t":       "u8",
+	"char16_t":      "u",
+	"char32_t":      "U",
+}
+
+// charLiteral returns a character literal for the decimal value val,
+// and reports whether the character can be written that way.
+// The wide parameter reports whether the type can hold any Unicode
+// character; otherwise we only write ASCII characters.
+func charLiteral(val string, wide bool) (string, bool) {
+	v, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		return "", false
+	}
+	switch v {
+	case 0:
+		return `'\0'`, true
+	case '\t':
+		return `'\t'`, true
+	case '\n':
+		return `'\n'`, true
+	case '\r':
+		return `'\r'`, true
+	case '\'', '\\':
+		return `'\` + string(rune(v)) + `'`, true
+	}
+	if v < 0x20 || v == 0x7f || (v >= utf8.RuneSelf && (!wide || !unicode.IsPrint(rune(v)))) {
+		return "", false
+	}
+	return "'" + string(rune(v)) + "'", true
+}
+
 func (l *Literal) print(ps *printState) {
 	isFloat := false
-	if b, ok := l.Type.(*BuiltinType); ok {
+	b, isBuiltin := l.Type.(*BuiltinType)
+	if isBuiltin {
 		if suffix, ok := builtinTypeSuffix[b.Name]; ok {
 			if l.Neg {
 				ps.writeByte('-')
 			}
-			ps.writeString(l.Val)
-			ps.writeString(suffix)
+			ps.writeString(ps.literalValue(l.Val))
+			if ps.literalStyle&literalNoSuffix == 0 {
+				ps.writeString(suffix)
+			}
+			return
+		} else if b.Name == "bool" && ps.literalStyle&literalBool != 0 {
+			if strings.Trim(l.Val, "0") == "" {
+				ps.writeString("false")
+			} else {
+				ps.writeString("true")
+			}
 			return
 		} else if b.Name == "bool" && !l.Neg {
 			switch l.Val {
@@ -3866,8 +4754,15 @@ func (l *Literal) print(ps *printState) {
 				ps.writeString("true")
 				return
 			}
+		} else if prefix, ok := charLiteralPrefix[b.Name]; ok && ps.literalStyle&literalChar != 0 && !l.Neg {
+			wide := prefix == "L" || prefix == "u" || prefix == "U"
+			if s, ok := charLiteral(l.Val, wide); ok {
+				ps.writeString(prefix)
+				ps.writeString(s)
+				return
+			}
 		} else if b.Name == "decltype(nullptr)" && (l.Val == "" || l.Val == "0") {
-			if ps.llvmStyle {
+			if ps.llvmLiterals {
 				ps.writeString("nullptr")
 			} else {
 				ps.print(l.Type)
@@ -3878,6 +4773,30 @@ func (l *Literal) print(ps *printState) {
 		}
 	}
 
+	if !isBuiltin && ps.literalStyle&literalEnumValue != 0 {
+		if l.Neg {
+			ps.writeByte('-')
+		}
+		ps.writeString(ps.literalValue(l.Val))
+		return
+	}
+
+	shortEnum := !isBuiltin && ps.literalStyle&literalEnumShort != 0
+	if shortEnum || (!isFloat && ps.literalStyle&literalFunctionalCast != 0) {
+		if shortEnum {
+			ps.print(baseName(l.Type))
+		} else {
+			ps.print(l.Type)
+		}
+		ps.startScope('(')
+		if l.Neg {
+			ps.writeByte('-')
+		}
+		ps.writeString(ps.literalValue(l.Val))
+		ps.endScope(')')
+		return
+	}
+
 	ps.startScope('(')
 	ps.print(l.Type)
 	ps.endScope(')')
@@ -3888,10 +4807,35 @@ func (l *Literal) print(ps *printState) {
 	if l.Neg {
 		ps.writeByte('-')
 	}
-	ps.writeString(l.Val)
 	if isFloat {
+		ps.writeString(l.Val)
 		ps.writeByte(']')
+	} else {
+		ps.writeString(ps.literalValue(l.Val))
+	}
+}
+
+// hexLiteralMin is the smallest integer literal that the HexLiterals
+// option prints in hexadecimal. Smaller values, such as array sizes
+// and small counts, are easier to read in decimal.
+const hexLiteralMin = 256
+
+// literalValue returns the value of an integer literal, which is
+// written in decimal, in the style selected by the options.
+func (ps *printState) literalValue(val string) string {
+	if ps.literalStyle&literalHex == 0 {
+		return val
+	}
+	if v, err := strconv.ParseUint(val, 10, 64); err == nil {
+		if v < hexLiteralMin {
+			return val
+		}
+		return "0x" + strconv.FormatUint(v, 16)
 	}
+	if v, ok := new(big.Int).SetString(val, 10); ok {
+		return "0x" + v.Text(16)
+	}
+	return val
 }
 
 func (l *Literal) Traverse(fn func(AST) bool) {
@@ -3939,6 +4883,11 @@ type StringLiteral struct {
 }
 
 func (sl *StringLiteral) print(ps *printState) {
+	if ps.literalStyle&literalChar != 0 {
+		// The contents of the string are not mangled.
+		ps.writeString(`"..."`)
+		return
+	}
 	ps.writeString(`"<`)
 	sl.Type.print(ps)
 	ps.writeString(`>"`)
@@ -4152,7 +5101,7 @@ type DefaultArg struct {
 }
 
 func (da *DefaultArg) print(ps *printState) {
-	if !ps.llvmStyle {
+	if !ps.llvmTypes {
 		fmt.Fprintf(&ps.buf, "{default arg#%d}::", da.Num+1)
 	}
 	ps.print(da.Arg)
@@ -4198,8 +5147,17 @@ type Closure struct {
 }
 
 func (cl *Closure) print(ps *printState) {
-	if ps.llvmStyle {
-		if cl.Num == 0 {
+	if ps.printNamed(cl) {
+		return
+	}
+	if ps.llvmUnnamed {
+		ps.writeString(fmt.Sprintf("$_%d", cl.Num))
+		return
+	}
+	if ps.llvmLambdas {
+		if ps.stableLambdas {
+			ps.writeString(fmt.Sprintf("'lambda_%08x'", cl.stableID()))
+		} else if cl.Num == 0 {
 			ps.writeString("'lambda'")
 		} else {
 			ps.writeString(fmt.Sprintf("'lambda%d'", cl.Num-1))
@@ -4208,9 +5166,32 @@ func (cl *Closure) print(ps *printState) {
 		ps.writeString("{lambda")
 	}
 	cl.printTypes(ps)
-	if !ps.llvmStyle {
-		ps.writeString(fmt.Sprintf("#%d}", cl.Num+1))
+	if !ps.llvmLambdas {
+		if ps.stableLambdas {
+			ps.writeString(fmt.Sprintf("#%08x}", cl.stableID()))
+		} else {
+			ps.writeString(fmt.Sprintf("#%d}", cl.Num+1))
+		}
+	}
+}
+
+// stableID returns a hash of the signature and number of the
+// closure, for the StableLambdaIDs option. Closures are numbered
+// among those with the same signature in the same scope, so the
+// number distinguishes them without depending on other closures.
+func (cl *Closure) stableID() uint32 {
+	sig := &Closure{
+		TemplateArgs:           cl.TemplateArgs,
+		TemplateArgsConstraint: cl.TemplateArgsConstraint,
+		Types:                  cl.Types,
+		CallConstraint:         cl.CallConstraint,
+	}
+	h := fnv.New32a()
+	io.WriteString(h, ASTToString(sig))
+	if cl.Num > 0 {
+		fmt.Fprintf(h, "#%d", cl.Num)
 	}
+	return h.Sum32()
 }
 
 func (cl *Closure) printTypes(ps *printState) {
@@ -4227,7 +5208,7 @@ func (cl *Closure) printTypes(ps *printState) {
 
 	if cl.TemplateArgsConstraint != nil {
 		ps.writeString(" requires ")
-		ps.print(cl.TemplateArgsConstraint)
+		ps.printConstraint(cl.TemplateArgsConstraint)
 		ps.writeByte(' ')
 	}
 
@@ -4237,7 +5218,7 @@ func (cl *Closure) printTypes(ps *printState) {
 
 	if cl.CallConstraint != nil {
 		ps.writeString(" requires ")
-		ps.print(cl.CallConstraint)
+		ps.printConstraint(cl.CallConstraint)
 	}
 }
 
@@ -4427,8 +5408,29 @@ type UnnamedType struct {
 	Num int
 }
 
+// printNamed prints the name that ps.namer returns for a, if any.
+// It reports whether it printed anything.
+func (ps *printState) printNamed(a AST) bool {
+	if ps.namer == nil {
+		return false
+	}
+	name := ps.namer(ps.namerScope, a)
+	if name == "" {
+		return false
+	}
+	ps.writeString(name)
+	return true
+}
+
 func (ut *UnnamedType) print(ps *printState) {
-	if ps.llvmStyle {
+	if ps.printNamed(ut) {
+		return
+	}
+	if ps.llvmUnnamed {
+		ps.writeString(fmt.Sprintf("__unnamed_%d", ut.Num+1))
+		return
+	}
+	if ps.llvmLambdas {
 		if ut.Num == 0 {
 			ps.writeString("'unnamed'")
 		} else {
@@ -4466,7 +5468,7 @@ type Clone struct {
 
 func (c *Clone) print(ps *printState) {
 	ps.print(c.Base)
-	if ps.llvmStyle {
+	if ps.llvmClones {
 		ps.writeByte(' ')
 		ps.startScope('(')
 		ps.writeString(c.Suffix)
@@ -4511,11 +5513,15 @@ func (c *Clone) goString(indent int, field string) string {
 type Special struct {
 	Prefix string
 	Val    AST
+
+	// Offsets is the adjustments made by a thunk.
+	// It is nil for other special names.
+	Offsets []CallOffset
 }
 
 func (s *Special) print(ps *printState) {
 	prefix := s.Prefix
-	if ps.llvmStyle {
+	if ps.llvmSpecials {
 		switch prefix {
 		case "TLS wrapper function for ":
 			prefix = "thread-local wrapper routine for "
@@ -4523,7 +5529,24 @@ func (s *Special) print(ps *printState) {
 			prefix = "thread-local initialization routine for "
 		}
 	}
-	ps.writeString(prefix)
+	if ps.thunkOffsets && len(s.Offsets) > 0 && strings.HasSuffix(prefix, " to ") {
+		prefix = prefix[:len(prefix)-len("to ")]
+		ps.writeString(prefix)
+		ps.writeByte('(')
+		for i, off := range s.Offsets {
+			if i > 0 {
+				ps.writeString("; ")
+			}
+			name := "this"
+			if i > 0 {
+				name = "result"
+			}
+			ps.writeString(off.text(name))
+		}
+		ps.writeString(") to ")
+	} else {
+		ps.writeString(prefix)
+	}
 	ps.print(s.Val)
 }
 
@@ -4541,7 +5564,7 @@ func (s *Special) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if val == nil {
 		return fn(s)
 	}
-	s = &Special{Prefix: s.Prefix, Val: val}
+	s = &Special{Prefix: s.Prefix, Val: val, Offsets: s.Offsets}
 	if r := fn(s); r != nil {
 		return r
 	}
@@ -4553,8 +5576,34 @@ func (s *Special) GoString() string {
 }
 
 func (s *Special) goString(indent int, field string) string {
-	return fmt.Sprintf("%*s%sSpecial: Prefix: %s\n%s", indent, "", field,
-		s.Prefix, s.Val.goString(indent+2, "Val: "))
+	var offsets string
+	for _, off := range s.Offsets {
+		offsets += fmt.Sprintf(" (%s)", off.text("this"))
+	}
+	return fmt.Sprintf("%*s%sSpecial: Prefix: %s%s\n%s", indent, "", field,
+		s.Prefix, offsets, s.Val.goString(indent+2, "Val: "))
+}
+
+// CallOffset is the adjustment made by a thunk, as a byte offset.
+type CallOffset struct {
+	// Virtual is whether the adjustment also uses a vcall
+	// offset found in the vtable.
+	Virtual bool
+	// Offset is the fixed adjustment.
+	Offset int
+	// VCallOffset is the offset in the vtable of the vcall
+	// offset, if Virtual is true.
+	VCallOffset int
+}
+
+// text returns the offset as a string, using name for the
+// adjusted value.
+func (c CallOffset) text(name string) string {
+	s := fmt.Sprintf("%s%+d", name, c.Offset)
+	if c.Virtual {
+		s += fmt.Sprintf(", vcall%+d", c.VCallOffset)
+	}
+	return s
 }
 
 // Special2 is like special, but uses two values.
@@ -4619,6 +5668,9 @@ type EnableIf struct {
 
 func (ei *EnableIf) print(ps *printState) {
 	ps.print(ei.Type)
+	if ps.noVendorQuals {
+		return
+	}
 	ps.writeString(" [enable_if:")
 	ps.printList(ei.Args, nil)
 	ps.writeString("]")
@@ -4702,7 +5754,9 @@ func (mn *ModuleName) print(ps *printState) {
 
 func (mn *ModuleName) Traverse(fn func(AST) bool) {
 	if fn(mn) {
-		mn.Parent.Traverse(fn)
+		if mn.Parent != nil {
+			mn.Parent.Traverse(fn)
+		}
 		mn.Name.Traverse(fn)
 	}
 }
@@ -4848,7 +5902,16 @@ type Constraint struct {
 func (c *Constraint) print(ps *printState) {
 	ps.print(c.Name)
 	ps.writeString(" requires ")
-	ps.print(c.Requires)
+	ps.printConstraint(c.Requires)
+}
+
+// printConstraint prints the constraint of a requires clause.
+func (ps *printState) printConstraint(a AST) {
+	if ps.elideConstraints {
+		ps.writeString("…")
+	} else {
+		ps.print(a)
+	}
 }
 
 func (c *Constraint) Traverse(fn func(AST) bool) {
@@ -5048,7 +6111,7 @@ func (er *ExprRequirement) Copy(fn func(AST) AST, skip func(AST) bool) AST {
 	if typeReq == nil {
 		typeReq = er.TypeReq
 	}
-	er = &ExprRequirement{Expr: expr, TypeReq: typeReq}
+	er = &ExprRequirement{Expr: expr, Noexcept: er.Noexcept, TypeReq: typeReq}
 	if r := fn(er); r != nil {
 		return r
 	}
@@ -5193,6 +6256,58 @@ func (eop *ExplicitObjectParameter) goString(indent int, field string) string {
 		eop.Base.goString(indent+2, ""))
 }
 
+// ObjCMethod is an Objective-C method name, as in
+// "-[Class(Category) selector:]". These are not mangled, but clang
+// uses them as the enclosing function of a local name.
+type ObjCMethod struct {
+	ClassMethod bool   // a class method, written with '+'
+	Class       string // the class name
+	Category    string // the category name, or empty
+	Selector    string // the selector
+}
+
+func (om *ObjCMethod) print(ps *printState) {
+	if om.ClassMethod {
+		ps.writeByte('+')
+	} else {
+		ps.writeByte('-')
+	}
+	ps.writeByte('[')
+	ps.writeString(om.Class)
+	if om.Category != "" {
+		ps.writeByte('(')
+		ps.writeString(om.Category)
+		ps.writeByte(')')
+	}
+	ps.writeByte(' ')
+	ps.writeString(om.Selector)
+	ps.writeByte(']')
+}
+
+func (om *ObjCMethod) Traverse(fn func(AST) bool) {
+	fn(om)
+}
+
+func (om *ObjCMethod) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(om) {
+		return nil
+	}
+	return fn(om)
+}
+
+func (om *ObjCMethod) GoString() string {
+	return om.goString(0, "")
+}
+
+func (om *ObjCMethod) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sObjCMethod: ClassMethod: %t Class: %s Category: %s Selector: %s",
+		indent, "", field, om.ClassMethod, om.Class, om.Category, om.Selector)
+}
+
+func (om *ObjCMethod) prec() precedence {
+	return precPrimary
+}
+
 // Print the inner types.
 func (ps *printState) printInner(prefixOnly bool) []AST {
 	var save []AST
@@ -40,3 +40,27 @@ func TestASTToString(t *testing.T) {
 		}
 	}
 }
+
+func TestPrintSizeEstimate(t *testing.T) {
+	const name = "_ZNSt6vectorIN4absl11string_viewESaIS1_EE17_M_realloc_insertIJRKS1_EEEvN9__gnu_cxx17__normal_iteratorIPS1_S3_EEDpOT_"
+	a, err := ToAST(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ASTToString(a)
+	if est := printSizeEstimate(name); est < len(s)/2 || est > 2*len(s) {
+		t.Errorf("printSizeEstimate = %d, want between %d and %d", est, len(s)/2, 2*len(s))
+	}
+
+	print := func(hint int) {
+		ps := &printState{sizeHint: hint}
+		if got, _ := ps.astToString(a, false, false, nil, nil); got != s {
+			t.Errorf("with hint %d got %q, want %q", hint, got, s)
+		}
+	}
+	got := testing.AllocsPerRun(100, func() { print(len(s)) })
+	want := testing.AllocsPerRun(100, func() { print(0) })
+	if got >= want {
+		t.Errorf("printing with a size hint allocated %v times, without %v times; want fewer", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ASTToDot returns a description of the AST in the Graphviz dot
+// language, for viewing the structure of a complex name. Each node
+// is a box labeled with its type and the values of its fields that
+// are not nodes, and each edge is labeled with the name of the field
+// that refers to a node. A node that appears more than once in the
+// AST is drawn once, with an edge from each place that it appears.
+func ASTToDot(a AST) string {
+	d := &dotWriter{ids: make(map[AST]int)}
+	d.buf.WriteString("digraph AST {\n\tnode [shape=box];\n")
+	if a != nil {
+		d.node(a)
+	}
+	d.buf.WriteString("}\n")
+	return d.buf.String()
+}
+
+// dotWriter is the state of ASTToDot.
+type dotWriter struct {
+	buf strings.Builder
+	ids map[AST]int
+}
+
+// node writes a node and the nodes it refers to, and returns the
+// name of the node in the graph.
+func (d *dotWriter) node(a AST) string {
+	if id, ok := d.ids[a]; ok {
+		return "n" + strconv.Itoa(id)
+	}
+	id := len(d.ids)
+	d.ids[a] = id
+	name := "n" + strconv.Itoa(id)
+
+	s := reflect.ValueOf(a).Elem()
+	label := []string{s.Type().Name()}
+	type edge struct {
+		to, label string
+	}
+	var edges []edge
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+		v := s.Field(i)
+		switch {
+		case f.PkgPath != "":
+		case isASTField(v.Type()):
+			if !v.IsNil() {
+				edges = append(edges, edge{d.node(v.Interface().(AST)), f.Name})
+			}
+		case v.Type() == astSliceType:
+			for j := 0; j < v.Len(); j++ {
+				if !v.Index(j).IsNil() {
+					edges = append(edges, edge{d.node(v.Index(j).Interface().(AST)), fmt.Sprintf("%s[%d]", f.Name, j)})
+				}
+			}
+		default:
+			if s, ok := scalarField(v); ok {
+				label = append(label, f.Name+": "+s)
+			}
+		}
+	}
+
+	fmt.Fprintf(&d.buf, "\t%s [label=%s];\n", name, strconv.Quote(strings.Join(label, "\n")))
+	for _, e := range edges {
+		fmt.Fprintf(&d.buf, "\t%s -> %s [label=%s];\n", name, e.to, strconv.Quote(e.label))
+	}
+	return name
+}
+
+// ASTToSExpr returns a description of the AST as an S-expression, for
+// viewing the structure of a complex name in a more compact form than
+// GoString. Each node is written as a list of its type followed by
+// its fields, as in
+//
+//	(Qualified :Scope (Name :Name "A") :Name (Name :Name "f"))
+//
+// Fields that are not nodes are omitted if they have their zero
+// value. A node that appears more than once in the AST is written in
+// full the first time it appears, preceded by a label such as #1=,
+// and after that is written as a reference such as #1#.
+func ASTToSExpr(a AST) string {
+	w := &sexprWriter{
+		counts: make(map[AST]int),
+		ids:    make(map[AST]int),
+	}
+	if a == nil {
+		return "nil"
+	}
+	countNodes(w.counts, reflect.ValueOf(&a).Elem())
+	w.node(a)
+	return w.buf.String()
+}
+
+// sexprWriter is the state of ASTToSExpr.
+type sexprWriter struct {
+	buf    strings.Builder
+	counts map[AST]int // number of times each node appears
+	ids    map[AST]int // labels of nodes that appear more than once
+}
+
+// node writes a node.
+func (w *sexprWriter) node(a AST) {
+	if id, ok := w.ids[a]; ok {
+		fmt.Fprintf(&w.buf, "#%d#", id)
+		return
+	}
+	if w.counts[a] > 1 {
+		id := len(w.ids) + 1
+		w.ids[a] = id
+		fmt.Fprintf(&w.buf, "#%d=", id)
+	}
+
+	s := reflect.ValueOf(a).Elem()
+	w.buf.WriteByte('(')
+	w.buf.WriteString(s.Type().Name())
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+		v := s.Field(i)
+		switch {
+		case f.PkgPath != "":
+		case isASTField(v.Type()):
+			if !v.IsNil() {
+				fmt.Fprintf(&w.buf, " :%s ", f.Name)
+				w.node(v.Interface().(AST))
+			}
+		case v.Type() == astSliceType:
+			if v.Len() > 0 {
+				fmt.Fprintf(&w.buf, " :%s (", f.Name)
+				for j := 0; j < v.Len(); j++ {
+					if j > 0 {
+						w.buf.WriteByte(' ')
+					}
+					if v.Index(j).IsNil() {
+						w.buf.WriteString("nil")
+					} else {
+						w.node(v.Index(j).Interface().(AST))
+					}
+				}
+				w.buf.WriteByte(')')
+			}
+		default:
+			if s, ok := scalarField(v); ok {
+				fmt.Fprintf(&w.buf, " :%s %s", f.Name, s)
+			}
+		}
+	}
+	w.buf.WriteByte(')')
+}
+
+// scalarField returns the value of a field that is not a node, and
+// reports whether the field should be shown, which it is if it does
+// not have its zero value.
+func scalarField(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String()), v.String() != ""
+	case reflect.Bool:
+		return "true", v.Bool()
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10), v.Int() != 0
+	default:
+		if v.IsZero() {
+			return "", false
+		}
+		return fmt.Sprint(v.Interface()), true
+	}
+}
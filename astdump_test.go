@@ -0,0 +1,68 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestASTToSExpr(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{
+			"_ZN1A1fEi",
+			`(Typed :Name (Qualified :Scope (Name :Name "A") :Name (Name :Name "f")) :Type (FunctionType :Args ((BuiltinType :Name "int"))))`,
+		},
+		{
+			"_Z1fIiEvT_",
+			`(Typed :Name #1=(Template :Name (Name :Name "f") :Args ((BuiltinType :Name "int"))) :Type (FunctionType :Return (BuiltinType :Name "void") :Args ((TemplateParam :Template #1#))))`,
+		},
+		{
+			"_ZTV1A",
+			`(Special :Prefix "vtable for " :Val (Name :Name "A"))`,
+		},
+	}
+	for _, test := range tests {
+		a, err := ToAST(test.input, TemplateParamNames)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if got := ASTToSExpr(a); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.input, got, test.want)
+		}
+	}
+	if got := ASTToSExpr(nil); got != "nil" {
+		t.Errorf("ASTToSExpr(nil) = %s, want nil", got)
+	}
+}
+
+func TestASTToDot(t *testing.T) {
+	a, err := ToAST("_Z1fIiEvT_", TemplateParamNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `digraph AST {
+	node [shape=box];
+	n2 [label="Name\nName: \"f\""];
+	n3 [label="BuiltinType\nName: \"int\""];
+	n1 [label="Template"];
+	n1 -> n2 [label="Name"];
+	n1 -> n3 [label="Args[0]"];
+	n5 [label="BuiltinType\nName: \"void\""];
+	n6 [label="TemplateParam"];
+	n6 -> n1 [label="Template"];
+	n4 [label="FunctionType"];
+	n4 -> n5 [label="Return"];
+	n4 -> n6 [label="Args[0]"];
+	n0 [label="Typed"];
+	n0 -> n1 [label="Name"];
+	n0 -> n4 [label="Type"];
+}
+`
+	if got := ASTToDot(a); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// MarshalAST returns a JSON encoding of an AST, so that it may be
+// stored or sent to another program. UnmarshalAST decodes it.
+//
+// Each node is encoded as a JSON object with a "@type" key whose value
+// is the name of the node type, such as "Qualified", and a key for
+// each field of the node, such as "Scope" and "Name". A nil AST is
+// encoded as null. A node that appears more than once in the AST,
+// such as the Template referred to by a TemplateParam, is encoded in
+// full the first time it appears, with an "@id" key, and after that
+// as an object with just a "@ref" key with the same value.
+func MarshalAST(a AST) ([]byte, error) {
+	e := &jsonEncoder{
+		counts: make(map[AST]int),
+		ids:    make(map[AST]int),
+	}
+	if a != nil {
+		countNodes(e.counts, reflect.ValueOf(&a).Elem())
+	}
+	if err := e.node(a); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// UnmarshalAST decodes a JSON encoding of an AST, as returned by
+// MarshalAST.
+func UnmarshalAST(data []byte) (AST, error) {
+	d := &jsonDecoder{
+		ids: make(map[int]AST),
+	}
+	return d.node(data)
+}
+
+// astTypes maps the names of the AST node types to the types.
+var astTypes = map[string]reflect.Type{
+	"Name":                         reflect.TypeOf(Name{}),
+	"Typed":                        reflect.TypeOf(Typed{}),
+	"Qualified":                    reflect.TypeOf(Qualified{}),
+	"Template":                     reflect.TypeOf(Template{}),
+	"TemplateParam":                reflect.TypeOf(TemplateParam{}),
+	"LambdaAuto":                   reflect.TypeOf(LambdaAuto{}),
+	"TemplateParamQualifiedArg":    reflect.TypeOf(TemplateParamQualifiedArg{}),
+	"Qualifiers":                   reflect.TypeOf(Qualifiers{}),
+	"Qualifier":                    reflect.TypeOf(Qualifier{}),
+	"TypeWithQualifiers":           reflect.TypeOf(TypeWithQualifiers{}),
+	"MethodWithQualifiers":         reflect.TypeOf(MethodWithQualifiers{}),
+	"BuiltinType":                  reflect.TypeOf(BuiltinType{}),
+	"PointerType":                  reflect.TypeOf(PointerType{}),
+	"ReferenceType":                reflect.TypeOf(ReferenceType{}),
+	"RvalueReferenceType":          reflect.TypeOf(RvalueReferenceType{}),
+	"ComplexType":                  reflect.TypeOf(ComplexType{}),
+	"ImaginaryType":                reflect.TypeOf(ImaginaryType{}),
+	"SuffixType":                   reflect.TypeOf(SuffixType{}),
+	"TransformedType":              reflect.TypeOf(TransformedType{}),
+	"VendorQualifier":              reflect.TypeOf(VendorQualifier{}),
+	"ArrayType":                    reflect.TypeOf(ArrayType{}),
+	"FunctionType":                 reflect.TypeOf(FunctionType{}),
+	"FunctionParam":                reflect.TypeOf(FunctionParam{}),
+	"PtrMem":                       reflect.TypeOf(PtrMem{}),
+	"FixedType":                    reflect.TypeOf(FixedType{}),
+	"BinaryFP":                     reflect.TypeOf(BinaryFP{}),
+	"BitIntType":                   reflect.TypeOf(BitIntType{}),
+	"VectorType":                   reflect.TypeOf(VectorType{}),
+	"ElaboratedType":               reflect.TypeOf(ElaboratedType{}),
+	"Decltype":                     reflect.TypeOf(Decltype{}),
+	"Operator":                     reflect.TypeOf(Operator{}),
+	"Constructor":                  reflect.TypeOf(Constructor{}),
+	"Destructor":                   reflect.TypeOf(Destructor{}),
+	"GlobalCDtor":                  reflect.TypeOf(GlobalCDtor{}),
+	"TaggedName":                   reflect.TypeOf(TaggedName{}),
+	"PackExpansion":                reflect.TypeOf(PackExpansion{}),
+	"ArgumentPack":                 reflect.TypeOf(ArgumentPack{}),
+	"SizeofPack":                   reflect.TypeOf(SizeofPack{}),
+	"SizeofArgs":                   reflect.TypeOf(SizeofArgs{}),
+	"TemplateParamName":            reflect.TypeOf(TemplateParamName{}),
+	"TypeTemplateParam":            reflect.TypeOf(TypeTemplateParam{}),
+	"NonTypeTemplateParam":         reflect.TypeOf(NonTypeTemplateParam{}),
+	"TemplateTemplateParam":        reflect.TypeOf(TemplateTemplateParam{}),
+	"ConstrainedTypeTemplateParam": reflect.TypeOf(ConstrainedTypeTemplateParam{}),
+	"TemplateParamPack":            reflect.TypeOf(TemplateParamPack{}),
+	"Cast":                         reflect.TypeOf(Cast{}),
+	"Nullary":                      reflect.TypeOf(Nullary{}),
+	"Unary":                        reflect.TypeOf(Unary{}),
+	"Binary":                       reflect.TypeOf(Binary{}),
+	"Trinary":                      reflect.TypeOf(Trinary{}),
+	"Fold":                         reflect.TypeOf(Fold{}),
+	"Subobject":                    reflect.TypeOf(Subobject{}),
+	"PtrMemCast":                   reflect.TypeOf(PtrMemCast{}),
+	"New":                          reflect.TypeOf(New{}),
+	"Literal":                      reflect.TypeOf(Literal{}),
+	"StringLiteral":                reflect.TypeOf(StringLiteral{}),
+	"LambdaExpr":                   reflect.TypeOf(LambdaExpr{}),
+	"ExprList":                     reflect.TypeOf(ExprList{}),
+	"InitializerList":              reflect.TypeOf(InitializerList{}),
+	"DefaultArg":                   reflect.TypeOf(DefaultArg{}),
+	"Closure":                      reflect.TypeOf(Closure{}),
+	"StructuredBindings":           reflect.TypeOf(StructuredBindings{}),
+	"UnnamedType":                  reflect.TypeOf(UnnamedType{}),
+	"Clone":                        reflect.TypeOf(Clone{}),
+	"RustAssocBinding":             reflect.TypeOf(RustAssocBinding{}),
+	"RustArray":                    reflect.TypeOf(RustArray{}),
+	"RustBasicType":                reflect.TypeOf(RustBasicType{}),
+	"RustConst":                    reflect.TypeOf(RustConst{}),
+	"RustCrate":                    reflect.TypeOf(RustCrate{}),
+	"RustDyn":                      reflect.TypeOf(RustDyn{}),
+	"RustDynTrait":                 reflect.TypeOf(RustDynTrait{}),
+	"RustFnSig":                    reflect.TypeOf(RustFnSig{}),
+	"RustGenerics":                 reflect.TypeOf(RustGenerics{}),
+	"RustImpl":                     reflect.TypeOf(RustImpl{}),
+	"RustLifetime":                 reflect.TypeOf(RustLifetime{}),
+	"RustNested":                   reflect.TypeOf(RustNested{}),
+	"RustPointer":                  reflect.TypeOf(RustPointer{}),
+	"RustReference":                reflect.TypeOf(RustReference{}),
+	"RustSymbol":                   reflect.TypeOf(RustSymbol{}),
+	"RustTuple":                    reflect.TypeOf(RustTuple{}),
+	"Special":                      reflect.TypeOf(Special{}),
+	"Special2":                     reflect.TypeOf(Special2{}),
+	"EnableIf":                     reflect.TypeOf(EnableIf{}),
+	"ModuleName":                   reflect.TypeOf(ModuleName{}),
+	"ModuleEntity":                 reflect.TypeOf(ModuleEntity{}),
+	"Friend":                       reflect.TypeOf(Friend{}),
+	"Constraint":                   reflect.TypeOf(Constraint{}),
+	"RequiresExpr":                 reflect.TypeOf(RequiresExpr{}),
+	"ExprRequirement":              reflect.TypeOf(ExprRequirement{}),
+	"TypeRequirement":              reflect.TypeOf(TypeRequirement{}),
+	"NestedRequirement":            reflect.TypeOf(NestedRequirement{}),
+	"ExplicitObjectParameter":      reflect.TypeOf(ExplicitObjectParameter{}),
+	"ObjCMethod":                   reflect.TypeOf(ObjCMethod{}),
+}
+
+var (
+	astType      = reflect.TypeOf((*AST)(nil)).Elem()
+	astSliceType = reflect.TypeOf([]AST(nil))
+)
+
+// isASTField reports whether t is the type of a field that holds a
+// single AST node.
+func isASTField(t reflect.Type) bool {
+	return t == astType || (t.Kind() == reflect.Ptr && t.Implements(astType))
+}
+
+// countNodes counts the number of times that each node appears in
+// v, which is a node or a field of a node. A node that appears more
+// than once is only examined the first time.
+func countNodes(counts map[AST]int, v reflect.Value) {
+	switch {
+	case isASTField(v.Type()):
+		if v.IsNil() {
+			return
+		}
+		a := v.Interface().(AST)
+		counts[a]++
+		if counts[a] > 1 {
+			return
+		}
+		s := reflect.Indirect(reflect.ValueOf(a))
+		for i := 0; i < s.NumField(); i++ {
+			if s.Type().Field(i).PkgPath == "" {
+				countNodes(counts, s.Field(i))
+			}
+		}
+	case v.Type() == astSliceType:
+		for i := 0; i < v.Len(); i++ {
+			countNodes(counts, v.Index(i))
+		}
+	}
+}
+
+// jsonEncoder is the state of MarshalAST.
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	counts map[AST]int // number of times each node appears
+	ids    map[AST]int // IDs of nodes that appear more than once
+}
+
+// node writes the encoding of a node.
+func (e *jsonEncoder) node(a AST) error {
+	v := reflect.ValueOf(a)
+	if a == nil || v.IsNil() {
+		e.buf.WriteString("null")
+		return nil
+	}
+	if id, ok := e.ids[a]; ok {
+		fmt.Fprintf(&e.buf, `{"@ref":%d}`, id)
+		return nil
+	}
+	s := v.Elem()
+	name := s.Type().Name()
+	if astTypes[name] != s.Type() {
+		return fmt.Errorf("demangle: can't marshal AST type %T", a)
+	}
+	fmt.Fprintf(&e.buf, `{"@type":%q`, name)
+	if e.counts[a] > 1 {
+		id := len(e.ids) + 1
+		e.ids[a] = id
+		fmt.Fprintf(&e.buf, `,"@id":%d`, id)
+	}
+	if op, ok := a.(*Operator); ok {
+		fmt.Fprintf(&e.buf, `,"@precedence":%d`, op.precedence)
+	}
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		fmt.Fprintf(&e.buf, `,%q:`, f.Name)
+		if err := e.value(s.Field(i)); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// value writes the encoding of a field of a node.
+func (e *jsonEncoder) value(v reflect.Value) error {
+	switch {
+	case isASTField(v.Type()):
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		return e.node(v.Interface().(AST))
+	case v.Type() == astSliceType:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		e.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+		return nil
+	default:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		e.buf.Write(b)
+		return nil
+	}
+}
+
+// jsonDecoder is the state of UnmarshalAST.
+type jsonDecoder struct {
+	ids map[int]AST // nodes with an "@id" key
+}
+
+// node decodes a node.
+func (d *jsonDecoder) node(data []byte) (AST, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, nil
+	}
+
+	if ref, ok := m["@ref"]; ok {
+		var id int
+		if err := json.Unmarshal(ref, &id); err != nil {
+			return nil, err
+		}
+		a, ok := d.ids[id]
+		if !ok {
+			return nil, fmt.Errorf("demangle: undefined AST reference %d", id)
+		}
+		return a, nil
+	}
+
+	var name string
+	if err := json.Unmarshal(m["@type"], &name); err != nil {
+		return nil, errors.New("demangle: missing or invalid AST type")
+	}
+	t, ok := astTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("demangle: unknown AST type %q", name)
+	}
+	p := reflect.New(t)
+	a := p.Interface().(AST)
+	if raw, ok := m["@id"]; ok {
+		var id int
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return nil, err
+		}
+		d.ids[id] = a
+	}
+	if op, ok := a.(*Operator); ok {
+		if raw, ok := m["@precedence"]; ok {
+			if err := json.Unmarshal(raw, &op.precedence); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s := p.Elem()
+	for i := 0; i < s.NumField(); i++ {
+		f := t.Field(i)
+		raw, ok := m[f.Name]
+		if f.PkgPath != "" || !ok {
+			continue
+		}
+		if err := d.value(s.Field(i), raw); err != nil {
+			return nil, fmt.Errorf("demangle: %s.%s: %v", name, f.Name, err)
+		}
+	}
+	return a, nil
+}
+
+// value decodes a field of a node into v.
+func (d *jsonDecoder) value(v reflect.Value, data []byte) error {
+	switch {
+	case isASTField(v.Type()):
+		a, err := d.node(data)
+		if err != nil || a == nil {
+			return err
+		}
+		av := reflect.ValueOf(a)
+		if !av.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("unexpected AST type %T", a)
+		}
+		v.Set(av)
+		return nil
+	case v.Type() == astSliceType:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil || elems == nil {
+			return err
+		}
+		s := make([]AST, len(elems))
+		for i, elem := range elems {
+			if err := d.value(reflect.ValueOf(&s[i]).Elem(), elem); err != nil {
+				return err
+			}
+		}
+		v.Set(reflect.ValueOf(s))
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMarshalAST(t *testing.T) {
+	a, err := ToAST("_ZN1A1fEi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalAST(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"@type":"Typed","Name":{"@type":"Qualified","Scope":{"@type":"Name","Name":"A","Internal":false},"Name":{"@type":"Name","Name":"f","Internal":false},"LocalName":false,"Discriminator":0},"Type":{"@type":"FunctionType","Return":null,"Args":[{"@type":"BuiltinType","Name":"int"}],"ForLocalName":false}}`
+	if got := string(data); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestUnmarshalASTShared(t *testing.T) {
+	a, err := ToAST("_Z1fIiEvT_", TemplateParamNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalAST(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := UnmarshalAST(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := b.(*Typed).Name.(*Template)
+	tp := b.(*Typed).Type.(*FunctionType).Args[0].(*TemplateParam)
+	if tp.Template != tmpl {
+		t.Errorf("template parameter refers to %p, want %p", tp.Template, tmpl)
+	}
+}
+
+func TestUnmarshalASTErrors(t *testing.T) {
+	var tests = []string{
+		``,
+		`{}`,
+		`{"@type":"NoSuchType"}`,
+		`{"@ref":1}`,
+		`{"@type":"Qualified","Scope":{"@type":"Name","Name":1}}`,
+		`{"@type":"TemplateParam","Template":{"@type":"Name","Name":"x"}}`,
+	}
+	for _, test := range tests {
+		if a, err := UnmarshalAST([]byte(test)); err == nil {
+			t.Errorf("%s: unexpected success %#v", test, a)
+		}
+	}
+}
+
+// TestASTJSONRoundTrip checks that each name in the testdata file
+// prints the same after a JSON round trip.
+func TestASTJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		input := scanner.Text()
+		a, err := ToAST(input)
+		if err != nil {
+			continue
+		}
+		data, err := MarshalAST(a)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		b, err := UnmarshalAST(data)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if got, want := ASTToString(b), ASTToString(a); got != want {
+			t.Errorf("%s:\ngot  %s\nwant %s", input, got, want)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestASTTypes checks that astTypes lists all the AST node types.
+func TestASTTypes(t *testing.T) {
+	re := regexp.MustCompile(`(?m)^func \(\w+ \*(\w+)\) Traverse\(`)
+	var matches [][]string
+	for _, file := range []string{"ast.go", "rustast.go"} {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches = append(matches, re.FindAllStringSubmatch(string(src), -1)...)
+	}
+	for _, m := range matches {
+		if _, ok := astTypes[m[1]]; !ok {
+			t.Errorf("astTypes is missing %s", m[1])
+		}
+	}
+	if len(matches) != len(astTypes) {
+		t.Errorf("found %d AST types in the source, astTypes has %d", len(matches), len(astTypes))
+	}
+	for name, typ := range astTypes {
+		if typ.Name() != name || strings.Contains(name, ".") {
+			t.Errorf("astTypes[%q] = %v", name, typ)
+		}
+	}
+}
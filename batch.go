@@ -0,0 +1,92 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"runtime"
+	"sync"
+)
+
+// A BatchResult is the result of demangling one name with ToStrings.
+// The name Result is used for the result of Demangle.
+type BatchResult struct {
+	// Name is the demangled name, as returned by ToString. If
+	// the name can't be demangled, it is the original name, as
+	// returned by Filter.
+	Name string
+
+	// Err is the error returned by ToString, or nil.
+	Err error
+}
+
+// ToStrings demangles a list of symbol names, such as the names in
+// a symbol table, and returns the results in the same order. Each
+// result is the same as calling ToString for the name, but
+// ToStrings reuses the memory that it allocates for one name when
+// demangling the next, and demangles a name that is the same as the
+// one before it only once, so it is faster than calling ToString in
+// a loop.
+func ToStrings(names []string, options ...Option) []BatchResult {
+	ret := make([]BatchResult, len(names))
+	var d Demangler
+	d.toStrings(names, ret, options)
+	return ret
+}
+
+// minParallelNames is the smallest number of names that
+// ToStringsParallel gives to each goroutine.
+const minParallelNames = 1024
+
+// ToStringsParallel is like ToStrings, but demangles the names using
+// up to runtime.GOMAXPROCS goroutines, each demangling a contiguous
+// part of the list. The results are in the same order as the names.
+// This is for programs that demangle a very large number of names,
+// such as all the symbols of a large executable; for a short list
+// it is the same as ToStrings.
+func ToStringsParallel(names []string, options ...Option) []BatchResult {
+	ret := make([]BatchResult, len(names))
+	workers := runtime.GOMAXPROCS(0)
+	if max := len(names) / minParallelNames; workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		var d Demangler
+		d.toStrings(names, ret, options)
+		return ret
+	}
+
+	var wg sync.WaitGroup
+	size := (len(names) + workers - 1) / workers
+	for start := 0; start < len(names); start += size {
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			var d Demangler
+			d.toStrings(names[start:end], ret[start:end], options)
+		}(start, end)
+	}
+	wg.Wait()
+	return ret
+}
+
+// toStrings implements ToStrings, storing the results in ret, which
+// has the same length as names.
+func (d *Demangler) toStrings(names []string, ret []BatchResult, options []Option) {
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			ret[i] = ret[i-1]
+			continue
+		}
+		s, err := d.ToString(name, options...)
+		if err != nil {
+			s = name
+		}
+		ret[i] = BatchResult{Name: s, Err: err}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestToStrings(t *testing.T) {
+	var tests = []struct {
+		names   []string
+		options []Option
+		want    []string
+		errs    []bool
+	}{
+		{
+			[]string{"_ZN1A1fEv", "_ZN1A1fEv", "main", "_RNvC1a4main", "_Z"},
+			nil,
+			[]string{"A::f()", "A::f()", "main", "a::main", "_Z"},
+			[]bool{false, false, true, false, true},
+		},
+		{
+			[]string{"_ZN1A1fEv", "_ZN1A1gEi"},
+			[]Option{NoParams},
+			[]string{"A::f", "A::g"},
+			[]bool{false, false},
+		},
+		{
+			nil,
+			nil,
+			nil,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		got := ToStrings(test.names, test.options...)
+		if len(got) != len(test.want) {
+			t.Errorf("%v: got %d results, want %d", test.names, len(got), len(test.want))
+			continue
+		}
+		for i, r := range got {
+			if r.Name != test.want[i] || (r.Err != nil) != test.errs[i] {
+				t.Errorf("%s: got %q, %v; want %q, error %t", test.names[i], r.Name, r.Err, test.want[i], test.errs[i])
+			}
+		}
+	}
+}
+
+// TestToStringsTestData checks that ToStrings gives the same
+// results as ToString for the names in the test data.
+func TestToStringsTestData(t *testing.T) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "--") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, options := range [][]Option{nil, {NoParams}, {MaxLengthBytes(20)}} {
+		got := ToStrings(names, options...)
+		par := ToStringsParallel(names, options...)
+		for i, name := range names {
+			want, wantErr := ToString(name, options...)
+			if wantErr != nil {
+				want = name
+			}
+			if got[i].Name != want || (got[i].Err == nil) != (wantErr == nil) {
+				t.Errorf("%s %v: got %q, %v; want %q, %v", name, options, got[i].Name, got[i].Err, want, wantErr)
+			}
+			if par[i] != got[i] {
+				t.Errorf("%s %v: ToStringsParallel got %q, %v; ToStrings got %q, %v", name, options, par[i].Name, par[i].Err, got[i].Name, got[i].Err)
+			}
+		}
+	}
+}
+
+func TestToStringsParallel(t *testing.T) {
+	// Enough names to use several goroutines, with duplicates
+	// at the boundaries between them.
+	base := []string{"_ZN1A1fEv", "_ZN1A1fEv", "main", "_Z1gi"}
+	want := []string{"A::f()", "A::f()", "main", "g(int)"}
+	var names []string
+	for i := 0; i < 4*minParallelNames; i++ {
+		names = append(names, base...)
+	}
+	got := ToStringsParallel(names)
+	if len(got) != len(names) {
+		t.Fatalf("got %d results, want %d", len(got), len(names))
+	}
+	for i, r := range got {
+		if r.Name != want[i%len(want)] {
+			t.Errorf("%d: %s: got %q, want %q", i, names[i], r.Name, want[i%len(want)])
+			break
+		}
+	}
+}
@@ -0,0 +1,550 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file demangles names mangled by the Borland C++ compiler,
+// such as "@Class@method$qv". The names are qualified names separated
+// by '@', optionally followed by '$' and a function signature.
+
+// borlandToString demangles a Borland C++ symbol.
+func borlandToString(name string, options []Option) (ret string, err error) {
+	if !strings.HasPrefix(name, "@") {
+		return "", ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	bst := &borlandState{str: name, maxDepth: defaultMaxDepth}
+	max := 0
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			bst.noParams = true
+		case o == NoTemplateParams:
+			bst.noTemplateParams = true
+		case o == ElideTemplateParams:
+			bst.noTemplateParams = true
+			bst.elideTemplateParams = true
+		case o == NoAngleSpace:
+			bst.noAngleSpace = true
+		case isMaxLength(o):
+			max = maxLength(o)
+		case isMaxDepth(o):
+			bst.maxDepth = optionValue(o)
+		}
+	}
+
+	bst.symbol()
+	if len(bst.str) > 0 {
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: bst.off})
+	}
+
+	s := string(bst.buf)
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// borlandMaxOutput is the maximum length of a demangled Borland name.
+// Repeated argument types can make the output exponentially larger
+// than the input.
+const borlandMaxOutput = 1 << 20
+
+// A borlandState holds the current state of demangling a Borland
+// string.
+type borlandState struct {
+	str      string   // remainder of string to demangle
+	off      int      // offset of str within original string
+	buf      []byte   // the demangled output
+	types    []string // argument types, for t references
+	depth    int      // current nesting depth
+	maxDepth int      // maximum nesting depth
+
+	noParams            bool // don't demangle function parameters
+	noTemplateParams    bool // don't demangle template arguments
+	elideTemplateParams bool // print "<...>" for template arguments
+	noAngleSpace        bool // print >> rather than > >
+}
+
+// fail panics with Error, to be caught in borlandToString.
+func (bst *borlandState) fail(err string) {
+	panic(Error{Msg: err, Offset: bst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (bst *borlandState) enter() {
+	bst.depth++
+	if bst.depth > bst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: bst.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (bst *borlandState) leave() {
+	bst.depth--
+}
+
+// advance advances the current string offset.
+func (bst *borlandState) advance(add int) {
+	if len(bst.str) < add {
+		panic("internal error")
+	}
+	bst.str = bst.str[add:]
+	bst.off += add
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (bst *borlandState) peek() byte {
+	if len(bst.str) == 0 {
+		return 0
+	}
+	return bst.str[0]
+}
+
+// checkChar requires that the next character in the string be c,
+// and advances past it.
+func (bst *borlandState) checkChar(c byte) {
+	if len(bst.str) == 0 || bst.str[0] != c {
+		bst.fail("expected " + string(c))
+	}
+	bst.advance(1)
+}
+
+// writeString adds a string to the output.
+func (bst *borlandState) writeString(s string) {
+	bst.buf = append(bst.buf, s...)
+	if len(bst.buf) > borlandMaxOutput {
+		panic(Error{Code: ErrTooLarge, Msg: "demangled output too large", Offset: bst.off})
+	}
+}
+
+// capture runs f and returns the output that it wrote,
+// removing it from the output.
+func (bst *borlandState) capture(f func()) string {
+	n := len(bst.buf)
+	f()
+	s := string(bst.buf[n:])
+	bst.buf = bst.buf[:n]
+	return s
+}
+
+// number parses a decimal number.
+func (bst *borlandState) number() int {
+	if !isDigit(bst.peek()) {
+		bst.fail("expected number")
+	}
+	val := 0
+	for isDigit(bst.peek()) {
+		val = val*10 + int(bst.str[0]-'0')
+		if val > bst.off+len(bst.str)+borlandMaxOutput {
+			bst.fail("numeric overflow")
+		}
+		bst.advance(1)
+	}
+	return val
+}
+
+// symbol parses a complete symbol:
+//
+//	@ <name> [ @ <name> ]* [ $ <signature> ]
+func (bst *borlandState) symbol() {
+	bst.checkChar('@')
+	last := ""
+	for {
+		last = bst.component(last)
+		if bst.peek() != '@' {
+			break
+		}
+		bst.advance(1)
+		bst.writeString("::")
+	}
+
+	if len(bst.str) == 0 {
+		// A data symbol.
+		return
+	}
+
+	bst.checkChar('$')
+	quals := ""
+	for {
+		if bst.peek() == 'x' {
+			quals += " const"
+		} else if bst.peek() == 'w' {
+			quals += " volatile"
+		} else {
+			break
+		}
+		bst.advance(1)
+	}
+	bst.checkChar('q')
+	start := len(bst.buf)
+	bst.writeString("(")
+	bst.argList()
+	bst.writeString(")")
+	bst.writeString(quals)
+	if bst.peek() == '$' {
+		// A template function encodes its return type,
+		// which we don't print.
+		bst.advance(1)
+		bst.capture(bst.typ)
+	}
+	if bst.noParams {
+		bst.buf = bst.buf[:start]
+	}
+}
+
+// borlandOperators maps the encoding of an operator, following "$b",
+// to its name.
+var borlandOperators = map[string]string{
+	"add":  "operator+",
+	"sub":  "operator-",
+	"mul":  "operator*",
+	"div":  "operator/",
+	"mod":  "operator%",
+	"inc":  "operator++",
+	"dec":  "operator--",
+	"asg":  "operator=",
+	"eql":  "operator==",
+	"neq":  "operator!=",
+	"lss":  "operator<",
+	"gtr":  "operator>",
+	"leq":  "operator<=",
+	"geq":  "operator>=",
+	"not":  "operator!",
+	"cmp":  "operator~",
+	"and":  "operator&",
+	"or":   "operator|",
+	"xor":  "operator^",
+	"land": "operator&&",
+	"lor":  "operator||",
+	"lsh":  "operator<<",
+	"rsh":  "operator>>",
+	"subs": "operator[]",
+	"call": "operator()",
+	"arow": "operator->",
+	"arwm": "operator->*",
+	"ind":  "operator*",
+	"adr":  "operator&",
+	"neg":  "operator-",
+	"pos":  "operator+",
+	"new":  "operator new",
+	"dele": "operator delete",
+	"nwa":  "operator new[]",
+	"dla":  "operator delete[]",
+	"coma": "operator,",
+	"rplu": "operator+=",
+	"rmin": "operator-=",
+	"rmul": "operator*=",
+	"rdiv": "operator/=",
+	"rmod": "operator%=",
+	"rand": "operator&=",
+	"ror":  "operator|=",
+	"rxor": "operator^=",
+	"rlsh": "operator<<=",
+	"rrsh": "operator>>=",
+}
+
+// component parses one component of a qualified name. last is the
+// name of the enclosing class, used for constructors and destructors.
+// It prints the component and returns its name without template
+// arguments.
+func (bst *borlandState) component(last string) string {
+	switch {
+	case strings.HasPrefix(bst.str, "%"):
+		return bst.template()
+	case strings.HasPrefix(bst.str, "$b"):
+		bst.advance(2)
+		i := strings.IndexByte(bst.str, '$')
+		if i < 0 {
+			i = len(bst.str)
+		}
+		code := bst.str[:i]
+		var s string
+		switch code {
+		case "ctr":
+			if last == "" {
+				bst.fail("constructor outside of class")
+			}
+			s = last
+		case "dtr":
+			if last == "" {
+				bst.fail("destructor outside of class")
+			}
+			s = "~" + last
+		default:
+			op, ok := borlandOperators[code]
+			if !ok {
+				bst.fail("unrecognized operator")
+			}
+			s = op
+		}
+		bst.advance(i)
+		bst.writeString(s)
+		return s
+	case strings.HasPrefix(bst.str, "$o"):
+		// A conversion operator; the type is followed by the
+		// signature.
+		bst.advance(2)
+		s := "operator " + bst.capture(bst.typ)
+		bst.writeString(s)
+		return s
+	}
+
+	i := strings.IndexAny(bst.str, "@$%")
+	if i < 0 {
+		i = len(bst.str)
+	}
+	if i == 0 || isDigit(bst.str[0]) {
+		// This rejects the names that the Microsoft compiler
+		// uses for __fastcall C functions, such as "@f@8".
+		bst.fail("invalid name")
+	}
+	s := bst.str[:i]
+	bst.advance(i)
+	bst.writeString(s)
+	return s
+}
+
+// template parses a template instance:
+//
+//	% <name> [ $ <arg> ]* %
+//
+// where each argument is t and a type, or a type and a value.
+// It prints the instance and returns the template name.
+func (bst *borlandState) template() string {
+	bst.checkChar('%')
+	i := strings.IndexAny(bst.str, "$%")
+	if i <= 0 {
+		bst.fail("invalid template name")
+	}
+	name := bst.str[:i]
+	bst.advance(i)
+	bst.writeString(name)
+
+	start := len(bst.buf)
+	bst.writeString("<")
+	for n := 0; bst.peek() == '$'; n++ {
+		bst.advance(1)
+		if n > 0 {
+			bst.writeString(", ")
+		}
+		if bst.peek() == 't' {
+			bst.advance(1)
+			bst.typ()
+			continue
+		}
+		bst.capture(bst.typ)
+		valStart := bst.off
+		j := strings.IndexAny(bst.str, "$%")
+		if j <= 0 {
+			bst.fail("invalid template value")
+		}
+		val := bst.str[:j]
+		if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+			bst.off = valStart
+			bst.fail("invalid template value")
+		}
+		bst.writeString(val)
+		bst.advance(j)
+	}
+	bst.checkChar('%')
+
+	if bst.noTemplateParams {
+		bst.buf = bst.buf[:start]
+		if bst.elideTemplateParams {
+			bst.writeString("<...>")
+		}
+		return name
+	}
+	if bst.buf[len(bst.buf)-1] == '>' && !bst.noAngleSpace {
+		bst.writeString(" ")
+	}
+	bst.writeString(">")
+	return name
+}
+
+// argList parses function argument types, up to the end of the
+// string or a '$' that introduces a return type.
+func (bst *borlandState) argList() {
+	start := len(bst.buf)
+	n := 0
+	for ; len(bst.str) > 0 && bst.peek() != '$'; n++ {
+		if n > 0 {
+			bst.writeString(", ")
+		}
+		switch bst.peek() {
+		case 'e':
+			bst.advance(1)
+			bst.writeString("...")
+		case 't':
+			// Repeat an earlier argument type, numbered from 1.
+			bst.advance(1)
+			c := bst.peek()
+			var i int
+			switch {
+			case '1' <= c && c <= '9':
+				i = int(c - '1')
+			case 'a' <= c && c <= 'z':
+				i = int(c-'a') + 9
+			default:
+				bst.fail("invalid type index")
+			}
+			if i >= len(bst.types) {
+				bst.fail("invalid type index")
+			}
+			bst.advance(1)
+			bst.writeString(bst.types[i])
+			bst.types = append(bst.types, bst.types[i])
+		default:
+			typeStart := len(bst.buf)
+			bst.typ()
+			bst.types = append(bst.types, string(bst.buf[typeStart:]))
+		}
+	}
+	switch {
+	case n == 0:
+		bst.fail("missing argument types")
+	case n == 1 && string(bst.buf[start:]) == "void":
+		bst.buf = bst.buf[:start]
+	}
+}
+
+// borlandBuiltinTypes maps an encoding to a builtin type.
+var borlandBuiltinTypes = map[string]string{
+	"v":  "void",
+	"o":  "bool",
+	"c":  "char",
+	"zc": "signed char",
+	"uc": "unsigned char",
+	"s":  "short",
+	"us": "unsigned short",
+	"i":  "int",
+	"ui": "unsigned int",
+	"l":  "long",
+	"ul": "unsigned long",
+	"j":  "__int64",
+	"uj": "unsigned __int64",
+	"b":  "wchar_t",
+	"f":  "float",
+	"d":  "double",
+	"g":  "long double",
+}
+
+// typ parses a type.
+func (bst *borlandState) typ() {
+	// Each declarator is a level of nesting, as is the type itself.
+	defer func(depth int) { bst.depth = depth }(bst.depth)
+	bst.enter()
+
+	// The declarator is built from the outside in, and the base
+	// type is printed in front of it. Rather than copying the
+	// declarator each time it grows, we keep the parts that go
+	// before it, innermost first, and the parts that go after it,
+	// and join them at the end.
+	var pre, post []string
+	wrap := func() {
+		if len(pre) > 0 || len(post) > 0 {
+			pre = append(pre, "(")
+			post = append(post, ")")
+		}
+	}
+
+	quals := ""
+	for {
+		switch c := bst.peek(); c {
+		case 'x':
+			bst.advance(1)
+			quals += "const "
+		case 'w':
+			bst.advance(1)
+			quals += "volatile "
+		case 'p', 'r':
+			bst.enter()
+			bst.advance(1)
+			d := "*"
+			if c == 'r' {
+				d = "&"
+			}
+			if quals != "" {
+				d += " " + strings.TrimSuffix(quals, " ")
+				quals = ""
+				if len(pre) > 0 || len(post) > 0 {
+					d += " "
+				}
+			}
+			pre = append(pre, d)
+		case 'a':
+			bst.enter()
+			bst.advance(1)
+			n := bst.number()
+			bst.checkChar('$')
+			wrap()
+			post = append(post, "["+strconv.Itoa(n)+"]")
+		case 'q':
+			bst.enter()
+			bst.advance(1)
+			args := bst.capture(bst.argList)
+			bst.checkChar('$')
+			wrap()
+			post = append(post, "("+args+")")
+		default:
+			bst.writeString(quals)
+			bst.baseType()
+			if len(pre) > 0 || len(post) > 0 {
+				bst.writeString(" ")
+				for i := len(pre) - 1; i >= 0; i-- {
+					bst.writeString(pre[i])
+				}
+				for _, s := range post {
+					bst.writeString(s)
+				}
+			}
+			return
+		}
+	}
+}
+
+// baseType parses a builtin type or a class name.
+func (bst *borlandState) baseType() {
+	if isDigit(bst.peek()) {
+		// A class name, whose components are separated by '@'.
+		n := bst.number()
+		if n == 0 || n > len(bst.str) {
+			bst.fail("invalid class name length")
+		}
+		bst.writeString(strings.Replace(bst.str[:n], "@", "::", -1))
+		bst.advance(n)
+		return
+	}
+	for _, l := range []int{2, 1} {
+		if len(bst.str) >= l {
+			if s, ok := borlandBuiltinTypes[bst.str[:l]]; ok {
+				bst.advance(l)
+				bst.writeString(s)
+				return
+			}
+		}
+	}
+	bst.fail("unrecognized type")
+}
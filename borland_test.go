@@ -0,0 +1,80 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestBorland(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"@Foo@bar$qv", "Foo::bar()"},
+		{"@Foo@bar$qi", "Foo::bar(int)"},
+		{"@Foo@bar$xqv", "Foo::bar() const"},
+		{"@Foo@count", "Foo::count"},
+		{"@func$qipxc", "func(int, const char *)"},
+		{"@ns@Foo@$bctr$qv", "ns::Foo::Foo()"},
+		{"@Foo@$bdtr$qv", "Foo::~Foo()"},
+		{"@Foo@$badd$qrx3Foo", "Foo::operator+(const Foo &)"},
+		{"@Foo@$oi$qv", "Foo::operator int()"},
+		{"@f$qpqi$v", "f(void (*)(int))"},
+		{"@f$qa10$i", "f(int [10])"},
+		{"@f$qpa10$i", "f(int (*)[10])"},
+		{"@f$q3Foot1", "f(Foo, Foo)"},
+		{"@f$q9ns@Widget", "f(ns::Widget)"},
+		{"@f$qucusuiulujzc", "f(unsigned char, unsigned short, unsigned int, unsigned long, unsigned __int64, signed char)"},
+		{"@f$qie", "f(int, ...)"},
+		{"@f$qppc", "f(char **)"},
+		{"@f$qxpc", "f(char * const)"},
+		{"@f$qpxpxc", "f(const char * const *)"},
+		{"@%Vec$ti%@size$qv", "Vec<int>::size()"},
+		{"@%Arr$ti$i10%@get$qui", "Arr<int, 10>::get(unsigned int)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestBorlandOptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"@Foo@bar$xqi", []Option{NoParams}, "Foo::bar"},
+		{"@%Vec$ti%@size$qv", []Option{NoTemplateParams}, "Vec::size()"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestBorlandFailure(t *testing.T) {
+	for _, input := range []string{
+		"@",
+		"@f@8",
+		"@Foo@",
+		"@f$q",
+		"@f$qk",
+		"@f$qt1",
+		"@$bctr$qv",
+		"@Foo@$bxyz$qv",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// This file defines functions that help build an AST by hand, as a
+// code generator might, and Validate, which checks that an AST can
+// be printed. The AST node types may also be built directly, as in
+// &PointerType{Base: &BuiltinType{Name: "int"}}.
+
+// NewName returns a Name node for an identifier.
+func NewName(name string) *Name {
+	return &Name{Name: name}
+}
+
+// NewQualifiedName returns the AST for a name qualified by its
+// enclosing scopes, as in NewQualifiedName("std", "vector") for
+// std::vector. With a single name it returns a Name.
+func NewQualifiedName(names ...string) AST {
+	if len(names) == 0 {
+		return nil
+	}
+	var ret AST = NewName(names[0])
+	for _, name := range names[1:] {
+		ret = &Qualified{Scope: ret, Name: NewName(name)}
+	}
+	return ret
+}
+
+// NewTemplate returns the AST for a template instantiation, such as
+// std::vector<int>.
+func NewTemplate(name AST, args ...AST) *Template {
+	return &Template{Name: name, Args: args}
+}
+
+// NewFunction returns the AST for a function with a name, a result
+// type, and parameter types. The result type may be nil; the
+// demangler only records it for a template function. A function
+// with no parameters has an empty params list, not void.
+func NewFunction(name, result AST, params ...AST) *Typed {
+	return &Typed{
+		Name: name,
+		Type: &FunctionType{Return: result, Args: params},
+	}
+}
+
+// NewBuiltinType returns the AST for a builtin type, such as "int"
+// or "unsigned long".
+func NewBuiltinType(name string) *BuiltinType {
+	return &BuiltinType{Name: name}
+}
+
+// NewPointer returns the AST for a pointer to a type.
+func NewPointer(base AST) *PointerType {
+	return &PointerType{Base: base}
+}
+
+// NewReference returns the AST for an lvalue reference to a type.
+func NewReference(base AST) *ReferenceType {
+	return &ReferenceType{Base: base}
+}
+
+// NewRvalueReference returns the AST for an rvalue reference to a
+// type.
+func NewRvalueReference(base AST) *RvalueReferenceType {
+	return &RvalueReferenceType{Base: base}
+}
+
+// NewQualifiers returns a Qualifiers node for a list of qualifiers,
+// such as "const" and "volatile".
+func NewQualifiers(names ...string) *Qualifiers {
+	q := &Qualifiers{Qualifiers: make([]AST, len(names))}
+	for i, name := range names {
+		q.Qualifiers[i] = &Qualifier{Name: name}
+	}
+	return q
+}
+
+// NewQualifiedType returns the AST for a type with qualifiers, as in
+// NewQualifiedType(NewBuiltinType("char"), "const") for char const.
+func NewQualifiedType(base AST, qualifiers ...string) *TypeWithQualifiers {
+	return &TypeWithQualifiers{Base: base, Qualifiers: NewQualifiers(qualifiers...)}
+}
+
+// NewOperator returns an Operator node for an operator name as it
+// is written after the keyword operator, such as "+" or "new", that
+// takes the given number of arguments in an expression. An Operator
+// must be built by NewOperator or by the demangler, as it records
+// the operator precedence, which is used when printing expressions.
+func NewOperator(name string, args int) (*Operator, error) {
+	name = strings.TrimSpace(name)
+	codes := make([]string, 0, len(operators))
+	for code := range operators {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		op := operators[code]
+		if strings.TrimSpace(op.name) == name && op.args == args {
+			return &Operator{Name: op.name, precedence: op.prec}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operator %q with %d arguments", name, args)
+}
+
+// A ValidationError is returned by Validate for an AST that can't be
+// printed.
+type ValidationError struct {
+	Node AST    // the invalid node
+	Msg  string // the problem with the node
+}
+
+// Error implements the builtin error interface for ValidationError.
+func (ve ValidationError) Error() string {
+	return fmt.Sprintf("invalid %T: %s", ve.Node, ve.Msg)
+}
+
+// Validate checks that an AST is well formed, and returns a
+// ValidationError for the first problem it finds. An AST returned by
+// ToAST is always valid; Validate is for an AST built or modified by
+// hand, which the printing functions may panic on or loop forever
+// printing. Validate checks that the fields that must be set are not
+// nil, that lists do not contain nil, that operator names are not
+// empty, that each TemplateParam refers to an argument of its
+// Template, and that no node contains itself. An empty Name is
+// valid; the demangler uses one for an array with no dimension.
+func Validate(a AST) error {
+	if a == nil {
+		return ValidationError{Msg: "nil AST"}
+	}
+	v := &validator{
+		active: make(map[AST]bool),
+		done:   make(map[AST]bool),
+	}
+	return v.node(a)
+}
+
+// optionalFields is the set of AST fields, as Type.Field, that may
+// be nil.
+var optionalFields = map[string]bool{
+	"Closure.CallConstraint":           true,
+	"Closure.TemplateArgsConstraint":   true,
+	"Constructor.Base":                 true,
+	"ExprRequirement.TypeReq":          true,
+	"Fold.Arg2":                        true,
+	"FunctionType.Return":              true,
+	"InitializerList.Type":             true,
+	"MethodWithQualifiers.Qualifiers":  true,
+	"ModuleName.Parent":                true,
+	"New.Init":                         true,
+	"New.Place":                        true,
+	"PackExpansion.Pack":               true,
+	"RustArray.Len":                    true,
+	"RustConst.Type":                   true,
+	"RustDyn.Lifetime":                 true,
+	"RustFnSig.Return":                 true,
+	"RustImpl.Trait":                   true,
+	"RustReference.Lifetime":           true,
+	"TemplateTemplateParam.Constraint": true,
+}
+
+// refFields is the set of AST fields, as Type.Field, that refer to a
+// node elsewhere in the AST. Validate does not look inside them.
+var refFields = map[string]bool{
+	"PackExpansion.Pack":     true,
+	"SizeofPack.Pack":        true,
+	"TemplateParam.Template": true,
+}
+
+// validator holds the state of Validate.
+type validator struct {
+	active map[AST]bool // nodes being checked
+	done   map[AST]bool // nodes already checked
+}
+
+// node checks a node.
+func (v *validator) node(a AST) error {
+	if v.done[a] {
+		return nil
+	}
+	if v.active[a] {
+		return ValidationError{Node: a, Msg: "node contains itself"}
+	}
+	v.active[a] = true
+	defer delete(v.active, a)
+
+	switch a := a.(type) {
+	case *Operator:
+		if a.Name == "" {
+			return ValidationError{Node: a, Msg: "empty operator name"}
+		}
+	case *TemplateParam:
+		if a.Template != nil && (a.Index < 0 || a.Index >= len(a.Template.Args)) {
+			return ValidationError{Node: a, Msg: fmt.Sprintf("index %d out of range of %d template arguments", a.Index, len(a.Template.Args))}
+		}
+	}
+
+	s := reflect.Indirect(reflect.ValueOf(a))
+	typ := s.Type()
+	for i := 0; i < s.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := typ.Name() + "." + field.Name
+		f := s.Field(i)
+		switch {
+		case isASTField(f.Type()):
+			if f.IsNil() {
+				if !optionalFields[name] {
+					return ValidationError{Node: a, Msg: fmt.Sprintf("%s is nil", field.Name)}
+				}
+				continue
+			}
+			if refFields[name] {
+				continue
+			}
+			if err := v.node(f.Interface().(AST)); err != nil {
+				return err
+			}
+		case f.Type() == astSliceType:
+			for j := 0; j < f.Len(); j++ {
+				e := f.Index(j)
+				if e.IsNil() {
+					return ValidationError{Node: a, Msg: fmt.Sprintf("%s[%d] is nil", field.Name, j)}
+				}
+				if err := v.node(e.Interface().(AST)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	v.done[a] = true
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	plus, err := NewOperator("+", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	charConst := NewQualifiedType(NewBuiltinType("char"), "const")
+	var tests = []struct {
+		a       AST
+		want    string
+		mangled string
+	}{
+		{
+			NewFunction(NewName("f"), nil),
+			"f()",
+			"_Z1fv",
+		},
+		{
+			NewFunction(NewQualifiedName("ns", "A", "f"), nil, NewBuiltinType("int"), NewPointer(charConst)),
+			"ns::A::f(int, char const*)",
+			"_ZN2ns1A1fEiPKc",
+		},
+		{
+			NewFunction(NewQualifiedName("A", "operator+"), nil, NewReference(NewQualifiedType(NewName("A"), "const"))),
+			"A::operator+(A const&)",
+			"",
+		},
+		{
+			NewFunction(&Qualified{Scope: NewName("A"), Name: plus}, nil, NewReference(NewQualifiedType(NewName("A"), "const"))),
+			"A::operator+(A const&)",
+			"_ZN1AplERKS_",
+		},
+		{
+			NewTemplate(NewQualifiedName("std", "vector"), NewBuiltinType("int")),
+			"std::vector<int>",
+			"",
+		},
+		{
+			NewFunction(NewName("g"), nil, NewRvalueReference(NewTemplate(NewName("B"), NewBuiltinType("long")))),
+			"g(B<long>&&)",
+			"_Z1gO1BIlE",
+		},
+	}
+	for _, test := range tests {
+		if err := Validate(test.a); err != nil {
+			t.Errorf("%s: %v", test.want, err)
+			continue
+		}
+		if got := ASTToString(test.a); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+		if test.mangled == "" {
+			continue
+		}
+		if got, err := Mangle(test.a); err != nil {
+			t.Errorf("%s: %v", test.want, err)
+		} else if got != test.mangled {
+			t.Errorf("%s: mangled to %s, want %s", test.want, got, test.mangled)
+		}
+	}
+}
+
+func TestNewOperator(t *testing.T) {
+	if op, err := NewOperator("new", 3); err != nil {
+		t.Error(err)
+	} else if got, want := ASTToString(op), "operator new"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if op, err := NewOperator("+", 4); err == nil {
+		t.Errorf("got %#v, want error", op)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	loop := &PointerType{}
+	loop.Base = loop
+	tmpl := NewTemplate(NewName("f"), NewBuiltinType("int"))
+	var tests = []struct {
+		a   AST
+		msg string
+	}{
+		{NewPointer(nil), "Base is nil"},
+		{NewFunction(NewName("f"), nil, NewBuiltinType("int"), nil), "Args[1] is nil"},
+		{NewQualifiedType(nil, "const"), "Base is nil"},
+		{&Operator{}, "empty operator name"},
+		{loop, "node contains itself"},
+		{&TemplateParam{Index: 0}, "Template is nil"},
+		{&TemplateParam{Index: 1, Template: tmpl}, "index 1 out of range of 1 template arguments"},
+		{&SizeofPack{}, "Pack is nil"},
+	}
+	for _, test := range tests {
+		err := Validate(test.a)
+		if err == nil {
+			t.Errorf("%#v: got nil, want error", test.a)
+			continue
+		}
+		ve, ok := err.(ValidationError)
+		if !ok {
+			t.Errorf("%#v: got error type %T, want ValidationError", test.a, err)
+		} else if ve.Msg != test.msg {
+			t.Errorf("%#v: got %q, want %q", test.a, ve.Msg, test.msg)
+		}
+	}
+	if err := Validate(nil); err == nil {
+		t.Error("nil AST: got nil, want error")
+	}
+}
+
+// TestValidateExpected checks that the ASTs of the names in the
+// testdata files are valid.
+func TestValidateExpected(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{filename, rustFilename} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			input := strings.TrimSpace(scanner.Text())
+			for _, options := range [][]Option{nil, {TemplateParamNames}} {
+				a, err := ToAST(input, options...)
+				if err != nil {
+					continue
+				}
+				if err := Validate(a); err != nil {
+					t.Errorf("%s %v: %v", input, options, err)
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+}
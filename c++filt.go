@@ -12,11 +12,14 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/ianlancetaylor/demangle"
@@ -32,11 +35,12 @@ func usage(w io.Writer, status int) {
 	flag.PrintDefaults()
 	fmt.Fprintln(w, `Demangled names are displayed to stdout
 If a name cannot be demangled it is just echoed to stdout.
-If no names are provided on the command line, stdin is read.`)
+If no names are provided on the command line, stdin is read,
+and the names found anywhere in each line are demangled.
+With -f, the single argument is a file to follow as it grows.`)
 	os.Exit(status)
 }
 
-var stripUnderscore = flag.Bool("_", false, "Ignore first leading underscore")
 var noParams = flag.Bool("p", false, "Do not display function argument types")
 var noTemplateParams = flag.Bool("T", false, "Do not display template parameters")
 var noEnclosingParams = flag.Bool("e", false, "Do not display enclosing parameters")
@@ -44,16 +48,42 @@ var noVerbose = flag.Bool("i", false, "Do not show implementation details (if an
 var help = flag.Bool("h", false, "Display help information")
 var debug = flag.Bool("d", false, "Display debugging information for strings on command line")
 var llvm = flag.Bool("llvm", false, "Demangle strings in LLVM style")
+var gnuV2 = flag.Bool("gnu-v2", false, "Also demangle old GNU v2 names")
+var fortran = flag.Bool("fortran", false, "Also decode gfortran external procedure names")
+var goSymbols = flag.Bool("go", false, "Also normalize Go symbol names")
+var jsonOutput = flag.Bool("json", false, "Print each symbol on the command line or input line as a JSON object")
 var maxLen = flag.Int("m", 0, "Maximum length as power of 2, between 1 and 30")
+var maxBytes = flag.Int("max-bytes", 0, "Maximum length in bytes")
+var follow bool
+var stripUnderscore bool
+var types bool
+
+func init() {
+	const usage = "Follow the single file named on the command line, demangling lines as they are appended"
+	flag.BoolVar(&follow, "f", false, usage)
+	flag.BoolVar(&follow, "follow", false, usage)
+
+	const stripUsage = "Ignore first leading underscore, as in the names printed by nm on macOS"
+	flag.BoolVar(&stripUnderscore, "_", false, stripUsage)
+	flag.BoolVar(&stripUnderscore, "strip-underscore", false, stripUsage)
+
+	const typesUsage = "Also demangle strings that are mangled types, such as PFvvE"
+	flag.BoolVar(&types, "t", false, typesUsage)
+	flag.BoolVar(&types, "types", false, typesUsage)
+}
+
+// followInterval is how often we check a followed file for new data.
+const followInterval = 250 * time.Millisecond
 
 // Unimplemented c++filt flags:
 // -n (opposite of -_)
-// -t (demangle types)
 // -s (set demangling style)
 // -V (print version information)
 
-// Characters considered to be part of a symbol.
-const symbolChars = "_$."
+// Characters considered to be part of a symbol. A Microsoft
+// Visual C++ name starts with '?' and contains '@', and a Borland
+// name starts with '@'.
+const symbolChars = "_$.?@"
 
 func main() {
 	flag.Usage = func() { usage(os.Stderr, 1) }
@@ -65,6 +95,18 @@ func main() {
 
 	out := bufio.NewWriter(os.Stdout)
 
+	if follow {
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "-f requires a single file name")
+			os.Exit(2)
+		}
+		if err := followFile(out, flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
 	if flag.NArg() > 0 {
 		for _, f := range flag.Args() {
 			if *debug {
@@ -74,6 +116,9 @@ func main() {
 				} else {
 					fmt.Fprintf(out, "%#v\n", a)
 				}
+			} else if *jsonOutput {
+				doJSON(out, f)
+				continue
 			} else {
 				doDemangle(out, f)
 			}
@@ -89,26 +134,11 @@ func main() {
 	scanner := bufio.NewScanner(bufio.NewReader(os.Stdin))
 	scanner.Buffer(nil, 1<<30)
 	for scanner.Scan() {
-		line := scanner.Text()
-		start := -1
-		for i, c := range line {
-			if unicode.IsLetter(c) || unicode.IsNumber(c) || strings.ContainsRune(symbolChars, c) {
-				if start < 0 {
-					start = i
-				}
-			} else {
-				if start >= 0 {
-					doDemangle(out, line[start:i])
-				}
-				out.WriteRune(c)
-				start = -1
-			}
-		}
-		if start >= 0 {
-			doDemangle(out, line[start:])
-			start = -1
+		if *jsonOutput {
+			doJSON(out, strings.TrimSpace(scanner.Text()))
+		} else {
+			demangleLine(out, scanner.Text())
 		}
-		out.WriteByte('\n')
 		if err := out.Flush(); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(2)
@@ -120,24 +150,166 @@ func main() {
 	}
 }
 
+// demangleLine demangles all the symbols in a line of text,
+// writing the line to out.
+func demangleLine(out *bufio.Writer, line string) {
+	start := -1
+	for i, c := range line {
+		if unicode.IsLetter(c) || unicode.IsNumber(c) || strings.ContainsRune(symbolChars, c) {
+			if start < 0 {
+				start = i
+			}
+		} else {
+			if start >= 0 {
+				demangleWord(out, line[start:i])
+			}
+			out.WriteRune(c)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		demangleWord(out, line[start:])
+	}
+	out.WriteByte('\n')
+}
+
+// demangleWord demangles the symbols in a word of a line of text.
+// A word that is not a symbol may contain one after a period or
+// dollar sign, as in the section name .text._ZN3foo3barEv, or may
+// be a symbol followed by the period that ends a sentence.
+func demangleWord(out *bufio.Writer, word string) {
+	if demangleName(out, word) {
+		return
+	}
+	if t := strings.TrimRight(word, "."); t != "" && len(t) < len(word) && demangleName(out, t) {
+		out.WriteString(word[len(t):])
+		return
+	}
+	for i := 2; i < len(word); i++ {
+		if (word[i-1] == '.' || word[i-1] == '$') && (strings.HasPrefix(word[i:], "_Z") || strings.HasPrefix(word[i:], "_R")) {
+			out.WriteString(word[:i])
+			demangleWord(out, word[i:])
+			return
+		}
+	}
+	// A symbol may have a version, as in _ZN3foo3barEv@@GLIBC_2.2.
+	if i := strings.IndexByte(word, '@'); i > 0 && demangleName(out, word[:i]) {
+		out.WriteString(word[i:])
+		return
+	}
+	out.WriteString(word)
+}
+
+// followFile demangles the lines of a file, like tail -f.
+// It prints the lines already in the file, and then waits for new
+// lines to be appended. If the file is truncated, it starts reading
+// again from the beginning. This only returns on error.
+func followFile(out *bufio.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var offset int64
+	var partial []byte
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			offset += int64(n)
+			data := append(partial, buf[:n]...)
+			for {
+				nl := bytes.IndexByte(data, '\n')
+				if nl < 0 {
+					break
+				}
+				demangleLine(out, strings.TrimSuffix(string(data[:nl]), "\r"))
+				data = data[nl+1:]
+			}
+			partial = append(partial[:0], data...)
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		// We are at the end of the file. Wait for more data.
+		time.Sleep(followInterval)
+
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() < offset {
+			// The file was truncated.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+			partial = partial[:0]
+		}
+	}
+}
+
 // Demangle a string just as the GNU c++filt program does.
 func doDemangle(out *bufio.Writer, name string) {
-	skip := 0
-	if name[0] == '.' || name[0] == '$' {
-		skip++
+	if !demangleName(out, name) {
+		out.WriteString(name)
 	}
-	if *stripUnderscore && name[skip] == '_' {
-		skip++
+}
+
+// demangleName writes the demangled form of a name, and reports
+// whether it did. It writes nothing if the name can't be demangled.
+// A leading '.' or '$' is skipped if the name can't be demangled
+// with it, as a Swift name such as $s4main3fooyyF can.
+func demangleName(out *bufio.Writer, name string) bool {
+	if result, ok := filterName(name); ok {
+		out.WriteString(result)
+		return true
 	}
-	result := demangle.Filter(name[skip:], options()...)
-	if result == name[skip:] {
-		out.WriteString(name)
-	} else {
-		if name[0] == '.' {
-			out.WriteByte('.')
+	if name[0] == '.' || name[0] == '$' {
+		if result, ok := filterName(name[1:]); ok {
+			if name[0] == '.' {
+				out.WriteByte('.')
+			}
+			out.WriteString(result)
+			return true
 		}
-		out.WriteString(result)
 	}
+	return false
+}
+
+// filterName returns the demangled form of a name, and reports
+// whether it could be demangled.
+func filterName(name string) (string, bool) {
+	if stripUnderscore && len(name) > 0 && name[0] == '_' {
+		name = name[1:]
+	}
+	if name == "" {
+		return "", false
+	}
+	result := demangle.Filter(name, options()...)
+	return result, result != name
+}
+
+// doJSON writes a symbol broken into parts as a line of JSON.
+// A symbol that can't be demangled is written as a JSON string.
+func doJSON(out *bufio.Writer, name string) {
+	var v interface{} = name
+	if st, err := demangle.ToStructured(name, options()...); err == nil {
+		v = st
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	out.Write(b)
+	out.WriteByte('\n')
 }
 
 // options returns the demangling options to use based on the command
@@ -159,8 +331,23 @@ func options() []demangle.Option {
 	if *llvm {
 		options = append(options, demangle.LLVMStyle)
 	}
+	if *gnuV2 {
+		options = append(options, demangle.GNUv2)
+	}
+	if *fortran {
+		options = append(options, demangle.Fortran)
+	}
+	if *goSymbols {
+		options = append(options, demangle.GoSymbols)
+	}
+	if types {
+		options = append(options, demangle.Types)
+	}
 	if *maxLen > 0 {
 		options = append(options, demangle.MaxLength(*maxLen))
 	}
+	if *maxBytes > 0 {
+		options = append(options, demangle.MaxLengthBytes(*maxBytes))
+	}
 	return options
 }
@@ -0,0 +1,73 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCxxfilt runs the c++filt program on some lines of text.
+func TestCxxfilt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that builds c++filt in short mode")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("skipping test: go command not found")
+	}
+	dir, err := ioutil.TempDir("", "demangle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prog := filepath.Join(dir, "c++filt")
+	if out, err := exec.Command(gocmd, "build", "-o", prog, "c++filt.go").CombinedOutput(); err != nil {
+		t.Fatalf("building c++filt: %v\n%s", err, out)
+	}
+
+	var tests = []struct {
+		args []string
+		in   string
+		want string
+		fail bool
+	}{
+		{nil, "_Z3foov", "foo()", false},
+		{nil, "call _ZN3foo3barEv.", "call foo::bar().", false},
+		{nil, ".text._ZN3foo3barEv", ".text.foo::bar()", false},
+		{nil, "$_Z3foov", "foo()", false},
+		{nil, "$s4main3fooyyF", "main.foo() -> ()", false},
+		{nil, "?f@@YAXXZ", "void __cdecl f(void)", false},
+		{nil, "@foo$qv", "foo()", false},
+		{nil, "_Z3foov@@GLIBC_2.2", "foo()@@GLIBC_2.2", false},
+		{nil, "what? _Z3foov", "what? foo()", false},
+		{[]string{"-_"}, "__Z3foov _$s4main3fooyyF", "foo() main.foo() -> ()", false},
+		{[]string{"-f"}, "", "-f requires a single file name", true},
+		{[]string{"-f", "a", "b"}, "", "-f requires a single file name", true},
+	}
+	for _, test := range tests {
+		cmd := exec.Command(prog, test.args...)
+		cmd.Stdin = strings.NewReader(test.in + "\n")
+		out, err := cmd.CombinedOutput()
+		if test.fail {
+			if err == nil {
+				t.Errorf("c++filt %v: unexpected success", test.args)
+			} else if got := strings.TrimSuffix(string(out), "\n"); got != test.want {
+				t.Errorf("c++filt %v: got error %q, want %q", test.args, got, test.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("c++filt %v: %v\n%s", test.args, err, out)
+		}
+		if got := strings.TrimSuffix(string(out), "\n"); got != test.want {
+			t.Errorf("c++filt %v on %q: got %q, want %q", test.args, test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+)
+
+// cacheShards is the number of shards in a Cache. Each shard has its
+// own lock, so that goroutines demangling different names rarely
+// wait for each other.
+const cacheShards = 16
+
+// A Cache remembers the results of ToString for recently demangled
+// names, which is useful for programs such as profilers that see the
+// same names many times. A Cache holds a bounded number of results,
+// and discards the least recently used ones.
+//
+// A Cache may be used concurrently by multiple goroutines.
+type Cache struct {
+	shards [cacheShards]cacheShard
+}
+
+// cacheShard is one shard of a Cache.
+type cacheShard struct {
+	mu      sync.Mutex
+	max     int
+	entries map[cacheKey]*list.Element
+	lru     list.List // of *cacheEntry, most recently used first
+}
+
+// cacheKey is the key of a cached result: the name and the options.
+type cacheKey struct {
+	name    string
+	options string
+}
+
+// cacheEntry is a cached result.
+type cacheEntry struct {
+	key cacheKey
+	s   string
+	err error
+}
+
+// NewCache returns a Cache that holds about size results.
+func NewCache(size int) *Cache {
+	per := (size + cacheShards - 1) / cacheShards
+	if per < 1 {
+		per = 1
+	}
+	c := new(Cache)
+	for i := range c.shards {
+		c.shards[i].max = per
+		c.shards[i].entries = make(map[cacheKey]*list.Element)
+	}
+	return c
+}
+
+// ToString returns the same result as the ToString function, using a
+// cached result if there is one. Errors are cached too.
+func (c *Cache) ToString(name string, options ...Option) (string, error) {
+	key := cacheKey{name: name}
+	if len(options) > 0 {
+		var buf []byte
+		for _, o := range options {
+			buf = strconv.AppendInt(buf, int64(o), 16)
+			buf = append(buf, ',')
+		}
+		key.options = string(buf)
+	}
+
+	sh := &c.shards[cacheHash(name)%cacheShards]
+	sh.mu.Lock()
+	if e, ok := sh.entries[key]; ok {
+		sh.lru.MoveToFront(e)
+		ce := e.Value.(*cacheEntry)
+		sh.mu.Unlock()
+		return ce.s, ce.err
+	}
+	sh.mu.Unlock()
+
+	// Demangle without holding the lock. If another goroutine
+	// demangles the same name at the same time, one of the
+	// results is kept.
+	s, err := ToString(name, options...)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, ok := sh.entries[key]; !ok {
+		sh.entries[key] = sh.lru.PushFront(&cacheEntry{key: key, s: s, err: err})
+		if sh.lru.Len() > sh.max {
+			last := sh.lru.Back()
+			sh.lru.Remove(last)
+			delete(sh.entries, last.Value.(*cacheEntry).key)
+		}
+	}
+	return s, err
+}
+
+// Len returns the number of results in the cache.
+func (c *Cache) Len() int {
+	n := 0
+	for i := range c.shards {
+		sh := &c.shards[i]
+		sh.mu.Lock()
+		n += sh.lru.Len()
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// cacheHash returns the FNV-1a hash of a name, to choose a shard.
+func cacheHash(name string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return h
+}
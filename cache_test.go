@@ -0,0 +1,63 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(100)
+	for i := 0; i < 2; i++ {
+		if s, err := c.ToString("_ZN1A1fEv"); err != nil || s != "A::f()" {
+			t.Errorf("got %q, %v", s, err)
+		}
+		if s, err := c.ToString("_ZN1A1fEv", NoParams); err != nil || s != "A::f" {
+			t.Errorf("NoParams: got %q, %v", s, err)
+		}
+		if _, err := c.ToString("_Z"); err == nil {
+			t.Error("_Z: unexpected success")
+		}
+	}
+	if got := c.Len(); got != 3 {
+		t.Errorf("Len = %d, want 3", got)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache(cacheShards)
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("_Z%d%sv", len(fmt.Sprint("f", i)), fmt.Sprint("f", i))
+		want := fmt.Sprintf("f%d()", i)
+		if s, err := c.ToString(name); err != nil || s != want {
+			t.Errorf("%s: got %q, %v; want %q", name, s, err, want)
+		}
+	}
+	if got := c.Len(); got > cacheShards {
+		t.Errorf("Len = %d, want at most %d", got, cacheShards)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	c := NewCache(8)
+	names := []string{"_ZN1A1fEv", "_ZN1A1gEv", "_Z1fi", "_Z1gc", "_ZTV1A"}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := names[i%len(names)]
+				want, _ := ToString(name)
+				if got, err := c.ToString(name); err != nil || got != want {
+					t.Errorf("%s: got %q, %v; want %q", name, got, err, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -30182,10 +30182,9 @@ var cases = [][2]string{
 // over time.
 var casesExpectedFailures = map[string]bool{
 	"_ZSteqIcEN9__gnu_cxx11__enable_ifIXsr9__is_charIT_EE7__valueEbE6__typeERKSbIS2_St11char_traitsIS2_ESaIS2_EESA_": true,
-	"_Z1fPU11objcproto1A11objc_object":             true,
-	"_Z1fPKU11objcproto1A7NSArray":                 true,
-	"_ZNK1AIJ1Z1Y1XEEcv1BIJDpPT_EEIJS2_S1_S0_EEEv": true,
-	"_ZNK3Ncr6Silver7Utility6detail12CallOnThreadIZ53-[DeploymentSetupController handleManualServerEntry:]E3$_5EclIJEEEDTclclL_ZNS2_4getTIS4_EERT_vEEspclsr3stdE7forwardIT_Efp_EEEDpOSA_": true,
+	"_Z1fPU11objcproto1A11objc_object":                 true,
+	"_Z1fPKU11objcproto1A7NSArray":                     true,
+	"_ZNK1AIJ1Z1Y1XEEcv1BIJDpPT_EEIJS2_S1_S0_EEEv":     true,
 	"_Z1fIJicEEvDp7MuncherIAstT__S1_E":                 true,
 	"_ZN5test31aINS_1XEMS1_PiEEvT_T0_DTdsfL0p_fL0p0_E": true,
 	"_Z1fPU3AS1KiS0_":                                  true,
@@ -30300,3 +30299,33 @@ func TestCases(t *testing.T) {
 		t.Logf("%d expected failures out of %d cases", expectedErrors, len(cases))
 	}
 }
+
+// BenchmarkToString demangles the names in cases, to measure the
+// time and memory used for a large set of real names.
+func BenchmarkToString(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, test := range cases {
+			ToString(test[0])
+		}
+	}
+}
+
+// BenchmarkToStringLong demangles the names in cases whose demangled
+// form is at least 1000 bytes long. Most of the time for these names
+// goes to substitutions and template arguments.
+func BenchmarkToStringLong(b *testing.B) {
+	var names []string
+	for _, test := range cases {
+		if len(test[1]) >= 1000 {
+			names = append(names, test[0])
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, name := range names {
+			ToString(name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// CtorKind is the variant of a C++ constructor. The values are
+// the same as those of enum gnu_v3_ctor_kinds in the GNU libiberty
+// library.
+type CtorKind int
+
+const (
+	NotCtor                      CtorKind = iota // not a constructor
+	CompleteObjectCtor                           // C1
+	BaseObjectCtor                               // C2
+	CompleteObjectAllocatingCtor                 // C3
+	UnifiedCtor                                  // C4
+	ObjectCtorGroup                              // C5
+)
+
+// DtorKind is the variant of a C++ destructor. The values are
+// the same as those of enum gnu_v3_dtor_kinds in the GNU libiberty
+// library.
+type DtorKind int
+
+const (
+	NotDtor            DtorKind = iota // not a destructor
+	DeletingDtor                       // D0
+	CompleteObjectDtor                 // D1
+	BaseObjectDtor                     // D2
+	UnifiedDtor                        // D4
+	ObjectDtorGroup                    // D5
+)
+
+// ctorKinds maps the character following C in a mangled name
+// to the kind of constructor.
+var ctorKinds = map[byte]CtorKind{
+	'1': CompleteObjectCtor,
+	'2': BaseObjectCtor,
+	'3': CompleteObjectAllocatingCtor,
+	'4': UnifiedCtor,
+	'5': ObjectCtorGroup,
+}
+
+// dtorKinds maps the character following D in a mangled name
+// to the kind of destructor.
+var dtorKinds = map[byte]DtorKind{
+	'0': DeletingDtor,
+	'1': CompleteObjectDtor,
+	'2': BaseObjectDtor,
+	'4': UnifiedDtor,
+	'5': ObjectDtorGroup,
+}
+
+// IsCtor reports which variant of a constructor a C++ symbol name
+// refers to. It returns NotCtor if the name is not the name of a
+// constructor, or can not be demangled.
+// This is like is_gnu_v3_mangled_ctor in the GNU libiberty library.
+func IsCtor(name string) CtorKind {
+	if c, ok := cdtorName(name).(*Constructor); ok {
+		return c.Kind
+	}
+	return NotCtor
+}
+
+// IsDtor reports which variant of a destructor a C++ symbol name
+// refers to. It returns NotDtor if the name is not the name of a
+// destructor, or can not be demangled.
+// This is like is_gnu_v3_mangled_dtor in the GNU libiberty library.
+func IsDtor(name string) DtorKind {
+	if d, ok := cdtorName(name).(*Destructor); ok {
+		return d.Kind
+	}
+	return NotDtor
+}
+
+// cdtorName returns the last component of the name of the entity
+// that a symbol name refers to, or nil if the name can not be
+// demangled.
+func cdtorName(name string) AST {
+	a, err := ToAST(name, NoParams)
+	if err != nil {
+		return nil
+	}
+	for {
+		switch n := a.(type) {
+		case *Typed:
+			a = n.Name
+		case *Template:
+			a = n.Name
+		case *Qualified:
+			a = n.Name
+		case *TaggedName:
+			a = n.Name
+		case *Clone:
+			a = n.Base
+		default:
+			return a
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestCDtor(t *testing.T) {
+	var tests = []struct {
+		input string
+		ctor  CtorKind
+		dtor  DtorKind
+	}{
+		{"_ZN1AC1Ev", CompleteObjectCtor, NotDtor},
+		{"_ZN1AC2Ei", BaseObjectCtor, NotDtor},
+		{"_ZN1AC3Ev", CompleteObjectAllocatingCtor, NotDtor},
+		{"_ZN1AC4Ev", UnifiedCtor, NotDtor},
+		{"_ZN1AC5Ev", ObjectCtorGroup, NotDtor},
+		{"_ZN1AD0Ev", NotCtor, DeletingDtor},
+		{"_ZN1AD1Ev", NotCtor, CompleteObjectDtor},
+		{"_ZN1AD2Ev", NotCtor, BaseObjectDtor},
+		{"_ZN1AD4Ev", NotCtor, UnifiedDtor},
+		{"_ZN1AD5Ev", NotCtor, ObjectDtorGroup},
+		{"_ZN1AC2IiEET_", BaseObjectCtor, NotDtor},
+		{"_ZN1AC2Ev.cold", BaseObjectCtor, NotDtor},
+		{"_ZN1AD1B5cxx11Ev", NotCtor, CompleteObjectDtor},
+		{"_ZN1BCI11AEi", CompleteObjectCtor, NotDtor},
+		{"_ZN1A1fEv", NotCtor, NotDtor},
+		{"_ZTV1A", NotCtor, NotDtor},
+		{"_ZThn8_N1AD1Ev", NotCtor, NotDtor},
+		{"_Z", NotCtor, NotDtor},
+		{"foo", NotCtor, NotDtor},
+	}
+	for _, test := range tests {
+		if got := IsCtor(test.input); got != test.ctor {
+			t.Errorf("IsCtor(%s) = %d, want %d", test.input, got, test.ctor)
+		}
+		if got := IsDtor(test.input); got != test.dtor {
+			t.Errorf("IsDtor(%s) = %d, want %d", test.input, got, test.dtor)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"io"
+	"strings"
+)
+
+// minChunkSize and maxChunkSize bound the size of the chunks that
+// a chunkBuffer allocates when it is not told how much to expect.
+const (
+	minChunkSize = 256
+	maxChunkSize = 64 << 10
+)
+
+// A chunkBuffer collects the output of the printer. Unlike a
+// bytes.Buffer it never moves the bytes that have been written:
+// when the current chunk is full it starts a new one, and String
+// joins the chunks once at the end. A demangled name can be many
+// megabytes long, and growing a single buffer would copy it over
+// and over. A name that fits in one chunk, which is nearly every
+// name, is returned by String without copying, as with a
+// strings.Builder.
+type chunkBuffer struct {
+	full []string        // chunks that have been filled
+	cur  strings.Builder // the chunk being written
+	n    int             // total length of the full chunks
+}
+
+// Len returns the number of bytes written.
+func (cb *chunkBuffer) Len() int {
+	return cb.n + cb.cur.Len()
+}
+
+// room returns the number of bytes that fit in the current chunk.
+func (cb *chunkBuffer) room() int {
+	return cb.cur.Cap() - cb.cur.Len()
+}
+
+// Grow makes room for at least n more bytes in the current chunk,
+// so that an expected amount of output is written without starting
+// a new chunk.
+func (cb *chunkBuffer) Grow(n int) {
+	if cb.room() >= n {
+		return
+	}
+	cb.start(n)
+}
+
+// next moves the current chunk, which is full, to the list of full
+// chunks and starts a new one, twice as large up to maxChunkSize.
+func (cb *chunkBuffer) next() {
+	size := 2 * cb.cur.Cap()
+	if size < minChunkSize {
+		size = minChunkSize
+	} else if size > maxChunkSize {
+		size = maxChunkSize
+	}
+	cb.start(size)
+}
+
+// start moves the current chunk, if not empty, to the list of full
+// chunks, and starts a new one of the given size.
+func (cb *chunkBuffer) start(size int) {
+	if cb.cur.Len() > 0 {
+		cb.full = append(cb.full, cb.cur.String())
+		cb.n += cb.cur.Len()
+	}
+	cb.cur = strings.Builder{}
+	cb.cur.Grow(size)
+}
+
+// WriteByte appends a byte.
+func (cb *chunkBuffer) WriteByte(b byte) error {
+	if cb.room() == 0 {
+		cb.next()
+	}
+	return cb.cur.WriteByte(b)
+}
+
+// WriteString appends a string.
+func (cb *chunkBuffer) WriteString(s string) (int, error) {
+	n := len(s)
+	for {
+		if r := cb.room(); r < len(s) {
+			cb.cur.WriteString(s[:r])
+			s = s[r:]
+			cb.next()
+			continue
+		}
+		cb.cur.WriteString(s)
+		return n, nil
+	}
+}
+
+// Write appends a byte slice, so that a chunkBuffer is an io.Writer.
+func (cb *chunkBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		if r := cb.room(); r < len(p) {
+			cb.cur.Write(p[:r])
+			p = p[r:]
+			cb.next()
+			continue
+		}
+		cb.cur.Write(p)
+		return n, nil
+	}
+}
+
+// String returns the bytes that have been written. If they are all
+// in the current chunk, it does not copy them.
+func (cb *chunkBuffer) String() string {
+	if len(cb.full) == 0 {
+		return cb.cur.String()
+	}
+	var sb strings.Builder
+	sb.Grow(cb.Len())
+	for _, c := range cb.full {
+		sb.WriteString(c)
+	}
+	sb.WriteString(cb.cur.String())
+	return sb.String()
+}
+
+// WriteTo writes the bytes that have been written to w.
+func (cb *chunkBuffer) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, c := range cb.full {
+		n, err := io.WriteString(w, c)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	n, err := io.WriteString(w, cb.cur.String())
+	total += int64(n)
+	return total, err
+}
+
+// Reset discards the bytes that have been written. The current
+// chunk is not reused, as String may have returned it.
+func (cb *chunkBuffer) Reset() {
+	for i := range cb.full {
+		cb.full[i] = ""
+	}
+	cb.full = cb.full[:0]
+	cb.cur.Reset()
+	cb.n = 0
+}
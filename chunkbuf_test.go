@@ -0,0 +1,112 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestChunkBuffer(t *testing.T) {
+	var tests = []struct {
+		grow   int
+		writes []string
+	}{
+		{0, nil},
+		{0, []string{"a"}},
+		{0, []string{"int", " ", "f", "(", "char", ")"}},
+		{0, []string{strings.Repeat("x", minChunkSize-1), "yz"}},
+		{0, []string{strings.Repeat("x", 3*maxChunkSize+7)}},
+		{10, []string{"0123456789"}},
+		{10, []string{"0123456789", "0"}},
+		{1 << 20, []string{strings.Repeat("abc", 1000)}},
+	}
+	for _, test := range tests {
+		var cb chunkBuffer
+		// Write twice, to check that Reset discards
+		// everything.
+		for i := 0; i < 2; i++ {
+			cb.Reset()
+			cb.Grow(test.grow)
+			var want bytes.Buffer
+			for j, s := range test.writes {
+				switch j % 3 {
+				case 0:
+					cb.WriteString(s)
+				case 1:
+					cb.Write([]byte(s))
+				case 2:
+					for k := 0; k < len(s); k++ {
+						cb.WriteByte(s[k])
+					}
+				}
+				want.WriteString(s)
+			}
+			if cb.Len() != want.Len() {
+				t.Errorf("%d %q: Len = %d, want %d", test.grow, test.writes, cb.Len(), want.Len())
+			}
+			if got := cb.String(); got != want.String() {
+				t.Errorf("%d %q: String = %q, want %q", test.grow, test.writes, got, want.String())
+			}
+			var out bytes.Buffer
+			if n, err := cb.WriteTo(&out); err != nil || n != int64(want.Len()) || out.String() != want.String() {
+				t.Errorf("%d %q: WriteTo = %d, %v, %q; want %d, nil, %q", test.grow, test.writes, n, err, out.String(), want.Len(), want.String())
+			}
+		}
+	}
+}
+
+func TestChunkBufferLarge(t *testing.T) {
+	// Build a name with a demangled form that is about a
+	// megabyte long, by doubling a function pointer type with
+	// substitutions.
+	var b strings.Builder
+	b.WriteString("_Z1f1A")
+	for k := 1; k <= 15; k++ {
+		prev := "S_"
+		if k > 1 {
+			prev = "S" + strings.ToUpper(strconv.FormatInt(int64(2*k-3), 36)) + "_"
+		}
+		b.WriteString("PFv" + prev + prev + "E")
+	}
+	name := b.String()
+
+	s, err := ToString(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s) < 512<<10 {
+		t.Fatalf("demangled name is %d bytes, want at least %d", len(s), 512<<10)
+	}
+	var w bytes.Buffer
+	if err := ToWriter(&w, name); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != s {
+		t.Errorf("ToWriter and ToString differ")
+	}
+
+	// The printer should not have copied the output into
+	// larger and larger buffers.
+	a, err := ToAST(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ps := newPrintState(a, nil)
+	a.print(ps)
+	if ps.buf.Len() != len(s) {
+		t.Errorf("printed %d bytes, want %d", ps.buf.Len(), len(s))
+	}
+	for i, c := range ps.buf.full {
+		if len(c) > maxChunkSize {
+			t.Errorf("chunk %d has length %d, want at most %d", i, len(c), maxChunkSize)
+		}
+	}
+	if c := ps.buf.cur.Cap(); c > maxChunkSize {
+		t.Errorf("current chunk has capacity %d, want at most %d", c, maxChunkSize)
+	}
+}
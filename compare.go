@@ -0,0 +1,173 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Comparator orders symbol names by their demangled components
+// rather than by their mangled bytes. Names are ordered by the
+// enclosing namespaces and classes, then by the unqualified name,
+// then by the number of function parameters, and finally by the
+// complete demangled string. Names that can not be demangled sort as
+// though they were unqualified names with no parameters.
+//
+// A Comparator caches the demangled form of each name that it sees.
+// It is not safe for concurrent use.
+type Comparator struct {
+	options []Option
+	cache   map[string]*sortKey
+}
+
+// NewComparator returns a new Comparator that demangles names using
+// the options.
+func NewComparator(options ...Option) *Comparator {
+	return &Comparator{
+		options: options,
+		cache:   make(map[string]*sortKey),
+	}
+}
+
+// Compare returns -1 if a sorts before b, 1 if a sorts after b,
+// and 0 if they are the same.
+func (c *Comparator) Compare(a, b string) int {
+	if a == b {
+		return 0
+	}
+	return c.key(a).compare(c.key(b))
+}
+
+// Less reports whether a sorts before b.
+// This is suitable for use with sort.Slice.
+func (c *Comparator) Less(a, b string) bool {
+	return c.Compare(a, b) < 0
+}
+
+// SortSymbols sorts a list of symbol names by their demangled
+// components, as described at Comparator.
+func SortSymbols(names []string, options ...Option) {
+	c := NewComparator(options...)
+	sort.SliceStable(names, func(i, j int) bool {
+		return c.Less(names[i], names[j])
+	})
+}
+
+// sortKey holds the demangled information used to sort a name.
+type sortKey struct {
+	mangled   string
+	scope     []string // enclosing scopes, outermost first
+	name      string   // unqualified name
+	arity     int      // number of parameters, -1 if not a function
+	demangled string   // complete demangled string
+}
+
+// key returns the sort key for a name, using the cache.
+func (c *Comparator) key(name string) *sortKey {
+	if k, ok := c.cache[name]; ok {
+		return k
+	}
+	k := makeSortKey(name, c.options)
+	c.cache[name] = k
+	return k
+}
+
+// makeSortKey builds the sort key for a name.
+func makeSortKey(name string, options []Option) *sortKey {
+	k := &sortKey{
+		mangled:   name,
+		name:      name,
+		arity:     -1,
+		demangled: name,
+	}
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		if s, err := ToString(name, options...); err == nil {
+			// A Rust name that we can't represent as an AST.
+			k.demangled = s
+			if i := strings.LastIndex(s, "::"); i >= 0 {
+				k.scope = strings.Split(s[:i], "::")
+				k.name = s[i+2:]
+			} else {
+				k.name = s
+			}
+		}
+		return k
+	}
+	k.demangled = ASTToString(a, options...)
+	k.scope, k.name, k.arity = nameComponents(a, options)
+	return k
+}
+
+// nameComponents returns the enclosing scopes, the unqualified name,
+// and the number of parameters of a demangled symbol.
+// The number of parameters is -1 if the symbol is not a function.
+func nameComponents(a AST, options []Option) ([]string, string, int) {
+	for {
+		c, ok := a.(*Clone)
+		if !ok {
+			break
+		}
+		a = c.Base
+	}
+
+	arity := -1
+	if t, ok := a.(*Typed); ok {
+		a = t.Name
+		typ := t.Type
+		if mwq, ok := typ.(*MethodWithQualifiers); ok {
+			typ = mwq.Method
+		}
+		if ft, ok := typ.(*FunctionType); ok {
+			arity = len(ft.Args)
+		}
+	}
+
+	var scope []string
+	for {
+		q, ok := a.(*Qualified)
+		if !ok {
+			break
+		}
+		scope = append(scope, ASTToString(q.Name, options...))
+		a = q.Scope
+	}
+	scope = append(scope, ASTToString(a, options...))
+
+	// scope is now innermost first.
+	for i, j := 0, len(scope)-1; i < j; i, j = i+1, j-1 {
+		scope[i], scope[j] = scope[j], scope[i]
+	}
+	return scope[:len(scope)-1], scope[len(scope)-1], arity
+}
+
+// compare compares two sort keys.
+func (k *sortKey) compare(o *sortKey) int {
+	for i := 0; i < len(k.scope) && i < len(o.scope); i++ {
+		if c := strings.Compare(k.scope[i], o.scope[i]); c != 0 {
+			return c
+		}
+	}
+	if len(k.scope) != len(o.scope) {
+		if len(k.scope) < len(o.scope) {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(k.name, o.name); c != 0 {
+		return c
+	}
+	if k.arity != o.arity {
+		if k.arity < o.arity {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(k.demangled, o.demangled); c != 0 {
+		return c
+	}
+	return strings.Compare(k.mangled, o.mangled)
+}
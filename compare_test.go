@@ -0,0 +1,53 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortSymbols(t *testing.T) {
+	names := []string{
+		"_ZN1B1fEv",
+		"_ZN1A1gEi",
+		"main",
+		"_ZN1A1B1fEv",
+		"_ZN1A1fEii",
+		"_ZN1A1fEi",
+		"_ZN1A1fE",
+		"_ZN1A1fEv",
+	}
+	want := []string{
+		"main",
+		"_ZN1A1fE",
+		"_ZN1A1fEv",
+		"_ZN1A1fEi",
+		"_ZN1A1fEii",
+		"_ZN1A1gEi",
+		"_ZN1A1B1fEv",
+		"_ZN1B1fEv",
+	}
+	SortSymbols(names)
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SortSymbols got %v, want %v", names, want)
+	}
+}
+
+func TestComparator(t *testing.T) {
+	c := NewComparator()
+	if got := c.Compare("_ZN1A1fEv", "_ZN1A1fEv"); got != 0 {
+		t.Errorf("Compare of equal names = %d, want 0", got)
+	}
+	if !c.Less("_ZN1A1fEi", "_ZN1A1fEii") {
+		t.Errorf("Less(f(int), f(int, int)) = false, want true")
+	}
+	if c.Less("_ZN1A1fEii", "_ZN1A1fEi") {
+		t.Errorf("Less(f(int, int), f(int)) = true, want false")
+	}
+	if len(c.cache) != 2 {
+		t.Errorf("cache has %d entries, want 2", len(c.cache))
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Contains reports whether the demangled form of a symbol name, as
+// returned by ToString with the same options, contains substr.
+// If the name can not be demangled, Contains reports false.
+//
+// For C++ names Contains searches the demangled name while it is
+// being printed, and stops as soon as it finds substr, without
+// building the whole string, which for some names is very long.
+func Contains(name, substr string, options ...Option) bool {
+	if substr == "" {
+		_, err := ToString(name, options...)
+		return err == nil
+	}
+	cw := &containsWriter{substr: []byte(substr)}
+	ToWriter(cw, name, options...)
+	return cw.found
+}
+
+// errFound is returned by containsWriter to stop printing.
+var errFound = errors.New("found substring")
+
+// containsWriter is an io.Writer that looks for substr in the data
+// written to it.
+type containsWriter struct {
+	substr []byte
+	found  bool
+
+	// The tail field holds the end of the data written so far,
+	// up to one byte shorter than substr, to find a match that
+	// spans two writes.
+	tail []byte
+}
+
+func (cw *containsWriter) Write(p []byte) (int, error) {
+	if cw.found {
+		return 0, errFound
+	}
+	keep := len(cw.substr) - 1
+	n := len(p)
+	if n > keep {
+		n = keep
+	}
+	cw.tail = append(cw.tail, p[:n]...)
+	if bytes.Contains(cw.tail, cw.substr) || bytes.Contains(p, cw.substr) {
+		cw.found = true
+		return 0, errFound
+	}
+	if len(p) >= keep {
+		cw.tail = append(cw.tail[:0], p[len(p)-keep:]...)
+	} else if len(cw.tail) > keep {
+		cw.tail = cw.tail[len(cw.tail)-keep:]
+	}
+	return len(p), nil
+}
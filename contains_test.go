@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestContains(t *testing.T) {
+	var tests = []struct {
+		name   string
+		substr string
+		want   bool
+	}{
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "push_back", true},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::allocator<int>", true},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "pop_back", false},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "", true},
+		{"_ZN1A1fEv", "A::f()", true},
+		{"_ZN1A1fEv", "A::f() const", false},
+		{"_RNvCs15kBYyAo9fc_7mycrate7example", "mycrate::example", true},
+		{"_Z1", "", false},
+		{"_Z1", "_Z", false},
+		{"not mangled", "mangled", false},
+	}
+	for _, test := range tests {
+		if got := Contains(test.name, test.substr); got != test.want {
+			t.Errorf("Contains(%q, %q) = %t, want %t", test.name, test.substr, got, test.want)
+		}
+	}
+
+	if Contains("_ZN1A1fEi", "int", NoParams) {
+		t.Errorf("Contains with NoParams: found parameter type")
+	}
+}
+
+func TestContainsLong(t *testing.T) {
+	// Build a name whose demangled form is several times
+	// flushSize, with distinct parameter names.
+	var sb strings.Builder
+	sb.WriteString("_Z1f")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "5a%04d", i)
+	}
+	name := sb.String()
+	s, err := ToString(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s) < 3*flushSize {
+		t.Fatalf("demangled name is only %d bytes", len(s))
+	}
+
+	for _, off := range []int{0, 100, flushSize - 5, flushSize - 1, flushSize, 2*flushSize - 3, len(s) - 12} {
+		substr := s[off : off+12]
+		if !Contains(name, substr) {
+			t.Errorf("did not find %q at offset %d", substr, off)
+		}
+	}
+	if Contains(name, "a2000") {
+		t.Errorf("found a2000, which is not in the name")
+	}
+}
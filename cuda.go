@@ -0,0 +1,95 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// This file handles the ways that nvcc, the CUDA compiler, wraps
+// Itanium C++ names.
+//
+// The host function that launches a kernel is named by adding
+// "__device_stub_" in front of the kernel name, as in
+// "__device_stub__Z6kernelPf". When that function is itself a C++
+// function its name is mangled again, as in
+// "_Z20__device_stub__Z6kernelPfPf".
+//
+// Device code may have clones of a function whose names add a suffix
+// starting with '$', as in "_Z6kernelPf$1". Tools that print device
+// code may also add a '$' in front of the name, as in "$_Z6kernelPf".
+
+// cudaStubPrefix is the prefix that nvcc adds to a kernel name to
+// name the host stub function.
+const cudaStubPrefix = "__device_stub_"
+
+// cudaToAST demangles a name that starts with one of the prefixes
+// used by nvcc. It reports false if the name does not start with
+// such a prefix, or if the wrapped name is not valid.
+func cudaToAST(name string, options []Option) (AST, bool) {
+	switch {
+	case strings.HasPrefix(name, cudaStubPrefix+"_Z"):
+		return cudaStub(name[len(cudaStubPrefix):], options)
+
+	case strings.HasPrefix(name, "$_Z"):
+		name = name[1:]
+		if a, err := doDemangle(name[2:], options...); err == nil {
+			return a, true
+		}
+		return cudaClone(name, options)
+
+	case strings.HasPrefix(name, "_Z"):
+		// A stub function with a C++ name. The parameter types
+		// of the stub are the same as those of the kernel, so
+		// we only need the kernel name.
+		i := 2
+		n := 0
+		for i < len(name) && isDigit(name[i]) {
+			n = n*10 + int(name[i]-'0')
+			if n > len(name) {
+				return nil, false
+			}
+			i++
+		}
+		if i == 2 || i+n > len(name) || !strings.HasPrefix(name[i:i+n], cudaStubPrefix+"_Z") {
+			return nil, false
+		}
+		if _, err := doDemangle(name[2:], options...); err != nil {
+			return nil, false
+		}
+		return cudaStub(name[i+len(cudaStubPrefix):i+n], options)
+	}
+	return nil, false
+}
+
+// cudaStub returns the AST for the stub function that launches the
+// kernel whose mangled name is kernel.
+func cudaStub(kernel string, options []Option) (AST, bool) {
+	a, err := ToAST(kernel, options...)
+	if err != nil {
+		return nil, false
+	}
+	return &Special{Prefix: "device stub for ", Val: a}, true
+}
+
+// cudaClone demangles a mangled name, starting with "_Z", that is
+// followed by a clone suffix starting with '$'. A source name in the
+// mangled name may also contain '$', so we try each one in turn.
+func cudaClone(name string, options []Option) (AST, bool) {
+	for i := 2; i < len(name)-1; i++ {
+		if name[i] != '$' {
+			continue
+		}
+		a, err := doDemangle(name[2:i], options...)
+		if err != nil {
+			continue
+		}
+		for _, o := range options {
+			if o == NoClones {
+				return a, true
+			}
+		}
+		return &Clone{Base: a, Suffix: name[i:]}, true
+	}
+	return nil, false
+}
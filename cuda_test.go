@@ -0,0 +1,52 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestCUDA(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"__device_stub__Z6kernelPf", nil, "device stub for kernel(float*)"},
+		{"__device_stub__Z3fooIiEvT_", nil, "device stub for void foo<int>(int)"},
+		{"__device_stub__Z6kernelPf", []Option{NoParams}, "device stub for kernel"},
+		{"_Z25__device_stub__Z6kernelPfPf", nil, "device stub for kernel(float*)"},
+		{"_Z6kernelPf$1", nil, "kernel(float*) [clone $1]"},
+		{"_Z6kernelPf$1", []Option{LLVMStyle}, "kernel(float*) ($1)"},
+		{"_Z6kernelPf$1", []Option{NoClones}, "kernel(float*)"},
+		{"_Z6kernelPf$1", []Option{NoParams}, "kernel"},
+		{"$_Z6kernelPf", nil, "kernel(float*)"},
+		{"$_Z6kernelPf$__cuda_sm3x_div_rn_noftz_f32_slowpath", nil, "kernel(float*) [clone $__cuda_sm3x_div_rn_noftz_f32_slowpath]"},
+		{"_ZN3$_01fEv$2", nil, "$_0::f() [clone $2]"},
+		{"_ZN3$_01fEv", nil, "$_0::f()"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestCUDAFailure(t *testing.T) {
+	for _, input := range []string{
+		"__device_stub_",
+		"__device_stub__Z",
+		"__device_stub__Zfoo",
+		"_Z25__device_stub__Z6kernel",
+		"_Z6kernelPf$",
+		"$_Z",
+		"$_Zfoo$1",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
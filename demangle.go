@@ -3,11 +3,21 @@
 // license that can be found in the LICENSE file.
 
 // Package demangle defines functions that demangle GCC/LLVM
-// C++ and Rust symbol names.
+// C++, Microsoft Visual C++, Borland C++, Watcom C++, Rust, Swift,
+// and D symbol names.
 // This package recognizes names that were mangled according to the C++ ABI
-// defined at http://codesourcery.com/cxx-abi/ and the Rust ABI
+// defined at http://codesourcery.com/cxx-abi/, names mangled by the
+// Microsoft Visual C++ compiler, the Borland C++ compiler, whose
+// names start with "@", and the Watcom C++ compiler, whose names
+// start with "W?", the Rust ABI
 // defined at
-// https://rust-lang.github.io/rfcs/2603-rust-symbol-name-mangling-v0.html
+// https://rust-lang.github.io/rfcs/2603-rust-symbol-name-mangling-v0.html,
+// the Swift ABI, whose names start with "$s" or, for older
+// versions of Swift, "$S" or "_T0", and the D ABI defined at
+// https://dlang.org/spec/abi.html, whose names start with "_D".
+// With options, it also demangles names mangled by GCC 2.x (GNUv2),
+// decodes gfortran procedure names (Fortran), and normalizes the
+// symbol names of the Go toolchain (GoSymbols).
 //
 // Most programs will want to call Filter or ToString.
 package demangle
@@ -16,10 +26,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 )
 
-// ErrNotMangledName is returned by CheckedDemangle if the string does
-// not appear to be a C++ symbol name.
+// ErrNotMangledName is returned by ToString and the other demangling
+// functions if the string does not appear to be a symbol name that
+// they can demangle.
 var ErrNotMangledName = errors.New("not a C++ or Rust mangled name")
 
 // Option is the type of demangler options.
@@ -57,8 +69,282 @@ const (
 	// LLVMStyle tries to translate an AST to a string in the
 	// style of the LLVM demangler. This does not affect
 	// the parsing of the AST, only the conversion of the AST
-	// to a string.
+	// to a string. It is the same as the LLVMExpressions,
+	// LLVMTypes, LLVMLambdas, LLVMLiterals, LLVMClones,
+	// LLVMSpecialNames, and NoAngleSpace options together,
+	// which may be used without LLVMStyle to select only some
+	// of the differences.
 	LLVMStyle
+
+	// The NoVendorQualifiers option omits vendor extended type
+	// qualifiers, such as calling conventions and address spaces,
+	// and the clang enable_if attribute, from the demangled string.
+	// They are still parsed, and remain in the AST.
+	NoVendorQualifiers
+
+	// The LLVMUnnamed option prints unnamed types as __unnamed_N
+	// and closure types as $_N, the names used by clang.
+	// This is independent of LLVMStyle, and takes precedence over it.
+	LLVMUnnamed
+
+	// The NoMSVC option disables demangling of names mangled by
+	// the Microsoft Visual C++ compiler, which start with "?".
+	// The ToAST function never recognizes those names.
+	NoMSVC
+
+	// The GNUv2 option enables demangling of names mangled by
+	// GCC 2.x, as in "foo__1Ai". Those names have no distinctive
+	// prefix, so they are only tried if the name is not recognized
+	// in any other way. The ToAST function never recognizes them.
+	GNUv2
+
+	// The RustHash option keeps the hash at the end of an old-style
+	// Rust symbol name, as in "core::fmt::write::h0123456789abcdef".
+	// By default the hash is omitted.
+	RustHash
+
+	// The Fortran option decodes gfortran external procedure names,
+	// which are the procedure name followed by an underscore, as in
+	// "solve_". Names of module procedures, as in "__m_MOD_solve",
+	// are always decoded.
+	Fortran
+
+	// The GoSymbols option normalizes the symbol names used by
+	// the Go toolchain, which are not mangled. Wrapper suffixes
+	// such as "-fm" are removed, shape types in instantiations of
+	// generic functions are printed without their package path,
+	// and type equality and hash functions are described, so that
+	// "type:.eq.main.T" becomes "equality function for main.T".
+	// Names that do not need normalizing are not recognized.
+	GoSymbols
+
+	// The NoAngleSpace option prints adjacent closing angle
+	// brackets as ">>", as C++11 permits, rather than "> >".
+	// The LLVMStyle option implies NoAngleSpace.
+	NoAngleSpace
+
+	// The WestConst option prints const and volatile qualifiers
+	// before the type that they qualify, as in "const char*",
+	// rather than after it, as in "char const*". Qualifiers of
+	// pointer, reference, array, and function types are always
+	// printed after the type.
+	WestConst
+
+	// The NoStdInlineNamespaces option omits the inline namespaces
+	// that standard library implementations use for versioning,
+	// so that std::__cxx11::string and std::__1::string are both
+	// printed as std::string.
+	NoStdInlineNamespaces
+
+	// The StdAbbreviations option prints instances of standard
+	// library templates in a shorter form: std::string rather than
+	// std::basic_string<char, std::char_traits<char>,
+	// std::allocator<char> >, and std::vector<int> rather than
+	// std::vector<int, std::allocator<int> >. Template arguments
+	// are omitted when they are the default arguments.
+	StdAbbreviations
+
+	// The NoReturnType option omits the return type of a function,
+	// which the mangled name only records for template functions,
+	// so that "void f<int>(int)" is printed as "f<int>(int)".
+	NoReturnType
+
+	// The NoABITags option omits ABI tags, such as the
+	// "[abi:cxx11]" in "std::__cxx11::list<int>::size[abi:cxx11]()".
+	NoABITags
+
+	// The BaseNameOnly option prints only the unqualified name of
+	// a C++ entity, without enclosing namespaces and classes,
+	// template arguments, or parameters. For example,
+	// "std::vector<int>::push_back(int const&)" is printed as
+	// "push_back".
+	BaseNameOnly
+
+	// The ScopeOnly option prints only the namespaces and classes
+	// that enclose a C++ entity, so that
+	// "std::vector<int>::push_back(int const&)" is printed as
+	// "std::vector<int>". An entity that is not in a scope is
+	// printed as the empty string. With BaseNameOnly, this prints
+	// the unqualified name of the innermost enclosing scope.
+	ScopeOnly
+
+	// The ShortAnonymousNamespace option prints an anonymous
+	// namespace as "{anonymous}", as GCC does in diagnostics,
+	// rather than as "(anonymous namespace)".
+	ShortAnonymousNamespace
+
+	// The NoAnonymousNamespace option omits anonymous namespaces,
+	// so that "(anonymous namespace)::f()" is printed as "f()".
+	NoAnonymousNamespace
+
+	// The NoMethodQualifiers option omits the const, volatile,
+	// and reference qualifiers of a member function, so that
+	// "A::f() const &" is printed as "A::f()".
+	NoMethodQualifiers
+
+	// The ElideTemplateParams option is like NoTemplateParams,
+	// but prints each list of template arguments as "<...>",
+	// so that "C<int>::D<long>" is printed as "C<...>::D<...>".
+	// This shows which names are templates.
+	// It applies to C++ names.
+	ElideTemplateParams
+
+	// The LLVMLambdas option prints lambdas and unnamed types as
+	// the LLVM demangler does, as in "'lambda'(int)" and
+	// "'unnamed'" rather than "{lambda(int)#1}" and
+	// "{unnamed type#1}", and prints the generic parameters of
+	// a lambda as "auto" rather than "auto:1".
+	LLVMLambdas
+
+	// The LLVMLiterals option prints a null pointer literal as
+	// "nullptr", and its type as "std::nullptr_t", as the LLVM
+	// demangler does.
+	LLVMLiterals
+
+	// The LLVMClones option prints a clone suffix as " (.cold)",
+	// as the LLVM demangler does, rather than " [clone .cold]".
+	// This also applies to the suffix of a Rust symbol name.
+	LLVMClones
+
+	// The LLVMSpecialNames option uses the LLVM demangler's
+	// descriptions of special symbols, such as
+	// "thread-local wrapper routine for" rather than
+	// "TLS wrapper function for".
+	LLVMSpecialNames
+
+	// The CanonicalSpacing option prints C++ names with a single
+	// canonical use of spaces, so that equivalent names are
+	// printed identically: there are never two adjacent spaces,
+	// adjacent closing angle brackets are printed as ">>", and
+	// there is never a space before a "&" or "*" that is part of
+	// a type, so that "A::f() const &" is printed as
+	// "A::f() const&". This is useful for names used as keys.
+	CanonicalSpacing
+
+	// The NoLiteralSuffixes option omits the suffixes of integer
+	// literals in template arguments and expressions, printing
+	// "5" rather than "5ul".
+	NoLiteralSuffixes
+
+	// The HexLiterals option prints integer literals of 256 or
+	// more in template arguments and expressions in hexadecimal,
+	// as in "0x1000". Smaller values are printed in decimal.
+	HexLiterals
+
+	// The FunctionalCastLiterals option prints a literal of a type
+	// that can not be written directly, such as an enum type,
+	// using a functional cast, as in "E(5)", rather than as a
+	// C-style cast, as in "(E)5".
+	FunctionalCastLiterals
+
+	// The BoolLiterals option prints every literal of type bool
+	// as "true" or "false". Normally only the values 0 and 1 are
+	// printed that way, and other values are printed as a cast,
+	// as in "(bool)2".
+	BoolLiterals
+
+	// The EnumLiteralValues option prints a literal of an enum
+	// type as just its value, so that "(A::D)131067" is printed
+	// as "131067".
+	EnumLiteralValues
+
+	// The ShortEnumLiterals option prints a literal of an enum
+	// type as a functional cast to the unqualified name of the
+	// type, so that "(A::D)131067" is printed as "D(131067)".
+	// EnumLiteralValues takes precedence over this option.
+	ShortEnumLiterals
+
+	// The CharLiterals option prints a literal of a character
+	// type as a character literal, such as 'A' or L'A', rather
+	// than as a cast of a number, such as "(char)65". Since the
+	// contents of a string literal are not mangled, a string
+	// literal is printed as "..." rather than as its type.
+	CharLiterals
+
+	// The LocalNameOnly option prints only the innermost local
+	// entity of a name defined inside a function, omitting the
+	// enclosing function, so that "f()::{lambda()#1}::operator()"
+	// is printed as "{lambda()#1}::operator()". Names that are not
+	// defined in a function are printed normally. This is for
+	// programs that show the enclosing function separately.
+	LocalNameOnly
+
+	// The StableLambdaIDs option identifies a lambda by a hash of
+	// its signature, as in "{lambda(int)#7f9e359e}", rather than by
+	// its number, as in "{lambda(int)#2}". Lambdas are numbered in
+	// the order in which they appear in the enclosing function,
+	// so the numbers change when a lambda is added or removed;
+	// the hash does not. Lambdas in the same scope with the same
+	// signature are told apart by also hashing their number among
+	// the lambdas with that signature, which changes only when
+	// such a lambda is added or removed.
+	StableLambdaIDs
+
+	// The ElideConstraints option prints the constraint of a
+	// C++20 requires clause as "…", so that a constrained
+	// template is printed as "f<T>() requires …". This shows that
+	// there is a constraint, which distinguishes the function
+	// from an overload, without printing a long expression.
+	ElideConstraints
+
+	// The ThunkOffsets option prints the adjustments made by a
+	// thunk, as in "non-virtual thunk (this-8) to A::f()". A
+	// virtual adjustment also prints the offset of the vcall
+	// offset in the vtable, as in "virtual thunk (this+0,
+	// vcall-24) to B::f()". A covariant return thunk prints the
+	// adjustment of this and then the adjustment of the result,
+	// separated by a semicolon.
+	ThunkOffsets
+
+	// The TemplateParamNames option prints template parameters
+	// by name rather than replacing them with the template
+	// arguments, so that "void f<int>(int)" is printed as
+	// "void f<T>(T)". The parameters of a template are named
+	// "T", "T1", "T2", and so on; the parameters of other templates
+	// in the same name use "U", "V", and so on. This is like
+	// NoTemplateParams, but keeps the relationship between the
+	// template arguments and the types that depend on them.
+	// It applies to C++ names.
+	TemplateParamNames
+
+	// The SkipExpressions option makes the C++ demangler step
+	// over the expressions in a name, such as expression template
+	// arguments, decltype types, and requires clauses, without
+	// building ASTs for them. Each expression is printed as "…".
+	// The types and names in an expression are still demangled,
+	// as a substitution may refer to them later. This is faster
+	// for a program that only wants the names of functions, as
+	// with NoParams and NoTemplateParams, which don't print most
+	// expressions anyway.
+	SkipExpressions
+
+	// The Types option also demangles a string that is the
+	// mangled form of a C++ type by itself, such as "PFvvE",
+	// which is "void (*)()", or "St6vectorIiSaIiEE", which is
+	// "std::vector<int, std::allocator<int> >", as c++filt -t
+	// does. Since a short word such as "i" or "Foo" may be a
+	// type, this is not useful when demangling arbitrary text.
+	// It only applies to strings that are not demangled as
+	// symbol names.
+	Types
+
+	// The LLVMExpressions option prints expressions as the LLVM
+	// demangler does. Operands are parenthesized only when the
+	// precedence of the operators requires it, binary operators
+	// are surrounded by spaces, and function parameters are
+	// printed as "fp" and "fp0" rather than "{parm#1}" and
+	// "{parm#2}", so that "decltype ({parm#1}+(g()))" is printed
+	// as "decltype(fp + g())".
+	LLVMExpressions
+
+	// The LLVMTypes option prints some types as the LLVM
+	// demangler does: the exception specification of a function
+	// type after its other qualifiers, vector types as
+	// "int vector[4]" rather than "int __vector(4)", and default
+	// arguments without "{default arg#1}::". The return type of
+	// a function is printed in a local name if the parameters of
+	// the enclosing function are.
+	LLVMTypes
 )
 
 // maxLengthShift is how we shift the MaxLength value.
@@ -79,20 +365,182 @@ func MaxLength(pow int) Option {
 	return Option(pow << maxLengthShift)
 }
 
-// isMaxLength reports whether an Option holds a maximum length.
+// valueOptionFlag is set in an Option that holds a value, such as
+// MaxLengthBytes. The kind of value is stored in the bits selected
+// by valueOptionKindMask, and the value itself in the bits below
+// them. Everything fits in 31 bits, so that an Option holds the
+// value directly even on a 32-bit system.
+const valueOptionFlag = 1 << 30
+
+// valueOptionKindShift is how we shift the kind of value.
+const valueOptionKindShift = 27
+
+// valueOptionKindMask is a mask for the kind of value.
+const valueOptionKindMask = 0x7 << valueOptionKindShift
+
+// valueOptionMask is a mask for the value itself.
+const valueOptionMask = 1<<valueOptionKindShift - 1
+
+// maxOptionValue is the largest value that an Option may hold.
+const maxOptionValue = 1 << 26
+
+// valueOptionKind is the kind of value held by an Option.
+type valueOptionKind int
+
+const (
+	valueMaxLength valueOptionKind = iota + 1
+	valueTemplateArgLength
+	valueMaxDepth
+	valueMaxExpansion
+)
+
+// newValueOption returns an Option that holds a value.
+func newValueOption(kind valueOptionKind, val int) Option {
+	return Option(valueOptionFlag | int(kind)<<valueOptionKindShift | val)
+}
+
+// optionKind returns the kind of value stored in an Option,
+// or 0 if the Option doesn't hold a value.
+func optionKind(opt Option) valueOptionKind {
+	if opt&valueOptionFlag == 0 {
+		return 0
+	}
+	return valueOptionKind((opt & valueOptionKindMask) >> valueOptionKindShift)
+}
+
+// optionValue returns the value stored in an Option.
+func optionValue(opt Option) int {
+	return int(opt & valueOptionMask)
+}
+
+// MaxLengthBytes returns an Option that limits the maximum length of
+// a demangled string to exactly n bytes. It is like MaxLength, but
+// does not require the limit to be a power of 2.
+// The value must be between 1 and 1<<26.
+func MaxLengthBytes(n int) Option {
+	if n <= 0 || n > maxOptionValue {
+		panic("demangle: invalid MaxLengthBytes value")
+	}
+	return newValueOption(valueMaxLength, n)
+}
+
+// MaxTemplateArgLength returns an Option that prints any template
+// argument longer than n bytes as "…", while still printing the
+// other arguments. This keeps the structure of names with very long
+// template arguments readable. It applies to C++ names.
+// The value must be between 1 and 1<<26.
+func MaxTemplateArgLength(n int) Option {
+	if n <= 0 || n > maxOptionValue {
+		panic("demangle: invalid MaxTemplateArgLength value")
+	}
+	return newValueOption(valueTemplateArgLength, n)
+}
+
+// MaxDepth returns an Option that limits how deeply the parts of a
+// name may be nested, such as a pointer to a pointer or a template
+// argument of a template argument. A name in any of the supported
+// schemes that is nested more deeply fails with the error code
+// ErrTooLarge. Printing an AST stops at twice that depth, and prints
+// "..." for the deeper parts, which matters only for an AST built by
+// hand. This bounds the stack used by the demangler for names read
+// from untrusted input. Without this option the limit is 1024, which
+// is far more than any real name uses. The value must be between 1
+// and 1<<20.
+func MaxDepth(n int) Option {
+	if n <= 0 || n > 1<<20 {
+		panic("demangle: invalid MaxDepth value")
+	}
+	return newValueOption(valueMaxDepth, n)
+}
+
+// defaultMaxDepth is the limit on nesting when there is no MaxDepth
+// option.
+const defaultMaxDepth = 1024
+
+// isMaxDepth reports whether an Option holds a maximum depth.
+func isMaxDepth(opt Option) bool {
+	return optionKind(opt) == valueMaxDepth
+}
+
+// MaxExpansion returns an Option that limits how much the back
+// references in an Itanium C++ or Rust name may expand it, counted
+// as the number of parts of the name, such as types and template
+// arguments, that the back references add to the demangled name. A
+// short name that uses back references to refer to back references
+// can demangle to a very long string; such a name fails with the
+// error code ErrExpansionLimit. Without this option the limit is
+// 1<<20, which is far more than any real name uses. The value must
+// be between 1 and 1<<26.
+//
+// This option does not apply to the other schemes. The back
+// references of D names and the substitutions of Swift names are not
+// counted; instead those demanglers fail with ErrTooLarge if the
+// demangled name is longer than 1<<20 bytes.
+func MaxExpansion(n int) Option {
+	if n <= 0 || n > maxOptionValue {
+		panic("demangle: invalid MaxExpansion value")
+	}
+	return newValueOption(valueMaxExpansion, n)
+}
+
+// defaultMaxExpansion is the limit on expansion when there is no
+// MaxExpansion option.
+const defaultMaxExpansion = 1 << 20
+
+// isMaxExpansion reports whether an Option holds a maximum expansion.
+func isMaxExpansion(opt Option) bool {
+	return optionKind(opt) == valueMaxExpansion
+}
+
+// A Namer returns a name to print for an unnamed type or a lambda,
+// for the NameUnnamed field of DemangleOptions. The a argument is an
+// *UnnamedType or a *Closure; the scope argument is the scope in
+// which it is defined, such as the enclosing function of a lambda,
+// or nil if unknown. The Namer should return the empty string to
+// print the default name.
+type Namer func(scope, a AST) string
+
+// A PrintHook is called for a node of an AST being printed, for the
+// PrintHook field of DemangleOptions. It may print the node itself
+// using p, and report true, or report false to print the node as
+// usual. A PrintHook will normally use a type switch to look for the
+// kinds of nodes that it prints, such as *Qualified or
+// *TemplateParam.
+type PrintHook func(a AST, p *HookPrinter) bool
+
+// isTemplateArgLength reports whether an Option holds a maximum
+// template argument length.
+func isTemplateArgLength(opt Option) bool {
+	return optionKind(opt) == valueTemplateArgLength
+}
+
+// isMaxLength reports whether an Option holds a maximum length,
+// set by either MaxLength or MaxLengthBytes.
 func isMaxLength(opt Option) bool {
+	if opt&valueOptionFlag != 0 {
+		return optionKind(opt) == valueMaxLength
+	}
 	return opt&maxLengthMask != 0
 }
 
 // maxLength returns the maximum length stored in an Option.
 func maxLength(opt Option) int {
+	if opt&valueOptionFlag != 0 {
+		return optionValue(opt)
+	}
 	return 1 << ((opt & maxLengthMask) >> maxLengthShift)
 }
 
-// Filter demangles a C++ or Rust symbol name,
-// returning the human-readable C++ or Rust name.
+// Filter demangles a symbol name in any of the schemes described in
+// the package documentation, returning the human-readable name.
 // If any error occurs during demangling, the input string is returned.
+// A name that IsMangled rejects, such as a C function name, is
+// returned without allocating any memory, unless one of the options
+// GNUv2, GoSymbols, Fortran, or Types is used.
 func Filter(name string, options ...Option) string {
+	if !mayDemangle(name, options) {
+		return name
+	}
 	ret, err := ToString(name, options...)
 	if err != nil {
 		return name
@@ -100,46 +548,125 @@ func Filter(name string, options ...Option) string {
 	return ret
 }
 
-// ToString demangles a C++ or Rust symbol name,
-// returning a human-readable C++ or Rust name or an error.
-// If the name does not appear to be a C++ or Rust symbol name at all,
-// the error will be ErrNotMangledName.
+// ToString demangles a symbol name in any of the schemes described in
+// the package documentation, returning a human-readable name or an
+// error. If the name does not appear to be a symbol name in any of
+// those schemes, the error will be ErrNotMangledName.
 func ToString(name string, options ...Option) (string, error) {
+	return toString(name, options, nil)
+}
+
+// toString implements ToString and ToStringOpts. The ex argument,
+// which may be nil, holds the settings of a DemangleOptions that
+// have no Option.
+func toString(name string, options []Option, ex *extraOptions) (string, error) {
+	sopts := options
+	max, marker, nopts := markerOptions(options, ex)
+	if max > 0 {
+		// Let a demangler that doesn't use an AST return a
+		// longer string, which we cut between tokens below.
+		sopts = nopts
+	}
+	s, a, err := toStringOrAST(name, sopts)
+	if a != nil {
+		ps := printStatePool.Get().(*printState)
+		ps.sizeHint = printSizeEstimate(name)
+		s, _ := ps.astToString(a, false, false, options, ex)
+		printStatePool.Put(ps)
+		return s, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if max > 0 {
+		s = truncateAtToken(s, max, marker)
+	}
+	return s, nil
+}
+
+// printStatePool holds printStates for toString to reuse.
+var printStatePool = sync.Pool{
+	New: func() interface{} { return new(printState) },
+}
+
+// toStringOrAST demangles a name. If the name is demangled using
+// an AST, it returns the AST without converting it to a string.
+func toStringOrAST(name string, options []Option) (string, AST, error) {
 	if strings.HasPrefix(name, "_R") {
-		return rustToString(name, options)
-	}
-
-	// Check for an old-style Rust mangled name.
-	// It starts with _ZN and ends with "17h" followed by 16 hex digits
-	// followed by "E" followed by an optional suffix starting with "."
-	// (which we ignore).
-	if strings.HasPrefix(name, "_ZN") {
-		rname := name
-		if pos := strings.LastIndex(rname, "E."); pos > 0 {
-			rname = rname[:pos+1]
-		}
-		if strings.HasSuffix(rname, "E") && len(rname) > 23 && rname[len(rname)-20:len(rname)-17] == "17h" {
-			noRust := false
-			for _, o := range options {
-				if o == NoRust {
-					noRust = true
-					break
-				}
+		s, err := rustToString(name, options)
+		return s, nil, err
+	}
+
+	if strings.HasPrefix(name, "?") {
+		for _, o := range options {
+			if o == NoMSVC {
+				return "", nil, ErrNotMangledName
 			}
-			if !noRust {
-				s, ok := oldRustToString(rname, options)
-				if ok {
-					return s, nil
-				}
+		}
+		s, err := msvcToString(name, options)
+		return s, nil, err
+	}
+
+	if strings.HasPrefix(name, "@") {
+		s, err := borlandToString(name, options)
+		return s, nil, err
+	}
+
+	if strings.HasPrefix(name, "W?") {
+		s, err := watcomToString(name, options)
+		return s, nil, err
+	}
+
+	if swiftPrefix(name) > 0 {
+		s, err := swiftToString(name, options)
+		return s, nil, err
+	}
+
+	if strings.HasPrefix(name, "_D") {
+		s, err := dlangToString(name, options)
+		return s, nil, err
+	}
+
+	if rname, ok := oldRustName(name); ok {
+		noRust := false
+		for _, o := range options {
+			if o == NoRust {
+				noRust = true
+				break
+			}
+		}
+		if !noRust {
+			s, ok := oldRustToString(rname, options)
+			if ok {
+				return s, nil, nil
 			}
 		}
 	}
 
 	a, err := ToAST(name, options...)
 	if err != nil {
-		return "", err
+		if s, err2 := fortranToString(name, options); err2 == nil {
+			return s, nil, nil
+		}
+		for _, o := range options {
+			if o == GoSymbols {
+				if s, err2 := goToString(name, options); err2 == nil {
+					return s, nil, nil
+				}
+				break
+			}
+		}
+		for _, o := range options {
+			if o == GNUv2 {
+				if s, err2 := gnuV2ToString(name, options); err2 == nil {
+					return s, nil, nil
+				}
+				break
+			}
+		}
+		return "", nil, err
 	}
-	return ASTToString(a, options...), nil
+	return "", a, nil
 }
 
 // ToAST demangles a C++ symbol name into an abstract syntax tree
@@ -148,10 +675,37 @@ func ToString(name string, options ...Option) (string, error) {
 // the parameter types are not demangled.
 // If the name does not appear to be a C++ symbol name at all, the
 // error will be ErrNotMangledName.
-// This function does not currently support Rust symbol names.
+// An Objective-C method name, such as "-[Class selector:]", is not
+// mangled, but is returned as an *ObjCMethod.
+// The names that the CUDA compiler builds from C++ names, such as
+// "__device_stub__Z6kernelPf" for the host stub of a kernel and
+// "_Z6kernelPf$1" for a clone of a device function, are also
+// demangled.
+// A Rust symbol name that uses the v0 mangling scheme, starting with
+// "_R", is returned as a *RustSymbol. Old style Rust symbol names
+// are returned as C++ names.
+// With the Types option, a string that is the mangled form of a C++
+// type by itself is returned as the AST of the type.
 func ToAST(name string, options ...Option) (AST, error) {
+	if om, ok := parseObjCMethod(name); ok {
+		return om, nil
+	}
+
+	if a, ok := cudaToAST(name, options); ok {
+		return a, nil
+	}
+
+	if strings.HasPrefix(name, "_R") {
+		return rustToAST(name, options)
+	}
+
 	if strings.HasPrefix(name, "_Z") {
 		a, err := doDemangle(name[2:], options...)
+		if err != nil && strings.Contains(name, "$") {
+			if ca, ok := cudaClone(name, options); ok {
+				return ca, nil
+			}
+		}
 		return a, adjustErr(err, 2)
 	}
 
@@ -165,39 +719,59 @@ func ToAST(name string, options ...Option) (AST, error) {
 		if err != nil {
 			return a, adjustErr(err, 4)
 		}
-		name = strings.TrimPrefix(name[block:], "_block_invoke")
-		if len(name) > 0 && name[0] == '_' {
-			name = name[1:]
+		rest := strings.TrimPrefix(name[block:], "_block_invoke")
+		if len(rest) > 0 && rest[0] == '_' {
+			rest = rest[1:]
 		}
-		for len(name) > 0 && isDigit(name[0]) {
-			name = name[1:]
+		for len(rest) > 0 && isDigit(rest[0]) {
+			rest = rest[1:]
 		}
-		if len(name) > 0 && name[0] != '.' {
-			return nil, errors.New("unparsed characters at end of mangled name")
+		if len(rest) > 0 && rest[0] != '.' {
+			return nil, Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: len(name) - len(rest)}
 		}
-		a = &Special{Prefix: "invocation function for block in ", Val: a}
+		a = &Special{Prefix: blockInvokePrefix, Val: a}
 		return a, nil
 	}
 
 	const prefix = "_GLOBAL_"
 	if strings.HasPrefix(name, prefix) {
 		// The standard demangler ignores NoParams for global
-		// constructors.  We are compatible.
-		i := 0
-		for i < len(options) {
-			if options[i] == NoParams {
-				options = append(options[:i], options[i+1:]...)
-			} else {
-				i++
+		// constructors.  We are compatible. Don't change the
+		// caller's slice.
+		var nopts []Option
+		for _, o := range options {
+			if o != NoParams {
+				nopts = append(nopts, o)
 			}
 		}
+		options = nopts
 		a, err := globalCDtorName(name[len(prefix):], options...)
 		return a, adjustErr(err, len(prefix))
 	}
 
+	for _, o := range options {
+		if o == Types {
+			return new(state).demangleTypeString(name, options)
+		}
+	}
+
 	return nil, ErrNotMangledName
 }
 
+// blockInvokePrefix is the Prefix of the Special node for a clang
+// block invocation function.
+const blockInvokePrefix = "invocation function for block in "
+
+// cppToAST is like ToAST, but returns ErrNotMangledName for a Rust
+// symbol name. It is used by functions that only handle the AST of
+// a C++ symbol name.
+func cppToAST(name string, options ...Option) (AST, error) {
+	if strings.HasPrefix(name, "_R") {
+		return nil, ErrNotMangledName
+	}
+	return ToAST(name, options...)
+}
+
 // globalCDtorName demangles a global constructor/destructor symbol name.
 // The parameter is the string following the "_GLOBAL_" prefix.
 func globalCDtorName(name string, options ...Option) (AST, error) {
@@ -236,12 +810,77 @@ func globalCDtorName(name string, options ...Option) (AST, error) {
 }
 
 // The doDemangle function is the entry point into the demangler proper.
-func doDemangle(name string, options ...Option) (ret AST, err error) {
+func doDemangle(name string, options ...Option) (AST, error) {
+	st := statePool.Get().(*state)
+	a, err := st.demangle(name, options)
+	st.release()
+	return a, err
+}
+
+// statePool holds states for doDemangle to reuse, so that demangling
+// a name does not allocate a state and its lists of substitutions
+// and templates. This is like a Demangler shared by all callers.
+var statePool = sync.Pool{
+	New: func() interface{} { return new(state) },
+}
+
+// maxPooledSubs is the most substitution candidates that a state in
+// statePool may have room for. The memory used for an unusually long
+// name is not kept.
+const maxPooledSubs = 1024
+
+// release returns st to statePool.
+func (st *state) release() {
+	if cap(st.subs) > maxPooledSubs {
+		return
+	}
+	statePool.Put(st)
+}
+
+// demangle implements doDemangle using st, keeping any memory that
+// st has already allocated, so that a Demangler can reuse it.
+func (st *state) demangle(name string, options []Option) (ret AST, err error) {
 	// When the demangling routines encounter an error, they panic
-	// with a value of type demangleErr.
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = nil
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	params, clones, err := st.reset(name, options)
+	if err != nil {
+		return nil, err
+	}
+	a := st.encoding(params, notForLocalName)
+
+	// Accept a clone suffix.
+	if clones {
+		for len(st.str) > 1 && st.str[0] == '.' && (isLower(st.str[1]) || st.str[1] == '_' || isDigit(st.str[1])) {
+			// Each clone suffix nests the name.
+			st.nest()
+			a = st.cloneSuffix(a)
+		}
+	}
+
+	if clones && len(st.str) > 0 {
+		st.failCode(ErrUnparsedSuffix, "unparsed characters at end of mangled name", 0)
+	}
+
+	return a, nil
+}
+
+// demangleTypeString is like demangle, but demangles a string that is a
+// type by itself, for the Types option.
+func (st *state) demangleTypeString(name string, options []Option) (ret AST, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if de, ok := r.(demangleErr); ok {
+			if de, ok := r.(Error); ok {
 				ret = nil
 				err = de
 				return
@@ -250,11 +889,32 @@ func doDemangle(name string, options ...Option) (ret AST, err error) {
 		}
 	}()
 
-	params := true
-	clones := true
+	if _, _, err := st.reset(name, options); err != nil {
+		return nil, err
+	}
+	a := st.simplify(st.demangleType(false))
+	if len(st.str) > 0 {
+		st.failCode(ErrUnparsedSuffix, "unparsed characters at end of mangled type", 0)
+	}
+	return a, nil
+}
+
+// reset prepares st to demangle name with the options, and reports
+// whether to demangle function parameters and clone suffixes.
+func (st *state) reset(name string, options []Option) (params, clones bool, err error) {
+	params = true
+	clones = true
 	verbose := false
+	tparamNames := false
+	skipExprs := false
+	depthLimit := defaultMaxDepth
+	expansionLimit := defaultMaxExpansion
 	for _, o := range options {
 		switch {
+		case o == TemplateParamNames:
+			tparamNames = true
+		case o == SkipExpressions:
+			skipExprs = true
 		case o == NoParams:
 			params = false
 			clones = false
@@ -262,31 +922,33 @@ func doDemangle(name string, options ...Option) (ret AST, err error) {
 			clones = false
 		case o == Verbose:
 			verbose = true
-		case o == NoTemplateParams || o == NoEnclosingParams || o == LLVMStyle || isMaxLength(o):
+		case isMaxDepth(o):
+			depthLimit = optionValue(o)
+		case isMaxExpansion(o):
+			expansionLimit = optionValue(o)
+		case o == NoTemplateParams || o == NoEnclosingParams || o == LLVMStyle || o == NoVendorQualifiers || o == LLVMUnnamed || o == NoAngleSpace || o == WestConst || o == NoStdInlineNamespaces || o == StdAbbreviations || o == NoReturnType || o == NoABITags || o == BaseNameOnly || o == ScopeOnly || o == ShortAnonymousNamespace || o == NoAnonymousNamespace || o == NoMethodQualifiers || o == ElideTemplateParams || o == LLVMLambdas || o == LLVMLiterals || o == LLVMClones || o == LLVMSpecialNames || o == CanonicalSpacing || o == NoLiteralSuffixes || o == HexLiterals || o == FunctionalCastLiterals || o == BoolLiterals || o == EnumLiteralValues || o == ShortEnumLiterals || o == CharLiterals || o == LocalNameOnly || o == StableLambdaIDs || o == ElideConstraints || o == ThunkOffsets || o == LLVMExpressions || o == LLVMTypes || isMaxLength(o) || isTemplateArgLength(o):
 			// These are valid options but only affect
 			// printing of the AST.
-		case o == NoRust:
+		case o == NoRust || o == NoMSVC || o == GNUv2 || o == RustHash || o == Fortran || o == GoSymbols || o == Types:
 			// Unimportant here.
 		default:
-			return nil, fmt.Errorf("unrecognized demangler option %v", o)
+			return false, false, fmt.Errorf("unrecognized demangler option %v", o)
 		}
 	}
 
-	st := &state{str: name, verbose: verbose}
-	a := st.encoding(params, notForLocalName)
-
-	// Accept a clone suffix.
-	if clones {
-		for len(st.str) > 1 && st.str[0] == '.' && (isLower(st.str[1]) || st.str[1] == '_' || isDigit(st.str[1])) {
-			a = st.cloneSuffix(a)
-		}
-	}
-
-	if clones && len(st.str) > 0 {
-		st.fail("unparsed characters at end of mangled name")
+	*st = state{
+		str:           name,
+		verbose:       verbose,
+		tparamNames:   tparamNames,
+		skipExprs:     skipExprs,
+		maxDepth:      depthLimit,
+		maxExpansion:  int64(expansionLimit),
+		subs:          st.subs[:0],
+		subSizes:      st.subSizes[:0],
+		templates:     st.templates[:0],
+		recordPartial: st.recordPartial,
 	}
-
-	return a, nil
+	return params, clones, nil
 }
 
 // A state holds the current state of demangling a string.
@@ -302,12 +964,57 @@ type state struct {
 	lambdaTemplateLevel int
 
 	parsingConstraint bool // whether parsing a constraint expression
+	tparamNames       bool // whether to keep template parameters
+	skipExprs         bool // whether to skip over expressions
+
+	// The current nesting depth, and the limit on it.
+	depth    int
+	maxDepth int
+
+	// The number of parts of the name that have been parsed,
+	// counting the parts that substitutions and template
+	// parameters refer to again each time; the deepest nesting
+	// depth reached within the current part; and the values of
+	// nodes and depth when the current part started. See enter.
+	nodes     int64
+	deepest   int
+	partNodes int64
+	partDepth int
+
+	// The size of the last part that was parsed, the sizes of
+	// the substitution candidates in subs, and the sizes of
+	// template arguments; see checkReference.
+	last     refSize
+	subSizes []refSize
+	argSizes map[AST]refSize
+
+	// The number of parts that substitutions and template
+	// parameters have added to the name, and the limit on it.
+	expansion    int64
+	maxExpansion int64
+
+	// The ASTs of the interned names used in this name, indexed
+	// like internedChars; see internedName.
+	interned [len(internedChars)]*internedASTs
+
+	// Substitutions that have been found not to refer to any
+	// template parameters, and so are shared rather than
+	// copied; see substitution.
+	noTemplateParams map[AST]bool
 
 	// Counts of template parameters without template arguments,
 	// for lambdas.
 	typeTemplateParamCount     int
 	nonTypeTemplateParamCount  int
 	templateTemplateParamCount int
+
+	// Information recorded for ToStringPartial about the longest
+	// leading name that was demangled; see notePartial.
+	recordPartial bool
+	partial       AST  // the name
+	partialStart  int  // offset where the name starts
+	partialOff    int  // offset after the name
+	partialScope  bool // whether partial is a scope of the name
 }
 
 // copy returns a copy of the current state.
@@ -317,9 +1024,9 @@ func (st *state) copy() *state {
 	return n
 }
 
-// fail panics with demangleErr, to be caught in doDemangle.
+// fail panics with Error, to be caught in doDemangle.
 func (st *state) fail(err string) {
-	panic(demangleErr{err: err, off: st.off})
+	panic(Error{Msg: err, Offset: st.off})
 }
 
 // failEarlier is like fail, but decrements the offset to indicate
@@ -328,7 +1035,122 @@ func (st *state) failEarlier(err string, dec int) {
 	if st.off < dec {
 		panic("internal error")
 	}
-	panic(demangleErr{err: err, off: st.off - dec})
+	panic(Error{Msg: err, Offset: st.off - dec})
+}
+
+// failCode is like failEarlier, but reports a more specific kind of
+// error than ErrSyntax.
+func (st *state) failCode(code ErrorCode, err string, dec int) {
+	if st.off < dec {
+		panic("internal error")
+	}
+	panic(Error{Code: code, Msg: err, Offset: st.off - dec})
+}
+
+// A part records the state of the enclosing part of a name while
+// parsing a nested one; see enter.
+type part struct {
+	nodes   int64
+	depth   int
+	deepest int
+}
+
+// A refSize is the size of a part of a name that a substitution or
+// template parameter may refer to: the number of parts it contains,
+// and how deeply they are nested.
+type refSize struct {
+	nodes int64
+	depth int
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested, such as a type. It fails if the parts are nested too
+// deeply. Each call is matched by a call to leave, passing the
+// value that enter returns.
+func (st *state) enter() part {
+	st.nest()
+	p := part{nodes: st.partNodes, depth: st.partDepth, deepest: st.deepest}
+	st.partNodes = st.nodes - 1
+	st.partDepth = st.depth
+	st.deepest = st.depth
+	return p
+}
+
+// leave is called when finishing parsing a part of a name.
+// It records the size of the part in st.last.
+func (st *state) leave(p part) {
+	st.last = st.partSize()
+	st.partNodes = p.nodes
+	st.partDepth = p.depth
+	if p.deepest > st.deepest {
+		st.deepest = p.deepest
+	}
+	st.depth--
+}
+
+// nest increases the nesting depth without starting a new part, for
+// a part of a name that nests the ones before it. It fails if the
+// parts are nested too deeply.
+func (st *state) nest() {
+	st.depth++
+	if st.depth > st.maxDepth {
+		st.failCode(ErrTooLarge, "name nested too deeply", 0)
+	}
+	if st.depth > st.deepest {
+		st.deepest = st.depth
+	}
+	st.nodes++
+}
+
+// partSize returns the size of the current part of the name so far.
+func (st *state) partSize() refSize {
+	return refSize{nodes: st.nodes - st.partNodes, depth: st.deepest - st.partDepth + 1}
+}
+
+// addSubst adds a as a substitution candidate. Its size is the size
+// of the current part of the name, which is where a was parsed.
+func (st *state) addSubst(a AST) {
+	st.subs.add(a)
+	st.subSizes = append(st.subSizes, st.partSize())
+}
+
+// noteArg records the size of the template argument a, which was
+// the last part parsed.
+func (st *state) noteArg(a AST) {
+	if st.argSizes == nil {
+		st.argSizes = make(map[AST]refSize)
+	}
+	st.argSizes[a] = st.last
+}
+
+// argSize returns the size of the template argument a.
+func (st *state) argSize(a AST) refSize {
+	if size, ok := st.argSizes[a]; ok {
+		return size
+	}
+	// An argument that we made up, such as the name of a
+	// template parameter of a lambda.
+	return refSize{nodes: 1, depth: 1}
+}
+
+// checkReference is called for a substitution or template parameter
+// that refers to a part of the name of the given size. It fails if
+// the part would be nested too deeply at the current depth, or if
+// the references in the name add too many parts. The part was
+// checked when it was parsed, but each reference to it nests it
+// more deeply and prints it again.
+func (st *state) checkReference(size refSize) {
+	if st.depth+size.depth > st.maxDepth {
+		st.failCode(ErrTooLarge, "name nested too deeply", 0)
+	}
+	if st.depth+size.depth > st.deepest {
+		st.deepest = st.depth + size.depth
+	}
+	st.nodes = addSize(st.nodes, size.nodes)
+	st.expansion = addSize(st.expansion, size.nodes)
+	if st.expansion > st.maxExpansion {
+		st.failCode(ErrExpansionLimit, "back references expand the name too much", 0)
+	}
 }
 
 // advance advances the current string offset.
@@ -349,26 +1171,75 @@ func (st *state) checkChar(c byte) {
 	st.advance(1)
 }
 
-// A demangleErr is an error at a specific offset in the mangled
-// string.
-type demangleErr struct {
-	err string
-	off int
+// An Error is an error at a specific offset in a mangled name.
+// The demangling functions return an Error, or ErrNotMangledName,
+// when a name can't be demangled.
+type Error struct {
+	// Code is the kind of error.
+	Code ErrorCode
+	// Msg describes the error.
+	Msg string
+	// Offset is the offset in the mangled name at which the
+	// error was found.
+	Offset int
 }
 
-// Error implements the builtin error interface for demangleErr.
-func (de demangleErr) Error() string {
-	return fmt.Sprintf("%s at %d", de.err, de.off)
+// Error implements the builtin error interface for Error.
+func (de Error) Error() string {
+	return fmt.Sprintf("%s at %d", de.Msg, de.Offset)
 }
 
-// adjustErr adjusts the position of err, if it is a demangleErr,
+// An ErrorCode describes the kind of an Error.
+type ErrorCode int
+
+const (
+	// ErrSyntax is a name that does not follow the mangling
+	// grammar. This is the code of most errors.
+	ErrSyntax ErrorCode = iota
+	// ErrUnparsedSuffix is a name with characters after the
+	// end of the mangled name.
+	ErrUnparsedSuffix
+	// ErrBadSubstitution is a back reference to an earlier
+	// component of the name that is out of range or invalid.
+	ErrBadSubstitution
+	// ErrBadTemplateParam is a reference to a template parameter
+	// that is out of range or not in the scope of a template.
+	ErrBadTemplateParam
+	// ErrTooLarge is a name whose demangled form is too large.
+	ErrTooLarge
+	// ErrExpansionLimit is a name whose back references expand
+	// it by more than the limit set by MaxExpansion.
+	ErrExpansionLimit
+)
+
+// String returns the name of the error code.
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrSyntax:
+		return "ErrSyntax"
+	case ErrUnparsedSuffix:
+		return "ErrUnparsedSuffix"
+	case ErrBadSubstitution:
+		return "ErrBadSubstitution"
+	case ErrBadTemplateParam:
+		return "ErrBadTemplateParam"
+	case ErrTooLarge:
+		return "ErrTooLarge"
+	case ErrExpansionLimit:
+		return "ErrExpansionLimit"
+	default:
+		return fmt.Sprintf("ErrorCode(%d)", int(c))
+	}
+}
+
+// adjustErr adjusts the position of err, if it is an Error,
 // and returns err.
 func adjustErr(err error, adj int) error {
 	if err == nil {
 		return nil
 	}
-	if de, ok := err.(demangleErr); ok {
-		de.off += adj
+	if de, ok := err.(Error); ok {
+		de.Offset += adj
 		return de
 	}
 	return err
@@ -387,6 +1258,8 @@ const (
 //	             <(data) name>
 //	             <special-name>
 func (st *state) encoding(params bool, local forLocalNameType) AST {
+	defer st.leave(st.enter())
+
 	if len(st.str) < 1 {
 		st.fail("expected encoding")
 	}
@@ -395,8 +1268,12 @@ func (st *state) encoding(params bool, local forLocalNameType) AST {
 		return st.specialName()
 	}
 
+	start := st.off
 	a, explicitObjectParameter := st.name()
-	a = simplify(a)
+	a = st.simplify(a)
+	if st.recordPartial {
+		st.notePartial(a, start, false)
+	}
 
 	if !params {
 		// Don't demangle the parameters.
@@ -485,7 +1362,7 @@ func (st *state) encoding(params bool, local forLocalNameType) AST {
 		st.lambdaTemplateLevel = oldLambdaTemplateLevel
 	}
 
-	ft = simplify(ft)
+	ft = st.simplify(ft)
 
 	// For a local name, discard the return type, so that it
 	// doesn't get confused with the top level return type.
@@ -606,7 +1483,10 @@ func (st *state) name() (AST, bool) {
 	case 'S':
 		if len(st.str) < 2 {
 			st.advance(1)
-			st.fail("expected substitution index")
+			st.failCode(ErrBadSubstitution, "expected substitution index", 0)
+		}
+		if a := st.internedName(); a != nil {
+			return a, false
 		}
 		var a AST
 		isCast := false
@@ -630,7 +1510,7 @@ func (st *state) name() (AST, bool) {
 			// substitution candidate if it did not come from a
 			// substitution.
 			if !subst {
-				st.subs.add(a)
+				st.addSubst(a)
 			}
 			args := st.templateArgs()
 			tmpl := &Template{Name: a, Args: args}
@@ -649,7 +1529,7 @@ func (st *state) name() (AST, bool) {
 
 	a, isCast := st.unqualifiedName(module)
 	if len(st.str) > 0 && st.str[0] == 'I' {
-		st.subs.add(a)
+		st.addSubst(a)
 		args := st.templateArgs()
 		tmpl := &Template{Name: a, Args: args}
 		if isCast {
@@ -717,6 +1597,8 @@ func (st *state) nestedName() (AST, bool) {
 func (st *state) prefix() AST {
 	var a AST
 
+	start := st.off
+
 	// The last name seen, for a constructor/destructor.
 	var last AST
 
@@ -736,8 +1618,15 @@ func (st *state) prefix() AST {
 		}
 	}
 
+	// Each component of the prefix nests the ones before it.
+	depth := st.depth
+	defer func() {
+		st.depth = depth
+	}()
+
 	var cast *Cast
 	for {
+		st.nest()
 		if len(st.str) == 0 {
 			st.fail("expected prefix")
 		}
@@ -769,6 +1658,7 @@ func (st *state) prefix() AST {
 				if last == nil {
 					st.fail("constructor before name is seen")
 				}
+				kind := ctorKinds[st.str[0]]
 				st.advance(1)
 				var base AST
 				if inheriting {
@@ -777,6 +1667,7 @@ func (st *state) prefix() AST {
 				next = &Constructor{
 					Name: getLast(last),
 					Base: base,
+					Kind: kind,
 				}
 				if len(st.str) > 0 && st.str[0] == 'B' {
 					next = st.taggedName(next)
@@ -791,8 +1682,9 @@ func (st *state) prefix() AST {
 					if last == nil {
 						st.fail("destructor before name is seen")
 					}
+					kind := dtorKinds[st.str[1]]
 					st.advance(2)
-					next = &Destructor{Name: getLast(last)}
+					next = &Destructor{Name: getLast(last), Kind: kind}
 					if len(st.str) > 0 && st.str[0] == 'B' {
 						next = st.taggedName(next)
 					}
@@ -881,8 +1773,12 @@ func (st *state) prefix() AST {
 			a = &Qualified{Scope: a, Name: next, LocalName: false}
 		}
 
+		if st.recordPartial {
+			st.notePartial(a, start, true)
+		}
+
 		if c != 'S' && (len(st.str) == 0 || st.str[0] != 'E') {
-			st.subs.add(a)
+			st.addSubst(a)
 		}
 	}
 }
@@ -906,9 +1802,9 @@ func (st *state) unqualifiedName(module AST) (r AST, isCast bool) {
 	if len(st.str) > 0 && st.str[0] == 'F' {
 		st.advance(1)
 		friend = true
-		if len(st.str) < 1 {
-			st.fail("expected unqualified name")
-		}
+	}
+	if len(st.str) < 1 {
+		st.fail("expected unqualified name")
 	}
 
 	var a AST
@@ -944,7 +1840,10 @@ func (st *state) unqualifiedName(module AST) (r AST, isCast bool) {
 		case 'L':
 			st.advance(1)
 			a = st.sourceName()
-			a = st.discriminator(a)
+			if n, ok := a.(*Name); ok {
+				n.Internal = true
+			}
+			a, _ = st.discriminator(a)
 		case 'U':
 			if len(st.str) < 2 {
 				st.advance(1)
@@ -1006,10 +1905,16 @@ func (st *state) sourceName() AST {
 		c1 := id[len(anonPrefix)]
 		c2 := id[len(anonPrefix)+1]
 		if (c1 == '.' || c1 == '_' || c1 == '$') && c2 == 'N' {
-			id = "(anonymous namespace)"
+			id = anonymousNamespace
 		}
 	}
 
+	// clang uses the name of an Objective-C method as the
+	// enclosing function of a local name.
+	if om, ok := parseObjCMethod(id); ok {
+		return om
+	}
+
 	n := &Name{Name: id}
 	return n
 }
@@ -1038,7 +1943,7 @@ func (st *state) moduleName(parent AST) AST {
 			Name:        name,
 			IsPartition: isPartition,
 		}
-		st.subs.add(ret)
+		st.addSubst(ret)
 	}
 	return ret
 }
@@ -1200,6 +2105,55 @@ var operators = map[string]operator{
 	"tw": {"throw ", 1, precUnary},
 }
 
+// operatorTable is a table of the operators, which is faster to
+// look up than the operators map. An operator code is a lower case
+// letter followed by a letter; the entry for a code is 1 plus the
+// index of the operator in operatorList, or 0 if there is no such
+// operator.
+var operatorTable, operatorList = buildOperatorTable()
+
+// buildOperatorTable builds operatorTable and operatorList.
+func buildOperatorTable() (*[26 * 52]uint8, []operator) {
+	table := new([26 * 52]uint8)
+	list := make([]operator, 0, len(operators))
+	for code, op := range operators {
+		i := operatorIndex(code)
+		if i < 0 {
+			panic("invalid operator code " + code)
+		}
+		list = append(list, op)
+		table[i] = uint8(len(list))
+	}
+	return table, list
+}
+
+// operatorIndex returns the index in operatorTable of an operator
+// code, or -1 if it is not a lower case letter followed by a letter.
+func operatorIndex(code string) int {
+	c1, c2 := code[0], code[1]
+	if !isLower(c1) {
+		return -1
+	}
+	i := int(c1-'a') * 52
+	switch {
+	case isLower(c2):
+		return i + int(c2-'a')
+	case isUpper(c2):
+		return i + 26 + int(c2-'A')
+	default:
+		return -1
+	}
+}
+
+// lookupOperator returns the operator for a two character code.
+func lookupOperator(code string) (operator, bool) {
+	i := operatorIndex(code)
+	if i < 0 || operatorTable[i] == 0 {
+		return operator{}, false
+	}
+	return operatorList[operatorTable[i]-1], true
+}
+
 // operatorName parses:
 //
 //	operator_name ::= many different two character encodings.
@@ -1232,7 +2186,7 @@ func (st *state) operatorName(inExpression bool) (AST, int) {
 		}
 
 		return &Cast{To: t}, 1
-	} else if op, ok := operators[code]; ok {
+	} else if op, ok := lookupOperator(code); ok {
 		return &Operator{Name: op.name, precedence: op.prec}, op.args
 	} else {
 		st.failEarlier("unrecognized operator code", 2)
@@ -1258,8 +2212,8 @@ func (st *state) localName() (AST, bool) {
 	if len(st.str) > 0 && st.str[0] == 's' {
 		st.advance(1)
 		var n AST = &Name{Name: "string literal"}
-		n = st.discriminator(n)
-		return &Qualified{Scope: fn, Name: n, LocalName: true}, false
+		n, d := st.discriminator(n)
+		return &Qualified{Scope: fn, Name: n, LocalName: true, Discriminator: d}, false
 	} else {
 		num := -1
 		if len(st.str) > 0 && st.str[0] == 'd' {
@@ -1268,11 +2222,11 @@ func (st *state) localName() (AST, bool) {
 			num = st.compactNumber()
 		}
 		n, explicitObjectParameter := st.name()
-		n = st.discriminator(n)
+		n, d := st.discriminator(n)
 		if num >= 0 {
 			n = &DefaultArg{Num: num, Arg: n}
 		}
-		return &Qualified{Scope: fn, Name: n, LocalName: true}, explicitObjectParameter
+		return &Qualified{Scope: fn, Name: n, LocalName: true, Discriminator: d}, explicitObjectParameter
 	}
 }
 
@@ -1361,18 +2315,18 @@ func (st *state) specialName() AST {
 			t := st.templateArg(nil)
 			return &Special{Prefix: "template parameter object for ", Val: t}
 		case 'h':
-			st.callOffset('h')
+			off := st.callOffset('h')
 			v := st.encoding(true, notForLocalName)
-			return &Special{Prefix: "non-virtual thunk to ", Val: v}
+			return &Special{Prefix: "non-virtual thunk to ", Val: v, Offsets: []CallOffset{off}}
 		case 'v':
-			st.callOffset('v')
+			off := st.callOffset('v')
 			v := st.encoding(true, notForLocalName)
-			return &Special{Prefix: "virtual thunk to ", Val: v}
+			return &Special{Prefix: "virtual thunk to ", Val: v, Offsets: []CallOffset{off}}
 		case 'c':
-			st.callOffset(0)
-			st.callOffset(0)
+			off1 := st.callOffset(0)
+			off2 := st.callOffset(0)
 			v := st.encoding(true, notForLocalName)
-			return &Special{Prefix: "covariant return thunk to ", Val: v}
+			return &Special{Prefix: "covariant return thunk to ", Val: v, Offsets: []CallOffset{off1, off2}}
 		case 'C':
 			derived := st.demangleType(false)
 			off := st.off
@@ -1466,8 +2420,8 @@ func (st *state) specialName() AST {
 // The c parameter, if not 0, is a character we just read which is the
 // start of the <call-offset>.
 //
-// We don't display the offset information anywhere.
-func (st *state) callOffset(c byte) {
+// The offsets are only printed with the ThunkOffsets option.
+func (st *state) callOffset(c byte) CallOffset {
 	if c == 0 {
 		if len(st.str) == 0 {
 			st.fail("missing call offset")
@@ -1475,16 +2429,18 @@ func (st *state) callOffset(c byte) {
 		c = st.str[0]
 		st.advance(1)
 	}
+	var ret CallOffset
 	switch c {
 	case 'h':
-		st.number()
+		ret.Offset = st.number()
 	case 'v':
-		st.number()
+		ret.Virtual = true
+		ret.Offset = st.number()
 		if len(st.str) == 0 || st.str[0] != '_' {
 			st.fail("expected _ after number")
 		}
 		st.advance(1)
-		st.number()
+		ret.VCallOffset = st.number()
 	default:
 		st.failEarlier("unrecognized call offset code", 1)
 	}
@@ -1492,6 +2448,7 @@ func (st *state) callOffset(c byte) {
 		st.fail("expected _ after call offset")
 	}
 	st.advance(1)
+	return ret
 }
 
 // builtinTypes maps the type letter to the type name.
@@ -1519,6 +2476,29 @@ var builtinTypes = map[byte]string{
 	'z': "...",
 }
 
+// builtinTypeTable is builtinTypes as a table, which is faster to
+// look up.
+var builtinTypeTable = func() *[256]string {
+	table := new([256]string)
+	for c, name := range builtinTypes {
+		table[c] = name
+	}
+	return table
+}()
+
+// dBuiltinTypes maps the letter after D to the name of a builtin
+// type, for the builtin types that are encoded as two letters.
+var dBuiltinTypes = [256]string{
+	'f': "decimal32",
+	'd': "decimal64",
+	'e': "decimal128",
+	'h': "half",
+	'u': "char8_t",
+	's': "char16_t",
+	'i': "char32_t",
+	'n': "decltype(nullptr)",
+}
+
 // demangleType parses:
 //
 //	<type> ::= <builtin-type>
@@ -1540,6 +2520,8 @@ var builtinTypes = map[byte]string{
 //	<builtin-type> ::= various one letter codes
 //	               ::= u <source-name>
 func (st *state) demangleType(isCast bool) AST {
+	defer st.leave(st.enter())
+
 	if len(st.str) == 0 {
 		st.fail("expected type")
 	}
@@ -1565,12 +2547,12 @@ func (st *state) demangleType(isCast bool) AST {
 	// Use correct substitution for a template parameter.
 	var sub AST
 
-	if btype, ok := builtinTypes[st.str[0]]; ok {
+	if btype := builtinTypeTable[st.str[0]]; btype != "" {
 		ret = &BuiltinType{Name: btype}
 		st.advance(1)
 		if q != nil {
 			ret = &TypeWithQualifiers{Base: ret, Qualifiers: q}
-			st.subs.add(ret)
+			st.addSubst(ret)
 		}
 		return ret
 	}
@@ -1618,7 +2600,7 @@ func (st *state) demangleType(isCast bool) AST {
 		if len(st.str) > 0 && st.str[0] == 'I' {
 			// See the function comment to explain this.
 			if !isCast {
-				st.subs.add(ret)
+				st.addSubst(ret)
 				args := st.templateArgs()
 				ret = &Template{Name: ret, Args: args}
 			} else {
@@ -1636,7 +2618,7 @@ func (st *state) demangleType(isCast bool) AST {
 			ret = st.substitution(false)
 			if _, ok := ret.(*ModuleName); ok {
 				ret, _ = st.unqualifiedName(ret)
-				st.subs.add(ret)
+				st.addSubst(ret)
 			}
 			if len(st.str) == 0 || st.str[0] != 'I' {
 				addSubst = false
@@ -1706,6 +2688,10 @@ func (st *state) demangleType(isCast bool) AST {
 		addSubst = false
 		c2 := st.str[0]
 		st.advance(1)
+		if btype := dBuiltinTypes[c2]; btype != "" {
+			ret = &BuiltinType{Name: btype}
+			break
+		}
 		switch c2 {
 		case 'T', 't':
 			// decltype(expression)
@@ -1728,23 +2714,6 @@ func (st *state) demangleType(isCast bool) AST {
 		case 'c':
 			ret = &Name{Name: "decltype(auto)"}
 
-		case 'f':
-			ret = &BuiltinType{Name: "decimal32"}
-		case 'd':
-			ret = &BuiltinType{Name: "decimal64"}
-		case 'e':
-			ret = &BuiltinType{Name: "decimal128"}
-		case 'h':
-			ret = &BuiltinType{Name: "half"}
-		case 'u':
-			ret = &BuiltinType{Name: "char8_t"}
-		case 's':
-			ret = &BuiltinType{Name: "char16_t"}
-		case 'i':
-			ret = &BuiltinType{Name: "char32_t"}
-		case 'n':
-			ret = &BuiltinType{Name: "decltype(nullptr)"}
-
 		case 'F':
 			accum := false
 			bits := 0
@@ -1817,9 +2786,9 @@ func (st *state) demangleType(isCast bool) AST {
 
 	if addSubst {
 		if sub != nil {
-			st.subs.add(sub)
+			st.addSubst(sub)
 		} else {
-			st.subs.add(ret)
+			st.addSubst(ret)
 		}
 	}
 
@@ -1840,7 +2809,7 @@ func (st *state) demangleType(isCast bool) AST {
 			}
 			ret = &TypeWithQualifiers{Base: ret, Qualifiers: q}
 		}
-		st.subs.add(ret)
+		st.addSubst(ret)
 	}
 
 	return ret
@@ -1889,7 +2858,7 @@ func (st *state) demangleCastTemplateArgs(tp AST, addSubst bool) AST {
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				if _, ok := r.(demangleErr); ok {
+				if _, ok := r.(Error); ok {
 					failed = true
 				} else {
 					panic(r)
@@ -1902,7 +2871,7 @@ func (st *state) demangleCastTemplateArgs(tp AST, addSubst bool) AST {
 
 	if !failed && len(st.str) > 0 && st.str[0] == 'I' {
 		if addSubst {
-			st.subs.add(tp)
+			st.addSubst(tp)
 		}
 		return &Template{Name: tp, Args: args}
 	}
@@ -2262,7 +3231,7 @@ func (st *state) templateParam() AST {
 			// See https://gcc.gnu.org/PR78252.
 			return &LambdaAuto{Index: n}
 		}
-		st.failEarlier(fmt.Sprintf("template parameter is not in scope of template (level %d >= %d)", level, len(st.templates)), st.off-off)
+		st.failCode(ErrBadTemplateParam, fmt.Sprintf("template parameter is not in scope of template (level %d >= %d)", level, len(st.templates)), st.off-off)
 	}
 
 	template := st.templates[level]
@@ -2280,9 +3249,11 @@ func (st *state) templateParam() AST {
 			// See https://gcc.gnu.org/PR78252.
 			return &LambdaAuto{Index: n}
 		}
-		st.failEarlier(fmt.Sprintf("template index out of range (%d >= %d)", n, len(template.Args)), st.off-off)
+		st.failCode(ErrBadTemplateParam, fmt.Sprintf("template index out of range (%d >= %d)", n, len(template.Args)), st.off-off)
 	}
 
+	st.checkReference(st.argSize(template.Args[n]))
+
 	return &TemplateParam{Index: n, Template: template}
 }
 
@@ -2301,10 +3272,10 @@ func (st *state) setTemplate(a AST, tmpl *Template) {
 				return false
 			}
 			if tmpl == nil {
-				st.fail("cast template parameter not in scope of template")
+				st.failCode(ErrBadTemplateParam, "cast template parameter not in scope of template", 0)
 			}
 			if a.Index >= len(tmpl.Args) {
-				st.fail(fmt.Sprintf("cast template index out of range (%d >= %d)", a.Index, len(tmpl.Args)))
+				st.failCode(ErrBadTemplateParam, fmt.Sprintf("cast template index out of range (%d >= %d)", a.Index, len(tmpl.Args)), 0)
 			}
 			a.Template = tmpl
 			return false
@@ -2344,6 +3315,7 @@ func (st *state) templateArgs() []AST {
 	var ret []AST
 	for len(st.str) == 0 || st.str[0] != 'E' {
 		arg := st.templateArg(ret)
+		st.noteArg(arg)
 		ret = append(ret, arg)
 
 		if len(st.str) > 0 && st.str[0] == 'Q' {
@@ -2368,6 +3340,8 @@ func (st *state) templateArgs() []AST {
 //	               ::= LZ <encoding> E
 //	               ::= <template-param-decl> <template-arg>
 func (st *state) templateArg(prev []AST) AST {
+	defer st.leave(st.enter())
+
 	if len(st.str) == 0 {
 		st.fail("missing template argument")
 	}
@@ -2501,6 +3475,15 @@ func (st *state) exprList(stop byte) AST {
 //	                    ::= dx <index expression> <braced-expression>
 //	                    ::= dX <range begin expression> <range end expression> <braced-expression>
 func (st *state) expression() AST {
+	if st.skipExprs {
+		st.skipExpression()
+		// Each skipped expression gets its own node, as
+		// an AST may be changed by the caller.
+		return &Name{Name: "…"}
+	}
+
+	defer st.leave(st.enter())
+
 	if len(st.str) == 0 {
 		st.fail("expected expression")
 	}
@@ -2750,6 +3733,204 @@ func (st *state) expression() AST {
 	}
 }
 
+// skipExpression steps over an expression for the SkipExpressions
+// option. It follows the grammar of expression, but only builds the
+// types and names that a later substitution may refer to.
+func (st *state) skipExpression() {
+	defer st.leave(st.enter())
+
+	if len(st.str) == 0 {
+		st.fail("expected expression")
+	}
+	var c1 byte
+	if len(st.str) > 1 {
+		c1 = st.str[1]
+	}
+	switch c0 := st.str[0]; {
+	case c0 == 'L':
+		st.exprPrimary()
+	case c0 == 'T':
+		st.templateParam()
+	case c0 == 's' && c1 == 'o':
+		st.advance(2)
+		st.subobject()
+	case c0 == 's' && c1 == 'r':
+		st.unresolvedName()
+	case c0 == 's' && (c1 == 'p' || c1 == 'Z'):
+		st.advance(2)
+		st.skipExpression()
+	case c0 == 's' && c1 == 'P':
+		st.advance(2)
+		for len(st.str) == 0 || st.str[0] != 'E' {
+			st.templateArg(nil)
+		}
+		st.advance(1)
+	case c0 == 'f' && c1 == 'p':
+		st.advance(2)
+		if len(st.str) > 0 && st.str[0] == 'T' {
+			st.advance(1)
+		} else {
+			st.cvQualifiers()
+			st.compactNumber()
+		}
+	case c0 == 'f' && c1 == 'L' && len(st.str) > 2 && isDigit(st.str[2]):
+		st.advance(2)
+		st.number()
+		if len(st.str) == 0 || st.str[0] != 'p' {
+			st.fail("expected p after function parameter scope count")
+		}
+		st.advance(1)
+		st.cvQualifiers()
+		st.compactNumber()
+	case c0 == 'm' && c1 == 'c':
+		st.advance(2)
+		st.demangleType(false)
+		st.skipExpression()
+		if len(st.str) > 0 && (st.str[0] == 'n' || isDigit(st.str[0])) {
+			st.number()
+		}
+		if len(st.str) == 0 || st.str[0] != 'E' {
+			st.fail("expected E after pointer-to-member conversion")
+		}
+		st.advance(1)
+	case isDigit(c0) || (c0 == 'o' && c1 == 'n'):
+		if c0 == 'o' {
+			st.advance(2)
+		}
+		st.unqualifiedName(nil)
+		if len(st.str) > 0 && st.str[0] == 'I' {
+			st.templateArgs()
+		}
+	case (c0 == 'i' || c0 == 't') && c1 == 'l':
+		st.advance(2)
+		if c0 == 't' {
+			st.demangleType(false)
+		}
+		st.skipExprList('E')
+	case c0 == 's' && c1 == 't':
+		st.operatorName(true)
+		st.demangleType(false)
+	case c0 == 'u':
+		st.advance(1)
+		name := st.sourceName()
+		if n, ok := name.(*Name); ok && n.Name == "__uuidof" {
+			if len(st.str) < 2 {
+				st.fail("missing uuidof argument")
+			}
+			if st.str[0] == 't' {
+				st.advance(1)
+				st.demangleType(false)
+				return
+			} else if st.str[0] == 'z' {
+				st.advance(1)
+				st.skipExpression()
+				return
+			}
+		}
+		for {
+			if len(st.str) == 0 {
+				st.fail("missing argument in vendor extended expressoin")
+			}
+			if st.str[0] == 'E' {
+				st.advance(1)
+				break
+			}
+			st.templateArg(nil)
+		}
+	case c0 == 'r' && (c1 == 'q' || c1 == 'Q'):
+		st.requiresExpr()
+	default:
+		if len(st.str) < 2 {
+			st.fail("missing operator code")
+		}
+		code := st.str[:2]
+		o, args := st.operatorName(true)
+		switch args {
+		case 0:
+		case 1:
+			if (code == "pp" || code == "mm") && len(st.str) > 0 && st.str[0] == '_' {
+				st.advance(1)
+			}
+			if _, ok := o.(*Cast); ok && len(st.str) > 0 && st.str[0] == '_' {
+				st.advance(1)
+				st.skipExprList('E')
+			} else {
+				st.skipExpression()
+			}
+		case 2:
+			if code == "sc" || code == "dc" || code == "cc" || code == "rc" {
+				st.demangleType(false)
+			} else if code[0] == 'f' {
+				st.operatorName(true)
+				st.skipExpression()
+				return
+			} else if code == "di" {
+				st.unqualifiedName(nil)
+			} else {
+				st.skipExpression()
+			}
+			if code == "cl" || code == "cp" {
+				st.skipExprList('E')
+			} else if code == "dt" || code == "pt" {
+				if len(st.str) > 0 && st.str[0] == 'L' {
+					st.exprPrimary()
+				} else {
+					st.unresolvedName()
+					if len(st.str) > 0 && st.str[0] == 'I' {
+						st.templateArgs()
+					}
+				}
+			} else {
+				st.skipExpression()
+			}
+		case 3:
+			if code[0] == 'n' {
+				if code[1] != 'w' && code[1] != 'a' {
+					panic("internal error")
+				}
+				st.skipExprList('_')
+				st.demangleType(false)
+				if len(st.str) > 0 && st.str[0] == 'E' {
+					st.advance(1)
+				} else if len(st.str) > 1 && st.str[0] == 'p' && st.str[1] == 'i' {
+					st.advance(2)
+					st.skipExprList('E')
+				} else if len(st.str) > 1 && st.str[0] == 'i' && st.str[1] == 'l' {
+					st.skipExpression()
+				} else {
+					st.fail("unrecognized new initializer")
+				}
+				return
+			}
+			if code[0] == 'f' {
+				st.operatorName(true)
+			} else {
+				st.skipExpression()
+			}
+			st.skipExpression()
+			st.skipExpression()
+		default:
+			st.fail(fmt.Sprintf("unsupported number of operator arguments: %d", args))
+		}
+	}
+}
+
+// skipExprList steps over a sequence of expressions up to a
+// terminating character, as exprList parses them.
+func (st *state) skipExprList(stop byte) {
+	if len(st.str) > 0 && st.str[0] == stop {
+		st.advance(1)
+		return
+	}
+	for {
+		st.skipExpression()
+		if len(st.str) > 0 && st.str[0] == stop {
+			st.advance(1)
+			return
+		}
+	}
+}
+
 // subobject parses:
 //
 //	<expression> ::= so <referent type> <expr> [<offset number>] <union-selector>* [p] E
@@ -2795,6 +3976,8 @@ func (st *state) subobject() AST {
 //	                  ::= srN <unresolved-type> <unresolved-qualifier-level>+ E <base-unresolved-name>
 //	                  ::= [gs] sr <unresolved-qualifier-level>+ E <base-unresolved-name>
 func (st *state) unresolvedName() AST {
+	defer st.leave(st.enter())
+
 	if len(st.str) >= 2 && st.str[:2] == "gs" {
 		st.advance(2)
 		n := st.unresolvedName()
@@ -2817,7 +4000,7 @@ func (st *state) unresolvedName() AST {
 			if len(st.str) > 0 && st.str[0] == 'I' {
 				args := st.templateArgs()
 				n = &Template{Name: n, Args: args}
-				st.subs.add(n)
+				st.addSubst(n)
 			}
 			return n
 		default:
@@ -2833,17 +4016,17 @@ func (st *state) unresolvedName() AST {
 					if q, ok := s.(*Qualified); ok {
 						a := q.Scope
 						if t, ok := a.(*Template); ok {
-							st.subs.add(t.Name)
-							st.subs.add(t)
+							st.addSubst(t.Name)
+							st.addSubst(t)
 						} else {
-							st.subs.add(a)
+							st.addSubst(a)
 						}
 						return s
 					}
 				}
 				n := st.sourceName()
 				if len(st.str) > 0 && st.str[0] == 'I' {
-					st.subs.add(n)
+					st.addSubst(n)
 					args := st.templateArgs()
 					n = &Template{Name: n, Args: args}
 				}
@@ -3001,7 +4184,16 @@ func (st *state) exprPrimary() AST {
 			st.fail("expected mangled name")
 		}
 		st.advance(1)
+
+		// The template parameters of the encoding are
+		// unrelated to those of the enclosing context.
+		oldTemplates := st.templates
+		oldLambdaTemplateLevel := st.lambdaTemplateLevel
+		st.templates = nil
+		st.lambdaTemplateLevel = 0
 		ret = st.encoding(true, notForLocalName)
+		st.templates = oldTemplates
+		st.lambdaTemplateLevel = oldLambdaTemplateLevel
 	} else {
 		t := st.demangleType(false)
 
@@ -3054,18 +4246,21 @@ func (st *state) exprPrimary() AST {
 //
 //	<discriminator> ::= _ <(non-negative) number> (when number < 10)
 //	                    __ <(non-negative) number> _ (when number >= 10)
-func (st *state) discriminator(a AST) AST {
+//
+// It returns the number of earlier entities with the same name,
+// which is 0 if there is no discriminator.
+func (st *state) discriminator(a AST) (AST, int) {
 	if len(st.str) == 0 || st.str[0] != '_' {
 		// clang can generate a discriminator at the end of
 		// the string with no underscore.
 		for i := 0; i < len(st.str); i++ {
 			if !isDigit(st.str[i]) {
-				return a
+				return a, 0
 			}
 		}
 		// Skip the trailing digits.
 		st.advance(len(st.str))
-		return a
+		return a, 0
 	}
 	off := st.off
 	st.advance(1)
@@ -3084,9 +4279,9 @@ func (st *state) discriminator(a AST) AST {
 		}
 		st.advance(1)
 	}
-	// We don't currently print out the discriminator, so we don't
-	// save it.
-	return a
+	// We don't print the discriminator, but we return it for
+	// local names.
+	return a, d + 1
 }
 
 // closureTypeName parses:
@@ -3162,6 +4357,8 @@ func (st *state) closureTypeName() AST {
 //
 // Returns nil, nil if not looking at a template-param-decl.
 func (st *state) templateParamDecl() (AST, AST) {
+	defer st.leave(st.enter())
+
 	if len(st.str) < 2 || st.str[0] != 'T' {
 		return nil, nil
 	}
@@ -3273,7 +4470,7 @@ func (st *state) unnamedTypeName() AST {
 	st.checkChar('t')
 	num := st.compactNumber()
 	ret := &UnnamedType{Num: num}
-	st.subs.add(ret)
+	st.addSubst(ret)
 	return ret
 }
 
@@ -3375,6 +4572,109 @@ var verboseAST = map[byte]AST{
 				Args: []AST{&BuiltinType{Name: "char"}}}}},
 }
 
+// An internedName is a name from the standard library that appears
+// in a large fraction of C++ symbols. We recognize these names
+// without parsing them, and build their ASTs at most once for each
+// name being demangled, sharing them among the places in the name
+// that use them. The ASTs are not shared between names, so a caller
+// may change the AST returned by ToAST.
+type internedName struct {
+	mangled string // the mangled name
+	char    int    // index of the character type in internedChars
+	kind    int    // internedString, internedTraits, or internedAlloc
+}
+
+// internedChars are the character types of the interned names.
+var internedChars = [...]byte{'c', 'w'}
+
+// The kinds of interned names.
+const (
+	internedString = iota // std::basic_string<T, std::char_traits<T>, std::allocator<T> >
+	internedTraits        // std::char_traits<T>
+	internedAlloc         // std::allocator<T>
+)
+
+// internedNames is the list of interned names, in order from longest
+// to shortest. Each starts with S, and is parsed by name.
+var internedNames = buildInternedNames()
+
+// buildInternedNames builds internedNames.
+func buildInternedNames() []internedName {
+	var long, short []internedName
+	for i, c := range internedChars {
+		tc := string(c)
+		traitsMangled := "St11char_traitsI" + tc + "E"
+		allocMangled := "SaI" + tc + "E"
+		long = append(long, internedName{
+			mangled: "SbI" + tc + traitsMangled + allocMangled + "E",
+			char:    i,
+			kind:    internedString,
+		})
+		short = append(short,
+			internedName{mangled: traitsMangled, char: i, kind: internedTraits},
+			internedName{mangled: allocMangled, char: i, kind: internedAlloc})
+	}
+	return append(long, short...)
+}
+
+// internedASTs holds the ASTs of the interned names for a character
+// type, for one name being demangled.
+type internedASTs struct {
+	traitsName AST // std::char_traits
+	traits     AST // std::char_traits<T>
+	alloc      AST // std::allocator<T>
+	str        AST // std::basic_string<T, std::char_traits<T>, std::allocator<T> >
+}
+
+// newInternedASTs builds the ASTs of the interned names for the
+// character type c.
+func newInternedASTs(c byte) *internedASTs {
+	t := &BuiltinType{Name: builtinTypeTable[c]}
+	traitsName := &Qualified{Scope: &Name{Name: "std"}, Name: &Name{Name: "char_traits"}}
+	traits := &Template{Name: traitsName, Args: []AST{t}}
+	alloc := &Template{
+		Name: &Qualified{Scope: &Name{Name: "std"}, Name: &Name{Name: "allocator"}},
+		Args: []AST{t},
+	}
+	str := &Template{
+		Name: &Qualified{Scope: &Name{Name: "std"}, Name: &Name{Name: "basic_string"}},
+		Args: []AST{t, traits, alloc},
+	}
+	return &internedASTs{traitsName: traitsName, traits: traits, alloc: alloc, str: str}
+}
+
+// internedName parses an interned name, if the string starts with
+// one, returning its AST and adding the substitution candidates that
+// parsing it would have added. It returns nil if the string does not
+// start with an interned name.
+func (st *state) internedName() AST {
+	for i := range internedNames {
+		in := &internedNames[i]
+		if !strings.HasPrefix(st.str, in.mangled) {
+			continue
+		}
+		st.advance(len(in.mangled))
+		ia := st.interned[in.char]
+		if ia == nil {
+			ia = newInternedASTs(internedChars[in.char])
+			st.interned[in.char] = ia
+		}
+		switch in.kind {
+		case internedString:
+			st.addSubst(ia.traitsName)
+			st.addSubst(ia.traits)
+			st.addSubst(ia.alloc)
+			return ia.str
+		case internedTraits:
+			st.addSubst(ia.traitsName)
+			return ia.traits
+		default:
+			return ia.alloc
+		}
+	}
+	return nil
+}
+
 // substitution parses:
 //
 //	<substitution> ::= S <seq-id> _
@@ -3389,17 +4689,25 @@ var verboseAST = map[byte]AST{
 func (st *state) substitution(forPrefix bool) AST {
 	st.checkChar('S')
 	if len(st.str) == 0 {
-		st.fail("missing substitution index")
+		st.failCode(ErrBadSubstitution, "missing substitution index", 0)
 	}
 	c := st.str[0]
 	off := st.off
 	if c == '_' || isDigit(c) || isUpper(c) {
 		id := st.seqID(false)
 		if id >= len(st.subs) {
-			st.failEarlier(fmt.Sprintf("substitution index out of range (%d >= %d)", id, len(st.subs)), st.off-off)
+			st.failCode(ErrBadSubstitution, fmt.Sprintf("substitution index out of range (%d >= %d)", id, len(st.subs)), st.off-off)
 		}
 
 		ret := st.subs[id]
+		st.checkReference(st.subSizes[id])
+
+		// A substitution that does not refer to any template
+		// parameters is the same wherever it appears, so
+		// share it rather than walking it again.
+		if st.noTemplateParams[ret] {
+			return ret
+		}
 
 		// We need to update any references to template
 		// parameters to refer to the currently active
@@ -3422,6 +4730,7 @@ func (st *state) substitution(forPrefix bool) AST {
 			oldLambdaTemplateLevel = oldLambdaTemplateLevel[:len(oldLambdaTemplateLevel)-1]
 		}
 
+		hasParams := false
 		copy := func(a AST) AST {
 			var index int
 			switch a := a.(type) {
@@ -3446,6 +4755,7 @@ func (st *state) substitution(forPrefix bool) AST {
 			default:
 				return nil
 			}
+			hasParams = true
 			if st.parsingConstraint {
 				// We don't try to substitute template
 				// parameters in a constraint expression.
@@ -3467,7 +4777,7 @@ func (st *state) substitution(forPrefix bool) AST {
 				// here.
 				template = rt
 			} else {
-				st.failEarlier("substituted template parameter not in scope of template", st.off-off)
+				st.failCode(ErrBadTemplateParam, "substituted template parameter not in scope of template", st.off-off)
 			}
 			if template == nil {
 				// This template parameter is within
@@ -3476,7 +4786,7 @@ func (st *state) substitution(forPrefix bool) AST {
 			}
 
 			if index >= len(template.Args) {
-				st.failEarlier(fmt.Sprintf("substituted template index out of range (%d >= %d)", index, len(template.Args)), st.off-off)
+				st.failCode(ErrBadTemplateParam, fmt.Sprintf("substituted template index out of range (%d >= %d)", index, len(template.Args)), st.off-off)
 			}
 
 			return &TemplateParam{Index: index, Template: template}
@@ -3498,7 +4808,7 @@ func (st *state) substitution(forPrefix bool) AST {
 			case *TemplateParam, *LambdaAuto:
 				return false
 			}
-			if seen[a] {
+			if seen[a] || st.noTemplateParams[a] {
 				return true
 			}
 			seen[a] = true
@@ -3509,6 +4819,13 @@ func (st *state) substitution(forPrefix bool) AST {
 			return c
 		}
 
+		if !hasParams {
+			if st.noTemplateParams == nil {
+				st.noTemplateParams = make(map[AST]bool)
+			}
+			st.noTemplateParams[ret] = true
+		}
+
 		return ret
 	} else {
 		st.advance(1)
@@ -3523,12 +4840,12 @@ func (st *state) substitution(forPrefix bool) AST {
 		}
 		a, ok := m[c]
 		if !ok {
-			st.failEarlier("unrecognized substitution code", 1)
+			st.failCode(ErrBadSubstitution, "unrecognized substitution code", 1)
 		}
 
 		if len(st.str) > 0 && st.str[0] == 'B' {
 			a = st.taggedName(a)
-			st.subs.add(a)
+			st.addSubst(a)
 		}
 
 		return a
@@ -3553,6 +4870,27 @@ func isLower(c byte) bool {
 // simplify replaces template parameters with their expansions, and
 // merges qualifiers.
 func simplify(a AST) AST {
+	return simplifyWith(a, simplifyOne)
+}
+
+// simplify is like the simplify function, but for the
+// TemplateParamNames option it leaves template parameters and pack
+// expansions in place, so that they can be printed by name.
+func (st *state) simplify(a AST) AST {
+	if !st.tparamNames {
+		return simplify(a)
+	}
+	return simplifyWith(a, func(a AST) AST {
+		switch a.(type) {
+		case *TemplateParam, *PackExpansion:
+			return nil
+		}
+		return simplifyOne(a)
+	})
+}
+
+// simplifyWith simplifies an AST by calling fn for each node.
+func simplifyWith(a AST, fn func(AST) AST) AST {
 	seen := make(map[AST]bool)
 	skip := func(a AST) bool {
 		if seen[a] {
@@ -3561,7 +4899,7 @@ func simplify(a AST) AST {
 		seen[a] = true
 		return false
 	}
-	if r := a.Copy(simplifyOne, skip); r != nil {
+	if r := a.Copy(fn, skip); r != nil {
 		return r
 	}
 	return a
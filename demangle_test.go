@@ -503,7 +503,7 @@ func TestFailure(t *testing.T) {
 		},
 		{
 			"_Z1aIeEU1RT_ZcvS1_",
-			"expected E after local name",
+			"cast template parameter not in scope",
 			18,
 		},
 		{
@@ -526,6 +526,16 @@ func TestFailure(t *testing.T) {
 			"expected unqualified name",
 			4,
 		},
+		{
+			"_ZNW6,WKw@-",
+			"expected unqualified name",
+			11,
+		},
+		{
+			"_ZNW33OO3TPLIS_3OneE1NIS_3TwoEVvPS1_PT_",
+			"expected unqualified name",
+			39,
+		},
 	}
 
 	for _, test := range tests {
@@ -534,19 +544,10 @@ func TestFailure(t *testing.T) {
 			t.Errorf("unexpected success for %s: %s", test.input, got)
 		} else if !strings.Contains(err.Error(), test.error) {
 			t.Errorf("unexpected error for %s: %v", test.input, err)
-		} else {
-			s := err.Error()
-			i := strings.LastIndex(s, " at ")
-			if i < 0 {
-				t.Errorf("missing offset in error for %s: %v", test.input, err)
-			} else {
-				off, oerr := strconv.Atoi(s[i+4:])
-				if oerr != nil {
-					t.Errorf("can't parse offset (%s) for %s: %v", s[i+4:], test.input, err)
-				} else if off != test.off {
-					t.Errorf("unexpected offset for %s: got %d, want %d", test.input, off, test.off)
-				}
-			}
+		} else if de, ok := err.(Error); !ok {
+			t.Errorf("error for %s has type %T, want Error", test.input, err)
+		} else if de.Offset != test.off {
+			t.Errorf("unexpected offset for %s: got %d, want %d", test.input, de.Offset, test.off)
 		}
 
 		if got := Filter(test.input); got != test.input {
@@ -569,3 +570,1163 @@ func TestMaxLength(t *testing.T) {
 		}
 	}
 }
+
+func TestMaxLengthBytes(t *testing.T) {
+	for _, n := range []int{1, 7, 200, 1 << 16, 1<<26 - 1, 1 << 26} {
+		opt := MaxLengthBytes(n)
+		if !isMaxLength(opt) {
+			t.Errorf("isMaxLength(%x) returned false", opt)
+		}
+		if got := maxLength(opt); got != n {
+			t.Errorf("maxLength(%x) = %v, want %v", opt, got, n)
+		}
+		if again := MaxLengthBytes(n); again != opt {
+			t.Errorf("MaxLengthBytes(%d) = %x, then %x", n, opt, again)
+		}
+	}
+
+	// The values are not stored in a table, so a program may use
+	// any number of them.
+	for n := 1; n < 10000; n++ {
+		for _, opt := range []Option{MaxLengthBytes(n), MaxTemplateArgLength(n), MaxExpansion(n)} {
+			if got := optionValue(opt); got != n {
+				t.Fatalf("optionValue(%x) = %d, want %d", opt, got, n)
+			}
+		}
+	}
+
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []Option{MaxLengthBytes(11)}, "std::vector"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []Option{MaxLengthBytes(200)}, "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_RNvCs1234_4main4main", []Option{MaxLengthBytes(6)}, "main::"},
+		{"?foo@bar@@YAXXZ", []Option{MaxLengthBytes(5)}, "void "},
+		{"_D3foo3barFZv", []Option{MaxLengthBytes(5)}, "foo.b"},
+	}
+	for _, test := range tests {
+		got, err := ToString(test.input, test.options...)
+		if err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestNoVendorQualifiers(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{
+			"_Z18IndirectExternCallIPU7stdcallU7regparmILi3EEFviiEiEvT_T0_S3_",
+			"void IndirectExternCall<void (*)(int, int), int>(void (*)(int, int), int, void (*)(int, int))",
+		},
+		{"_Z2f0PU3AS1c", "f0(char*)"},
+		{"_Z3fooUa9enable_ifIXLi1EEEv", "foo()"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, NoVendorQualifiers); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestLLVMUnnamed(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZN1CUt_C2Ev", "C::__unnamed_1::__unnamed_1()"},
+		{"_ZN1AUt0_E", "A::__unnamed_2"},
+		{"_ZZ1fvENKUlvE_clEv", "f()::$_0::operator()() const"},
+		{"_ZZ1fvENKUlvE0_clEv", "f()::$_1::operator()() const"},
+	}
+
+	for _, test := range tests {
+		for _, opts := range [][]Option{{LLVMUnnamed}, {LLVMUnnamed, LLVMStyle}} {
+			if got, err := ToString(test.input, opts...); err != nil {
+				t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			} else if got != test.want {
+				t.Errorf("demangling %s with %v: got %s, want %s", test.input, opts, got, test.want)
+			}
+		}
+	}
+}
+
+func TestNoAngleSpace(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_Z1fI1AI1BIiEEEvv", "void f<A<B<int>>>()"},
+		{"_ZN1AIS_IiEE1fEv", "A<A<int>>::f()"},
+		{"_Z1fI1AIiEEvv", "void f<A<int>>()"},
+		{"_Z1fIiEvv", "void f<int>()"},
+	}
+
+	for _, test := range tests {
+		for _, opts := range [][]Option{{NoAngleSpace}, {NoAngleSpace, LLVMStyle}} {
+			if got, err := ToString(test.input, opts...); err != nil {
+				t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			} else if got != test.want {
+				t.Errorf("demangling %s with %v: got %s, want %s", test.input, opts, got, test.want)
+			}
+		}
+	}
+}
+
+func TestWestConst(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_Z1fPKc", nil, "f(const char*)"},
+		{"_Z1fPVKi", nil, "f(const volatile int*)"},
+		{"_Z1fPKPc", nil, "f(char* const*)"},
+		{"_Z1fPKPKc", nil, "f(const char* const*)"},
+		{"_Z1fRKSs", nil, "f(const std::string&)"},
+		{"_Z1fA10_Kc", nil, "f(const char [10])"},
+		{"_Z1fPA2_A3_Ki", nil, "f(const int (*) [2][3])"},
+		{"_Z1fIiEvPKT_", nil, "void f<int>(const int*)"},
+		{"_Z1fIPiEvPKT_", nil, "void f<int*>(int* const*)"},
+		{"_Z1fIKiEvv", nil, "void f<const int>()"},
+		{"_Z1fM1AKFvvE", nil, "f(void (A::*)() const)"},
+		{"_ZNK1A1fEv", nil, "A::f() const"},
+		{"_Z1fPKc", []Option{LLVMStyle}, "f(const char*)"},
+		{"_Z1fRK1AIiE", []Option{LLVMStyle}, "f(const A<int>&)"},
+	}
+
+	for _, test := range tests {
+		options := append([]Option{WestConst}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, options, got, test.want)
+		}
+	}
+}
+
+func TestNoStdInlineNamespaces(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEE6appendEPKcm", "std::basic_string<char, std::char_traits<char>, std::allocator<char> >::append(char const*, unsigned long)"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNKSt3__18functionIFvvEEclEv", "std::function<void ()>::operator()() const"},
+		{"_ZNSt3__110__function6__funcIPFvvENS_9allocatorIS3_EES2_EclEv", "std::__function::__func<void (*)(), std::allocator<void (*)()>, void ()>::operator()()"},
+		{"_ZNSt6__ndk16vectorIiNS_9allocatorIiEEE5clearEv", "std::vector<int, std::allocator<int> >::clear()"},
+		{"_ZNSt2Cr6vectorIiNS_9allocatorIiEEE5clearEv", "std::vector<int, std::allocator<int> >::clear()"},
+		{"_ZNSt3__u6vectorIiNS_9allocatorIiEEE5clearEv", "std::vector<int, std::allocator<int> >::clear()"},
+		{"_ZN3foo3__13barEv", "foo::__1::bar()"},
+		{"_ZNSt6__pstl3fooEv", "std::__pstl::foo()"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, NoStdInlineNamespaces); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestNoReturnType(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_Z1fIiEvT_", nil, "f<int>(int)"},
+		{"_Z1fIiEvT_", []Option{LLVMStyle}, "f<int>(int)"},
+		{"_Z1fIiEPFviET_", nil, "f<int>(int)"},
+		{"_Z1fIiEvPFT_vE", nil, "f<int>(int (*)())"},
+		{"_ZNK1A1fIiEEiv", nil, "A::f<int>() const"},
+		{"_ZZ1fIiEvvE1x", []Option{LLVMStyle}, "f<int>()::x"},
+		{"_Z1fi", nil, "f(int)"},
+		{"_Z1fIiEvT_", []Option{NoParams}, "f<int>"},
+	}
+
+	for _, test := range tests {
+		options := append([]Option{NoReturnType}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, options, got, test.want)
+		}
+	}
+}
+
+func TestNoABITags(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZNKSt7__cxx114listIiE4sizeB5cxx11Ev", "std::__cxx11::list<int>::size() const"},
+		{"_ZNSt3__14swapB7v170000IiEEvRT_S2_", "void std::__1::swap<int>(int&, int&)"},
+		{"_ZN1AB5cxx111fEv", "A::f()"},
+		{"_Z1fB7v170000v", "f()"},
+		{"_ZN1AB3fooB3bar1fB3bazEv", "A::f()"},
+		{"_Z1fI1AB5cxx11Evv", "void f<A>()"},
+	}
+
+	for _, test := range tests {
+		for _, opts := range [][]Option{{NoABITags}, {NoABITags, LLVMStyle}} {
+			if got, err := ToString(test.input, opts...); err != nil {
+				t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			} else if got != test.want {
+				t.Errorf("demangling %s with %v: got %s, want %s", test.input, opts, got, test.want)
+			}
+		}
+	}
+}
+
+func TestBaseNameOnly(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", "push_back"},
+		{"_ZNSaIcEC1ERKS_", "allocator"},
+		{"_ZNSaIcED2Ev", "~allocator"},
+		{"_ZNK1AclEv", "operator()"},
+		{"_ZN1AcviEv", "operator int"},
+		{"_Z1fIiEvT_", "f"},
+		{"_ZN2ns1fB5cxx11Ev", "f"},
+		{"_ZZ1fvE1x", "x"},
+		{"_ZTVN2ns1AE", "vtable for A"},
+		{"_ZGVZ1fvE1x", "guard variable for x"},
+		{"_Z1fv.cold", "f"},
+		{"_ZN1AIiE1fIcEEvT_", "f"},
+		{"_ZL1xv", "x"},
+	}
+
+	for _, test := range tests {
+		for _, opts := range [][]Option{{BaseNameOnly}, {BaseNameOnly, LLVMStyle}} {
+			if got, err := ToString(test.input, opts...); err != nil {
+				t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			} else if got != test.want {
+				t.Errorf("demangling %s with %v: got %s, want %s", test.input, opts, got, test.want)
+			}
+		}
+	}
+}
+
+func TestScopeOnly(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", nil, "std::__1::vector<int, std::__1::allocator<int> >"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", []Option{NoTemplateParams}, "std::__1::vector"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backERKi", []Option{BaseNameOnly}, "vector"},
+		{"_ZNSaIcEC1ERKS_", nil, "std::allocator<char>"},
+		{"_ZN1C1D1E1fEv", nil, "C::D::E"},
+		{"_ZN1AIiE1fIcEEvT_", nil, "A<int>"},
+		{"_ZZ1fiE1x", nil, "f(int)"},
+		{"_ZTVN2ns1AE", nil, "ns"},
+		{"_Z1fv", nil, ""},
+		{"_Z1fIiEvT_", nil, ""},
+		{"_ZN2ns1fEv.cold", nil, "ns"},
+	}
+
+	for _, test := range tests {
+		options := append([]Option{ScopeOnly}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, options, got, test.want)
+		}
+	}
+}
+
+func TestAnonymousNamespace(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZN12_GLOBAL__N_11fEv", nil, "(anonymous namespace)::f()"},
+		{"_ZN12_GLOBAL__N_11fEv", []Option{ShortAnonymousNamespace}, "{anonymous}::f()"},
+		{"_ZN12_GLOBAL__N_11fEv", []Option{NoAnonymousNamespace}, "f()"},
+		{"_ZN2ns12_GLOBAL__N_11A1fEv", []Option{ShortAnonymousNamespace}, "ns::{anonymous}::A::f()"},
+		{"_ZN2ns12_GLOBAL__N_11A1fEv", []Option{NoAnonymousNamespace}, "ns::A::f()"},
+		{"_ZN12_GLOBAL__N_11A1fEv", []Option{NoAnonymousNamespace}, "A::f()"},
+		{"_Z1fN12_GLOBAL__N_11AE", []Option{ShortAnonymousNamespace, LLVMStyle}, "f({anonymous}::A)"},
+		{"_Z1fN12_GLOBAL__N_11AE", []Option{NoAnonymousNamespace, LLVMStyle}, "f(A)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestNoMethodQualifiers(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZNK1A1fEv", "A::f()"},
+		{"_ZNVK1A1fEv", "A::f()"},
+		{"_ZNR1A1fEv", "A::f()"},
+		{"_ZNKO1A1fEv", "A::f()"},
+		{"_ZNK1AclEv", "A::operator()()"},
+		{"_ZNK1A1fIiEEvv", "void A::f<int>()"},
+		{"_Z1fM1AKFvvE", "f(void (A::*)() const)"},
+		{"_ZZNK1A1fEvE1x", "A::f()::x"},
+		{"_ZN1A1fEv", "A::f()"},
+	}
+
+	for _, test := range tests {
+		for _, opts := range [][]Option{{NoMethodQualifiers}, {NoMethodQualifiers, LLVMStyle}} {
+			if got, err := ToString(test.input, opts...); err != nil {
+				t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			} else if got != test.want {
+				t.Errorf("demangling %s with %v: got %s, want %s", test.input, opts, got, test.want)
+			}
+		}
+	}
+}
+
+func TestExceptionSpecs(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+		llvm  string
+	}{
+		{"_Z1fPDoFvvE", "f(void (*)() noexcept)", "f(void (*)() noexcept)"},
+		{"_Z1fPDOtrEFvvE", "f(void (*)() noexcept(throw))", "f(void (*)() noexcept(throw))"},
+		{"_Z1fPDwiEFvvE", "f(void (*)() throw(int))", "f(void (*)() throw(int))"},
+		{"_Z1fIDoFvvEEvv", "void f<void () noexcept>()", "void f<void () noexcept>()"},
+		{"_Z1fRDoFvvE", "f(void (&)() noexcept)", "f(void (&)() noexcept)"},
+		{"_Z1fM1AKDoFvvE", "f(void (A::*)() noexcept const)", "f(void (A::*)() const noexcept)"},
+		{"_Z1fM1AVKDwiEFvvE", "f(void (A::*)() throw(int) const volatile)", "f(void (A::*)() const volatile throw(int))"},
+		{"_Z1fM1AKDOtrEFvvE", "f(void (A::*)() noexcept(throw) const)", "f(void (A::*)() const noexcept(throw))"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got, err := ToString(test.input, LLVMStyle); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.llvm {
+			t.Errorf("demangling %s with LLVMStyle: got %s, want %s", test.input, got, test.llvm)
+		}
+	}
+}
+
+func TestElideTemplateParams(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZN1CIiE1DIlEEvv", nil, "void C<...>::D<...>()"},
+		{"_ZN1CIiE1DE", nil, "C<...>::D"},
+		{"_ZltIiEbRK1AIT_ES4_", nil, "bool operator< <...>(A<...> const&, A<...> const&)"},
+		{"??$foo@H@@YAXH@Z", nil, "void __cdecl foo<...>(int)"},
+		{"??0?$C@H@@QAE@XZ", nil, "public: __thiscall C<...>::C(void)"},
+		{"foo__t3Bar1Zi", []Option{GNUv2}, "Bar<...>::foo(void)"},
+	}
+	for _, test := range tests {
+		options := append([]Option{ElideTemplateParams}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestMaxTemplateArgLength(t *testing.T) {
+	var tests = []struct {
+		input string
+		max   int
+		want  string
+	}{
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", 3, "std::vector<int, …>::push_back(int const&)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", 30, "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNSt3mapIiSt6vectorIiSaIiEESt4lessIiESaISt4pairIKiS2_EEE4findERS6_", 3, "std::map<int, …, …, …>::find(int const&)"},
+		{"_ZNSt3mapIiSt6vectorIiSaIiEESt4lessIiESaISt4pairIKiS2_EEE4findERS6_", 16, "std::map<int, …, std::less<int>, …>::find(int const&)"},
+		{"_ZN1AIS_IS_IiEEE1fEv", 10, "A<A<A<int> > >::f()"},
+		{"_ZN1AIS_IS_IiEEE1fEv", 9, "A<…>::f()"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, MaxTemplateArgLength(test.max)); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %d: got %q, want %q", test.input, test.max, got, test.want)
+		}
+	}
+}
+
+func TestLLVMStyleParts(t *testing.T) {
+	var tests = []struct {
+		input  string
+		option Option
+		want   string
+	}{
+		{"_ZZ4mainENKUlvE_clEv", LLVMLambdas, "main::'lambda'()::operator()() const"},
+		{"_ZZ4mainENKUlT_E_clIiEEDaS_", LLVMLambdas, "auto main::'lambda'(auto)::operator()<int>(int) const"},
+		{"_ZN4mainUt_E", LLVMLambdas, "main::'unnamed'"},
+		{"_Z1fv.cold", LLVMLambdas, "f() [clone .cold]"},
+		{"_Z1fILDn0EEvv", LLVMLiterals, "void f<nullptr>()"},
+		{"_Z1fDn", LLVMLiterals, "f(std::nullptr_t)"},
+		{"_Z1fv.cold", LLVMClones, "f() (.cold)"},
+		{"_ZZ4mainENKUlvE_clEv", LLVMClones, "main::{lambda()#1}::operator()() const"},
+		{"_ZTW1x", LLVMSpecialNames, "thread-local wrapper routine for x"},
+		{"_ZTH1x", LLVMSpecialNames, "thread-local initialization routine for x"},
+		{"_ZN1AIJXgtLi1ELi2EEEE1fEv", LLVMSpecialNames, "A<((1)>(2))>::f()"},
+		{"_Z1fIiEDTplfp_clL_Z1gvEEET_", LLVMExpressions, "decltype(fp + g()) f<int>(int)"},
+		{"_ZN1AIJXgtLi1ELi2EEEE1fEv", LLVMExpressions, "A<(1 > 2)>::f()"},
+		{"_Z1fDv4_i", LLVMExpressions, "f(int __vector(4))"},
+		{"_Z1fDv4_i", LLVMTypes, "f(int vector[4])"},
+		{"_ZZ1fvEd_NKUlvE_clEv", LLVMTypes, "f()::{lambda()#1}::operator()() const"},
+		{"_Z1fIiEDTplfp_clL_Z1gvEEET_", LLVMTypes, "decltype ({parm#1}+(g())) f<int>(int)"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.option); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.option, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, test.option, got, test.want)
+		}
+	}
+
+	// LLVMStyle is the same as the options for its parts.
+	parts := []Option{LLVMExpressions, LLVMTypes, LLVMLambdas, LLVMLiterals, LLVMClones, LLVMSpecialNames, NoAngleSpace}
+	for _, test := range cases {
+		want, err := ToString(test[0], LLVMStyle)
+		if err != nil {
+			continue
+		}
+		if got, err := ToString(test[0], parts...); err != nil || got != want {
+			t.Errorf("demangling %s with the parts of LLVMStyle: got %q, %v, want %q", test[0], got, err, want)
+		}
+	}
+}
+
+func TestCanonicalSpacing(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZNKR1A1fEv", nil, "A::f() const&"},
+		{"_ZNKR1A1fEv", []Option{LLVMStyle}, "A::f() const&"},
+		{"_ZNO1A1fEv", nil, "A::f()&&"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", nil, "std::vector<int, std::allocator<int>>::push_back(int const&)"},
+		{"_Z1fPFvvEM1AFvvE", nil, "f(void (*)(), void (A::*)())"},
+	}
+	for _, test := range tests {
+		options := append([]Option{CanonicalSpacing}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, options, got, test.want)
+		}
+	}
+}
+
+func TestLiteralOptions(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_Z1fILm42EEvv", []Option{NoLiteralSuffixes}, "void f<42>()"},
+		{"_Z1fILm4096EEvv", []Option{HexLiterals}, "void f<0x1000ul>()"},
+		{"_Z1fILm4096EEvv", []Option{HexLiterals, NoLiteralSuffixes}, "void f<0x1000>()"},
+		{"_Z1fILi5EEvv", []Option{HexLiterals}, "void f<5>()"},
+		{"_Z1fILm42EEvv", []Option{HexLiterals}, "void f<42ul>()"},
+		{"_Z1fILi255EEvv", []Option{HexLiterals}, "void f<255>()"},
+		{"_Z1fILi256EEvv", []Option{HexLiterals}, "void f<0x100>()"},
+		{"_Z1fILin300EEvv", []Option{HexLiterals}, "void f<-0x12c>()"},
+		{"_Z1fILx99999999999999999999999EEvv", []Option{HexLiterals}, "void f<0x152d02c7e14af67fffffll>()"},
+		{"_Z1fIL1E5EEvv", []Option{FunctionalCastLiterals}, "void f<E(5)>()"},
+		{"_Z1fILc65EEvv", []Option{FunctionalCastLiterals, HexLiterals}, "void f<char(65)>()"},
+		{"_Z1fILf3f800000EEvv", []Option{FunctionalCastLiterals, HexLiterals}, "void f<(float)[3f800000]>()"},
+		{"_Z1fILb1EEvv", []Option{FunctionalCastLiterals, NoLiteralSuffixes}, "void f<true>()"},
+		{"_Z1fILb2EEvv", nil, "void f<(bool)2>()"},
+		{"_Z1fILb2EEvv", []Option{BoolLiterals}, "void f<true>()"},
+		{"_Z1fILbn1EEvv", []Option{BoolLiterals}, "void f<true>()"},
+		{"_Z1fILb0EEvv", []Option{BoolLiterals}, "void f<false>()"},
+		{"_Z1fILb00EEvv", []Option{BoolLiterals}, "void f<false>()"},
+		{"_Z1fILb1ELb2EEvv", []Option{BoolLiterals, LLVMStyle}, "void f<true, true>()"},
+		{"_Z1fILN1A1DE131067EEvv", nil, "void f<(A::D)131067>()"},
+		{"_Z1fILN1A1DE131067EEvv", []Option{EnumLiteralValues}, "void f<131067>()"},
+		{"_Z1fILN1A1DEn5EEvv", []Option{EnumLiteralValues, HexLiterals}, "void f<-5>()"},
+		{"_Z1fILN1A1DE131067EEvv", []Option{ShortEnumLiterals}, "void f<D(131067)>()"},
+		{"_Z1fILN1A1DE131067EEvv", []Option{ShortEnumLiterals, FunctionalCastLiterals}, "void f<D(131067)>()"},
+		{"_Z1fILN1A1DE131067EEvv", []Option{ShortEnumLiterals, EnumLiteralValues}, "void f<131067>()"},
+		{"_Z1fILc65EEvv", []Option{ShortEnumLiterals, EnumLiteralValues}, "void f<(char)65>()"},
+		{"_Z1fILc65EEvv", []Option{CharLiterals}, "void f<'A'>()"},
+		{"_Z1fILc10EEvv", []Option{CharLiterals}, `void f<'\n'>()`},
+		{"_Z1fILc39EEvv", []Option{CharLiterals}, `void f<'\''>()`},
+		{"_Z1fILc1EEvv", []Option{CharLiterals}, "void f<(char)1>()"},
+		{"_Z1fILh200EEvv", []Option{CharLiterals}, "void f<(unsigned char)200>()"},
+		{"_Z1fILw955EEvv", []Option{CharLiterals}, "void f<L'λ'>()"},
+		{"_Z1fILDi65EEvv", []Option{CharLiterals}, "void f<U'A'>()"},
+		{"_Z1fIXLA6_KcEEEvv", []Option{CharLiterals}, `void f<"...">()`},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestLocalNameOnly(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZZN1C1D1E1F1G1HEvENKUlvE_clEv", "{lambda()#1}::operator()() const"},
+		{"_ZZN1C1HEvENUlvE_E", "{lambda()#1}"},
+		{"_ZZ1fvEN1A1gEv", "A::g()"},
+		{"_ZZZ1fvEN1A1gEvENKUlvE_clEv", "{lambda()#1}::operator()() const"},
+		{"_ZGVZ1fvE1x", "guard variable for x"},
+		{"_ZZ1fvENKUlvE_clEv.cold", "{lambda()#1}::operator()() const [clone .cold]"},
+		{"_ZN1A1fEv", "A::f()"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, LocalNameOnly); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestStableLambdaIDs(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZZ4mainENKUliE_clEi", nil, "main::{lambda(int)#7f9e359e}::operator()(int) const"},
+		{"_ZZ4mainENKUliE0_clEi", nil, "main::{lambda(int)#65fa6682}::operator()(int) const"},
+		{"_ZZ4mainENKUlvE_clEv", nil, "main::{lambda()#948f6575}::operator()() const"},
+		{"_ZZ4mainENKUlvE0_clEv", nil, "main::{lambda()#c4c774a9}::operator()() const"},
+		{"_ZZ4mainENKUliE0_clEi", []Option{LLVMStyle}, "main::'lambda_65fa6682'(int)::operator()(int) const"},
+		{"_ZZ4mainENKUliE0_clEi", []Option{LLVMUnnamed}, "main::$_1::operator()(int) const"},
+	}
+	for _, test := range tests {
+		options := append([]Option{StableLambdaIDs}, test.options...)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %q, want %q", test.input, options, got, test.want)
+		}
+	}
+
+	// Lambdas in the same scope with the same signature must
+	// still be told apart.
+	seen := make(map[string]string)
+	for _, disc := range []string{"", "0", "1", "2", "10"} {
+		name := "_ZZ4mainENKUlvE" + disc + "_clEv"
+		got, err := ToString(name, StableLambdaIDs)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", name, err)
+			continue
+		}
+		if prev, ok := seen[got]; ok {
+			t.Errorf("demangling %s and %s both give %s", prev, name, got)
+		}
+		seen[got] = name
+	}
+}
+
+func TestElideConstraints(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZN5test21AIiEF1fEzQ4TrueIT_E", "test2::A<int>::friend f(...) requires …"},
+		{"_ZN5test2F1gIvEEvzQaa4TrueIT_E4TrueITL0__E", "void test2::friend g<void>(...) requires …"},
+		{"_ZZN5test71fIiEEvvENKUlTyQaa1CIT_E1CITL0__ET0_E_clIiiEEDaS3_Q1CIDtfp_EE", "auto test7::f<int>()::{lambda<typename $T> requires … (auto:2)#1}::operator()<int, int>(int) const requires …"},
+		{"_ZN1A1fEv", "A::f()"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, ElideConstraints); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestThunkOffsets(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZThn8_N1C1fEv", "non-virtual thunk (this-8) to C::f()"},
+		{"_ZTv0_n24_N1C1fEv", "virtual thunk (this+0, vcall-24) to C::f()"},
+		{"_ZTch16_v0_n32_N1C1gEv", "covariant return thunk (this+16; result+0, vcall-32) to C::g()"},
+		{"_ZTV1C", "vtable for C"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, ThunkOffsets); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if strings.Contains(got, "(this") {
+			t.Errorf("demangling %s without ThunkOffsets: got %q", test.input, got)
+		}
+	}
+}
+
+func TestNameUnnamed(t *testing.T) {
+	namer := DemangleOptions{NameUnnamed: func(scope, a AST) string {
+		var scopeName string
+		if scope != nil {
+			scopeName = ASTToString(scope)
+		}
+		switch a := a.(type) {
+		case *Closure:
+			if scopeName == "f()" {
+				return "f_lambda"
+			}
+		case *UnnamedType:
+			return scopeName + "_anon" + strconv.Itoa(a.Num)
+		}
+		return ""
+	}}
+	namerLLVM := namer
+	namerLLVM.LLVMUnnamed = true
+	var tests = []struct {
+		input string
+		opts  DemangleOptions
+		want  string
+	}{
+		{"_ZZ1fvENKUlvE_clEv", namer, "f()::f_lambda::operator()() const"},
+		{"_ZZ1gvENKUlvE_clEv", namer, "g()::{lambda()#1}::operator()() const"},
+		{"_ZN1AUt_E", namer, "A::A_anon0"},
+		{"_ZN1AUt_E", namerLLVM, "A::A_anon0"},
+		{"_ZN1AUt_E", DemangleOptions{}, "A::{unnamed type#1}"},
+	}
+	for _, test := range tests {
+		if got, err := ToStringOpts(test.input, test.opts); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestTemplateParamNames(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_Z1fIiEvT_", "void f<T>(T)"},
+		{"_Z1fIiEvi", "void f<T>(int)"},
+		{"_Z1fIiEPT_RKS0_", "T* f<T>(T const&)"},
+		{"_Z1fIiEvSt6vectorIT_E", "void f<T>(std::vector<T>)"},
+		{"_Z3fooIiFvdEEvT0_", "void foo<T, T1>(T1)"},
+		{"_Z1fIJilEEvDpT_", "void f<T...>(T...)"},
+		{"_ZN1AIiE1fIlEEvT_", "void A<int>::f<T>(T)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_Z1gIJidEEDTcl1fspplfp_Li1EEEDpT_", "decltype (f(({parm#1}+(1))...)) g<T...>(T...)"},
+		{"_ZW3foo1fv", "f@foo()"},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", "Outer::Inner::Fn@FOO(Outer::Inner::X&)"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, TemplateParamNames); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestOverridePrint(t *testing.T) {
+	dotted := func(a AST, p *HookPrinter) bool {
+		switch a := a.(type) {
+		case *Qualified:
+			if a.LocalName {
+				return false
+			}
+			p.Print(a.Scope)
+			p.WriteString(".")
+			p.Print(a.Name)
+			return true
+		case *BuiltinType:
+			if a.Name == "int" {
+				p.WriteString("i32")
+				return true
+			}
+		}
+		return false
+	}
+	params := func(a AST, p *HookPrinter) bool {
+		if tp, ok := a.(*TemplateParam); ok {
+			p.WriteString("$" + strconv.Itoa(tp.Index))
+			return true
+		}
+		return false
+	}
+	brackets := func(a AST, p *HookPrinter) bool {
+		if _, ok := a.(*Template); ok {
+			p.WriteString("[")
+			p.PrintDefault(a)
+			p.WriteString("]")
+			return true
+		}
+		return false
+	}
+	var tests = []struct {
+		input string
+		opts  DemangleOptions
+		want  string
+	}{
+		{"_ZN2ns1A1fEv", DemangleOptions{PrintHook: dotted}, "ns.A.f()"},
+		{"_ZN2ns1A1fEi", DemangleOptions{PrintHook: dotted}, "ns.A.f(i32)"},
+		{"_ZN2ns1AIiE1fEv", DemangleOptions{PrintHook: dotted}, "ns.A<i32>.f()"},
+		{"_ZN2ns1A1fEv", DemangleOptions{}, "ns::A::f()"},
+		{"_Z1fIiEvT_", DemangleOptions{PrintHook: params, TemplateParamNames: true}, "void f<T>($0)"},
+		{"_Z1fIiEvT_", DemangleOptions{PrintHook: params}, "void f<int>(int)"},
+		{"_ZN1AIiE1fEv", DemangleOptions{PrintHook: brackets}, "[A<int>]::f()"},
+		{"_ZN1AIS_IiEE1fEv", DemangleOptions{PrintHook: brackets}, "[A<[A<int>]>]::f()"},
+	}
+	for _, test := range tests {
+		if got, err := ToStringOpts(test.input, test.opts); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	var tests = []struct {
+		input string
+		code  ErrorCode
+		off   int
+	}{
+		{"_Z1", ErrSyntax, 3},
+		{"_Z1fv$", ErrSyntax, 5},
+		{"_Z1fvE", ErrUnparsedSuffix, 5},
+		{"_Z1fS0_", ErrBadSubstitution, 5},
+		{"_Z1fSy", ErrBadSubstitution, 5},
+		{"_Z1fT_", ErrBadTemplateParam, 4},
+		{"_Z1fIiEvT0_", ErrBadTemplateParam, 8},
+		{"___Z1fv_block_invoke_2x", ErrUnparsedSuffix, 22},
+		{"_RNvB9_4main", ErrBadSubstitution, 5},
+	}
+	for _, test := range tests {
+		_, err := ToString(test.input)
+		de, ok := err.(Error)
+		if !ok {
+			t.Errorf("%s: got error %v (%T), want Error", test.input, err, err)
+			continue
+		}
+		if de.Code != test.code || de.Offset != test.off {
+			t.Errorf("%s: got %v at %d (%v), want %v at %d", test.input, de.Code, de.Offset, de, test.code, test.off)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	// subs returns a C++ name whose parameters are each a pointer
+	// to the previous parameter, written as a substitution.
+	subs := func(n int) string {
+		var b strings.Builder
+		b.WriteString("_Z1fPi")
+		for i := 0; i < n; i++ {
+			b.WriteString("PS")
+			if i > 0 {
+				b.WriteString(strings.ToUpper(strconv.FormatInt(int64(i-1), 36)))
+			}
+			b.WriteString("_")
+		}
+		return b.String()
+	}
+
+	var tests = []struct {
+		input   string
+		options []Option
+		ok      bool
+	}{
+		{"_Z1f" + strings.Repeat("P", 100) + "i", nil, true},
+		{"_Z1f" + strings.Repeat("P", 2000) + "i", nil, false},
+		{"_Z1f" + strings.Repeat("P", 2000) + "i", []Option{MaxDepth(4000)}, true},
+		{"_Z1fPPi", []Option{MaxDepth(4)}, true},
+		{"_Z1fPPPPi", []Option{MaxDepth(4)}, false},
+		{"_ZN" + strings.Repeat("1a", 2000) + "E", nil, false},
+		{"_Z1fv" + strings.Repeat(".a", 2000), nil, false},
+		{subs(100), nil, true},
+		{subs(2000), nil, false},
+		{"_R" + strings.Repeat("Nv", 100) + "C1a" + strings.Repeat("1b", 100), nil, true},
+		{"_R" + strings.Repeat("Nv", 2000) + "C1a" + strings.Repeat("1b", 2000), nil, false},
+		{"?x@@3" + strings.Repeat("PA", 100) + "HA", nil, true},
+		{"?x@@3" + strings.Repeat("PA", 2000) + "HA", nil, false},
+		{"_D1a1fFP" + strings.Repeat("P", 100) + "iZv", nil, true},
+		{"_D1a1fFP" + strings.Repeat("P", 5000000) + "iZv", nil, false},
+		{"_D1a1fFPiZv", []Option{MaxDepth(6)}, true},
+		{"_D1a1fFPPPPPPiZv", []Option{MaxDepth(6)}, false},
+		{"@f$q" + strings.Repeat("p", 100) + "i", nil, true},
+		{"@f$q" + strings.Repeat("p", 200000) + "i", nil, false},
+		{"@f$q" + strings.Repeat("pq", 2000) + "i" + strings.Repeat("$", 2000), nil, false},
+		{"W?x$n" + strings.Repeat("pn", 100) + "i", nil, true},
+		{"W?x$n" + strings.Repeat("pn", 200000) + "i", nil, false},
+		{"$s" + strings.Repeat("Say", 100) + "Si" + strings.Repeat("G", 100) + "D", nil, true},
+		{"$s" + strings.Repeat("Say", 100000) + "Si" + strings.Repeat("G", 100000) + "D", nil, false},
+		{"$s" + strings.Repeat("Say", 100) + "Si" + strings.Repeat("G", 100) + "D", []Option{MaxDepth(50)}, false},
+	}
+	for _, test := range tests {
+		_, err := ToString(test.input, test.options...)
+		if test.ok {
+			if err != nil {
+				t.Errorf("%.40s...: unexpected error %v", test.input, err)
+			}
+			continue
+		}
+		de, ok := err.(Error)
+		if !ok || de.Code != ErrTooLarge {
+			t.Errorf("%.40s...: got error %v, want ErrTooLarge", test.input, err)
+		}
+		if !strings.HasPrefix(test.input, "_Z") && !strings.HasPrefix(test.input, "_R") {
+			continue
+		}
+		if _, err := ToAST(test.input, test.options...); err == nil {
+			t.Errorf("%.40s...: ToAST succeeded, want error", test.input)
+		}
+	}
+
+	var a AST = NewBuiltinType("int")
+	for i := 0; i < 10; i++ {
+		a = NewPointer(a)
+	}
+	if got := ASTToString(a, MaxDepth(2)); !strings.Contains(got, "...") || strings.Contains(got, "int") {
+		t.Errorf("ASTToString with MaxDepth(2) = %q, want it to stop with ...", got)
+	}
+	if got, want := ASTToString(a), "int"+strings.Repeat("*", 10); got != want {
+		t.Errorf("ASTToString = %q, want %q", got, want)
+	}
+}
+
+func TestInternedNames(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{
+			"_Z1fSbIcSt11char_traitsIcESaIcEES_S0_S1_S2_",
+			"f(std::basic_string<char, std::char_traits<char>, std::allocator<char> >, std::char_traits, std::char_traits<char>, std::allocator<char>, std::basic_string<char, std::char_traits<char>, std::allocator<char> >)",
+		},
+		{
+			"_Z1fSbIwSt11char_traitsIwESaIwEES0_S1_S2_",
+			"f(std::basic_string<wchar_t, std::char_traits<wchar_t>, std::allocator<wchar_t> >, std::char_traits<wchar_t>, std::allocator<wchar_t>, std::basic_string<wchar_t, std::char_traits<wchar_t>, std::allocator<wchar_t> >)",
+		},
+		{
+			"_Z1fSaIcESt11char_traitsIcES_S0_S1_",
+			"f(std::allocator<char>, std::char_traits<char>, std::allocator<char>, std::char_traits, std::char_traits<char>)",
+		},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("%s: %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	// The AST of an interned name is shared within a name, but
+	// not between names, so that changing an AST returned by
+	// ToAST does not change others.
+	params := func(name string) []AST {
+		a, err := ToAST(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return a.(*Typed).Type.(*FunctionType).Args
+	}
+	p1, p2 := params("_Z1fSaIcESaIcE"), params("_Z1gSaIcE")
+	if p1[0] != p1[1] {
+		t.Errorf("std::allocator<char> not shared within a name: %p != %p", p1[0], p1[1])
+	}
+	if p1[0] == p2[0] {
+		t.Errorf("std::allocator<char> shared between names: %p", p1[0])
+	}
+	p1[0].(*Template).Args[0] = &BuiltinType{Name: "int"}
+	if got := ASTToString(p2[0]); got != "std::allocator<char>" {
+		t.Errorf("after changing another AST, got %q, want %q", got, "std::allocator<char>")
+	}
+}
+
+func TestSharedSubstitutions(t *testing.T) {
+	// A substitution without template parameters is shared.
+	a, err := ToAST("_Z1fN1a1bES0_S0_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := a.(*Typed).Type.(*FunctionType).Args
+	if len(args) != 3 || args[1] != args[2] {
+		t.Errorf("substitutions of a::b do not share nodes: %#v", args)
+	}
+
+	// A substitution with template parameters is copied, so
+	// that it refers to the current template.
+	const name = "_ZN1AIiE1fIcEEvT_S2_"
+	if got, err := ToString(name); err != nil || got != "void A<int>::f<char>(char, char)" {
+		t.Errorf("ToString(%q) = %q, %v", name, got, err)
+	}
+}
+
+func TestMaxExpansion(t *testing.T) {
+	// cpp returns a C++ name whose parameter types each refer
+	// twice to the one before, doubling the size of the
+	// demangled name each time.
+	cpp := func(n int) string {
+		seq := func(i int) string {
+			if i == 0 {
+				return "S_"
+			}
+			return "S" + strings.ToUpper(strconv.FormatInt(int64(i-1), 36)) + "_"
+		}
+		var b strings.Builder
+		b.WriteString("_Z1f1A")
+		for k := 1; k <= n; k++ {
+			prev := seq(2 * (k - 1))
+			b.WriteString("PFv" + prev + prev + "E")
+		}
+		return b.String()
+	}
+
+	// rust is the same for a Rust name, using tuple types.
+	rust := func(n int) string {
+		const digits = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+		backref := func(off int) string {
+			if off == 0 {
+				return "B_"
+			}
+			num := ""
+			for v := off - 1; ; v /= 62 {
+				num = string(digits[v%62]) + num
+				if v < 62 {
+					break
+				}
+			}
+			return "B" + num + "_"
+		}
+		s := "INvC1a1f"
+		prev := len(s)
+		s += "l"
+		for k := 1; k <= n; k++ {
+			off := len(s)
+			s += "T" + backref(prev) + backref(prev) + "E"
+			prev = off
+		}
+		return "_R" + s + "E"
+	}
+
+	var tests = []struct {
+		input   string
+		options []Option
+		ok      bool
+	}{
+		{cpp(5), nil, true},
+		{cpp(5), []Option{MaxExpansion(10)}, false},
+		{cpp(40), nil, false},
+		{cpp(18), nil, false},
+		{cpp(18), []Option{MaxExpansion(1 << 22)}, true},
+		{rust(5), nil, true},
+		{rust(5), []Option{MaxExpansion(10)}, false},
+		{rust(40), nil, false},
+	}
+	for _, test := range tests {
+		for _, fn := range []func(string, ...Option) error{
+			func(name string, options ...Option) error {
+				_, err := ToString(name, options...)
+				return err
+			},
+			func(name string, options ...Option) error {
+				_, err := ToAST(name, options...)
+				return err
+			},
+		} {
+			err := fn(test.input, test.options...)
+			if test.ok {
+				if err != nil {
+					t.Errorf("%.40s...: unexpected error %v", test.input, err)
+				}
+				continue
+			}
+			if de, ok := err.(Error); !ok || de.Code != ErrExpansionLimit {
+				t.Errorf("%.40s...: got error %v, want ErrExpansionLimit", test.input, err)
+			}
+		}
+	}
+}
+
+func TestLookupTables(t *testing.T) {
+	for code, op := range operators {
+		if got, ok := lookupOperator(code); !ok || got != op {
+			t.Errorf("lookupOperator(%q) = %v, %t, want %v", code, got, ok, op)
+		}
+	}
+	for _, code := range []string{"aa", "zz", "aZ", "A1", "_a", "a_", "cv", "v1"} {
+		_, want := operators[code]
+		if _, ok := lookupOperator(code); ok != want {
+			t.Errorf("lookupOperator(%q) found %t, want %t", code, ok, want)
+		}
+	}
+	for c := 0; c < 256; c++ {
+		if got, want := builtinTypeTable[c], builtinTypes[byte(c)]; got != want {
+			t.Errorf("builtinTypeTable[%q] = %q, want %q", c, got, want)
+		}
+	}
+}
+
+func TestSkipExpressions(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_Z1fIiEDTcl1gIT_EEEv", nil, "decltype (…) f<int>()"},
+		{"_ZN1AIXadL_Z1fvEEE1gEv", nil, "A<…>::g()"},
+		{"_Z1fILi1EEvv", nil, "void f<1>()"},
+		{"_Z1fIiEvPAszT__i", nil, "void f<int>(int (*) […])"},
+		{"_Z1fIiEDTcl1gIT_EEEv", []Option{NoParams, NoTemplateParams}, "f"},
+		{"_ZZN5test21gIPFfvEEEvT_DTclfL0p_EEE8variable", []Option{NoParams, NoTemplateParams}, "test2::g(float (*)(), decltype (…))::variable"},
+	}
+	for _, test := range tests {
+		options := append(test.options, SkipExpressions)
+		if got, err := ToString(test.input, options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, options, got, test.want)
+		}
+	}
+
+	// Skipping expressions must consume the same characters as
+	// parsing them, and only change how they are printed.
+	for _, c := range cases {
+		want, err := ToString(c[0], NoParams, NoTemplateParams)
+		if err != nil {
+			continue
+		}
+		got, err := ToString(c[0], NoParams, NoTemplateParams, SkipExpressions)
+		if err != nil {
+			t.Errorf("demangling %s with SkipExpressions: unexpected error %v", c[0], err)
+		} else if got != want && !strings.Contains(got, "…") {
+			t.Errorf("demangling %s with SkipExpressions: got %s, want %s", c[0], got, want)
+		}
+	}
+
+	// Changing a skipped expression in one AST must not change
+	// it in another.
+	a1, err := ToAST("_Z1fIiEDTcl1gIT_EEEv", SkipExpressions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := ToAST("_Z1fIiEDTcl1gIT_EEEv", SkipExpressions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1.Traverse(func(a AST) bool {
+		if n, ok := a.(*Name); ok && n.Name == "…" {
+			n.Name = "expr"
+		}
+		return true
+	})
+	if got, want := ASTToString(a2), "decltype (…) f<int>()"; got != want {
+		t.Errorf("after changing another AST, got %s, want %s", got, want)
+	}
+
+	const name = "_ZTAXtl1StlA32_cLc104ELc101ELc108ELc108ELc111ELc32ELc119ELc111ELc114ELc108ELc100EEEE"
+	var d Demangler
+	got := testing.AllocsPerRun(100, func() { d.ToString(name, NoParams, NoTemplateParams, SkipExpressions) })
+	want := testing.AllocsPerRun(100, func() { d.ToString(name, NoParams, NoTemplateParams) })
+	if got >= want {
+		t.Errorf("demangling with SkipExpressions allocated %v times, without %v times; want fewer", got, want)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"i", "int"},
+		{"PFvvE", "void (*)()"},
+		{"St6vectorIiSaIiEE", "std::vector<int, std::allocator<int> >"},
+		{"PKc", "char const*"},
+		{"M1AFivE", "int (A::*)()"},
+		{"N1a1bIJicEEE", "a::b<int, char>"},
+		{"St9bad_alloc", "std::bad_alloc"},
+	}
+	for _, test := range tests {
+		if got, err := ToString(test.input, Types); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if _, err := ToString(test.input); err != ErrNotMangledName {
+			t.Errorf("demangling %s without Types: got error %v, want %v", test.input, err, ErrNotMangledName)
+		}
+		if got := Filter(test.input, Types); got != test.want {
+			t.Errorf("Filter(%s, Types) = %s, want %s", test.input, got, test.want)
+		}
+	}
+
+	// Symbol names are not affected, and words that are not
+	// types are still rejected.
+	if got, err := ToString("_Z1fv", Types); err != nil || got != "f()" {
+		t.Errorf("demangling _Z1fv with Types: got %q, %v, want %q", got, err, "f()")
+	}
+	for _, s := range []string{"", "foo", "main", "PFvv"} {
+		if _, err := ToString(s, Types); err == nil {
+			t.Errorf("demangling %q with Types: unexpected success", s)
+		}
+		if got := Filter(s, Types); got != s {
+			t.Errorf("Filter(%q, Types) = %q, want unchanged", s, got)
+		}
+	}
+}
+
+func TestRustHash(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE", nil, "core::fmt::Arguments"},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE", []Option{RustHash}, "core::fmt::Arguments::h1234567890abcdef"},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE.llvm.1234", []Option{RustHash}, "core::fmt::Arguments::h1234567890abcdef"},
+		{"_ZN71_$LT$Test$u20$$u2b$$u20$$u27$static$u20$as$u20$foo..Bar$LT$Test$GT$$GT$3bar17h930b740aa94f1d3aE", nil, "<Test + 'static as foo::Bar<Test>>::bar"},
+		{"_ZN71_$LT$Test$u20$$u2b$$u20$$u27$static$u20$as$u20$foo..Bar$LT$Test$GT$$GT$3bar17h930b740aa94f1d3aE", []Option{RustHash}, "<Test + 'static as foo::Bar<Test>>::bar::h930b740aa94f1d3a"},
+		{"_ZN3foo3barE", []Option{RustHash}, "foo::bar"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
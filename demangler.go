@@ -0,0 +1,56 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// A Demangler demangles symbol names, reusing the memory that it
+// allocates for one name when demangling the next. This is for
+// programs that demangle a great many names. A Demangler is not safe
+// for concurrent use; use a Demangler for each goroutine.
+// The zero value is ready to use.
+type Demangler struct {
+	st state
+	ps printState
+}
+
+// ToString is like the ToString function, but reuses memory held by d.
+func (d *Demangler) ToString(name string, options ...Option) (string, error) {
+	if !strings.HasPrefix(name, "_Z") || strings.Contains(name, "$") || strings.Contains(name, cudaStubPrefix) {
+		return ToString(name, options...)
+	}
+	if _, ok := oldRustName(name); ok {
+		return ToString(name, options...)
+	}
+	a, err := d.st.demangle(name[2:], options)
+	if err != nil {
+		// Let ToString try other demanglers and
+		// adjust the error.
+		return ToString(name, options...)
+	}
+	d.ps.sizeHint = printSizeEstimate(name)
+	s, _ := d.ps.astToString(a, false, false, options, nil)
+	return s, nil
+}
+
+// Filter is like the Filter function, but reuses memory held by d.
+func (d *Demangler) Filter(name string, options ...Option) string {
+	if !mayDemangle(name, options) {
+		return name
+	}
+	ret, err := d.ToString(name, options...)
+	if err != nil {
+		return name
+	}
+	return ret
+}
+
+// Reset discards the memory held by d, such as after demangling an
+// unusually long name. It is not necessary to call Reset between
+// calls to ToString.
+func (d *Demangler) Reset() {
+	d.st = state{}
+	d.ps = printState{}
+}
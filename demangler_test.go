@@ -0,0 +1,63 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDemanglerReuse checks that reusing a Demangler gives the same
+// results as ToString for the names in the test data.
+func TestDemanglerReuse(t *testing.T) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var d Demangler
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "--") {
+			continue
+		}
+		for _, options := range [][]Option{nil, {NoParams}, {LLVMStyle, MaxLength(5)}} {
+			want, wantErr := ToString(line, options...)
+			got, gotErr := d.ToString(line, options...)
+			if got != want || (gotErr == nil) != (wantErr == nil) {
+				t.Errorf("%s %v: got %q, %v; want %q, %v", line, options, got, gotErr, want, wantErr)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Reset()
+	if got, want := d.Filter("_ZN1A1fEv"), "A::f()"; got != want {
+		t.Errorf("after Reset got %q, want %q", got, want)
+	}
+	if got, want := d.Filter("_Z"), "_Z"; got != want {
+		t.Errorf("Filter(_Z) = %q, want %q", got, want)
+	}
+}
+
+func TestDemanglerAllocs(t *testing.T) {
+	const name = "_ZNSt6vectorIN4absl11string_viewESaIS1_EE17_M_realloc_insertIJRKS1_EEEvN9__gnu_cxx17__normal_iteratorIPS1_S3_EEDpOT_"
+	var d Demangler
+	d.ToString(name)
+	got := testing.AllocsPerRun(100, func() { d.ToString(name) })
+	want := testing.AllocsPerRun(100, func() {
+		var fresh Demangler
+		fresh.ToString(name)
+	})
+	if got >= want {
+		t.Errorf("Demangler.ToString allocated %v times, a new Demangler %v times; want fewer", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A Scheme is a name mangling scheme, as reported by Detect.
+type Scheme int
+
+const (
+	// SchemeUnknown is used for names that do not appear to be
+	// mangled.
+	SchemeUnknown Scheme = iota
+
+	// SchemeItanium is the C++ ABI used by GCC and LLVM,
+	// with names that start with "_Z".
+	SchemeItanium
+
+	// SchemeRustV0 is the Rust v0 mangling, with names that
+	// start with "_R".
+	SchemeRustV0
+
+	// SchemeRustLegacy is the old Rust mangling, which uses
+	// C++ style names that end with a hash.
+	SchemeRustLegacy
+
+	// SchemeMSVC is the Microsoft Visual C++ mangling, with names
+	// that start with "?".
+	SchemeMSVC
+
+	// SchemeSwift is the Swift mangling, with names that start
+	// with "$s" or, for older versions, "$S" or "_T0".
+	SchemeSwift
+
+	// SchemeD is the D mangling, with names that start with "_D".
+	SchemeD
+
+	// SchemeBorland is the Borland C++ mangling, with names that
+	// start with "@".
+	SchemeBorland
+
+	// SchemeWatcom is the Watcom C++ mangling, with names that
+	// start with "W?".
+	SchemeWatcom
+
+	// SchemeFortran is the gfortran naming of module procedures,
+	// as in "__m_MOD_p".
+	SchemeFortran
+
+	// SchemeObjC is an Objective-C method name, which is not
+	// mangled, as in "-[Class selector:]".
+	SchemeObjC
+)
+
+// schemeNames is used by Scheme.String.
+var schemeNames = [...]string{
+	SchemeUnknown:    "unknown",
+	SchemeItanium:    "Itanium C++",
+	SchemeRustV0:     "Rust v0",
+	SchemeRustLegacy: "Rust legacy",
+	SchemeMSVC:       "Microsoft Visual C++",
+	SchemeSwift:      "Swift",
+	SchemeD:          "D",
+	SchemeBorland:    "Borland C++",
+	SchemeWatcom:     "Watcom C++",
+	SchemeFortran:    "Fortran",
+	SchemeObjC:       "Objective-C",
+}
+
+// String returns a human-readable name for the scheme.
+func (s Scheme) String() string {
+	if s >= 0 && int(s) < len(schemeNames) {
+		return schemeNames[s]
+	}
+	return "Scheme(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Detect returns the mangling scheme that ToString would use for name.
+// It only looks at the form of the name, and does not demangle it,
+// so a name may be detected as using a scheme even though it is not
+// valid in that scheme. Names mangled by GCC 2.x, which ToString only
+// recognizes with the GNUv2 option, are reported as SchemeUnknown, as
+// are external Fortran procedure names and Go symbol names.
+func Detect(name string) Scheme {
+	switch {
+	case strings.HasPrefix(name, "-[") || strings.HasPrefix(name, "+["):
+		if _, ok := parseObjCMethod(name); ok {
+			return SchemeObjC
+		}
+		return SchemeUnknown
+	case strings.HasPrefix(name, "_R"):
+		return SchemeRustV0
+	case strings.HasPrefix(name, "?"):
+		return SchemeMSVC
+	case strings.HasPrefix(name, "@"):
+		if isBorlandName(name) {
+			return SchemeBorland
+		}
+		return SchemeUnknown
+	case strings.HasPrefix(name, "W?"):
+		return SchemeWatcom
+	case swiftPrefix(name) > 0:
+		return SchemeSwift
+	case strings.HasPrefix(name, "_D"):
+		return SchemeD
+	}
+	if _, ok := oldRustName(name); ok {
+		return SchemeRustLegacy
+	}
+	if strings.HasPrefix(name, "_Z") || strings.HasPrefix(name, "___Z") || strings.HasPrefix(name, "_GLOBAL_") || strings.HasPrefix(name, cudaStubPrefix+"_Z") || strings.HasPrefix(name, "$_Z") {
+		return SchemeItanium
+	}
+	if _, _, ok := fortranModuleProc(name); ok {
+		return SchemeFortran
+	}
+	return SchemeUnknown
+}
+
+// isBorlandName reports whether a name that starts with '@' looks
+// like a Borland name, rather than a Microsoft __fastcall C name
+// such as "@f@8".
+func isBorlandName(name string) bool {
+	if len(name) < 2 || isDigit(name[1]) {
+		return false
+	}
+	i := strings.LastIndexByte(name, '@')
+	if i <= 0 || i == len(name)-1 || strings.ContainsAny(name[1:i], "@$%") {
+		return true
+	}
+	for j := i + 1; j < len(name); j++ {
+		if !isDigit(name[j]) {
+			return true
+		}
+	}
+	return false
+}
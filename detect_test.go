@@ -0,0 +1,64 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Scheme
+	}{
+		{"_Z3fooi", SchemeItanium},
+		{"_ZN3foo3barEv", SchemeItanium},
+		{"___Z1fv_block_invoke", SchemeItanium},
+		{"_GLOBAL__sub_I_foo.cc", SchemeItanium},
+		{"__device_stub__Z6kernelPf", SchemeItanium},
+		{"$_Z6kernelPf$1", SchemeItanium},
+		{"_RNvCs1234_4main4main", SchemeRustV0},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE", SchemeRustLegacy},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE.llvm.42", SchemeRustLegacy},
+		{"?foo@@YAXH@Z", SchemeMSVC},
+		{"$s4main3fooyyF", SchemeSwift},
+		{"_T04main3fooyyF", SchemeSwift},
+		{"_D4test3fooFiZv", SchemeD},
+		{"@Foo@bar$qv", SchemeBorland},
+		{"@Foo@count", SchemeBorland},
+		{"@f@8", SchemeUnknown},
+		{"W?foo$n(i)v", SchemeWatcom},
+		{"__solvers_MOD_jacobi", SchemeFortran},
+		{"-[Foo bar:]", SchemeObjC},
+		{"+[Foo(Cat) bar]", SchemeObjC},
+		{"-[Foo bar", SchemeUnknown},
+		{"main", SchemeUnknown},
+		{"foo__1Ai", SchemeUnknown},
+		{"", SchemeUnknown},
+	}
+
+	for _, test := range tests {
+		if got := Detect(test.input); got != test.want {
+			t.Errorf("Detect(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestSchemeString(t *testing.T) {
+	tests := []struct {
+		scheme Scheme
+		want   string
+	}{
+		{SchemeUnknown, "unknown"},
+		{SchemeItanium, "Itanium C++"},
+		{SchemeRustLegacy, "Rust legacy"},
+		{SchemeFortran, "Fortran"},
+		{Scheme(100), "Scheme(100)"},
+	}
+
+	for _, test := range tests {
+		if got := test.scheme.String(); got != test.want {
+			t.Errorf("Scheme(%d).String() = %q, want %q", int(test.scheme), got, test.want)
+		}
+	}
+}
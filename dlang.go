@@ -0,0 +1,1114 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file demangles D symbols, as described at
+// https://dlang.org/spec/abi.html#name_mangling.
+// The output follows the GNU libiberty D demangler.
+
+// dlangToString demangles a D symbol.
+func dlangToString(name string, options []Option) (ret string, err error) {
+	if !strings.HasPrefix(name, "_D") {
+		return "", ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	ds := &dlangState{orig: name, lastBackref: len(name), maxDepth: defaultMaxDepth}
+	max := 0
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			ds.noParams = true
+		case o == NoTemplateParams:
+			ds.noTemplateParams = true
+		case isMaxLength(o):
+			max = maxLength(o)
+		case isMaxDepth(o):
+			ds.maxDepth = optionValue(o)
+		}
+	}
+
+	if name == "_Dmain" {
+		ds.writeString("D main")
+	} else {
+		ds.mangle()
+		if ds.off < len(name) {
+			panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: ds.off})
+		}
+	}
+
+	s := string(ds.buf)
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// dlangMaxOutput is the maximum length of a demangled D symbol.
+// Back references can make the output exponentially larger than the
+// input, so we give up on absurdly long results.
+const dlangMaxOutput = 1 << 20
+
+// A dlangState holds the current state of demangling a D string.
+// Back references are offsets from the current position, so we keep
+// the whole string and an offset into it.
+type dlangState struct {
+	orig        string // the original string
+	off         int    // offset of the next character to parse
+	buf         []byte // the demangled output
+	lastBackref int    // offset of the innermost type back reference
+	depth       int    // current nesting depth
+	maxDepth    int    // maximum nesting depth
+
+	noParams         bool // don't print function parameters
+	noTemplateParams bool // don't print template arguments
+}
+
+// fail panics with Error, to be caught in dlangToString.
+func (ds *dlangState) fail(err string) {
+	panic(Error{Msg: err, Offset: ds.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (ds *dlangState) enter() {
+	ds.depth++
+	if ds.depth > ds.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: ds.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (ds *dlangState) leave() {
+	ds.depth--
+}
+
+// peekAt returns the character at offset i, or 0 past the end.
+func (ds *dlangState) peekAt(i int) byte {
+	if i < 0 || i >= len(ds.orig) {
+		return 0
+	}
+	return ds.orig[i]
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (ds *dlangState) peek() byte {
+	return ds.peekAt(ds.off)
+}
+
+// next returns the next character and advances past it.
+func (ds *dlangState) next() byte {
+	if ds.off >= len(ds.orig) {
+		ds.fail("unexpected end of mangled name")
+	}
+	c := ds.orig[ds.off]
+	ds.off++
+	return c
+}
+
+// hasPrefixAt reports whether the string at offset i starts with s.
+func (ds *dlangState) hasPrefixAt(i int, s string) bool {
+	return i <= len(ds.orig) && strings.HasPrefix(ds.orig[i:], s)
+}
+
+// writeString adds a string to the output.
+func (ds *dlangState) writeString(s string) {
+	ds.buf = append(ds.buf, s...)
+	if len(ds.buf) > dlangMaxOutput {
+		panic(Error{Code: ErrTooLarge, Msg: "demangled output too large", Offset: ds.off})
+	}
+}
+
+// capture runs f and returns the output that it wrote,
+// removing it from the output.
+func (ds *dlangState) capture(f func()) string {
+	n := len(ds.buf)
+	f()
+	s := string(ds.buf[n:])
+	ds.buf = ds.buf[:n]
+	return s
+}
+
+// try runs f. If f fails, try restores the state and returns false.
+func (ds *dlangState) try(f func()) (ok bool) {
+	off, n, backref := ds.off, len(ds.buf), ds.lastBackref
+	defer func() {
+		if r := recover(); r != nil {
+			// Don't backtrack over a name that is too large;
+			// the alternatives won't be any smaller.
+			if de, isErr := r.(Error); !isErr || de.Code == ErrTooLarge {
+				panic(r)
+			}
+			ds.off, ds.buf, ds.lastBackref = off, ds.buf[:n], backref
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// number parses a decimal number, which may not end the string.
+func (ds *dlangState) number() int {
+	if !isDigit(ds.peek()) {
+		ds.fail("expected number")
+	}
+	val := 0
+	for isDigit(ds.peek()) {
+		if val > (len(ds.orig)*10+9)/10 {
+			ds.fail("numeric overflow")
+		}
+		val = val*10 + int(ds.next()-'0')
+	}
+	if ds.off >= len(ds.orig) {
+		ds.fail("unexpected end of mangled name")
+	}
+	return val
+}
+
+// decodeBackref decodes the number of a back reference at offset i,
+// which is written in base 26 with upper case letters, ending with a
+// lower case letter. It returns the number and the offset following
+// it, or -1 and -1 if there is no valid number.
+func (ds *dlangState) decodeBackref(i int) (int, int) {
+	val := 0
+	for isUpper(ds.peekAt(i)) || isLower(ds.peekAt(i)) {
+		if val > len(ds.orig) {
+			break
+		}
+		val *= 26
+		c := ds.peekAt(i)
+		if isLower(c) {
+			val += int(c - 'a')
+			if val <= 0 {
+				break
+			}
+			return val, i + 1
+		}
+		val += int(c - 'A')
+		i++
+	}
+	return -1, -1
+}
+
+// backref parses a back reference, starting with 'Q', and returns
+// the offset to which it refers.
+func (ds *dlangState) backref() int {
+	qpos := ds.off
+	if ds.next() != 'Q' {
+		ds.fail("expected back reference")
+	}
+	ref, end := ds.decodeBackref(ds.off)
+	if end < 0 || ref > qpos {
+		panic(Error{Code: ErrBadSubstitution, Msg: "invalid back reference", Offset: ds.off})
+	}
+	ds.off = end
+	return qpos - ref
+}
+
+// symbolNameP reports whether a symbol name starts at offset i.
+func (ds *dlangState) symbolNameP(i int) bool {
+	c := ds.peekAt(i)
+	if isDigit(c) {
+		return true
+	}
+	if ds.hasPrefixAt(i, "__T") || ds.hasPrefixAt(i, "__U") {
+		return true
+	}
+	if c != 'Q' {
+		return false
+	}
+	ref, end := ds.decodeBackref(i + 1)
+	if end < 0 || ref > i {
+		return false
+	}
+	return isDigit(ds.peekAt(i - ref))
+}
+
+// callConventionP reports whether a calling convention starts at
+// offset i.
+func (ds *dlangState) callConventionP(i int) bool {
+	switch ds.peekAt(i) {
+	case 'F', 'U', 'V', 'W', 'R', 'Y':
+		return true
+	}
+	return false
+}
+
+// mangle parses a mangled name:
+//
+//	_D QualifiedName Type
+//	_D QualifiedName Z
+//
+// The type of a function is its return type, which is not printed.
+func (ds *dlangState) mangle() {
+	ds.off += 2
+	ds.qualified(true)
+	if ds.peek() == 'Z' {
+		// Artificial symbols end with 'Z' and have no type.
+		ds.off++
+	} else {
+		ds.capture(ds.parseType)
+	}
+}
+
+// qualified parses a qualified name: a sequence of symbol names,
+// each of which may be followed by the arguments of a function.
+// If suffixModifiers is true, the type modifiers of a member
+// function are printed after its arguments.
+func (ds *dlangState) qualified(suffixModifiers bool) {
+	ds.enter()
+	defer ds.leave()
+
+	n := 0
+	for {
+		// Skip over anonymous symbols.
+		if ds.peek() == '0' {
+			for ds.peek() == '0' {
+				ds.off++
+			}
+		} else {
+			if n > 0 {
+				ds.writeString(".")
+			}
+			n++
+			ds.identifier()
+
+			if ds.peek() == 'M' || ds.callConventionP(ds.off) {
+				ds.try(func() {
+					ds.nestedFunctionArgs(suffixModifiers)
+				})
+			}
+		}
+
+		if !ds.symbolNameP(ds.off) {
+			break
+		}
+	}
+}
+
+// nestedFunctionArgs parses the arguments of a function that is part
+// of a qualified name:
+//
+//	M? TypeModifiers? CallConvention FuncAttrs Arguments ArgClose
+//
+// If this doesn't end before the end of the string, it is really the
+// type of the symbol, and we fail so that the caller backtracks.
+func (ds *dlangState) nestedFunctionArgs(suffixModifiers bool) {
+	mods := ""
+	if ds.peek() == 'M' {
+		ds.off++
+		mods = ds.capture(ds.typeModifiers)
+	}
+	start := len(ds.buf)
+	ds.functionTypeNoReturn()
+	if ds.noParams {
+		ds.buf = ds.buf[:start]
+	} else if suffixModifiers {
+		ds.writeString(mods)
+	}
+	if ds.off >= len(ds.orig) {
+		ds.fail("function type at end of qualified name")
+	}
+}
+
+// callConvention parses a calling convention, and prints it if it is
+// not the D convention.
+func (ds *dlangState) callConvention() {
+	switch ds.next() {
+	case 'F':
+	case 'U':
+		ds.writeString("extern(C) ")
+	case 'W':
+		ds.writeString("extern(Windows) ")
+	case 'V':
+		ds.writeString("extern(Pascal) ")
+	case 'R':
+		ds.writeString("extern(C++) ")
+	case 'Y':
+		ds.writeString("extern(Objective-C) ")
+	default:
+		ds.off--
+		ds.fail("expected calling convention")
+	}
+}
+
+// typeModifiers parses the modifiers of the this pointer of a member
+// function.
+func (ds *dlangState) typeModifiers() {
+	for {
+		switch ds.peek() {
+		case 'x':
+			ds.writeString(" const")
+		case 'y':
+			ds.writeString(" immutable")
+		case 'O':
+			ds.writeString(" shared")
+		case 'N':
+			if ds.peekAt(ds.off+1) != 'g' {
+				return
+			}
+			ds.off++
+			ds.writeString(" inout")
+		default:
+			return
+		}
+		ds.off++
+	}
+}
+
+// dlangAttributes maps the character following 'N' to a function
+// attribute.
+var dlangAttributes = map[byte]string{
+	'a': "pure ",
+	'b': "nothrow ",
+	'c': "ref ",
+	'd': "@property ",
+	'e': "@trusted ",
+	'f': "@safe ",
+	'i': "@nogc ",
+	'j': "return ",
+	'l': "scope ",
+	'm': "@live ",
+}
+
+// attributes parses function attributes.
+func (ds *dlangState) attributes() {
+	for ds.peek() == 'N' {
+		switch c := ds.peekAt(ds.off + 1); c {
+		case 'g', 'h', 'k', 'n':
+			// These are the start of a parameter type:
+			// inout, vector, return, or typeof(*null).
+			return
+		default:
+			attr, ok := dlangAttributes[c]
+			if !ok {
+				ds.off++
+				ds.fail("unknown function attribute")
+			}
+			ds.writeString(attr)
+			ds.off += 2
+		}
+	}
+}
+
+// functionTypeNoReturn parses a function type without its return type,
+// printing the arguments in parentheses. It returns the calling
+// convention and the attributes, which are printed elsewhere.
+func (ds *dlangState) functionTypeNoReturn() (call, attrs string) {
+	call = ds.capture(ds.callConvention)
+	attrs = ds.capture(ds.attributes)
+	ds.writeString("(")
+	ds.functionArgs()
+	ds.writeString(")")
+	return call, attrs
+}
+
+// functionType parses a function type, which is printed as
+//
+//	CallConvention ReturnType(Arguments) Attributes
+func (ds *dlangState) functionType() {
+	var call, attrs string
+	args := ds.capture(func() {
+		call, attrs = ds.functionTypeNoReturn()
+	})
+	ret := ds.capture(ds.parseType)
+	ds.writeString(call)
+	ds.writeString(ret)
+	ds.writeString(args)
+	ds.writeString(" ")
+	ds.writeString(attrs)
+}
+
+// functionArgs parses the arguments of a function, up to and
+// including the character that closes them.
+func (ds *dlangState) functionArgs() {
+	for n := 0; ; n++ {
+		switch ds.peek() {
+		case 'X':
+			// (T t...) style variadic.
+			ds.off++
+			ds.writeString("...")
+			return
+		case 'Y':
+			// (T t, ...) style variadic.
+			ds.off++
+			if n > 0 {
+				ds.writeString(", ")
+			}
+			ds.writeString("...")
+			return
+		case 'Z':
+			ds.off++
+			return
+		case 0:
+			ds.fail("unterminated function arguments")
+		}
+
+		if n > 0 {
+			ds.writeString(", ")
+		}
+		if ds.peek() == 'M' {
+			ds.off++
+			ds.writeString("scope ")
+		}
+		if ds.hasPrefixAt(ds.off, "Nk") {
+			ds.off += 2
+			ds.writeString("return ")
+		}
+		switch ds.peek() {
+		case 'I':
+			ds.off++
+			ds.writeString("in ")
+			if ds.peek() == 'K' {
+				ds.off++
+				ds.writeString("ref ")
+			}
+		case 'J':
+			ds.off++
+			ds.writeString("out ")
+		case 'K':
+			ds.off++
+			ds.writeString("ref ")
+		case 'L':
+			ds.off++
+			ds.writeString("lazy ")
+		}
+		ds.parseType()
+	}
+}
+
+// dlangBasicTypes maps a character to a basic type.
+var dlangBasicTypes = map[byte]string{
+	'n': "typeof(null)",
+	'v': "void",
+	'g': "byte",
+	'h': "ubyte",
+	's': "short",
+	't': "ushort",
+	'i': "int",
+	'k': "uint",
+	'l': "long",
+	'm': "ulong",
+	'f': "float",
+	'd': "double",
+	'e': "real",
+	'o': "ifloat",
+	'p': "idouble",
+	'j': "ireal",
+	'q': "cfloat",
+	'r': "cdouble",
+	'c': "creal",
+	'b': "bool",
+	'a': "char",
+	'u': "wchar",
+	'w': "dchar",
+}
+
+// wrapType prints a type inside a prefix and ")".
+func (ds *dlangState) wrapType(prefix string) {
+	ds.writeString(prefix)
+	ds.parseType()
+	ds.writeString(")")
+}
+
+// parseType parses a type.
+func (ds *dlangState) parseType() {
+	ds.enter()
+	defer ds.leave()
+
+	c := ds.peek()
+	if s, ok := dlangBasicTypes[c]; ok {
+		ds.off++
+		ds.writeString(s)
+		return
+	}
+
+	switch c {
+	case 'O':
+		ds.off++
+		ds.wrapType("shared(")
+	case 'x':
+		ds.off++
+		ds.wrapType("const(")
+	case 'y':
+		ds.off++
+		ds.wrapType("immutable(")
+	case 'N':
+		ds.off++
+		switch ds.next() {
+		case 'g':
+			ds.wrapType("inout(")
+		case 'h':
+			ds.wrapType("__vector(")
+		case 'n':
+			ds.writeString("typeof(*null)")
+		default:
+			ds.off--
+			ds.fail("unrecognized type")
+		}
+	case 'A':
+		ds.off++
+		ds.parseType()
+		ds.writeString("[]")
+	case 'G':
+		ds.off++
+		start := ds.off
+		for isDigit(ds.peek()) {
+			ds.off++
+		}
+		dim := ds.orig[start:ds.off]
+		ds.parseType()
+		ds.writeString("[" + dim + "]")
+	case 'H':
+		ds.off++
+		key := ds.capture(ds.parseType)
+		ds.parseType()
+		ds.writeString("[" + key + "]")
+	case 'P':
+		ds.off++
+		if !ds.callConventionP(ds.off) {
+			ds.parseType()
+			ds.writeString("*")
+			return
+		}
+		// Function pointer types don't include the trailing asterisk.
+		ds.functionType()
+		ds.writeString("function")
+	case 'F', 'U', 'W', 'V', 'R', 'Y':
+		ds.functionType()
+		ds.writeString("function")
+	case 'C', 'S', 'E', 'T':
+		// Class, struct, enum, typedef.
+		ds.off++
+		ds.qualified(false)
+	case 'D':
+		ds.off++
+		mods := ds.capture(ds.typeModifiers)
+		if ds.peek() == 'Q' {
+			ds.typeBackref(true)
+		} else {
+			ds.functionType()
+		}
+		ds.writeString("delegate")
+		ds.writeString(mods)
+	case 'B':
+		ds.off++
+		ds.tuple()
+	case 'z':
+		ds.off++
+		switch ds.next() {
+		case 'i':
+			ds.writeString("cent")
+		case 'k':
+			ds.writeString("ucent")
+		default:
+			ds.off--
+			ds.fail("unrecognized type")
+		}
+	case 'Q':
+		ds.typeBackref(false)
+	default:
+		ds.fail("unrecognized type")
+	}
+}
+
+// typeBackref parses a back reference to a type, or, if isFunction,
+// to a function type.
+func (ds *dlangState) typeBackref(isFunction bool) {
+	// A reference can only refer to an earlier position, which
+	// prevents infinite recursion.
+	if ds.off >= ds.lastBackref {
+		panic(Error{Code: ErrBadSubstitution, Msg: "recursive back reference", Offset: ds.off})
+	}
+	saved := ds.lastBackref
+	ds.lastBackref = ds.off
+	defer func() {
+		ds.lastBackref = saved
+	}()
+
+	ref := ds.backref()
+	end := ds.off
+	ds.off = ref
+	if isFunction {
+		ds.functionType()
+	} else {
+		ds.parseType()
+	}
+	ds.off = end
+}
+
+// tuple parses a tuple type after 'B'.
+func (ds *dlangState) tuple() {
+	n := ds.number()
+	ds.writeString("Tuple!(")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			ds.writeString(", ")
+		}
+		ds.parseType()
+	}
+	ds.writeString(")")
+}
+
+// identifier parses a symbol name, which may be a back reference or
+// a template instance.
+func (ds *dlangState) identifier() {
+	if ds.peek() == 'Q' {
+		// A back reference to an identifier.
+		ref := ds.backref()
+		end := ds.off
+		ds.off = ref
+		l := ds.number()
+		if l > len(ds.orig)-ds.off {
+			ds.fail("identifier length too large")
+		}
+		ds.lname(l)
+		ds.off = end
+		return
+	}
+
+	if ds.hasPrefixAt(ds.off, "__T") || ds.hasPrefixAt(ds.off, "__U") {
+		// A template instance without a length.
+		ds.template(-1)
+		return
+	}
+
+	l := ds.number()
+	if l == 0 || l > len(ds.orig)-ds.off {
+		ds.fail("invalid identifier length")
+	}
+
+	if l >= 5 && (ds.hasPrefixAt(ds.off, "__T") || ds.hasPrefixAt(ds.off, "__U")) {
+		ds.template(l)
+		return
+	}
+
+	// A fake parent __Sddd distinguishes declarations with the
+	// same name in a function; skip it.
+	if l >= 4 && ds.hasPrefixAt(ds.off, "__S") {
+		i := ds.off + 3
+		for i < ds.off+l && isDigit(ds.orig[i]) {
+			i++
+		}
+		if i == ds.off+l {
+			ds.off = i
+			ds.identifier()
+			return
+		}
+	}
+
+	ds.lname(l)
+}
+
+// dlangSpecialNames are the special symbols that describe their
+// enclosing name, which are followed by 'Z'.
+var dlangSpecialNames = map[string]string{
+	"__initZ":       "initializer for ",
+	"__vtblZ":       "vtable for ",
+	"__ClassZ":      "ClassInfo for ",
+	"__InterfaceZ":  "Interface for ",
+	"__ModuleInfoZ": "ModuleInfo for ",
+}
+
+// lname prints an identifier of length l.
+func (ds *dlangState) lname(l int) {
+	s := ds.orig[ds.off : ds.off+l]
+	switch s {
+	case "__ctor":
+		ds.writeString("this")
+	case "__dtor":
+		ds.writeString("~this")
+	case "__postblit":
+		if ds.hasPrefixAt(ds.off+l, "MFZ") {
+			ds.writeString("this(this)")
+			ds.off += l + 3
+			return
+		}
+		ds.writeString(s)
+	default:
+		if ds.peekAt(ds.off+l) == 'Z' {
+			if prefix, ok := dlangSpecialNames[s+"Z"]; ok {
+				// Remove the trailing '.' and prepend
+				// the description.
+				if n := len(ds.buf); n > 0 {
+					ds.buf = ds.buf[:n-1]
+				}
+				ds.buf = append([]byte(prefix), ds.buf...)
+				break
+			}
+		}
+		ds.writeString(s)
+	}
+	ds.off += l
+}
+
+// template parses a template instance:
+//
+//	Number __T LName TemplateArgs Z
+//	Number __U LName TemplateArgs Z
+//
+// l is the length of the instance, or -1 if it is not known.
+func (ds *dlangState) template(l int) {
+	start := ds.off
+	if !ds.symbolNameP(ds.off+3) || ds.peekAt(ds.off+3) == '0' {
+		ds.fail("invalid template name")
+	}
+	ds.off += 3
+	ds.identifier()
+	args := ds.capture(ds.templateArgs)
+	if !ds.noTemplateParams {
+		ds.writeString("!(" + args + ")")
+	}
+	if l >= 0 && ds.off-start != l {
+		ds.fail("template length mismatch")
+	}
+}
+
+// templateArgs parses template arguments, up to and including the
+// closing 'Z'.
+func (ds *dlangState) templateArgs() {
+	ds.enter()
+	defer ds.leave()
+
+	for n := 0; ; n++ {
+		switch ds.peek() {
+		case 'Z':
+			ds.off++
+			return
+		case 0:
+			ds.fail("unterminated template arguments")
+		}
+		if n > 0 {
+			ds.writeString(", ")
+		}
+
+		// Skip over the specialized template prefix.
+		if ds.peek() == 'H' {
+			ds.off++
+		}
+
+		switch ds.next() {
+		case 'S':
+			ds.templateSymbolParam()
+		case 'T':
+			ds.parseType()
+		case 'V':
+			typ := ds.peek()
+			if typ == 'Q' {
+				// Look at the real type.
+				save := ds.off
+				typ = ds.peekAt(ds.backref())
+				ds.off = save
+			}
+			name := ds.capture(ds.parseType)
+			ds.value(name, typ)
+		case 'X':
+			// An externally mangled parameter.
+			l := ds.number()
+			if l > len(ds.orig)-ds.off {
+				ds.fail("invalid external parameter length")
+			}
+			ds.writeString(ds.orig[ds.off : ds.off+l])
+			ds.off += l
+		default:
+			ds.off--
+			ds.fail("unrecognized template argument")
+		}
+	}
+}
+
+// templateSymbolParam parses a symbol template argument.
+func (ds *dlangState) templateSymbolParam() {
+	if ds.hasPrefixAt(ds.off, "_D") && ds.symbolNameP(ds.off+2) {
+		ds.mangle()
+		return
+	}
+	if ds.peek() == 'Q' {
+		ds.qualified(false)
+		return
+	}
+
+	// Older compilers encode the length of the symbol, and the
+	// symbol can start with a digit, so it's not clear where the
+	// length ends. Try each possibility, starting with the longest
+	// length, and ending with parsing the whole symbol.
+	start := ds.off
+	l := ds.number()
+	if l == 0 {
+		ds.fail("invalid symbol parameter length")
+	}
+	end := ds.off
+	for pend, psize := end, l; ; pend, psize = pend-1, psize/10 {
+		whole := psize == 0
+		if whole {
+			pend, psize = end, l
+		}
+		ok := ds.try(func() {
+			ds.off = pend
+			if ds.symbolNameP(ds.off) {
+				ds.qualified(false)
+			} else if ds.hasPrefixAt(ds.off, "_D") && ds.symbolNameP(ds.off+2) {
+				ds.mangle()
+			} else {
+				ds.fail("invalid symbol parameter")
+			}
+			if !whole && ds.off-pend != psize {
+				ds.fail("symbol parameter length mismatch")
+			}
+		})
+		if ok {
+			return
+		}
+		if whole || pend <= start {
+			break
+		}
+	}
+	ds.off = start
+	ds.fail("invalid symbol parameter")
+}
+
+// value parses a template value argument. name is the type of the
+// value, used for struct literals; typ is the first character of
+// the mangled type.
+func (ds *dlangState) value(name string, typ byte) {
+	ds.enter()
+	defer ds.leave()
+
+	switch c := ds.peek(); {
+	case c == 'n':
+		ds.off++
+		ds.writeString("null")
+	case c == 'N':
+		ds.off++
+		ds.writeString("-")
+		ds.integer(typ)
+	case c == 'i':
+		ds.off++
+		ds.integer(typ)
+	case isDigit(c):
+		// Early versions of D2 didn't use 'i'.
+		ds.integer(typ)
+	case c == 'e':
+		ds.off++
+		ds.real()
+	case c == 'c':
+		ds.off++
+		ds.real()
+		ds.writeString("+")
+		if ds.next() != 'c' {
+			ds.off--
+			ds.fail("invalid complex value")
+		}
+		ds.real()
+		ds.writeString("i")
+	case c == 'a' || c == 'w' || c == 'd':
+		ds.stringValue()
+	case c == 'A':
+		ds.off++
+		n := ds.number()
+		ds.writeString("[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				ds.writeString(", ")
+			}
+			ds.value("", 0)
+			if typ == 'H' {
+				ds.writeString(":")
+				ds.value("", 0)
+			}
+		}
+		ds.writeString("]")
+	case c == 'S':
+		ds.off++
+		n := ds.number()
+		ds.writeString(name + "(")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				ds.writeString(", ")
+			}
+			ds.value("", 0)
+		}
+		ds.writeString(")")
+	case c == 'f':
+		ds.off++
+		if !ds.hasPrefixAt(ds.off, "_D") || !ds.symbolNameP(ds.off+2) {
+			ds.fail("invalid function literal")
+		}
+		ds.mangle()
+	default:
+		ds.fail("unrecognized template value")
+	}
+}
+
+// integer parses an integer value of a type whose mangling starts
+// with typ. Characters and booleans are printed as such.
+func (ds *dlangState) integer(typ byte) {
+	switch typ {
+	case 'a', 'u', 'w':
+		val := ds.number()
+		if typ == 'a' && val >= 0x20 && val < 0x7f {
+			ds.writeString("'" + string(rune(val)) + "'")
+			return
+		}
+		var prefix string
+		var width int
+		switch typ {
+		case 'a':
+			prefix, width = `\x`, 2
+		case 'u':
+			prefix, width = `\u`, 4
+		case 'w':
+			prefix, width = `\U`, 8
+		}
+		hex := strconv.FormatInt(int64(val), 16)
+		for len(hex) < width {
+			hex = "0" + hex
+		}
+		ds.writeString("'" + prefix + hex + "'")
+	case 'b':
+		if ds.number() != 0 {
+			ds.writeString("true")
+		} else {
+			ds.writeString("false")
+		}
+	default:
+		start := ds.off
+		if !isDigit(ds.peek()) {
+			ds.fail("expected integer")
+		}
+		for isDigit(ds.peek()) {
+			ds.off++
+		}
+		ds.writeString(ds.orig[start:ds.off])
+		switch typ {
+		case 'h', 't', 'k':
+			ds.writeString("u")
+		case 'l':
+			ds.writeString("L")
+		case 'm':
+			ds.writeString("uL")
+		}
+	}
+}
+
+// isHexDigit reports whether c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// real parses a floating point value, which is written in
+// hexadecimal with the exponent following 'P'.
+func (ds *dlangState) real() {
+	for _, s := range []struct{ mangled, printed string }{
+		{"NAN", "NaN"},
+		{"INF", "Inf"},
+		{"NINF", "-Inf"},
+	} {
+		if ds.hasPrefixAt(ds.off, s.mangled) {
+			ds.off += len(s.mangled)
+			ds.writeString(s.printed)
+			return
+		}
+	}
+
+	if ds.peek() == 'N' {
+		ds.off++
+		ds.writeString("-")
+	}
+	if !isHexDigit(ds.peek()) {
+		ds.fail("invalid floating point value")
+	}
+	ds.writeString("0x" + string(ds.next()) + ".")
+	start := ds.off
+	for isHexDigit(ds.peek()) {
+		ds.off++
+	}
+	ds.writeString(ds.orig[start:ds.off])
+	if ds.next() != 'P' {
+		ds.off--
+		ds.fail("missing floating point exponent")
+	}
+	ds.writeString("p")
+	if ds.peek() == 'N' {
+		ds.off++
+		ds.writeString("-")
+	}
+	start = ds.off
+	for isDigit(ds.peek()) {
+		ds.off++
+	}
+	ds.writeString(ds.orig[start:ds.off])
+}
+
+// stringValue parses a string literal, whose characters are written
+// in hexadecimal.
+func (ds *dlangState) stringValue() {
+	typ := ds.next()
+	n := ds.number()
+	if ds.next() != '_' {
+		ds.off--
+		ds.fail("invalid string literal")
+	}
+	ds.writeString(`"`)
+	for i := 0; i < n; i++ {
+		if !isHexDigit(ds.peek()) || !isHexDigit(ds.peekAt(ds.off+1)) {
+			ds.fail("invalid string literal character")
+		}
+		hex := ds.orig[ds.off : ds.off+2]
+		var v byte
+		for j := 0; j < 2; j++ {
+			c := hex[j]
+			switch {
+			case isDigit(c):
+				v = v*16 + c - '0'
+			case 'a' <= c && c <= 'f':
+				v = v*16 + c - 'a' + 10
+			default:
+				v = v*16 + c - 'A' + 10
+			}
+		}
+		switch v {
+		case '\t':
+			ds.writeString(`\t`)
+		case '\n':
+			ds.writeString(`\n`)
+		case '\r':
+			ds.writeString(`\r`)
+		case '\f':
+			ds.writeString(`\f`)
+		case '\v':
+			ds.writeString(`\v`)
+		default:
+			if v >= 0x20 && v < 0x7f {
+				ds.writeString(string(rune(v)))
+			} else {
+				ds.writeString(`\x` + hex)
+			}
+		}
+		ds.off += 2
+	}
+	ds.writeString(`"`)
+	if typ != 'a' {
+		ds.writeString(string(rune(typ)))
+	}
+}
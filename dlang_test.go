@@ -0,0 +1,101 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestDlang(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"_Dmain", "D main"},
+		{"_D8demangle3fooFiZv", "demangle.foo(int)"},
+		{"_D4test3fooFZi", "test.foo()"},
+		{"_D8demangle4testFAiZv", "demangle.test(int[])"},
+		{"_D3foo3barFNaNbNiNfPxaZi", "foo.bar(const(char)*)"},
+		{"_D4test3fooFPFiZvZv", "test.foo(void(int) function)"},
+		{"_D4test3fooFDFNaNbiZvZv", "test.foo(void(int) pure nothrow delegate)"},
+		{"_D4test4testFDxFZvZv", "test.test(void() delegate const)"},
+		{"_D4test3fooFxAaZv", "test.foo(const(char[]))"},
+		{"_D4test3fooFNgiZv", "test.foo(inout(int))"},
+		{"_D4test3fooFHiAaZv", "test.foo(char[][int])"},
+		{"_D4test3fooFG3iZv", "test.foo(int[3])"},
+		{"_D4test3fooFNhG4iZv", "test.foo(__vector(int[4]))"},
+		{"_D4test3fooFB2iiZv", "test.foo(Tuple!(int, int))"},
+		{"_D4test3fooFzizkZv", "test.foo(cent, ucent)"},
+		{"_D4test3fooFKiJiLiZv", "test.foo(ref int, out int, lazy int)"},
+		{"_D4test3fooFMNkKPiZv", "test.foo(scope return ref int*)"},
+		{"_D4test3fooFIKiZv", "test.foo(in ref int)"},
+		{"_D4test3fooFiXv", "test.foo(int...)"},
+		{"_D4test3fooFiYv", "test.foo(int, ...)"},
+		{"_D4test1S3fooMxFZv", "test.S.foo() const"},
+		{"_D4test3barFZ3bazFZv", "test.bar().baz()"},
+		{"_D4test1xi", "test.x"},
+		{"_D4test3fooFZ1xi", "test.foo().x"},
+		{"_D4test6__initZ", "initializer for test"},
+		{"_D4test1C6__vtblZ", "vtable for test.C"},
+		{"_D4test1C7__ClassZ", "ClassInfo for test.C"},
+		{"_D4test12__ModuleInfoZ", "ModuleInfo for test"},
+		{"_D4test__T3fooTiZQhFiZv", "test.foo!(int).foo(int)"},
+		{"_D4test__T3fooVii5ZQjFZv", "test.foo!(5).foo()"},
+		{"_D4test__T3fooVbi1ZQjFZv", "test.foo!(true).foo()"},
+		{"_D4test__T3fooVai97ZQkFZv", "test.foo!('a').foo()"},
+		{"_D4test__T3fooVwi10ZQkFZv", `test.foo!('\U0000000a').foo()`},
+		{"_D4test__T3fooVmi10ZQkFZv", "test.foo!(10uL).foo()"},
+		{"_D3std6format__T6formatTaTiZQmFNaNfIAaiZAya", "std.format.format!(char, int).format(in char[], int)"},
+		{"_D4core4time8Duration__T5totalVAyaa7_7365636f6e6473ZQBcMxFNaNbNdNfZl", `core.time.Duration.total!("seconds").total() const`},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got := Filter(test.input); got != test.want {
+			t.Errorf("filtering %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestDlangOptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"_D4test3barFZ3bazFZv", []Option{NoParams}, "test.bar.baz"},
+		{"_D4test1S3fooMxFZv", []Option{NoParams}, "test.S.foo"},
+		{"_D3std6format__T6formatTaTiZQmFNaNfIAaiZAya", []Option{NoParams}, "std.format.format!(char, int).format"},
+		{"_D3std6format__T6formatTaTiZQmFNaNfIAaiZAya", []Option{NoTemplateParams}, "std.format.format.format(in char[], int)"},
+		{"_D3std6format__T6formatTaTiZQmFNaNfIAaiZAya", []Option{NoParams, NoTemplateParams}, "std.format.format.format"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestDlangFailure(t *testing.T) {
+	for _, input := range []string{
+		"_D",
+		"_D4test",
+		"_D9test",
+		"_D4test3fooFiZvjunk",
+		"_D4test3fooFi",
+		"_D4test1S6__ctorMFiZSQqQk",
+		"_D4test__T3fooVAyaa3_616263ZQtFZv",
+		"_D4test3fooFQaZv",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
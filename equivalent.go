@@ -0,0 +1,73 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// Equivalent reports whether two symbol names denote the same entity,
+// ignoring differences that depend on the ABI or the C++ library
+// rather than on the source code. For C++ names these differences
+// are ABI tags, such as [abi:cxx11]; inline namespaces used for
+// library versioning, such as std::__cxx11 and std::__1; and the
+// variant of a constructor or destructor, such as the complete object
+// and base object constructors. Other names are equivalent if they
+// demangle to the same string. A name that can not be demangled is
+// only equivalent to itself.
+func Equivalent(a, b string, options ...Option) bool {
+	if a == b {
+		return true
+	}
+	ka, err := equivalenceKey(a, options)
+	if err != nil {
+		return false
+	}
+	kb, err := equivalenceKey(b, options)
+	if err != nil {
+		return false
+	}
+	return ka == kb
+}
+
+// inlineNamespaces is the set of inline namespaces that Equivalent
+// ignores.
+var inlineNamespaces = map[string]bool{
+	"__cxx11": true, // libstdc++ C++11 ABI
+	"__8":     true, // libstdc++ versioned namespace
+	"__1":     true, // libc++
+	"__ndk1":  true, // libc++ on Android
+}
+
+// equivalenceKey returns the string used by Equivalent to compare
+// names: the demangled name with the ABI differences removed.
+func equivalenceKey(name string, options []Option) (string, error) {
+	a, err := ToAST(name, options...)
+	if err != nil {
+		// This may be a name that has no AST, such as a Rust
+		// name.
+		return ToString(name, options...)
+	}
+	a = Transform(a, func(n AST) AST {
+		switch n := n.(type) {
+		case *TaggedName:
+			return n.Name
+		case *Qualified:
+			if n.LocalName {
+				return nil
+			}
+			switch scope := n.Scope.(type) {
+			case *Name:
+				if inlineNamespaces[scope.Name] {
+					return n.Name
+				}
+			case *Qualified:
+				if s, ok := scope.Name.(*Name); ok && inlineNamespaces[s.Name] && !scope.LocalName {
+					return &Qualified{Scope: scope.Scope, Name: n.Name}
+				}
+			}
+		}
+		return nil
+	})
+	// Constructors and destructors print the same way whatever
+	// their variant, so we don't need to change them.
+	return ASTToString(a, options...), nil
+}
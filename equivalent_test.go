@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestEquivalent(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want bool
+	}{
+		{"_Z1fv", "_Z1fv", true},
+		{"_Z1fv", "_Z1fi", false},
+		{"_Z1fB5cxx11v", "_Z1fv", true},
+		{"_ZN1AB3tag1fEv", "_ZN1A1fEv", true},
+		{"_ZN1AC1Ev", "_ZN1AC2Ev", true},
+		{"_ZN1AD0Ev", "_ZN1AD2Ev", true},
+		{"_ZN1AC1Ev", "_ZN1AD1Ev", false},
+		{"_ZNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEC1Ev", "_ZNSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEC2Ev", true},
+		{"_ZNSt7__cxx114listIiSaIiEE5clearEv", "_ZNSt4listIiSaIiEE5clearEv", true},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE5clearEv", "_ZNSt6vectorIiSaIiEE5clearEv", true},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE5clearEv", "_ZNSt6vectorIlSaIlEE5clearEv", false},
+		{"_ZZ1fvE1a", "_ZZ1fvE1a", true},
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"_Z1fv", "f", false},
+	}
+	for _, test := range tests {
+		if got := Equivalent(test.a, test.b); got != test.want {
+			t.Errorf("Equivalent(%q, %q) = %t, want %t", test.a, test.b, got, test.want)
+		}
+		if got := Equivalent(test.b, test.a); got != test.want {
+			t.Errorf("Equivalent(%q, %q) = %t, want %t", test.b, test.a, got, test.want)
+		}
+	}
+
+	if !Equivalent("_Z1fv", "_Z1fi", NoParams) {
+		t.Errorf("Equivalent with NoParams: got false, want true")
+	}
+}
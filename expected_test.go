@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -71,6 +72,7 @@ func TestExpected(t *testing.T) {
 		expect := getLine(t, scanner, &lineno)
 
 		testNoParams := false
+		cdtor := ""
 		skip := false
 		if len(format) > 0 && format[0] == '-' {
 			for _, arg := range strings.Fields(format) {
@@ -85,7 +87,7 @@ func TestExpected(t *testing.T) {
 				case "--ret-postfix", "--ret-drop":
 					skip = true
 				case "--is-v3-ctor", "--is-v3-dtor":
-					skip = true
+					cdtor = arg
 				default:
 					if !strings.HasPrefix(arg, "--format=") {
 						t.Errorf("%s:%d: unrecognized argument %s", filename, report, arg)
@@ -96,11 +98,8 @@ func TestExpected(t *testing.T) {
 		}
 
 		// The libiberty testsuite passes DMGL_TYPES to
-		// demangle type names, but that doesn't seem useful
-		// and we don't support it.
-		if !strings.HasPrefix(input, "_Z") && !strings.HasPrefix(input, "_GLOBAL_") {
-			skip = true
-		}
+		// demangle type names, which is our Types option.
+		types := !strings.HasPrefix(input, "_Z") && !strings.HasPrefix(input, "_GLOBAL_")
 
 		var expectNoParams string
 		if testNoParams {
@@ -111,9 +110,22 @@ func TestExpected(t *testing.T) {
 			continue
 		}
 
-		oneTest(t, report, input, expect, true)
+		if cdtor != "" {
+			var got int
+			if cdtor == "--is-v3-ctor" {
+				got = int(IsCtor(input))
+			} else {
+				got = int(IsDtor(input))
+			}
+			if strconv.Itoa(got) != expect {
+				t.Errorf("%s:%d: %s: got %d, want %s", filename, report, cdtor, got, expect)
+			}
+			continue
+		}
+
+		oneTest(t, report, input, expect, true, types)
 		if testNoParams {
-			oneTest(t, report, input, expectNoParams, false)
+			oneTest(t, report, input, expectNoParams, false, types)
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -122,20 +134,22 @@ func TestExpected(t *testing.T) {
 }
 
 // oneTest tests one entry from demangle-expected.
-func oneTest(t *testing.T, report int, input, expect string, params bool) {
+func oneTest(t *testing.T, report int, input, expect string, params, types bool) {
 	if *verbose {
 		fmt.Println(input)
 	}
 
 	exception := exceptions[input]
 
-	var s string
-	var err error
-	if params {
-		s, err = ToString(input)
-	} else {
-		s, err = ToString(input, NoParams)
+	var options []Option
+	if !params {
+		options = append(options, NoParams)
+	}
+	if types {
+		options = append(options, Types)
 	}
+
+	s, err := ToString(input, options...)
 	if err != nil {
 		if exception {
 			t.Logf("%s:%d: ignore expected difference: got %q, want %q", filename, report, err, expect)
@@ -156,12 +170,7 @@ func oneTest(t *testing.T, report int, input, expect string, params bool) {
 		if exception {
 			t.Logf("%s:%d: ignore expected difference: got %q, want %q", filename, report, s, expect)
 		} else {
-			var a AST
-			if params {
-				a, err = ToAST(input)
-			} else {
-				a, err = ToAST(input, NoParams)
-			}
+			a, err := ToAST(input, options...)
 			if err != nil {
 				t.Logf("ToAST error: %v", err)
 			} else {
@@ -174,7 +183,7 @@ func oneTest(t *testing.T, report int, input, expect string, params bool) {
 	}
 
 	if s == expect && s != input && params && len(expect) > 200 {
-		ss, err := ToString(input, MaxLength(6))
+		ss, err := ToString(input, append(options, MaxLength(6))...)
 		if err != nil {
 			t.Errorf("%s:%d: error with MaxLength: %v", filename, report, err)
 		} else if ss != expect[:64] {
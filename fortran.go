@@ -0,0 +1,88 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// This file decodes the names that gfortran uses for Fortran
+// procedures and variables. A module procedure p in module m is
+// named "__m_MOD_p". An external procedure p is named "p_".
+
+// fortranModuleSep separates a module name from a procedure name.
+const fortranModuleSep = "_MOD_"
+
+// fortranToString decodes a gfortran module procedure name,
+// returning "module::procedure".
+// If the Fortran option is used, an external procedure name with a
+// trailing underscore is also decoded.
+func fortranToString(name string, options []Option) (string, error) {
+	external := false
+	max := 0
+	for _, o := range options {
+		if o == Fortran {
+			external = true
+		} else if isMaxLength(o) {
+			max = maxLength(o)
+		}
+	}
+
+	var s string
+	if mod, proc, ok := fortranModuleProc(name); ok {
+		s = mod + "::" + proc
+	} else if external && isFortranExternal(name) {
+		s = name[:len(name)-1]
+	} else {
+		return "", ErrNotMangledName
+	}
+
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// fortranModuleProc splits a module procedure name into the module
+// name and the procedure name.
+func fortranModuleProc(name string) (mod, proc string, ok bool) {
+	if !strings.HasPrefix(name, "__") {
+		return "", "", false
+	}
+	i := strings.Index(name[2:], fortranModuleSep)
+	if i <= 0 {
+		return "", "", false
+	}
+	mod = name[2 : 2+i]
+	proc = name[2+i+len(fortranModuleSep):]
+	if !isFortranName(mod) || proc == "" || !isFortranName(proc) {
+		return "", "", false
+	}
+	return mod, proc, true
+}
+
+// isFortranExternal reports whether name looks like an external
+// procedure name: a lower case Fortran name followed by an underscore.
+func isFortranExternal(name string) bool {
+	if len(name) < 2 || name[len(name)-1] != '_' {
+		return false
+	}
+	return isFortranName(name[:len(name)-1])
+}
+
+// isFortranName reports whether s is a Fortran name as gfortran
+// emits it: it starts with a letter or underscore, and contains only
+// letters, digits, and underscores. User names are lower case, but
+// compiler generated names such as __vtab_m_T are not.
+func isFortranName(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isLower(c) && !isUpper(c) && !isDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
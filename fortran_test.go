@@ -0,0 +1,56 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestFortran(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"__solvers_MOD_jacobi", nil, "solvers::jacobi"},
+		{"__m_MOD_p", nil, "m::p"},
+		{"__mesh_utils_MOD_build_grid", nil, "mesh_utils::build_grid"},
+		{"__m_MOD___vtab_m_T", nil, "m::__vtab_m_T"},
+		{"__solvers_MOD_jacobi", []Option{Fortran}, "solvers::jacobi"},
+		{"jacobi_", []Option{Fortran}, "jacobi"},
+		{"build_grid_", []Option{Fortran}, "build_grid"},
+		{"__solvers_MOD_jacobi", []Option{MaxLength(3)}, "solvers:"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+		if got := Filter(test.input, test.options...); got != test.want {
+			t.Errorf("filtering %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestFortranFailure(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+	}{
+		{"jacobi_", nil},
+		{"__MOD_p", nil},
+		{"__m_MOD_", nil},
+		{"__m_MOD_p.q", nil},
+		{"_", []Option{Fortran}},
+		{"1abc_", []Option{Fortran}},
+		{"jacobi", []Option{Fortran}},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err == nil {
+			t.Errorf("demangling %s with %v: got %s, want error", test.input, test.options, got)
+		}
+	}
+}
@@ -0,0 +1,906 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file demangles names mangled by GCC 2.x, before the C++ ABI
+// was adopted. The output follows the style of the old GNU c++filt,
+// as in "A::foo(int, char const *)".
+//
+// These names have no distinctive prefix, and many ordinary C
+// identifiers would demangle into nonsense, so this is only used
+// when the GNUv2 option is passed.
+
+// gnuV2ToString demangles a GNU v2 symbol.
+func gnuV2ToString(name string, options []Option) (ret string, err error) {
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	gst := &gnuV2State{str: name, maxDepth: defaultMaxDepth}
+	max := 0
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			gst.noParams = true
+		case o == NoTemplateParams:
+			gst.noTemplateParams = true
+		case o == ElideTemplateParams:
+			gst.noTemplateParams = true
+			gst.elideTemplateParams = true
+		case o == NoAngleSpace:
+			gst.noAngleSpace = true
+		case isMaxLength(o):
+			max = maxLength(o)
+		case isMaxDepth(o):
+			gst.maxDepth = optionValue(o)
+		}
+	}
+
+	gst.symbol()
+	gst.checkEnd()
+
+	s := string(gst.buf)
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// gnuV2MaxOutput is the maximum length of a demangled GNU v2 name.
+// Repeated argument types can make the output exponentially larger
+// than the input.
+const gnuV2MaxOutput = 1 << 20
+
+// A gnuV2State holds the current state of demangling a GNU v2 string.
+type gnuV2State struct {
+	str      string   // remainder of string to demangle
+	off      int      // offset of str within original string
+	buf      []byte   // the demangled output
+	types    []string // argument types, for T and N references
+	depth    int      // current nesting depth
+	maxDepth int      // maximum nesting depth
+
+	noParams            bool // don't demangle function parameters
+	noTemplateParams    bool // don't demangle template arguments
+	elideTemplateParams bool // print "<...>" for template arguments
+	noAngleSpace        bool // print >> rather than > >
+}
+
+// fail panics with Error, to be caught in gnuV2ToString.
+func (gst *gnuV2State) fail(err string) {
+	panic(Error{Msg: err, Offset: gst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (gst *gnuV2State) enter() {
+	gst.depth++
+	if gst.depth > gst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: gst.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (gst *gnuV2State) leave() {
+	gst.depth--
+}
+
+// advance advances the current string offset.
+func (gst *gnuV2State) advance(add int) {
+	if len(gst.str) < add {
+		panic("internal error")
+	}
+	gst.str = gst.str[add:]
+	gst.off += add
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (gst *gnuV2State) peek() byte {
+	if len(gst.str) == 0 {
+		return 0
+	}
+	return gst.str[0]
+}
+
+// checkChar requires that the next character in the string be c,
+// and advances past it.
+func (gst *gnuV2State) checkChar(c byte) {
+	if len(gst.str) == 0 || gst.str[0] != c {
+		gst.fail("expected " + string(c))
+	}
+	gst.advance(1)
+}
+
+// checkEnd fails if any of the string is left unparsed.
+func (gst *gnuV2State) checkEnd() {
+	if len(gst.str) > 0 {
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: gst.off})
+	}
+}
+
+// writeString adds a string to the output.
+func (gst *gnuV2State) writeString(s string) {
+	gst.buf = append(gst.buf, s...)
+	if len(gst.buf) > gnuV2MaxOutput {
+		panic(Error{Code: ErrTooLarge, Msg: "demangled output too large", Offset: gst.off})
+	}
+}
+
+// capture runs f and returns the output that it wrote,
+// removing it from the output.
+func (gst *gnuV2State) capture(f func()) string {
+	n := len(gst.buf)
+	f()
+	s := string(gst.buf[n:])
+	gst.buf = gst.buf[:n]
+	return s
+}
+
+// try runs f. If f fails, try restores the state and returns false.
+func (gst *gnuV2State) try(f func()) (ok bool) {
+	str, off, n, types := gst.str, gst.off, len(gst.buf), len(gst.types)
+	defer func() {
+		if r := recover(); r != nil {
+			// Don't backtrack over a name that is too large;
+			// the alternatives won't be any smaller.
+			if de, isErr := r.(Error); !isErr || de.Code == ErrTooLarge {
+				panic(r)
+			}
+			gst.str, gst.off, gst.buf, gst.types = str, off, gst.buf[:n], gst.types[:types]
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// demangleSub demangles s, a part of the name at offset off that is
+// mangled in its own right, by calling f with a new state that uses
+// the default options. It returns the result and whether it
+// succeeded. The new state starts at the current depth, so that
+// nested names count toward the limit.
+func (gst *gnuV2State) demangleSub(s string, off int, f func(*gnuV2State)) (ret string, ok bool) {
+	gst.enter()
+	defer gst.leave()
+
+	sub := &gnuV2State{str: s, off: off, depth: gst.depth, maxDepth: gst.maxDepth}
+	if !sub.try(func() {
+		f(sub)
+		sub.checkEnd()
+	}) {
+		return "", false
+	}
+	return string(sub.buf), true
+}
+
+// number parses a decimal number, consuming all the digits.
+func (gst *gnuV2State) number() int {
+	if !isDigit(gst.peek()) {
+		gst.fail("expected number")
+	}
+	val := 0
+	for isDigit(gst.peek()) {
+		val = val*10 + int(gst.str[0]-'0')
+		if val > len(gst.str)+gst.off+gnuV2MaxOutput {
+			gst.fail("numeric overflow")
+		}
+		gst.advance(1)
+	}
+	return val
+}
+
+// count parses a count. It is a single digit, unless it is followed
+// by more digits and an underscore, as in "12_".
+func (gst *gnuV2State) count() int {
+	if !isDigit(gst.peek()) {
+		gst.fail("expected count")
+	}
+	i := 1
+	for i < len(gst.str) && isDigit(gst.str[i]) {
+		i++
+	}
+	if i > 1 && i < len(gst.str) && gst.str[i] == '_' {
+		n := gst.number()
+		gst.advance(1)
+		return n
+	}
+	n := int(gst.str[0] - '0')
+	gst.advance(1)
+	return n
+}
+
+// isGNUv2SepChar reports whether c separates the parts of a special
+// name, which depends on what the assembler accepts.
+func isGNUv2SepChar(c byte) bool {
+	return c == '$' || c == '.'
+}
+
+// isGNUv2ClassStart reports whether c starts a class name.
+func isGNUv2ClassStart(c byte) bool {
+	return isDigit(c) || c == 'Q' || c == 't'
+}
+
+// symbol parses a complete symbol.
+func (gst *gnuV2State) symbol() {
+	switch {
+	case strings.HasPrefix(gst.str, "_GLOBAL_") && len(gst.str) > 11 && gst.str[8] == gst.str[10] && (isGNUv2SepChar(gst.str[8]) || gst.str[8] == '_'):
+		var prefix string
+		switch gst.str[9] {
+		case 'I':
+			prefix = "global constructors keyed to "
+		case 'D':
+			prefix = "global destructors keyed to "
+		default:
+			gst.fail("unrecognized global constructor")
+		}
+		gst.advance(11)
+		gst.writeString(prefix)
+		gst.rest()
+		return
+
+	case strings.HasPrefix(gst.str, "_vt") && len(gst.str) > 3 && isGNUv2SepChar(gst.str[3]):
+		gst.advance(4)
+		for n := 0; ; n++ {
+			if n > 0 {
+				gst.writeString("::")
+			}
+			if isGNUv2ClassStart(gst.peek()) {
+				gst.className()
+			} else {
+				i := strings.IndexAny(gst.str, "$.")
+				if i < 0 {
+					i = len(gst.str)
+				}
+				if i == 0 {
+					gst.fail("empty virtual table name")
+				}
+				gst.writeString(gst.str[:i])
+				gst.advance(i)
+			}
+			if len(gst.str) == 0 {
+				break
+			}
+			if !isGNUv2SepChar(gst.peek()) {
+				gst.fail("unexpected character in virtual table name")
+			}
+			gst.advance(1)
+		}
+		gst.writeString(" virtual table")
+		return
+
+	case strings.HasPrefix(gst.str, "__thunk_"):
+		gst.advance(8)
+		delta := gst.number()
+		gst.checkChar('_')
+		gst.writeString("virtual function thunk (delta:-" + strconv.Itoa(delta) + ") for ")
+		gst.function()
+		return
+
+	case (strings.HasPrefix(gst.str, "__ti") || strings.HasPrefix(gst.str, "__tf")) && len(gst.str) > 4 && (isGNUv2ClassStart(gst.str[4]) || !strings.Contains(gst.str[4:], "__")):
+		suffix := " type_info node"
+		if gst.str[3] == 'f' {
+			suffix = " type_info function"
+		}
+		gst.advance(4)
+		gst.typ()
+		gst.writeString(suffix)
+		return
+
+	case len(gst.str) > 1 && gst.str[0] == '_' && isGNUv2ClassStart(gst.str[1]):
+		// A static data member.
+		if gst.try(func() {
+			gst.advance(1)
+			gst.className()
+			if len(gst.str) < 2 || !isGNUv2SepChar(gst.str[0]) {
+				gst.fail("not a static member")
+			}
+		}) {
+			gst.advance(1)
+			gst.writeString("::")
+			gst.rest()
+			return
+		}
+	}
+
+	gst.function()
+}
+
+// rest prints the rest of the string, demangled if possible.
+func (gst *gnuV2State) rest() {
+	s, off := gst.str, gst.off
+	gst.advance(len(s))
+	if d, ok := gst.demangleSub(s, off, (*gnuV2State).symbol); ok {
+		s = d
+	}
+	gst.writeString(s)
+}
+
+// function parses a function name and signature.
+func (gst *gnuV2State) function() {
+	var name string
+	ctor, dtor := false, false
+	switch {
+	case strings.HasPrefix(gst.str, "_$_") || strings.HasPrefix(gst.str, "_._"):
+		dtor = true
+		gst.advance(3)
+		if !isGNUv2ClassStart(gst.peek()) {
+			gst.fail("expected class name for destructor")
+		}
+	case strings.HasPrefix(gst.str, "__") && len(gst.str) > 2 && isGNUv2ClassStart(gst.str[2]):
+		ctor = true
+		gst.advance(2)
+	default:
+		i := gst.findSignature()
+		name = gst.operatorName(gst.str[:i])
+		gst.advance(i + 2)
+	}
+
+	// The class name is printed as soon as it is parsed, as it
+	// comes first.
+	static := false
+	quals := ""
+	hasClass := false
+	base := ""
+	for done := false; !done; {
+		switch c := gst.peek(); {
+		case c == 'S':
+			static = true
+			gst.advance(1)
+		case c == 'C':
+			quals += " const"
+			gst.advance(1)
+		case c == 'V':
+			quals += " volatile"
+			gst.advance(1)
+		case c == 'F' && !ctor && !dtor:
+			gst.advance(1)
+			if quals != "" || static {
+				gst.fail("qualifiers on a non-member function")
+			}
+			done = true
+		case isGNUv2ClassStart(c):
+			base = gst.classNameAndBase()
+			hasClass, done = true, true
+		default:
+			gst.fail("unrecognized function signature")
+		}
+	}
+
+	switch {
+	case ctor:
+		name = base
+	case dtor:
+		name = "~" + base
+	}
+	if hasClass {
+		gst.writeString("::")
+	}
+	gst.writeString(name)
+
+	if gst.noParams {
+		gst.capture(func() { gst.argList(false) })
+		return
+	}
+	gst.writeString("(")
+	gst.argList(false)
+	gst.writeString(")")
+	gst.writeString(quals)
+	if static {
+		gst.writeString(" static")
+	}
+}
+
+// findSignature returns the offset of the "__" that separates the
+// function name from its signature.
+func (gst *gnuV2State) findSignature() int {
+	start := 1
+	if strings.HasPrefix(gst.str, "__") {
+		// An operator name such as __pl.
+		start = 2
+	}
+	if len(gst.str) < start {
+		gst.fail("no function signature")
+	}
+	for {
+		i := strings.Index(gst.str[start:], "__")
+		if i < 0 {
+			gst.fail("no function signature")
+		}
+		i += start
+		// In a name such as foo___1A the function is foo_.
+		for i+2 < len(gst.str) && gst.str[i+2] == '_' {
+			i++
+		}
+		if i+2 < len(gst.str) {
+			switch c := gst.str[i+2]; {
+			case isGNUv2ClassStart(c), c == 'F', c == 'C', c == 'V', c == 'S':
+				return i
+			}
+		}
+		start = i + 1
+	}
+}
+
+// gnuV2Operators maps the encoding of an operator to its name.
+var gnuV2Operators = map[string]string{
+	"nw":  " new",
+	"dl":  " delete",
+	"vn":  " new []",
+	"vd":  " delete []",
+	"as":  "=",
+	"ne":  "!=",
+	"eq":  "==",
+	"ge":  ">=",
+	"gt":  ">",
+	"le":  "<=",
+	"lt":  "<",
+	"pl":  "+",
+	"apl": "+=",
+	"mi":  "-",
+	"ami": "-=",
+	"ml":  "*",
+	"aml": "*=",
+	"dv":  "/",
+	"adv": "/=",
+	"md":  "%",
+	"amd": "%=",
+	"ls":  "<<",
+	"als": "<<=",
+	"rs":  ">>",
+	"ars": ">>=",
+	"aa":  "&&",
+	"oo":  "||",
+	"nt":  "!",
+	"pp":  "++",
+	"mm":  "--",
+	"ad":  "&",
+	"aad": "&=",
+	"or":  "|",
+	"aor": "|=",
+	"er":  "^",
+	"aer": "^=",
+	"co":  "~",
+	"cm":  ", ",
+	"rf":  "->",
+	"rm":  "->*",
+	"cl":  "()",
+	"vc":  "[]",
+	"mx":  ">?",
+	"mn":  "<?",
+	"cn":  "?:",
+	"sz":  " sizeof",
+}
+
+// operatorName returns the name of a function, which starts the
+// remaining string, translating operator names such as __pl and type
+// conversions such as __opi.
+func (gst *gnuV2State) operatorName(name string) string {
+	if !strings.HasPrefix(name, "__") {
+		return name
+	}
+	op := name[2:]
+	if s, ok := gnuV2Operators[op]; ok {
+		if s == ", " {
+			return "operator,"
+		}
+		return "operator" + s
+	}
+	if strings.HasPrefix(op, "op") && len(op) > 2 {
+		if s, ok := gst.demangleSub(op[2:], gst.off+4, (*gnuV2State).typ); ok {
+			return "operator " + s
+		}
+	}
+	return name
+}
+
+// className parses a class name.
+func (gst *gnuV2State) className() {
+	gst.classNameAndBase()
+}
+
+// classNameAndBase parses a class name, possibly qualified or a
+// template. It prints the full name and returns the unqualified name
+// without template arguments, which is the name of a constructor.
+func (gst *gnuV2State) classNameAndBase() string {
+	switch c := gst.peek(); {
+	case isDigit(c):
+		s := gst.identifier()
+		gst.writeString(s)
+		return s
+	case c == 't':
+		return gst.template()
+	case c == 'Q':
+		gst.advance(1)
+		var n int
+		if gst.peek() == '_' {
+			gst.advance(1)
+			n = gst.number()
+			gst.checkChar('_')
+		} else {
+			if !isDigit(gst.peek()) {
+				gst.fail("expected qualifier count")
+			}
+			n = int(gst.str[0] - '0')
+			gst.advance(1)
+			if gst.peek() == '_' {
+				gst.advance(1)
+			}
+		}
+		if n < 1 {
+			gst.fail("invalid qualifier count")
+		}
+		base := ""
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				gst.writeString("::")
+			}
+			switch c := gst.peek(); {
+			case isDigit(c):
+				base = gst.identifier()
+				gst.writeString(base)
+			case c == 't':
+				base = gst.template()
+			default:
+				gst.fail("unrecognized qualified name")
+			}
+		}
+		return base
+	default:
+		gst.fail("expected class name")
+		panic("not reached")
+	}
+}
+
+// identifier parses a length-prefixed identifier.
+func (gst *gnuV2State) identifier() string {
+	n := gst.number()
+	if n == 0 || n > len(gst.str) {
+		gst.fail("invalid identifier length")
+	}
+	s := gst.str[:n]
+	gst.advance(n)
+	return s
+}
+
+// template parses a template instance:
+//
+//	t <name> <count> <arg>*
+//
+// where each argument is either Z and a type, or a type and a value.
+// It prints the full name and returns the template name.
+func (gst *gnuV2State) template() string {
+	gst.checkChar('t')
+	name := gst.identifier()
+	n := gst.count()
+	gst.writeString(name)
+	start := len(gst.buf)
+	gst.writeString("<")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			gst.writeString(", ")
+		}
+		if gst.peek() == 'Z' {
+			gst.advance(1)
+			gst.typ()
+		} else {
+			gst.templateValue()
+		}
+	}
+	if gst.noTemplateParams {
+		gst.buf = gst.buf[:start]
+		if gst.elideTemplateParams {
+			gst.writeString("<...>")
+		}
+		return name
+	}
+	if gst.buf[len(gst.buf)-1] == '>' && !gst.noAngleSpace {
+		gst.writeString(" ")
+	}
+	gst.writeString(">")
+	return name
+}
+
+// templateValue parses a template value argument, which is its type
+// followed by the value.
+func (gst *gnuV2State) templateValue() {
+	kind := byte(0)
+	for i := 0; i < len(gst.str); i++ {
+		if c := gst.str[i]; c != 'C' && c != 'V' && c != 'U' && c != 'S' {
+			kind = c
+			break
+		}
+	}
+	gst.capture(gst.typ)
+
+	switch kind {
+	case 'P', 'R':
+		// The address of a symbol.
+		n := gst.number()
+		if n == 0 || n > len(gst.str) {
+			gst.fail("invalid symbol length")
+		}
+		sym, off := gst.str[:n], gst.off
+		gst.advance(n)
+		if d, ok := gst.demangleSub(sym, off, (*gnuV2State).symbol); ok {
+			sym = d
+		}
+		gst.writeString("&")
+		gst.writeString(sym)
+		return
+	case 'b':
+		switch gst.peek() {
+		case '0':
+			gst.advance(1)
+			gst.writeString("false")
+			return
+		case '1':
+			gst.advance(1)
+			gst.writeString("true")
+			return
+		}
+		gst.fail("invalid bool value")
+	case 'c', 's', 'i', 'l', 'x', 'w':
+		neg := ""
+		if gst.peek() == 'm' {
+			gst.advance(1)
+			neg = "-"
+		}
+		var val int
+		if gst.peek() == '_' {
+			gst.advance(1)
+			val = gst.number()
+			gst.checkChar('_')
+		} else {
+			val = gst.number()
+		}
+		if kind == 'c' && neg == "" && val >= 0x20 && val < 0x7f {
+			gst.writeString("'" + string(rune(val)) + "'")
+			return
+		}
+		gst.writeString(neg + strconv.Itoa(val))
+		return
+	}
+	gst.fail("unsupported template value")
+}
+
+// argList parses a list of argument types. If nested, the list is
+// part of a function type and ends with '_'; otherwise the list
+// continues to the end of the string.
+func (gst *gnuV2State) argList(nested bool) {
+	n := 0
+	next := func() {
+		if n > 0 {
+			gst.writeString(", ")
+		}
+		n++
+	}
+	for len(gst.str) > 0 && !(nested && gst.peek() == '_') {
+		switch gst.peek() {
+		case 'e':
+			gst.advance(1)
+			next()
+			gst.writeString("...")
+		case 'T':
+			// Repeat an earlier argument type.
+			gst.advance(1)
+			t := gst.repeatedType()
+			next()
+			gst.writeString(t)
+		case 'N':
+			// Repeat an earlier argument type several times.
+			gst.advance(1)
+			r := gst.count()
+			t := gst.repeatedType()
+			if r*(len(t)+2) > gnuV2MaxOutput {
+				panic(Error{Code: ErrTooLarge, Msg: "demangled output too large", Offset: gst.off})
+			}
+			for i := 0; i < r; i++ {
+				next()
+				gst.writeString(t)
+			}
+		default:
+			next()
+			start := len(gst.buf)
+			gst.typ()
+			gst.types = append(gst.types, string(gst.buf[start:]))
+		}
+	}
+	if nested && len(gst.str) == 0 {
+		gst.fail("unterminated argument list")
+	}
+	if n == 0 {
+		gst.writeString("void")
+	}
+}
+
+// repeatedType parses the index of an earlier argument type.
+func (gst *gnuV2State) repeatedType() string {
+	n := gst.count()
+	if n >= len(gst.types) {
+		gst.fail("invalid type index")
+	}
+	return gst.types[n]
+}
+
+// gnuV2BuiltinTypes maps a character to a builtin type.
+var gnuV2BuiltinTypes = map[byte]string{
+	'v': "void",
+	'b': "bool",
+	'c': "char",
+	's': "short",
+	'i': "int",
+	'l': "long",
+	'x': "long long",
+	'f': "float",
+	'd': "double",
+	'r': "long double",
+	'w': "wchar_t",
+}
+
+// typ parses a type.
+func (gst *gnuV2State) typ() {
+	// Each declarator is a level of nesting, as is the type itself.
+	defer func(depth int) { gst.depth = depth }(gst.depth)
+	gst.enter()
+
+	// The declarator is built from the outside in, and the base
+	// type is printed in front of it. Rather than copying the
+	// declarator each time it grows, we keep the parts that go
+	// before it, innermost first, and the parts that go after it,
+	// and join them at the end.
+	var pre, post []string
+	first := func() byte {
+		if len(pre) > 0 {
+			return pre[len(pre)-1][0]
+		}
+		if len(post) > 0 {
+			return post[0][0]
+		}
+		return 0
+	}
+	wrap := func() {
+		if first() != 0 {
+			pre = append(pre, "(")
+			post = append(post, ")")
+		}
+	}
+
+	quals := ""
+	for {
+		switch c := gst.peek(); c {
+		case 'C':
+			gst.advance(1)
+			quals += " const"
+		case 'V':
+			gst.advance(1)
+			quals += " volatile"
+		case 'u':
+			gst.advance(1)
+			quals += " __restrict"
+		case 'P', 'R':
+			gst.enter()
+			gst.advance(1)
+			d := "*"
+			if c == 'R' {
+				d = "&"
+			}
+			if quals != "" {
+				d += quals[1:]
+				quals = ""
+			}
+			switch first() {
+			case 0, '(', '[':
+			default:
+				d += " "
+			}
+			pre = append(pre, d)
+		case 'A':
+			gst.enter()
+			gst.advance(1)
+			n := gst.number()
+			gst.checkChar('_')
+			wrap()
+			post = append(post, "["+strconv.Itoa(n)+"]")
+		case 'F':
+			gst.enter()
+			gst.advance(1)
+			args := gst.capture(func() { gst.argList(true) })
+			gst.checkChar('_')
+			wrap()
+			post = append(post, "("+args+")")
+		case 'M':
+			// A pointer to member. The class is followed by
+			// the member type, which for a method pointer is
+			// its qualifiers and its function type.
+			gst.enter()
+			gst.advance(1)
+			d := gst.capture(gst.className) + "::*"
+			if first() != 0 {
+				d += " "
+			}
+			pre = append(pre, d)
+			mquals := ""
+			for gst.peek() == 'C' || gst.peek() == 'V' {
+				if gst.peek() == 'C' {
+					mquals += " const"
+				} else {
+					mquals += " volatile"
+				}
+				gst.advance(1)
+			}
+			if gst.peek() == 'F' {
+				gst.advance(1)
+				args := gst.capture(func() { gst.argList(true) })
+				gst.checkChar('_')
+				pre = append(pre, "(")
+				post = append(post, ")("+args+")"+mquals)
+			} else {
+				quals += mquals
+			}
+		case 'G':
+			// An explicit marker for a class name.
+			gst.advance(1)
+		default:
+			gst.baseType()
+			gst.writeString(quals)
+			if first() != 0 {
+				gst.writeString(" ")
+				for i := len(pre) - 1; i >= 0; i-- {
+					gst.writeString(pre[i])
+				}
+				for _, s := range post {
+					gst.writeString(s)
+				}
+			}
+			return
+		}
+	}
+}
+
+// baseType parses a builtin type or a class name.
+func (gst *gnuV2State) baseType() {
+	prefix := ""
+	for {
+		switch gst.peek() {
+		case 'U':
+			prefix += "unsigned "
+		case 'S':
+			prefix += "signed "
+		case 'J':
+			prefix += "__complex__ "
+		default:
+			if isGNUv2ClassStart(gst.peek()) {
+				if prefix != "" {
+					gst.fail("modifier on class type")
+				}
+				gst.className()
+				return
+			}
+			s, ok := gnuV2BuiltinTypes[gst.peek()]
+			if !ok {
+				gst.fail("unrecognized type")
+			}
+			gst.advance(1)
+			gst.writeString(prefix + s)
+			return
+		}
+		gst.advance(1)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGNUv2(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"foo__1Aii", "A::foo(int, int)"},
+		{"foo__Fii", "foo(int, int)"},
+		{"foo__Fv", "foo(void)"},
+		{"foo__C1Ai", "A::foo(int) const"},
+		{"foo__S3Bar", "Bar::foo(void) static"},
+		{"foo___1A", "A::foo_(void)"},
+		{"__1Ai", "A::A(int)"},
+		{"_$_1A", "A::~A(void)"},
+		{"_._1A", "A::~A(void)"},
+		{"foo__Q22ns1Ai", "ns::A::foo(int)"},
+		{"__ad__FRC6SubDir", "operator&(SubDir const &)"},
+		{"__eq__FRC6BitSetT0", "operator==(BitSet const &, BitSet const &)"},
+		{"__ls__FR7ostreamPFR3ios_R3ios", "operator<<(ostream &, ios &(*)(ios &))"},
+		{"__pl__1ARC1A", "A::operator+(A const &)"},
+		{"__nw__FUi", "operator new(unsigned int)"},
+		{"__vc__3fooi", "foo::operator[](int)"},
+		{"__cl__3fooi", "foo::operator()(int)"},
+		{"__opi__3foo", "foo::operator int(void)"},
+		{"__opPc__3foo", "foo::operator char *(void)"},
+		{"foo__FiN30", "foo(int, int, int, int)"},
+		{"foo__FPFPc_v", "foo(void (*)(char *))"},
+		{"foo__FPCc", "foo(char const *)"},
+		{"foo__FCPc", "foo(char *const)"},
+		{"foo__FPCPc", "foo(char *const *)"},
+		{"foo__FM3fooi", "foo(int foo::*)"},
+		{"foo__FA10_i", "foo(int [10])"},
+		{"foo__FPA10_i", "foo(int (*)[10])"},
+		{"foo__FScUcUl", "foo(signed char, unsigned char, unsigned long)"},
+		{"foo__Fie", "foo(int, ...)"},
+		{"foo__t4List1Zii", "List<int>::foo(int)"},
+		{"__t4List1Zi", "List<int>::List(void)"},
+		{"_$_t4List1Zi", "List<int>::~List(void)"},
+		{"bar__t3Foo1Zt3Foo1Zii", "Foo<Foo<int> >::bar(int)"},
+		{"foo__t3Foo2i_10_i5i", "Foo<10, 5>::foo(int)"},
+		{"_GLOBAL_$I$foo", "global constructors keyed to foo"},
+		{"_GLOBAL_$D$foo__1Ai", "global destructors keyed to A::foo(int)"},
+		{"_vt$3foo", "foo virtual table"},
+		{"_vt$3foo$3bar", "foo::bar virtual table"},
+		{"_3foo$bar", "foo::bar"},
+		{"__thunk_4_foo__1Ai", "virtual function thunk (delta:-4) for A::foo(int)"},
+		{"__ti3foo", "foo type_info node"},
+		{"__tf3foo", "foo type_info function"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, GNUv2); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got := Filter(test.input, GNUv2); got != test.want {
+			t.Errorf("filtering %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got := Filter(test.input); got != test.input {
+			t.Errorf("filtering %s without GNUv2: got %s", test.input, got)
+		}
+	}
+}
+
+func TestGNUv2Options(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"foo__C1Ai", []Option{GNUv2, NoParams}, "A::foo"},
+		{"foo__t4List1Zii", []Option{GNUv2, NoTemplateParams}, "List::foo(int)"},
+		{"bar__t3Foo1Zt3Foo1Zii", []Option{GNUv2, NoAngleSpace}, "Foo<Foo<int>>::bar(int)"},
+		{"_Z3fooi", []Option{GNUv2}, "foo(int)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestGNUv2Failure(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"_",
+		"a",
+		"main",
+		"printf",
+		"foo__",
+		"foo__1AFi",
+		"foo__9A",
+		"foo__FT0",
+		"foo__FPFi",
+		"_GLOBAL_$X$foo",
+	} {
+		if got, err := ToString(input, GNUv2); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+		if got := Filter(input, GNUv2); got != input {
+			t.Errorf("filtering %s: got %s", input, got)
+		}
+	}
+}
+
+func TestGNUv2Depth(t *testing.T) {
+	// nestedSymbols returns a name whose template argument is
+	// the address of a name whose template argument is ...
+	nestedSymbols := func(n int) string {
+		s := "g__Fi"
+		for i := 0; i < n; i++ {
+			s = "f__t1A1Pi" + strconv.Itoa(len(s)) + s + "i"
+		}
+		return s
+	}
+
+	var tests = []struct {
+		input   string
+		options []Option
+		ok      bool
+	}{
+		{"f__" + strings.Repeat("t1A1Z", 100) + "i", nil, true},
+		{"f__" + strings.Repeat("t1A1Z", 200000) + "i", nil, false},
+		{"f__F" + strings.Repeat("P", 100) + "i", nil, true},
+		{"f__F" + strings.Repeat("P", 200000) + "i", nil, false},
+		{"f__FPPi", []Option{MaxDepth(4)}, true},
+		{"f__FPPPPi", []Option{MaxDepth(4)}, false},
+		{"_GLOBAL_$I$" + strings.Repeat("_GLOBAL_$I$", 2000) + "f__Fi", nil, false},
+		{nestedSymbols(10), nil, true},
+		{nestedSymbols(2000), nil, false},
+	}
+	for _, test := range tests {
+		// ToString reports a failure of GNUv2 as the failure
+		// to demangle the name as C++, so call gnuV2ToString
+		// to see the error.
+		_, err := gnuV2ToString(test.input, test.options)
+		if test.ok {
+			if err != nil {
+				t.Errorf("%.40s...: unexpected error %v", test.input, err)
+			}
+			continue
+		}
+		if de, ok := err.(Error); !ok || de.Code != ErrTooLarge {
+			t.Errorf("%.40s...: got error %v, want ErrTooLarge", test.input, err)
+		}
+	}
+}
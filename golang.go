@@ -0,0 +1,167 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// This file normalizes the symbol names used by the Go toolchain.
+// Go does not mangle names: a function is named by its package path
+// and its name, as in "main.(*T).Method". However, the compiler and
+// linker add suffixes for wrapper functions, use special prefixes
+// for the functions and data that describe types, and name the
+// instantiations of generic functions using shape types, as in
+// "main.F[go.shape.int]".
+
+// goShapePrefix is the package path of shape types.
+const goShapePrefix = "go.shape."
+
+// goSpecialPrefixes maps the prefixes of compiler generated symbols
+// to a description. The Go 1.20 toolchain changed the first '.' in
+// these names to ':'.
+var goSpecialPrefixes = []struct {
+	prefix string
+	desc   string
+}{
+	{"type:.eq.", "equality function for "},
+	{"type..eq.", "equality function for "},
+	{"type:.hash.", "hash function for "},
+	{"type..hash.", "hash function for "},
+}
+
+// goSuffixes are suffixes added to the name of a function for the
+// wrappers that the compiler and linker generate. A '#' matches a
+// sequence of digits.
+var goSuffixes = []string{
+	"-fm",          // method value wrapper
+	"-tramp#",      // linker trampoline
+	".abi0",        // ABI wrapper for an assembly function
+	".abiinternal", // ABI wrapper for a Go function
+}
+
+// goToString normalizes a Go symbol name.
+// It returns ErrNotMangledName if the name is not a Go symbol name
+// that needs normalizing.
+func goToString(name string, options []Option) (string, error) {
+	max := 0
+	for _, o := range options {
+		if isMaxLength(o) {
+			max = maxLength(o)
+		}
+	}
+
+	s := name
+	changed := false
+	for {
+		t := goStripSuffix(s)
+		if t == s {
+			break
+		}
+		s = t
+		changed = true
+	}
+
+	prefix := ""
+	for _, sp := range goSpecialPrefixes {
+		if strings.HasPrefix(s, sp.prefix) {
+			prefix = sp.desc
+			s = s[len(sp.prefix):]
+			break
+		}
+	}
+	if prefix == "" && (strings.HasPrefix(s, "type:") || strings.HasPrefix(s, "type.")) && len(s) > 5 && s[5] != '.' {
+		prefix = "type descriptor for "
+		s = s[5:]
+	}
+
+	if strings.Contains(s, goShapePrefix) {
+		s = goShapes(s)
+		changed = true
+	}
+
+	switch {
+	case s == "":
+		return "", ErrNotMangledName
+	case prefix != "":
+		s = prefix + s
+	case !changed || !strings.Contains(s, "."):
+		// Every Go function name includes a package path.
+		return "", ErrNotMangledName
+	}
+
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// goStripSuffix removes one of goSuffixes from the end of s.
+// If there is no such suffix it returns s.
+func goStripSuffix(s string) string {
+	for _, suffix := range goSuffixes {
+		if strings.HasSuffix(suffix, "#") {
+			t := s
+			for len(t) > 0 && isDigit(t[len(t)-1]) {
+				t = t[:len(t)-1]
+			}
+			if t != s && strings.HasSuffix(t, suffix[:len(suffix)-1]) {
+				return t[:len(t)-len(suffix)+1]
+			}
+		} else if strings.HasSuffix(s, suffix) {
+			return s[:len(s)-len(suffix)]
+		}
+	}
+	return s
+}
+
+// goShapes removes the package path from shape types in s.
+// Go 1.18 also added a suffix "_N" to each shape type, giving its
+// index in the list of type arguments; we remove that too.
+func goShapes(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, goShapePrefix)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i+len(goShapePrefix):]
+		end := goTypeEnd(s)
+		t := s[:end]
+		j := len(t)
+		for j > 0 && isDigit(t[j-1]) {
+			j--
+		}
+		if j > 1 && j < len(t) && t[j-1] == '_' {
+			t = t[:j-1]
+		}
+		b.WriteString(t)
+		s = s[end:]
+	}
+}
+
+// goTypeEnd returns the length of the type at the start of s, which
+// is ended by a ',' or ']' that is not nested inside brackets.
+func goTypeEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
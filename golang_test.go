@@ -0,0 +1,85 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestGoSymbols(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"main.(*T).Method-fm", "main.(*T).Method"},
+		{"net/http.(*Server).Serve-fm", "net/http.(*Server).Serve"},
+		{"runtime.memmove.abi0", "runtime.memmove"},
+		{"runtime.morestack.abiinternal", "runtime.morestack"},
+		{"runtime.mallocgc-tramp0", "runtime.mallocgc"},
+		{"runtime.mallocgc-tramp12", "runtime.mallocgc"},
+		{"type:.eq.main.T", "equality function for main.T"},
+		{"type..eq.main.T", "equality function for main.T"},
+		{"type:.eq.[2]interface {}", "equality function for [2]interface {}"},
+		{"type:.hash.main.T", "hash function for main.T"},
+		{"type..hash.main.T", "hash function for main.T"},
+		{"type:*main.T", "type descriptor for *main.T"},
+		{"type:main.Pair[go.shape.int,go.shape.string]", "type descriptor for main.Pair[int,string]"},
+		{"main.F[go.shape.int]", "main.F[int]"},
+		{"main.F[go.shape.int_0]", "main.F[int]"},
+		{"main.F[go.shape.*uint8,go.shape.string_1]", "main.F[*uint8,string]"},
+		{"main.F[go.shape.[]int]", "main.F[[]int]"},
+		{"main.F[go.shape.map[string]int]", "main.F[map[string]int]"},
+		{"main.F[go.shape.struct { X int; Y int }]", "main.F[struct { X int; Y int }]"},
+		{"main.F[go.shape.func(int) string]", "main.F[func(int) string]"},
+		{"main.(*List[go.shape.int]).Push", "main.(*List[int]).Push"},
+		{"main.(*List[go.shape.int]).Push-fm", "main.(*List[int]).Push"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, GoSymbols); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got := Filter(test.input); got != test.input {
+			t.Errorf("filtering %s without GoSymbols: got %s", test.input, got)
+		}
+	}
+}
+
+func TestGoSymbolsOptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"main.F[go.shape.int]", []Option{GoSymbols, MaxLength(3)}, "main.F[i"},
+		{"_Z3fooi", []Option{GoSymbols}, "foo(int)"},
+		{"__m_MOD_p", []Option{GoSymbols}, "m::p"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestGoSymbolsFailure(t *testing.T) {
+	for _, input := range []string{
+		"main.main",
+		"runtime.gcBgMarkWorker.func1",
+		"type:.namedata.*main.T-",
+		"foo-fm",
+		"-fm",
+		"type:",
+		"type:.eq.",
+		"main.f-tramp",
+	} {
+		if got, err := ToString(input, GoSymbols); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"hash/fnv"
+	"io"
+	"strconv"
+)
+
+// ToHash demangles a symbol name and returns a short hash of the
+// demangled name, as 16 hexadecimal digits. The hash is the 64-bit
+// FNV-1a hash of the string that ToString returns with the same
+// options, so it only changes if that string changes. It is
+// intended for use as a key that identifies a symbol.
+//
+// For C++ names the hash is computed while printing the demangled
+// name, without building the whole string, which for some names
+// is very long.
+func ToHash(name string, options ...Option) (string, error) {
+	h, err := toHash(name, options)
+	if err != nil {
+		return "", err
+	}
+	s := strconv.FormatUint(h, 16)
+	for len(s) < 16 {
+		s = "0" + s
+	}
+	return s, nil
+}
+
+// toHash returns the hash described at ToHash as a number.
+func toHash(name string, options []Option) (uint64, error) {
+	h := fnv.New64a()
+	if err := ToWriter(h, name, options...); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// stringHash returns the hash of a string.
+func stringHash(s string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, s)
+	return h.Sum64()
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestToHash(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+	}{
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", nil},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []Option{NoParams}},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []Option{MaxLengthBytes(20)}},
+		{"_ZNKR1A1fEv", []Option{CanonicalSpacing}},
+		{"_ZN1A1fEv", []Option{ScopeOnly}},
+		{"_Z1fv", []Option{ScopeOnly}},
+		{"_RNvCs1234_4main4main", nil},
+		{"?foo@bar@@YAXXZ", nil},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE", nil},
+	}
+	for _, test := range tests {
+		s, err := ToString(test.input, test.options...)
+		if err != nil {
+			t.Errorf("ToString(%s): unexpected error %v", test.input, err)
+			continue
+		}
+		want := stringHash(s)
+		got, err := toHash(test.input, test.options)
+		if err != nil {
+			t.Errorf("toHash(%s): unexpected error %v", test.input, err)
+		} else if got != want {
+			t.Errorf("toHash(%s, %v) = %x, want %x", test.input, test.options, got, want)
+		}
+		h, err := ToHash(test.input, test.options...)
+		if err != nil {
+			t.Errorf("ToHash(%s): unexpected error %v", test.input, err)
+		} else if len(h) != 16 {
+			t.Errorf("ToHash(%s) = %q, want 16 digits", test.input, h)
+		}
+	}
+
+	if _, err := ToHash("_Z"); err == nil {
+		t.Error("ToHash(_Z) succeeded unexpectedly")
+	}
+}
+
+// TestToHashExpected checks that hashing while printing matches the
+// hash of the whole string for the long names in the test data.
+func TestToHashExpected(t *testing.T) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "_Z") {
+			continue
+		}
+		for _, options := range [][]Option{nil, {LLVMStyle, CanonicalSpacing}} {
+			s, err := ToString(line, options...)
+			if err != nil {
+				continue
+			}
+			got, err := toHash(line, options)
+			if err != nil {
+				t.Errorf("toHash(%s): unexpected error %v", line, err)
+			} else if want := stringHash(s); got != want {
+				t.Errorf("toHash(%s, %v) = %x, want %x", line, options, got, want)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// Identifiers demangles a C++ or Rust symbol name and returns the
+// identifiers that appear in it, such as the names of namespaces,
+// classes, functions, and the types used as parameters and template
+// arguments, in the order in which they first appear, without
+// duplicates. For "std::vector<foo::Bar>::push_back(foo::Bar const&)"
+// it returns "std", "vector", "foo", "Bar", and "push_back". This is
+// intended for code search indexers, which key symbols by the
+// identifiers that they contain. Builtin types, operators, ABI tags,
+// and vendor qualifiers are not identifiers.
+// If the name does not appear to be a C++ or Rust symbol name at
+// all, the error will be ErrNotMangledName.
+func Identifiers(name string, options ...Option) ([]string, error) {
+	a, err := ToAST(name, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if isSourceName(id) && !seen[id] {
+			seen[id] = true
+			ret = append(ret, id)
+		}
+	}
+	visited := make(map[AST]bool)
+	var walk func(AST) bool
+	walk = func(a AST) bool {
+		if visited[a] {
+			return false
+		}
+		visited[a] = true
+		switch a := a.(type) {
+		case *Name:
+			add(a.Name)
+		case *TaggedName:
+			a.Name.Traverse(walk)
+			return false
+		case *VendorQualifier:
+			a.Type.Traverse(walk)
+			return false
+		case *RustCrate:
+			add(a.Name)
+		case *RustNested:
+			a.Prefix.Traverse(walk)
+			add(a.Name)
+			return false
+		case *RustAssocBinding:
+			add(a.Name)
+		}
+		return true
+	}
+	a.Traverse(walk)
+	return ret, nil
+}
+
+// isSourceName reports whether s, the name of a Name node, is an
+// identifier. The demangler also uses Name nodes for things such as
+// array dimensions and "(anonymous namespace)".
+func isSourceName(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isLower(c) && !isUpper(c) && !isDigit(c) && c != '_' && c != '$' && c < 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdentifiers(t *testing.T) {
+	var tests = []struct {
+		name string
+		want []string
+	}{
+		{"_Z1fv", []string{"f"}},
+		{"_Z1fi", []string{"f"}},
+		{"_ZN2ns1A1fERKS0_", []string{"ns", "A", "f"}},
+		{"_ZNSt6vectorIN3foo3BarESaIS1_EE9push_backERKS1_", []string{"std", "vector", "foo", "Bar", "allocator", "push_back"}},
+		{"_ZN12_GLOBAL__N_11gEv", []string{"g"}},
+		{"_ZN1AB5cxx111hEv", []string{"A", "h"}},
+		{"_Z3fooPU8__vector4BaseA10_i", []string{"foo", "Base"}},
+		{"_ZplRK1XS1_", []string{"X"}},
+		{"_ZTV1A", []string{"A"}},
+		{"_ZZ1fvE5local", []string{"f", "local"}},
+		{"_RNvNtCs1234_7mycrate3foo3bar", []string{"mycrate", "foo", "bar"}},
+		{"_RNCNvC1a4main0B3_", []string{"a", "main"}},
+		{"_ZW3foo1fv", []string{"foo", "f"}},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", []string{"Outer", "Inner", "FOO", "Fn", "X"}},
+	}
+	for _, test := range tests {
+		got, err := Identifiers(test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if _, err := Identifiers("_Z1"); err == nil {
+		t.Error("invalid name: got nil, want error")
+	}
+	if _, err := Identifiers("main"); err != ErrNotMangledName {
+		t.Errorf("unmangled name: got %v, want ErrNotMangledName", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// IsMangled reports whether name appears to be a mangled symbol name
+// that ToString can demangle. It looks at the prefix of the name and
+// at the first few characters after the prefix, but does not
+// demangle the name and does not allocate memory, so it is much
+// faster than ToString at rejecting a name that is not mangled, such
+// as the name of a C function. If IsMangled returns false, ToString
+// returns an error for the name, unless one of the options GNUv2,
+// GoSymbols, Fortran, or Types is used. If IsMangled returns true,
+// ToString may still return an error.
+func IsMangled(name string) bool {
+	for s := SchemeItanium; s <= SchemeObjC; s++ {
+		if IsMangledAs(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMangledAs is like IsMangled, but reports whether name appears to
+// be mangled using a specific scheme. For SchemeFortran it checks
+// for a module procedure name; external procedure names, which
+// ToString only demangles with the Fortran option, look like C names.
+func IsMangledAs(name string, scheme Scheme) bool {
+	switch scheme {
+	case SchemeItanium:
+		return isItaniumName(name)
+	case SchemeRustV0:
+		return len(name) > 2 && strings.HasPrefix(name, "_R") && isUpper(name[2])
+	case SchemeRustLegacy:
+		_, ok := oldRustName(name)
+		return ok
+	case SchemeMSVC:
+		return len(name) > 1 && name[0] == '?'
+	case SchemeSwift:
+		n := swiftPrefix(name)
+		return n > 0 && n < len(name)
+	case SchemeD:
+		return len(name) > 2 && strings.HasPrefix(name, "_D")
+	case SchemeBorland:
+		return len(name) > 1 && name[0] == '@'
+	case SchemeWatcom:
+		return len(name) > 2 && strings.HasPrefix(name, "W?")
+	case SchemeFortran:
+		_, _, ok := fortranModuleProc(name)
+		return ok
+	case SchemeObjC:
+		return len(name) >= 6 && (name[0] == '-' || name[0] == '+') && name[1] == '[' && name[len(name)-1] == ']'
+	default:
+		return false
+	}
+}
+
+// mayDemangle reports whether ToString might demangle name with the
+// options. It is a quick check for Filter, which is often called for
+// names that are not mangled.
+func mayDemangle(name string, options []Option) bool {
+	for _, o := range options {
+		if o == GNUv2 || o == GoSymbols || o == Fortran || o == Types {
+			return true
+		}
+	}
+	return IsMangled(name)
+}
+
+// isItaniumName reports whether name appears to be a C++ name using
+// the Itanium ABI, in any of the forms that ToAST accepts.
+func isItaniumName(name string) bool {
+	const global = "_GLOBAL_"
+	switch {
+	case strings.HasPrefix(name, "_Z"):
+		return isEncodingStart(name[2:])
+	case strings.HasPrefix(name, "___Z"):
+		return isEncodingStart(name[4:]) && strings.Contains(name, "_block_invoke")
+	case strings.HasPrefix(name, global):
+		rest := name[len(global):]
+		return len(rest) >= 4 && (rest[0] == '.' || rest[0] == '_' || rest[0] == '$') && (rest[1] == 'I' || rest[1] == 'D') && rest[2] == '_'
+	case strings.HasPrefix(name, cudaStubPrefix+"_Z"):
+		return isEncodingStart(name[len(cudaStubPrefix)+2:])
+	case strings.HasPrefix(name, "$_Z"):
+		return isEncodingStart(name[3:])
+	default:
+		return false
+	}
+}
+
+// isEncodingStart reports whether s, which follows the _Z prefix,
+// could start an <encoding>. The shortest encoding, such as "1f" or
+// the operator "nw", is two characters.
+func isEncodingStart(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	c := s[0]
+	switch {
+	case isDigit(c):
+		// A <source-name>: the length must be in range.
+		n := 0
+		i := 0
+		for i < len(s) && isDigit(s[i]) {
+			n = n*10 + int(s[i]-'0')
+			if n > len(s) {
+				return false
+			}
+			i++
+		}
+		return n > 0 && i+n <= len(s)
+	case isLower(c):
+		// An operator name.
+		return true
+	default:
+		return strings.IndexByte("DFGLNSTUWZ", c) >= 0
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsMangled(t *testing.T) {
+	var tests = []struct {
+		name   string
+		want   bool
+		scheme Scheme
+	}{
+		{"_ZN1A1fEv", true, SchemeItanium},
+		{"_Z1fv", true, SchemeItanium},
+		{"_ZL3foov", true, SchemeItanium},
+		{"_ZTV1A", true, SchemeItanium},
+		{"_Znwm", true, SchemeItanium},
+		{"___Z1fv_block_invoke", true, SchemeItanium},
+		{"_GLOBAL__I__Z1fv", true, SchemeItanium},
+		{"_RNvC1a4main", true, SchemeRustV0},
+		{"_ZN3foo3bar17h05af221e174051e9E", true, SchemeRustLegacy},
+		{"?x@@3HA", true, SchemeMSVC},
+		{"$s4main1fyyF", true, SchemeSwift},
+		{"_D4test1fFZv", true, SchemeD},
+		{"__m_MOD_p", true, SchemeFortran},
+		{"-[Foo bar:]", true, SchemeObjC},
+		{"main", false, SchemeUnknown},
+		{"memcpy", false, SchemeUnknown},
+		{"_start", false, SchemeUnknown},
+		{"_Z", false, SchemeUnknown},
+		{"_Z9f", false, SchemeUnknown},
+		{"_Zx", false, SchemeUnknown},
+		{"_Z$", false, SchemeUnknown},
+		{"_R", false, SchemeUnknown},
+		{"_GLOBAL_OFFSET_TABLE_", false, SchemeUnknown},
+		{"_GLOBAL__sub_I_foo.cc", false, SchemeUnknown},
+		{"___Z1fv", false, SchemeUnknown},
+		{"", false, SchemeUnknown},
+	}
+	for _, test := range tests {
+		if got := IsMangled(test.name); got != test.want {
+			t.Errorf("IsMangled(%q) = %t, want %t", test.name, got, test.want)
+		}
+		if test.want && !IsMangledAs(test.name, test.scheme) {
+			t.Errorf("IsMangledAs(%q, %v) = false, want true", test.name, test.scheme)
+		}
+		if !test.want {
+			if _, err := ToString(test.name); err == nil {
+				t.Errorf("ToString(%q) succeeded for a name that is not mangled", test.name)
+			}
+		}
+	}
+
+	if n := testing.AllocsPerRun(100, func() { IsMangled("__libc_start_main") }); n != 0 {
+		t.Errorf("IsMangled allocated %v times, want 0", n)
+	}
+}
+
+// TestIsMangledToString checks that ToString fails for every name
+// that IsMangled rejects, using the names in the test data and some
+// random changes to them.
+func TestIsMangledToString(t *testing.T) {
+	var names []string
+	for _, file := range []string{filename, rustFilename} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "--") {
+				continue
+			}
+			names = append(names, line)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	const chars = "_$?@.0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	for _, name := range names {
+		check := []string{name}
+		for i := 0; i < 5 && len(name) > 0; i++ {
+			b := []byte(name)
+			n := 1 + r.Intn(4)
+			if n > len(b) {
+				n = len(b)
+			}
+			for j := 0; j < n; j++ {
+				b[r.Intn(len(b))] = chars[r.Intn(len(chars))]
+			}
+			check = append(check, string(b), string(b[:r.Intn(len(b)+1)]))
+		}
+		for _, c := range check {
+			if IsMangled(c) {
+				continue
+			}
+			if s, err := ToString(c); err == nil {
+				t.Errorf("IsMangled(%q) = false, but ToString returned %q", c, s)
+			}
+		}
+	}
+}
+
+func TestFilterAllocs(t *testing.T) {
+	names := []string{
+		"main",
+		"memcpy",
+		"__libc_start_main",
+		"_start",
+		"_Z",
+		"_ZN",
+		"_Z9f",
+		"_R",
+		"_GLOBAL_OFFSET_TABLE_",
+		"",
+	}
+	var d Demangler
+	for _, name := range names {
+		if got := Filter(name); got != name {
+			t.Errorf("Filter(%q) = %q", name, got)
+		}
+		if n := testing.AllocsPerRun(100, func() { Filter(name) }); n != 0 {
+			t.Errorf("Filter(%q) allocated %v times, want 0", name, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { Filter(name, NoParams, LLVMStyle) }); n != 0 {
+			t.Errorf("Filter(%q) with options allocated %v times, want 0", name, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { d.Filter(name) }); n != 0 {
+			t.Errorf("Demangler.Filter(%q) allocated %v times, want 0", name, n)
+		}
+	}
+}
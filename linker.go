@@ -0,0 +1,254 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// LinkerDiagnosticKind is the kind of problem reported by a linker
+// diagnostic.
+type LinkerDiagnosticKind int
+
+const (
+	// UndefinedSymbol is a reference to a symbol that is not defined.
+	UndefinedSymbol LinkerDiagnosticKind = iota
+
+	// DuplicateSymbol is a symbol that is defined more than once.
+	DuplicateSymbol
+)
+
+// String returns a description of the diagnostic kind.
+func (k LinkerDiagnosticKind) String() string {
+	switch k {
+	case UndefinedSymbol:
+		return "undefined symbol"
+	case DuplicateSymbol:
+		return "duplicate symbol"
+	default:
+		return "unknown linker diagnostic"
+	}
+}
+
+// LinkerDiagnostic is a symbol found in a linker error message.
+type LinkerDiagnostic struct {
+	Kind LinkerDiagnosticKind
+
+	// Line is the line of the message in which the symbol appears.
+	Line string
+
+	// Symbol is the symbol as it appears in the message.
+	// It may be mangled or not, depending on the linker.
+	Symbol string
+
+	// Offset is the byte offset of Symbol within Line.
+	Offset int
+
+	// Demangled is the demangled symbol. If Symbol could not be
+	// demangled, this is the same as Symbol, unless the linker
+	// provided its own demangled form.
+	Demangled string
+}
+
+// ParseLinkerDiagnostics looks for undefined and duplicate symbol
+// errors in the output of a linker, and returns the symbols that it
+// finds, demangled using the options.
+//
+// The formats recognized are those used by the GNU linker, gold,
+// lld (including lld-link), the macOS linker, and the Microsoft linker.
+// Lines that are not recognized are ignored.
+func ParseLinkerDiagnostics(text string, options ...Option) []LinkerDiagnostic {
+	var ret []LinkerDiagnostic
+
+	// inUndefinedBlock is set after the macOS linker prints
+	// "Undefined symbols for architecture", which is followed by
+	// indented lines listing the symbols.
+	inUndefinedBlock := false
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if inUndefinedBlock {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				trimmed := strings.TrimLeft(line, " \t")
+				if strings.HasPrefix(trimmed, `"`) {
+					if sym, off, ok := quotedSymbol(line, len(line)-len(trimmed)); ok {
+						ret = append(ret, makeLinkerDiagnostic(UndefinedSymbol, line, sym, off, "", options))
+					}
+				}
+				continue
+			}
+			inUndefinedBlock = false
+		}
+
+		if strings.HasPrefix(line, "Undefined symbols for architecture") {
+			inUndefinedBlock = true
+			continue
+		}
+
+		if d, ok := parseLinkerLine(line, options); ok {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// linkerPatterns are the fixed strings that introduce a symbol in a
+// single line linker message.
+var linkerPatterns = []struct {
+	text   string
+	kind   LinkerDiagnosticKind
+	quoted bool // whether the symbol is quoted
+}{
+	// GNU ld and gold.
+	{"undefined reference to ", UndefinedSymbol, true},
+	{"multiple definition of ", DuplicateSymbol, true},
+
+	// lld.
+	{"undefined symbol: ", UndefinedSymbol, false},
+	{"duplicate symbol: ", DuplicateSymbol, false},
+
+	// macOS linker.
+	{"duplicate symbol ", DuplicateSymbol, true},
+}
+
+// parseLinkerLine looks for a symbol in a single line of linker output.
+func parseLinkerLine(line string, options []Option) (LinkerDiagnostic, bool) {
+	if d, ok := parseMSVCLinkerLine(line, options); ok {
+		return d, true
+	}
+
+	for _, p := range linkerPatterns {
+		i := strings.Index(line, p.text)
+		if i < 0 {
+			continue
+		}
+		start := i + len(p.text)
+		if p.quoted {
+			if sym, off, ok := quotedSymbol(line, start); ok {
+				return makeLinkerDiagnostic(p.kind, line, sym, off, "", options), true
+			}
+			continue
+		}
+		sym := strings.TrimSpace(line[start:])
+		if sym == "" {
+			continue
+		}
+		off := start + strings.Index(line[start:], sym)
+		return makeLinkerDiagnostic(p.kind, line, sym, off, "", options), true
+	}
+	return LinkerDiagnostic{}, false
+}
+
+// parseMSVCLinkerLine parses the diagnostics of the Microsoft linker,
+// which look like
+//
+//	error LNK2019: unresolved external symbol "void __cdecl f(void)" (?f@@YAXXZ) referenced in function main
+//	error LNK2005: "void __cdecl f(void)" (?f@@YAXXZ) already defined in a.obj
+func parseMSVCLinkerLine(line string, options []Option) (LinkerDiagnostic, bool) {
+	var kind LinkerDiagnosticKind
+	var start int
+	if i := strings.Index(line, "unresolved external symbol "); i >= 0 {
+		kind = UndefinedSymbol
+		start = i + len("unresolved external symbol ")
+	} else if strings.Contains(line, "already defined in") {
+		i := strings.Index(line, "LNK2005: ")
+		if i < 0 {
+			i = strings.Index(line, "LNK4006: ")
+		}
+		if i < 0 {
+			return LinkerDiagnostic{}, false
+		}
+		kind = DuplicateSymbol
+		start = i + len("LNK2005: ")
+	} else {
+		return LinkerDiagnostic{}, false
+	}
+
+	// The symbol may be preceded by the linker's demangled form
+	// in double quotes.
+	display := ""
+	rest := start
+	if strings.HasPrefix(line[start:], `"`) {
+		end := strings.IndexByte(line[start+1:], '"')
+		if end < 0 {
+			return LinkerDiagnostic{}, false
+		}
+		display = line[start+1 : start+1+end]
+		rest = start + end + 2
+	}
+
+	if strings.HasPrefix(line[rest:], " (") {
+		end := strings.IndexByte(line[rest+2:], ')')
+		if end > 0 {
+			off := rest + 2
+			sym := line[off : off+end]
+			return makeLinkerDiagnostic(kind, line, sym, off, display, options), true
+		}
+	}
+
+	if display != "" {
+		return makeLinkerDiagnostic(kind, line, display, start+1, display, options), true
+	}
+
+	sym := line[start:]
+	if i := strings.IndexByte(sym, ' '); i >= 0 {
+		sym = sym[:i]
+	}
+	if sym == "" {
+		return LinkerDiagnostic{}, false
+	}
+	return makeLinkerDiagnostic(kind, line, sym, start, "", options), true
+}
+
+// quotedSymbol returns the quoted symbol starting at offset start in
+// line. The linkers use `sym', 'sym', "sym", and ‘sym’.
+// It returns the symbol and its offset in line.
+func quotedSymbol(line string, start int) (string, int, bool) {
+	s := line[start:]
+	var close string
+	switch {
+	case strings.HasPrefix(s, "`"), strings.HasPrefix(s, "'"):
+		close = "'"
+		s = s[1:]
+		start++
+	case strings.HasPrefix(s, `"`):
+		close = `"`
+		s = s[1:]
+		start++
+	case strings.HasPrefix(s, "‘"):
+		close = "’"
+		s = s[len("‘"):]
+		start += len("‘")
+	default:
+		return "", 0, false
+	}
+	end := strings.LastIndex(s, close)
+	if end <= 0 {
+		return "", 0, false
+	}
+	return s[:end], start, true
+}
+
+// makeLinkerDiagnostic builds a LinkerDiagnostic, demangling the symbol.
+// If display is not empty, it is the demangled form provided by the
+// linker, used if we can't demangle the symbol ourselves.
+func makeLinkerDiagnostic(kind LinkerDiagnosticKind, line, sym string, off int, display string, options []Option) LinkerDiagnostic {
+	demangled := Filter(sym, options...)
+	if demangled == sym && strings.HasPrefix(sym, "_") {
+		// The macOS linker adds an extra leading underscore.
+		if d := Filter(sym[1:], options...); d != sym[1:] {
+			demangled = d
+		}
+	}
+	if demangled == sym && display != "" {
+		demangled = display
+	}
+	return LinkerDiagnostic{
+		Kind:      kind,
+		Line:      line,
+		Symbol:    sym,
+		Offset:    off,
+		Demangled: demangled,
+	}
+}
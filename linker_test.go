@@ -0,0 +1,83 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestParseLinkerDiagnostics(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  []LinkerDiagnostic
+	}{
+		{
+			"/usr/bin/ld: a.o: in function `main':\na.cc:(.text+0x5): undefined reference to `_ZN1A1fEv'\ncollect2: error: ld returned 1 exit status",
+			[]LinkerDiagnostic{
+				{Kind: UndefinedSymbol, Line: "a.cc:(.text+0x5): undefined reference to `_ZN1A1fEv'", Symbol: "_ZN1A1fEv", Offset: 42, Demangled: "A::f()"},
+			},
+		},
+		{
+			"b.o:b.cc:(.text+0x0): multiple definition of `_Z1fi'; a.o:a.cc:(.text+0x0): first defined here",
+			[]LinkerDiagnostic{
+				{Kind: DuplicateSymbol, Line: "b.o:b.cc:(.text+0x0): multiple definition of `_Z1fi'; a.o:a.cc:(.text+0x0): first defined here", Symbol: "_Z1fi", Offset: 46, Demangled: "f(int)"},
+			},
+		},
+		{
+			"ld.lld: error: undefined symbol: _ZN1A1fEv\n>>> referenced by a.cc:3",
+			[]LinkerDiagnostic{
+				{Kind: UndefinedSymbol, Line: "ld.lld: error: undefined symbol: _ZN1A1fEv", Symbol: "_ZN1A1fEv", Offset: 33, Demangled: "A::f()"},
+			},
+		},
+		{
+			"ld.lld: error: duplicate symbol: f(int)",
+			[]LinkerDiagnostic{
+				{Kind: DuplicateSymbol, Line: "ld.lld: error: duplicate symbol: f(int)", Symbol: "f(int)", Offset: 33, Demangled: "f(int)"},
+			},
+		},
+		{
+			"Undefined symbols for architecture arm64:\n  \"__ZN1A1fEv\", referenced from:\n      _main in a.o\nld: symbol(s) not found for architecture arm64",
+			[]LinkerDiagnostic{
+				{Kind: UndefinedSymbol, Line: "  \"__ZN1A1fEv\", referenced from:", Symbol: "__ZN1A1fEv", Offset: 3, Demangled: "A::f()"},
+			},
+		},
+		{
+			"duplicate symbol '__Z1fi' in:",
+			[]LinkerDiagnostic{
+				{Kind: DuplicateSymbol, Line: "duplicate symbol '__Z1fi' in:", Symbol: "__Z1fi", Offset: 18, Demangled: "f(int)"},
+			},
+		},
+		{
+			`a.obj : error LNK2019: unresolved external symbol "void __cdecl f(void)" (?f@@YAXXZ) referenced in function main`,
+			[]LinkerDiagnostic{
+				{Kind: UndefinedSymbol, Line: `a.obj : error LNK2019: unresolved external symbol "void __cdecl f(void)" (?f@@YAXXZ) referenced in function main`, Symbol: "?f@@YAXXZ", Offset: 74, Demangled: "void __cdecl f(void)"},
+			},
+		},
+		{
+			`b.obj : error LNK2005: "void __cdecl f(void)" (?f@@YAXXZ) already defined in a.obj`,
+			[]LinkerDiagnostic{
+				{Kind: DuplicateSymbol, Line: `b.obj : error LNK2005: "void __cdecl f(void)" (?f@@YAXXZ) already defined in a.obj`, Symbol: "?f@@YAXXZ", Offset: 47, Demangled: "void __cdecl f(void)"},
+			},
+		},
+		{
+			"nothing to see here",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		got := ParseLinkerDiagnostics(test.input)
+		if len(got) != len(test.want) {
+			t.Errorf("ParseLinkerDiagnostics(%q) = %v, want %v", test.input, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("ParseLinkerDiagnostics(%q)[%d] = %+v, want %+v", test.input, i, got[i], test.want[i])
+			}
+			if g := got[i]; g.Line[g.Offset:g.Offset+len(g.Symbol)] != g.Symbol {
+				t.Errorf("ParseLinkerDiagnostics(%q)[%d]: bad offset %d", test.input, i, g.Offset)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// EnclosingFunction returns the mangled name of the function that
+// encloses a local entity, such as a lambda or a static variable
+// defined in a function. For example, for the operator() of a lambda
+// in f(), "_ZZ1fvENKUlvE_clEv", it returns "_Z1fv". It also handles
+// the guard variable of a static variable, and the vtable, typeinfo,
+// and thunks of a local class. For an entity defined in a lambda it
+// returns the lambda's function, which is itself a local entity.
+// The second result is false if the name is not a C++ symbol name
+// of a local entity.
+//
+// The local entity without the enclosing function is returned by
+// ToString with the LocalNameOnly option.
+func EnclosingFunction(name string) (fn string, ok bool) {
+	if !strings.HasPrefix(name, "_Z") {
+		return "", false
+	}
+	if _, err := ToAST(name); err != nil {
+		return "", false
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isErr := r.(Error); !isErr {
+				panic(r)
+			}
+			fn, ok = "", false
+		}
+	}()
+
+	st := &state{str: name[2:], maxDepth: defaultMaxDepth, maxExpansion: defaultMaxExpansion}
+	if len(st.str) > 2 {
+		switch st.str[:2] {
+		case "GV", "GR", "TV", "TT", "TI", "TS":
+			st.advance(2)
+		case "Th", "Tv":
+			c := st.str[1]
+			st.advance(2)
+			st.callOffset(c)
+		case "Tc":
+			st.advance(2)
+			st.callOffset(0)
+			st.callOffset(0)
+		}
+	}
+	if len(st.str) == 0 || st.str[0] != 'Z' {
+		return "", false
+	}
+	st.advance(1)
+	start := st.off
+	st.encoding(true, forLocalName)
+	if len(st.str) == 0 || st.str[0] != 'E' {
+		return "", false
+	}
+	return "_Z" + name[2+start:2+st.off], true
+}
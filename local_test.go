@@ -0,0 +1,38 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestEnclosingFunction(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_ZZ1fvENKUlvE_clEv", "_Z1fv"},
+		{"_ZZ1fvE1x", "_Z1fv"},
+		{"_ZZN2ns1fIiEEvT_E1x_0", "_ZN2ns1fIiEEvT_"},
+		{"_ZGVZ1fvE1x", "_Z1fv"},
+		{"_ZTVZ1fvE1A", "_Z1fv"},
+		{"_ZThn8_Z1fvEN1A1gEv", "_Z1fv"},
+		{"_ZZZ1fvENKUlvE_clEvE1x", "_ZZ1fvENKUlvE_clEv"},
+		{"_ZZ1fSt6vectorIiSaIiEEE1x", "_Z1fSt6vectorIiSaIiEE"},
+		{"_Z1fv", ""},
+		{"_Z1fIZ1gvEUlvE_EvT_", ""},
+		{"_ZZ1fvE", ""},
+		{"f", ""},
+	}
+	for _, test := range tests {
+		got, ok := EnclosingFunction(test.input)
+		if ok != (test.want != "") || got != test.want {
+			t.Errorf("EnclosingFunction(%s) = %q, %t, want %q", test.input, got, ok, test.want)
+		}
+		if ok {
+			if _, err := ToString(got); err != nil {
+				t.Errorf("demangling %s from %s: %v", got, test.input, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,920 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mangle returns the Itanium C++ ABI mangled name of an AST, such as
+// one returned by ToAST. It is the inverse of ToAST: demangling the
+// result produces the same string as printing the AST. The result
+// uses substitutions, including the standard abbreviations such as
+// St and Sa, as the ABI requires.
+//
+// A function template is mangled using its template parameters in
+// the parameter types only if the AST was built with the
+// TemplateParamNames option. Otherwise the template arguments are
+// used directly, which demangles the same way but is not the name
+// that a compiler would generate.
+//
+// Mangle supports names, functions, types, template arguments that
+// are types or literals, and the special names used for vtables,
+// typeinfo, guard variables, and thunks. It returns an error for an
+// AST that uses anything else. That includes expressions, such as
+// *Binary, *Unary, *Trinary, *Fold, *New, *SizeofPack, *SizeofArgs,
+// *ExprList, and *InitializerList, and types that refer to them,
+// such as *Decltype; lambdas and other *Closure nodes; template
+// constraints, *Constraint and *EnableIf; *BitIntType, *VectorType,
+// *VendorQualifier, *TransformedType, and *ElaboratedType; names in
+// C++20 modules, *ModuleEntity; *ExplicitObjectParameter, *Friend,
+// *StructuredBindings, *TemplateParamQualifiedArg, and *ObjCMethod;
+// *Special2 and the other special names not listed above; exception
+// specifications other than a plain noexcept; and a conversion
+// operator template whose type is a class that is not a template
+// parameter, unless the AST was built with TemplateParamNames.
+func Mangle(a AST) (ret string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if me, ok := r.(mangleErr); ok {
+				ret = ""
+				err = me
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	m := mangler{
+		subs: make(map[string]int),
+		keys: make(map[AST]string),
+	}
+	m.writeString("_Z")
+	m.encoding(a)
+	return m.buf.String(), nil
+}
+
+// mangleErr is the error type used by Mangle for a node that can't
+// be mangled.
+type mangleErr struct {
+	a AST
+}
+
+// Error implements the builtin error interface for mangleErr.
+func (me mangleErr) Error() string {
+	return fmt.Sprintf("can't mangle %T", me.a)
+}
+
+// mangler holds the state of Mangle.
+type mangler struct {
+	buf strings.Builder
+
+	// The subs field maps the key of each substitution candidate
+	// to its index, and nsubs is the number of candidates seen.
+	subs  map[string]int
+	nsubs int
+
+	// The keys field caches the key of a node, which is its
+	// mangled form without substitutions.
+	keys map[AST]string
+
+	// The nosubs field is set when computing a key.
+	nosubs bool
+}
+
+// fail panics with a mangleErr for a.
+func (m *mangler) fail(a AST) {
+	panic(mangleErr{a: a})
+}
+
+func (m *mangler) writeString(s string) {
+	m.buf.WriteString(s)
+}
+
+func (m *mangler) writeByte(b byte) {
+	m.buf.WriteByte(b)
+}
+
+// key returns the key used to look up a substitution candidate.
+// Two nodes with the same key are the same entity.
+func (m *mangler) key(a AST) string {
+	if k, ok := m.keys[a]; ok {
+		return k
+	}
+	km := mangler{keys: m.keys, nosubs: true}
+	km.mangleType(a)
+	k := km.buf.String()
+	m.keys[a] = k
+	return k
+}
+
+// substitution writes a substitution for a, and reports whether
+// it did so.
+func (m *mangler) substitution(a AST) bool {
+	if s := abbreviation(a); s != "" {
+		m.writeString(s)
+		return true
+	}
+	if m.nosubs {
+		return false
+	}
+	i, ok := m.subs[m.key(a)]
+	if !ok {
+		return false
+	}
+	m.writeByte('S')
+	if i > 0 {
+		m.writeString(strings.ToUpper(strconv.FormatInt(int64(i-1), 36)))
+	}
+	m.writeByte('_')
+	return true
+}
+
+// addSub adds a as a substitution candidate.
+func (m *mangler) addSub(a AST) {
+	if m.nosubs {
+		return
+	}
+	k := m.key(a)
+	if _, ok := m.subs[k]; !ok {
+		m.subs[k] = m.nsubs
+	}
+	m.nsubs++
+}
+
+// abbreviation returns the standard abbreviation for a, such as Sa
+// for std::allocator, or "" if there is none.
+func abbreviation(a AST) string {
+	switch a := a.(type) {
+	case *Qualified:
+		n, ok := a.Name.(*Name)
+		if !ok || a.LocalName || !isStdName(a.Scope) {
+			return ""
+		}
+		for c, s := range subAST {
+			if q, ok := s.(*Qualified); ok {
+				if sn, ok := q.Name.(*Name); ok && *sn == *n {
+					return "S" + string(c)
+				}
+			}
+		}
+	case *Template:
+		// The long forms of Ss, Si, So, and Sd print differently
+		// than the same template written out, as in
+		// std::basic_ostream<char, std::char_traits<char> >,
+		// or the template with template parameters that refer
+		// to those arguments. So we only use the abbreviation
+		// for the AST that the demangler uses for it.
+		for c, v := range verboseAST {
+			if a == v {
+				return "S" + string(c)
+			}
+		}
+	}
+	return ""
+}
+
+// encoding mangles:
+//
+//	<encoding> ::= <(function) name> <bare-function-type>
+//	           ::= <(data) name>
+//	           ::= <special-name>
+func (m *mangler) encoding(a AST) {
+	switch a := a.(type) {
+	case *Typed:
+		t := a.Type
+		mwq, _ := t.(*MethodWithQualifiers)
+		if mwq != nil {
+			t = mwq.Method
+		}
+		ft, ok := t.(*FunctionType)
+		if !ok {
+			m.fail(a.Type)
+		}
+		m.name(a.Name, mwq, len(ft.Args))
+		if ft.Return != nil && !hasReturnType(a.Name) {
+			// A Java function with a return type.
+			m.writeByte('J')
+		}
+		m.bareFunctionType(ft)
+	case *Clone:
+		m.encoding(a.Base)
+		m.writeString(a.Suffix)
+	case *Special:
+		m.specialName(a)
+	default:
+		m.name(a, nil, -1)
+	}
+}
+
+// name mangles:
+//
+//	<name> ::= <nested-name>
+//	       ::= <unscoped-name>
+//	       ::= <unscoped-template-name> <template-args>
+//	       ::= <local-name>
+//
+// The mwq argument holds the qualifiers of a method, if any.
+// The nargs argument is the number of function parameters,
+// or -1 if not known.
+func (m *mangler) name(a AST, mwq *MethodWithQualifiers, nargs int) {
+	switch a := a.(type) {
+	case *Qualified:
+		if a.LocalName {
+			m.localName(a, mwq, nargs)
+			return
+		}
+		if mwq == nil && isStdName(a.Scope) {
+			m.writeString("St")
+			m.unqualifiedName(a.Name, nargs)
+			return
+		}
+		m.writeByte('N')
+		m.methodQualifiers(mwq)
+		m.prefix(a.Scope)
+		// Assume that an operator in a class is a member,
+		// with an implicit this parameter.
+		if nargs >= 0 {
+			nargs++
+		}
+		m.unqualifiedName(a.Name, nargs)
+		m.writeByte('E')
+	case *Template:
+		if ambiguousCast(a) {
+			m.fail(a)
+		}
+		if mwq != nil || m.nested(a) {
+			m.writeByte('N')
+			m.methodQualifiers(mwq)
+			m.prefix(a.Name)
+			m.templateArgs(a.Args)
+			m.writeByte('E')
+			return
+		}
+		m.prefix(a.Name)
+		m.templateArgs(a.Args)
+	default:
+		if mwq != nil {
+			m.fail(mwq)
+		}
+		m.unqualifiedName(a, nargs)
+	}
+}
+
+// nested reports whether the template a, which is not a method,
+// must be mangled as a nested name. That is not necessary if the
+// template name is in the std namespace, or is a substitution.
+func (m *mangler) nested(a *Template) bool {
+	q, ok := a.Name.(*Qualified)
+	if !ok || q.LocalName || isStdName(q.Scope) || abbreviation(q) != "" {
+		return false
+	}
+	if m.nosubs {
+		return true
+	}
+	_, ok = m.subs[m.key(q)]
+	return !ok
+}
+
+// ambiguousCast reports whether a is a conversion operator template
+// whose type is a class that is not a template parameter, as in
+// "operator A const&<A>". A compiler mangles the type using the
+// template parameter, T_, but if the AST was built without the
+// TemplateParamNames option we only have the argument. Mangling that
+// would read the template arguments as arguments of the class.
+func ambiguousCast(a *Template) bool {
+	n := a.Name
+	if q, ok := n.(*Qualified); ok {
+		n = q.Name
+	}
+	c, ok := n.(*Cast)
+	if !ok {
+		return false
+	}
+	t := c.To
+	for {
+		switch tt := t.(type) {
+		case *TypeWithQualifiers:
+			t = tt.Base
+		case *PointerType:
+			t = tt.Base
+		case *ReferenceType:
+			t = tt.Base
+		case *RvalueReferenceType:
+			t = tt.Base
+		case *Name, *Qualified:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// methodQualifiers mangles the qualifiers of a method in a
+// nested name.
+func (m *mangler) methodQualifiers(mwq *MethodWithQualifiers) {
+	if mwq == nil {
+		return
+	}
+	m.cvQualifiers(mwq.Qualifiers, false)
+	switch mwq.RefQualifier {
+	case "&":
+		m.writeByte('R')
+	case "&&":
+		m.writeByte('O')
+	}
+}
+
+// cvQualifiers mangles a Qualifiers node. If fn is true the
+// qualifiers are for a function type, which may have an exception
+// specification.
+func (m *mangler) cvQualifiers(a AST, fn bool) {
+	if a == nil {
+		return
+	}
+	qs, ok := a.(*Qualifiers)
+	if !ok {
+		m.fail(a)
+	}
+	var r, v, k, do, dx bool
+	for _, qa := range qs.Qualifiers {
+		q, ok := qa.(*Qualifier)
+		if !ok {
+			m.fail(qa)
+		}
+		switch {
+		case q.Name == "restrict" || q.Name == "__restrict":
+			r = true
+		case q.Name == "volatile":
+			v = true
+		case q.Name == "const":
+			k = true
+		case fn && q.Name == "noexcept" && len(q.Exprs) == 0:
+			do = true
+		case fn && q.Name == "transaction_safe":
+			dx = true
+		default:
+			m.fail(q)
+		}
+	}
+	if r {
+		m.writeByte('r')
+	}
+	if v {
+		m.writeByte('V')
+	}
+	if k {
+		m.writeByte('K')
+	}
+	if do {
+		m.writeString("Do")
+	}
+	if dx {
+		m.writeString("Dx")
+	}
+}
+
+// prefix mangles a prefix of a nested name, which is a substitution
+// candidate.
+func (m *mangler) prefix(a AST) {
+	if m.substitution(a) {
+		return
+	}
+	m.prefixBody(a)
+	m.addSub(a)
+}
+
+// prefixBody mangles a prefix without checking for a substitution.
+func (m *mangler) prefixBody(a AST) {
+	switch a := a.(type) {
+	case *Qualified:
+		if a.LocalName {
+			m.localName(a, nil, -1)
+			return
+		}
+		if isStdName(a.Scope) {
+			m.writeString("St")
+		} else {
+			m.prefix(a.Scope)
+		}
+		m.unqualifiedName(a.Name, -1)
+	case *Template:
+		if ambiguousCast(a) {
+			m.fail(a)
+		}
+		m.prefix(a.Name)
+		m.templateArgs(a.Args)
+	case *TemplateParam:
+		m.templateParam(a)
+	default:
+		m.unqualifiedName(a, -1)
+	}
+}
+
+// unqualifiedName mangles:
+//
+//	<unqualified-name> ::= <operator-name>
+//	                   ::= <ctor-dtor-name>
+//	                   ::= <source-name>
+//	                   ::= <local-source-name>
+//	                   ::= <unnamed-type-name>
+//	                   ::= <unqualified-name> <abi-tag>
+func (m *mangler) unqualifiedName(a AST, nargs int) {
+	switch a := a.(type) {
+	case *Name:
+		if a.Internal {
+			m.writeByte('L')
+		}
+		m.sourceName(a.Name)
+	case *Constructor:
+		m.writeByte('C')
+		if a.Base != nil {
+			m.writeByte('I')
+		}
+		m.writeByte(ctorCode(a.Kind))
+		if a.Base != nil {
+			m.mangleType(a.Base)
+		}
+	case *Destructor:
+		m.writeByte('D')
+		m.writeByte(dtorCode(a.Kind))
+	case *Operator:
+		m.writeString(operatorCode(a, nargs))
+	case *Cast:
+		m.writeString("cv")
+		m.mangleType(a.To)
+	case *Unary:
+		op, ok := a.Op.(*Operator)
+		n, ok2 := a.Expr.(*Name)
+		if !ok || !ok2 || op.Name != `operator"" ` {
+			m.fail(a)
+		}
+		m.writeString("li")
+		m.sourceName(n.Name)
+	case *TaggedName:
+		m.unqualifiedName(a.Name, nargs)
+		tag, ok := a.Tag.(*Name)
+		if !ok {
+			m.fail(a.Tag)
+		}
+		m.writeByte('B')
+		m.sourceName(tag.Name)
+	case *UnnamedType:
+		m.writeString("Ut")
+		if a.Num > 0 {
+			m.writeString(strconv.Itoa(a.Num - 1))
+		}
+		m.writeByte('_')
+		m.addSub(a)
+	default:
+		m.fail(a)
+	}
+}
+
+// sourceName mangles an identifier.
+func (m *mangler) sourceName(s string) {
+	if s == anonymousNamespace {
+		s = "_GLOBAL__N_1"
+	}
+	m.writeString(strconv.Itoa(len(s)))
+	m.writeString(s)
+}
+
+// ctorCode returns the character used in a mangled name for a kind
+// of constructor. It uses the complete object constructor for an
+// unknown kind.
+func ctorCode(kind CtorKind) byte {
+	for c, k := range ctorKinds {
+		if k == kind {
+			return c
+		}
+	}
+	return '1'
+}
+
+// dtorCode is like ctorCode for a destructor.
+func dtorCode(kind DtorKind) byte {
+	for c, k := range dtorKinds {
+		if k == kind {
+			return c
+		}
+	}
+	return '1'
+}
+
+// operatorCodes maps the name of an operator to the codes used for
+// it, sorted.
+var operatorCodes = func() map[string][]string {
+	r := make(map[string][]string)
+	for code, op := range operators {
+		r[op.name] = append(r[op.name], code)
+	}
+	for _, codes := range r {
+		sort.Strings(codes)
+	}
+	return r
+}()
+
+// operatorCode returns the code for an operator. Some operators,
+// such as -, have both a unary and a binary form; nargs is the
+// number of operands, if known, used to pick between them.
+func operatorCode(op *Operator, nargs int) string {
+	codes := operatorCodes[op.Name]
+	if len(codes) == 0 {
+		panic(mangleErr{a: op})
+	}
+	if len(codes) == 1 {
+		return codes[0]
+	}
+	want := nargs
+	if want < 0 {
+		want = 2
+	}
+	for _, code := range codes {
+		if operators[code].args == want {
+			return code
+		}
+	}
+	return codes[0]
+}
+
+// localName mangles:
+//
+//	<local-name> ::= Z <(function) encoding> E <(entity) name> [<discriminator>]
+//	             ::= Z <(function) encoding> E s [<discriminator>]
+//	             ::= Z <(function) encoding> E d [<parameter> number>] _ <entity name>
+func (m *mangler) localName(q *Qualified, mwq *MethodWithQualifiers, nargs int) {
+	m.writeByte('Z')
+	m.encoding(q.Scope)
+	m.writeByte('E')
+	if n, ok := q.Name.(*Name); ok && n.Name == "string literal" {
+		m.writeByte('s')
+	} else {
+		n := q.Name
+		if da, ok := n.(*DefaultArg); ok {
+			m.writeByte('d')
+			if da.Num > 0 {
+				m.writeString(strconv.Itoa(da.Num - 1))
+			}
+			m.writeByte('_')
+			n = da.Arg
+		}
+		m.name(n, mwq, nargs)
+	}
+	if d := q.Discriminator; d > 0 {
+		d--
+		if d < 10 {
+			m.writeByte('_')
+			m.writeString(strconv.Itoa(d))
+		} else {
+			m.writeString("__")
+			m.writeString(strconv.Itoa(d))
+			m.writeByte('_')
+		}
+	}
+}
+
+// bareFunctionType mangles:
+//
+//	<bare-function-type> ::= [J]<type>+
+func (m *mangler) bareFunctionType(ft *FunctionType) {
+	if ft.Return != nil {
+		m.mangleType(ft.Return)
+	}
+	if len(ft.Args) == 0 {
+		m.writeByte('v')
+	}
+	for _, arg := range ft.Args {
+		m.mangleType(arg)
+	}
+}
+
+// builtinCodes maps the name of a builtin type to its code.
+var builtinCodes = func() map[string]string {
+	r := map[string]string{
+		"decimal32":         "Df",
+		"decimal64":         "Dd",
+		"decimal128":        "De",
+		"half":              "Dh",
+		"char8_t":           "Du",
+		"char16_t":          "Ds",
+		"char32_t":          "Di",
+		"decltype(nullptr)": "Dn",
+	}
+	for c, name := range builtinTypes {
+		r[name] = string(c)
+	}
+	return r
+}()
+
+// mangleType mangles:
+//
+//	<type> ::= <builtin-type>
+//	       ::= <function-type>
+//	       ::= <class-enum-type>
+//	       ::= <array-type>
+//	       ::= <pointer-to-member-type>
+//	       ::= <template-param>
+//	       ::= <substitution>
+//	       ::= <CV-qualifiers> <type>
+//	       ::= P <type>
+//	       ::= R <type>
+//	       ::= O <type> (C++0x)
+//	       ::= C <type>
+//	       ::= G <type>
+//	       ::= Dp <type>
+func (m *mangler) mangleType(a AST) {
+	// Builtin types are not substitution candidates.
+	switch a := a.(type) {
+	case *BuiltinType:
+		code, ok := builtinCodes[a.Name]
+		if !ok {
+			m.fail(a)
+		}
+		m.writeString(code)
+		return
+	case *BinaryFP:
+		m.writeString("DF")
+		m.writeString(strconv.Itoa(a.Bits))
+		m.writeByte('_')
+		return
+	case *Name:
+		switch a.Name {
+		case "auto":
+			m.writeString("Da")
+			return
+		case "decltype(auto)":
+			m.writeString("Dc")
+			return
+		}
+	}
+
+	if m.substitution(a) {
+		return
+	}
+
+	switch a := a.(type) {
+	case *Name, *TaggedName:
+		m.unqualifiedName(a, -1)
+	case *UnnamedType:
+		// This adds itself as a candidate.
+		m.unqualifiedName(a, -1)
+		return
+	case *Qualified:
+		if !a.LocalName && !isStdName(a.Scope) {
+			m.writeByte('N')
+			m.prefixBody(a)
+			m.writeByte('E')
+		} else {
+			m.prefixBody(a)
+		}
+	case *Template:
+		if m.nested(a) {
+			m.writeByte('N')
+			m.prefixBody(a)
+			m.writeByte('E')
+		} else {
+			m.prefixBody(a)
+		}
+	case *TemplateParam:
+		m.templateParam(a)
+	case *TypeWithQualifiers:
+		if at, ok := a.Base.(*ArrayType); ok {
+			// The qualifiers of an array type are mangled
+			// on the element type.
+			m.arrayDimension(at)
+			m.mangleType(&TypeWithQualifiers{Base: at.Element, Qualifiers: a.Qualifiers})
+		} else {
+			m.cvQualifiers(a.Qualifiers, false)
+			m.mangleType(a.Base)
+		}
+	case *PointerType:
+		m.writeByte('P')
+		m.mangleType(a.Base)
+	case *ReferenceType:
+		m.writeByte('R')
+		m.mangleType(a.Base)
+	case *RvalueReferenceType:
+		m.writeByte('O')
+		m.mangleType(a.Base)
+	case *ComplexType:
+		m.writeByte('C')
+		m.mangleType(a.Base)
+	case *ImaginaryType:
+		m.writeByte('G')
+		m.mangleType(a.Base)
+	case *PackExpansion:
+		m.writeString("Dp")
+		m.mangleType(a.Base)
+	case *FunctionType:
+		m.functionType(a, "")
+	case *MethodWithQualifiers:
+		// The unqualified function type is not a candidate.
+		ft, ok := a.Method.(*FunctionType)
+		if !ok {
+			m.fail(a.Method)
+		}
+		m.cvQualifiers(a.Qualifiers, true)
+		m.functionType(ft, a.RefQualifier)
+	case *ArrayType:
+		m.arrayDimension(a)
+		m.mangleType(a.Element)
+	case *PtrMem:
+		m.writeByte('M')
+		m.mangleType(a.Class)
+		m.mangleType(a.Member)
+	default:
+		m.fail(a)
+	}
+	m.addSub(a)
+}
+
+// arrayDimension mangles the start of an array type, up to the
+// element type:
+//
+//	<array-type> ::= A <(positive dimension) number> _ <(element) type>
+func (m *mangler) arrayDimension(at *ArrayType) {
+	m.writeByte('A')
+	if at.Dimension != nil {
+		n, ok := at.Dimension.(*Name)
+		if !ok {
+			m.fail(at.Dimension)
+		}
+		m.writeString(n.Name)
+	}
+	m.writeByte('_')
+}
+
+// functionType mangles:
+//
+//	<function-type> ::= F [Y] <bare-function-type> [<ref-qualifier>] E
+func (m *mangler) functionType(ft *FunctionType, ref string) {
+	m.writeByte('F')
+	if ft.Return == nil {
+		m.writeByte('v')
+	} else {
+		m.mangleType(ft.Return)
+	}
+	if len(ft.Args) == 0 {
+		m.writeByte('v')
+	}
+	for _, arg := range ft.Args {
+		m.mangleType(arg)
+	}
+	switch ref {
+	case "&":
+		m.writeByte('R')
+	case "&&":
+		m.writeByte('O')
+	}
+	m.writeByte('E')
+}
+
+// templateParam mangles:
+//
+//	<template-param> ::= T_
+//	                 ::= T <(parameter-2 non-negative) number> _
+func (m *mangler) templateParam(a *TemplateParam) {
+	m.writeByte('T')
+	if a.Index > 0 {
+		m.writeString(strconv.Itoa(a.Index - 1))
+	}
+	m.writeByte('_')
+}
+
+// templateArgs mangles:
+//
+//	<template-args> ::= I <template-arg>+ E
+func (m *mangler) templateArgs(args []AST) {
+	m.writeByte('I')
+	for _, arg := range args {
+		m.templateArg(arg)
+	}
+	m.writeByte('E')
+}
+
+// templateArg mangles:
+//
+//	<template-arg> ::= <type>
+//	               ::= <expr-primary>
+//	               ::= J <template-arg>* E
+func (m *mangler) templateArg(a AST) {
+	switch a := a.(type) {
+	case *Literal:
+		if a.Type == nil {
+			m.fail(a)
+		}
+		m.writeByte('L')
+		m.mangleType(a.Type)
+		if a.Neg {
+			m.writeByte('n')
+		}
+		m.writeString(a.Val)
+		m.writeByte('E')
+	case *ArgumentPack:
+		m.writeByte('J')
+		for _, arg := range a.Args {
+			m.templateArg(arg)
+		}
+		m.writeByte('E')
+	default:
+		m.mangleType(a)
+	}
+}
+
+// specialName mangles:
+//
+//	<special-name> ::= TV <type>
+//	               ::= TT <type>
+//	               ::= TI <type>
+//	               ::= TS <type>
+//	               ::= TF <type>
+//	               ::= TH <name>
+//	               ::= TW <name>
+//	               ::= GV <name>
+//	               ::= T <call-offset> <base encoding>
+//	               ::= Tc <call-offset> <call-offset> <base encoding>
+//	               ::= GTt <encoding>
+//	               ::= GTn <encoding>
+func (m *mangler) specialName(a *Special) {
+	switch a.Prefix {
+	case "vtable for ":
+		m.writeString("TV")
+		m.mangleType(a.Val)
+	case "VTT for ":
+		m.writeString("TT")
+		m.mangleType(a.Val)
+	case "typeinfo for ":
+		m.writeString("TI")
+		m.mangleType(a.Val)
+	case "typeinfo name for ":
+		m.writeString("TS")
+		m.mangleType(a.Val)
+	case "typeinfo fn for ":
+		m.writeString("TF")
+		m.mangleType(a.Val)
+	case "TLS init function for ":
+		m.writeString("TH")
+		m.name(a.Val, nil, -1)
+	case "TLS wrapper function for ":
+		m.writeString("TW")
+		m.name(a.Val, nil, -1)
+	case "guard variable for ":
+		m.writeString("GV")
+		m.name(a.Val, nil, -1)
+	case "non-virtual thunk to ", "virtual thunk to ":
+		if len(a.Offsets) != 1 {
+			m.fail(a)
+		}
+		m.writeByte('T')
+		m.callOffset(a.Offsets[0])
+		m.encoding(a.Val)
+	case "covariant return thunk to ":
+		if len(a.Offsets) != 2 {
+			m.fail(a)
+		}
+		m.writeString("Tc")
+		m.callOffset(a.Offsets[0])
+		m.callOffset(a.Offsets[1])
+		m.encoding(a.Val)
+	case "transaction clone for ":
+		m.writeString("GTt")
+		m.encoding(a.Val)
+	case "non-transaction clone for ":
+		m.writeString("GTn")
+		m.encoding(a.Val)
+	default:
+		m.fail(a)
+	}
+}
+
+// callOffset mangles:
+//
+//	<call-offset> ::= h <nv-offset> _
+//	              ::= v <v-offset> _
+func (m *mangler) callOffset(c CallOffset) {
+	if c.Virtual {
+		m.writeByte('v')
+		m.number(c.Offset)
+		m.writeByte('_')
+		m.number(c.VCallOffset)
+	} else {
+		m.writeByte('h')
+		m.number(c.Offset)
+	}
+	m.writeByte('_')
+}
+
+// number mangles a possibly negative number.
+func (m *mangler) number(n int) {
+	if n < 0 {
+		m.writeByte('n')
+		n = -n
+	}
+	m.writeString(strconv.Itoa(n))
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMangle(t *testing.T) {
+	var tests = []string{
+		"_Z1fv",
+		"_Z1fi",
+		"_ZN2ns1fEv",
+		"_ZN1A1fEv",
+		"_ZNK1A1fEv",
+		"_ZNKR1A1fEv",
+		"_ZNO1A1fEv",
+		"_ZN1AC1Ev",
+		"_ZN1AC2ERKS_",
+		"_ZN1AD0Ev",
+		"_ZN1AplERKS_",
+		"_ZN1AngEv",
+		"_ZN1AcviEv",
+		"_ZN12_GLOBAL__N_11fEv",
+		"_ZL1fv",
+		"_ZN2ns1AIiE1fEv",
+		"_ZNSt6vectorIiSaIiEE9push_backERKi",
+		"_ZSt4swapIiEvRT_S1_",
+		"_Z1fIiEvT_",
+		"_Z1fIJidEEvDpT_",
+		"_Z1fIiLb1ELin4EEvv",
+		"_Z1fPKcS0_",
+		"_Z1fPFvvEPFivE",
+		"_Z1fM1AFvvEMS_KFvvE",
+		"_Z1fPA10_i",
+		"_Z1fN2ns1AES0_",
+		"_Z1fSsSaIcE",
+		"_ZNSoC1Ev",
+		"_ZSt16__ostream_insertIcSt11char_traitsIcEERSt13basic_ostreamIT_T0_ES6_PKS3_l",
+		"_Z1fSt13basic_ostreamIcSt11char_traitsIcEE",
+		"_ZZ1fvE1a",
+		"_ZZ1fvE1a_0",
+		"_ZZN1A1fEvE1b",
+		"_Z1fB3abcv",
+		"_Z1fv.constprop.0",
+		"_ZSt4cout",
+		"_ZTV1A",
+		"_ZTIN2ns1AE",
+		"_ZTSi",
+		"_ZGVZ1fvE1a",
+		"_ZThn8_N1B1fEv",
+		"_ZTv0_n24_N1B1fEv",
+		"_ZTch0_v0_n32_N1B1fEv",
+	}
+	for _, test := range tests {
+		a, err := ToAST(test, TemplateParamNames)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test, err)
+			continue
+		}
+		got, err := Mangle(a)
+		if err != nil {
+			t.Errorf("mangling %s: unexpected error %v", test, err)
+		} else if got != test {
+			t.Errorf("mangling %s: got %s", test, got)
+		}
+	}
+}
+
+func TestMangleError(t *testing.T) {
+	var tests = []string{
+		// An expression.
+		"_Z1fIiEvDTplT_T_E",
+		// A name in a module.
+		"_ZNStW3STD9allocatorIiE1MEPi",
+	}
+	for _, test := range tests {
+		a, err := ToAST(test)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test, err)
+			continue
+		}
+		if s, err := Mangle(a); err == nil {
+			t.Errorf("mangling %s: got %s, want error", test, s)
+		}
+	}
+}
+
+// TestMangleCases checks that for each name in cases that can be
+// mangled, with or without the TemplateParamNames option, the result
+// demangles to the same string as the original name.
+func TestMangleCases(t *testing.T) {
+	t.Parallel()
+
+	for _, options := range [][]Option{nil, {TemplateParamNames}} {
+		for _, c := range cases {
+			a, err := ToAST(c[0], options...)
+			if err != nil {
+				continue
+			}
+			mangled, err := Mangle(a)
+			if err != nil {
+				continue
+			}
+			want, err := ToString(c[0])
+			if err != nil {
+				t.Errorf("%s: %v", c[0], err)
+				continue
+			}
+			got, err := ToString(mangled)
+			if err != nil {
+				t.Errorf("%s %v: mangled to %s: %v", c[0], options, mangled, err)
+			} else if got != want {
+				t.Errorf("%s %v: mangled to %s:\ngot  %s\nwant %s", c[0], options, mangled, got, want)
+			}
+		}
+	}
+}
+
+// TestMangleExpected checks that for each name in the testdata file
+// that can be mangled, the result demangles to the same string.
+func TestMangleExpected(t *testing.T) {
+	t.Parallel()
+
+	// Names for which the round trip prints differently.
+	skip := map[string]bool{
+		// The qualifiers merged from an array substitution
+		// are printed in a different order.
+		"_Z3fooIA6_KiEvA9_KT_rVPrS4_": true,
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	count := 0
+	for scanner.Scan() {
+		input := scanner.Text()
+		if !strings.HasPrefix(input, "_Z") || skip[input] {
+			continue
+		}
+		want, err := ToString(input)
+		if err != nil {
+			continue
+		}
+		a, err := ToAST(input, TemplateParamNames)
+		if err != nil {
+			continue
+		}
+		mangled, err := Mangle(a)
+		if err != nil {
+			continue
+		}
+		got, err := ToString(mangled)
+		if err != nil {
+			t.Errorf("%s: mangled to %s: %v", input, mangled, err)
+		} else if got != want {
+			t.Errorf("%s: mangled to %s:\ngot  %s\nwant %s", input, mangled, got, want)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count < 100 {
+		t.Errorf("only mangled %d names", count)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"math"
+	"reflect"
+)
+
+// Metrics describes the complexity of a demangled symbol name, as
+// returned by ToMetrics. A program can use it to find names that are
+// unusually expensive to print, and skip or truncate them, before
+// printing them.
+type Metrics struct {
+	// Nodes is the number of distinct nodes in the AST. A node
+	// that a back reference refers to is counted once.
+	Nodes int
+
+	// ExpandedNodes is the number of nodes in the AST with each
+	// back reference replaced by a copy of the nodes that it
+	// refers to, which is roughly the number of nodes that are
+	// visited when printing the name. It is math.MaxInt64 if the
+	// number is larger than that.
+	ExpandedNodes int64
+
+	// Expansion is ExpandedNodes divided by Nodes. It is 1 for a
+	// name that doesn't use back references, and is very large
+	// for a pathological name that uses back references to
+	// build a very long demangled string from a short mangled
+	// name.
+	Expansion float64
+
+	// TemplateDepth is the maximum nesting depth of template
+	// argument lists: 0 for a name without template arguments,
+	// 1 for "f<int>", 2 for "f<std::vector<int> >", and so on.
+	TemplateDepth int
+
+	// Lambdas is the number of distinct lambda and closure types
+	// in the name.
+	Lambdas int
+}
+
+// ToMetrics demangles a C++ or Rust symbol name and returns metrics
+// describing the complexity of the name, without printing it.
+// If the name does not appear to be a C++ or Rust symbol name at
+// all, the error will be ErrNotMangledName.
+func ToMetrics(name string, options ...Option) (*Metrics, error) {
+	a, err := ToAST(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	return ASTMetrics(a), nil
+}
+
+// ASTMetrics returns metrics describing the complexity of an AST.
+func ASTMetrics(a AST) *Metrics {
+	mc := &metricsCounter{
+		expanded: make(map[AST]int64),
+		depths:   make(map[AST]int),
+	}
+	m := &Metrics{
+		ExpandedNodes: mc.node(a),
+		TemplateDepth: mc.depths[a],
+	}
+	m.Nodes = len(mc.expanded)
+	for n := range mc.expanded {
+		switch n := n.(type) {
+		case *Closure:
+			m.Lambdas++
+		case *RustNested:
+			if n.Namespace == 'C' {
+				m.Lambdas++
+			}
+		}
+	}
+	if m.Nodes > 0 {
+		m.Expansion = float64(m.ExpandedNodes) / float64(m.Nodes)
+	}
+	return m
+}
+
+// metricsCounter holds the state of ASTMetrics.
+type metricsCounter struct {
+	// The expanded size of each node seen so far. A node that is
+	// being counted has the size 0.
+	expanded map[AST]int64
+
+	// The template depth of each node seen so far.
+	depths map[AST]int
+}
+
+// node returns the expanded size of a node, and records its size and
+// its template depth.
+func (mc *metricsCounter) node(a AST) int64 {
+	if size, ok := mc.expanded[a]; ok {
+		return size
+	}
+	mc.expanded[a] = 0
+
+	size := int64(1)
+	depth := 0
+	add := func(child AST) {
+		size = addSize(size, mc.node(child))
+		if d := mc.depths[child]; d > depth {
+			depth = d
+		}
+	}
+
+	s := reflect.Indirect(reflect.ValueOf(a))
+	typ := s.Type()
+	for i := 0; i < s.NumField(); i++ {
+		if typ.Field(i).PkgPath != "" || refFields[typ.Name()+"."+typ.Field(i).Name] {
+			continue
+		}
+		f := s.Field(i)
+		switch {
+		case isASTField(f.Type()):
+			if !f.IsNil() {
+				add(f.Interface().(AST))
+			}
+		case f.Type() == astSliceType:
+			for j := 0; j < f.Len(); j++ {
+				if e := f.Index(j); !e.IsNil() {
+					add(e.Interface().(AST))
+				}
+			}
+		}
+	}
+
+	switch a := a.(type) {
+	case *Template:
+		if len(a.Args) > 0 {
+			depth = mc.maxDepth(a.Args) + 1
+			if d := mc.depths[a.Name]; d > depth {
+				depth = d
+			}
+		}
+	case *RustGenerics:
+		depth = mc.maxDepth(a.Args) + 1
+		if d := mc.depths[a.Path]; d > depth {
+			depth = d
+		}
+	}
+
+	mc.expanded[a] = size
+	mc.depths[a] = depth
+	return size
+}
+
+// maxDepth returns the maximum template depth of a list of nodes
+// that have been counted.
+func (mc *metricsCounter) maxDepth(list []AST) int {
+	max := 0
+	for _, a := range list {
+		if d := mc.depths[a]; d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// addSize adds two sizes, returning math.MaxInt64 on overflow.
+func addSize(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToMetrics(t *testing.T) {
+	var tests = []struct {
+		name string
+		want Metrics
+	}{
+		{"_Z1fv", Metrics{Nodes: 3, ExpandedNodes: 3, Expansion: 1}},
+		{"_Z1f1AS_S_S_", Metrics{Nodes: 4, ExpandedNodes: 7, Expansion: 1.75}},
+		{"_Z1fIiEvv", Metrics{Nodes: 6, ExpandedNodes: 6, Expansion: 1, TemplateDepth: 1}},
+		{"_Z1fI1AIiEEvv", Metrics{Nodes: 8, ExpandedNodes: 8, Expansion: 1, TemplateDepth: 2}},
+		{"_ZZ1fvENKUlvE_clEv", Metrics{Nodes: 12, ExpandedNodes: 12, Expansion: 1, Lambdas: 1}},
+		{"_RNCNvC1a4main0B3_", Metrics{Nodes: 4, ExpandedNodes: 4, Expansion: 1, Lambdas: 1}},
+	}
+	for _, test := range tests {
+		got, err := ToMetrics(test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if *got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, *got, test.want)
+		}
+	}
+
+	if _, err := ToMetrics("_Z1"); err == nil {
+		t.Error("invalid name: got nil, want error")
+	}
+	if _, err := ToMetrics("main"); err != ErrNotMangledName {
+		t.Errorf("unmangled name: got %v, want ErrNotMangledName", err)
+	}
+}
+
+// TestToMetricsExpansion checks the metrics of a name that prints
+// each back reference twice, so that the printed size doubles with
+// each level.
+func TestToMetricsExpansion(t *testing.T) {
+	// Each level is a pair of the previous level.
+	a := AST(NewBuiltinType("int"))
+	for i := 0; i < 100; i++ {
+		a = NewTemplate(NewName("P"), a, a)
+	}
+	m := ASTMetrics(a)
+	if m.Nodes != 201 {
+		t.Errorf("got %d nodes, want 201", m.Nodes)
+	}
+	if m.ExpandedNodes != math.MaxInt64 {
+		t.Errorf("got %d expanded nodes, want %d", m.ExpandedNodes, int64(math.MaxInt64))
+	}
+	if m.TemplateDepth != 100 {
+		t.Errorf("got template depth %d, want 100", m.TemplateDepth)
+	}
+	if m.Expansion < 1e15 {
+		t.Errorf("got expansion %g, want a large value", m.Expansion)
+	}
+}
@@ -0,0 +1,1608 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// This file demangles names mangled by the Microsoft Visual C++
+// compiler, as used by MSVC, clang-cl, and PDB files.
+// The scheme is not formally documented. The output follows the
+// llvm-undname program, which is in turn similar to the undname
+// program shipped with MSVC.
+
+// msvcToString demangles a Microsoft Visual C++ symbol.
+func msvcToString(name string, options []Option) (ret string, err error) {
+	if !strings.HasPrefix(name, "?") {
+		return "", ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	mst := &msvcState{str: name, maxDepth: defaultMaxDepth}
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			mst.noParams = true
+		case o == NoTemplateParams:
+			mst.noTemplateParams = true
+		case o == ElideTemplateParams:
+			mst.noTemplateParams = true
+			mst.elideTemplateParams = true
+		case isMaxLength(o):
+			mst.max = maxLength(o)
+		case isMaxDepth(o):
+			mst.maxDepth = optionValue(o)
+		}
+	}
+
+	s := mst.symbol()
+
+	if len(mst.str) > 0 {
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: mst.off})
+	}
+
+	if mst.max > 0 && len(s) > mst.max {
+		s = s[:mst.max]
+	}
+	return s, nil
+}
+
+// msvcBackrefs holds the back references that may appear in an MSVC
+// mangled name. Each table holds at most 10 entries.
+type msvcBackrefs struct {
+	names  []string    // names, referenced by a digit in a name
+	params []*msvcType // parameter types, referenced by a digit in a parameter list
+}
+
+// A msvcState holds the current state of demangling an MSVC string.
+type msvcState struct {
+	str                 string // remainder of string to demangle
+	off                 int    // offset of str within original string
+	backrefs            msvcBackrefs
+	noParams            bool // don't print function parameters
+	noTemplateParams    bool // don't print template arguments
+	elideTemplateParams bool // print "<...>" for template arguments
+	max                 int  // maximum output length
+	depth               int  // current nesting depth
+	maxDepth            int  // maximum nesting depth
+}
+
+// fail panics with Error, to be caught in msvcToString.
+func (mst *msvcState) fail(err string) {
+	panic(Error{Msg: err, Offset: mst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (mst *msvcState) enter() {
+	mst.depth++
+	if mst.depth > mst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: mst.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (mst *msvcState) leave() {
+	mst.depth--
+}
+
+// advance advances the current string offset.
+func (mst *msvcState) advance(add int) {
+	if len(mst.str) < add {
+		panic("internal error")
+	}
+	mst.str = mst.str[add:]
+	mst.off += add
+}
+
+// checkChar requires that the next character in the string be c,
+// and advances past it.
+func (mst *msvcState) checkChar(c byte) {
+	if len(mst.str) == 0 || mst.str[0] != c {
+		mst.fail("expected " + string(c))
+	}
+	mst.advance(1)
+}
+
+// consume advances past prefix if the string starts with it,
+// and reports whether it did.
+func (mst *msvcState) consume(prefix string) bool {
+	if !strings.HasPrefix(mst.str, prefix) {
+		return false
+	}
+	mst.advance(len(prefix))
+	return true
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (mst *msvcState) peek() byte {
+	if len(mst.str) == 0 {
+		return 0
+	}
+	return mst.str[0]
+}
+
+// next returns the next character and advances past it.
+func (mst *msvcState) next() byte {
+	if len(mst.str) == 0 {
+		mst.fail("unexpected end of mangled name")
+	}
+	c := mst.str[0]
+	mst.advance(1)
+	return c
+}
+
+// msvcQuals is a set of type qualifiers.
+type msvcQuals int
+
+const (
+	msvcConst msvcQuals = 1 << iota
+	msvcVolatile
+	msvcRestrict
+	msvcUnaligned
+)
+
+// msvcTypeKind is the kind of a msvcType.
+type msvcTypeKind int
+
+const (
+	msvcSimple   msvcTypeKind = iota // builtin or class type
+	msvcPointer                      // pointer, reference, or member pointer
+	msvcFunction                     // function type
+	msvcArray                        // array type
+)
+
+// msvcType is a demangled MSVC type. Types are printed using the C
+// declarator syntax, so printing is split into a prefix that appears
+// before the declared name and a suffix that appears after it.
+type msvcType struct {
+	kind  msvcTypeKind
+	name  string    // name of simple type, including class keyword
+	quals msvcQuals // qualifiers of the type itself
+	base  *msvcType // pointed to type, array element, or function result
+
+	// For a pointer.
+	ptr   string // "*", "&", or "&&"
+	class string // class of pointer to member
+
+	// For a function.
+	cc        string      // calling convention
+	params    []*msvcType // parameter types
+	variadic  bool        // whether the function takes ...
+	funcQuals msvcQuals   // qualifiers on this
+	ref       string      // "", "&", or "&&"
+	noexcept  bool
+
+	// For an array.
+	dims []int64
+}
+
+// msvcBuf is a buffer used to print MSVC types.
+type msvcBuf struct {
+	buf []byte
+}
+
+func (b *msvcBuf) writeString(s string) {
+	b.buf = append(b.buf, s...)
+}
+
+func (b *msvcBuf) writeByte(c byte) {
+	b.buf = append(b.buf, c)
+}
+
+func (b *msvcBuf) last() byte {
+	if len(b.buf) == 0 {
+		return 0
+	}
+	return b.buf[len(b.buf)-1]
+}
+
+// spaceIfNecessary adds a space if the last character would run into
+// a following identifier.
+func (b *msvcBuf) spaceIfNecessary() {
+	c := b.last()
+	if isDigit(c) || isLower(c) || isUpper(c) || c == '_' || c == '>' {
+		b.writeByte(' ')
+	}
+}
+
+// writeQuals writes a list of qualifiers, each preceded by a space
+// if space is true or if it is not the first.
+func (b *msvcBuf) writeQuals(q msvcQuals, space bool) {
+	for _, qn := range []struct {
+		q    msvcQuals
+		name string
+	}{
+		{msvcConst, "const"},
+		{msvcVolatile, "volatile"},
+		{msvcRestrict, "__restrict"},
+	} {
+		if q&qn.q != 0 {
+			if space {
+				b.writeByte(' ')
+			}
+			b.writeString(qn.name)
+			space = true
+		}
+	}
+}
+
+// String returns the type as an abstract declarator.
+func (t *msvcType) String() string {
+	var b msvcBuf
+	t.printPre(&b, true)
+	t.printPost(&b)
+	return string(b.buf)
+}
+
+// printPre prints the part of the type that precedes the name.
+// If cc is false, the calling convention of a function type is omitted.
+func (t *msvcType) printPre(b *msvcBuf, cc bool) {
+	switch t.kind {
+	case msvcSimple:
+		if t.quals&msvcUnaligned != 0 {
+			b.writeString("__unaligned ")
+		}
+		b.writeString(t.name)
+		b.writeQuals(t.quals, true)
+	case msvcPointer:
+		if t.base.kind == msvcFunction {
+			t.base.printPre(b, false)
+		} else {
+			t.base.printPre(b, true)
+		}
+		b.spaceIfNecessary()
+		if t.quals&msvcUnaligned != 0 {
+			b.writeString("__unaligned ")
+		}
+		switch t.base.kind {
+		case msvcArray:
+			b.writeByte('(')
+		case msvcFunction:
+			b.writeByte('(')
+			if t.base.cc != "" {
+				b.writeString(t.base.cc)
+				b.writeByte(' ')
+			}
+		}
+		if t.class != "" {
+			b.writeString(t.class)
+			b.writeString("::")
+		}
+		b.writeString(t.ptr)
+		b.writeQuals(t.quals, false)
+	case msvcFunction:
+		if t.base != nil {
+			t.base.printPre(b, true)
+			b.writeByte(' ')
+		}
+		if cc && t.cc != "" {
+			b.writeString(t.cc)
+		}
+	case msvcArray:
+		t.base.printPre(b, true)
+		b.writeQuals(t.quals, true)
+	}
+}
+
+// printPost prints the part of the type that follows the name.
+func (t *msvcType) printPost(b *msvcBuf) {
+	switch t.kind {
+	case msvcPointer:
+		if t.base.kind == msvcFunction || t.base.kind == msvcArray {
+			b.writeByte(')')
+		}
+		t.base.printPost(b)
+	case msvcFunction:
+		t.printParams(b)
+		t.printFuncQuals(b)
+		if t.base != nil {
+			t.base.printPost(b)
+		}
+	case msvcArray:
+		for _, d := range t.dims {
+			fmt.Fprintf(&msvcWriter{b}, "[%d]", d)
+		}
+		t.base.printPost(b)
+	}
+}
+
+// printParams prints the parameter list of a function type.
+func (t *msvcType) printParams(b *msvcBuf) {
+	b.writeByte('(')
+	if len(t.params) == 0 && !t.variadic {
+		b.writeString("void")
+	}
+	for i, p := range t.params {
+		if i > 0 {
+			b.writeString(", ")
+		}
+		p.printPre(b, true)
+		p.printPost(b)
+	}
+	if t.variadic {
+		if b.last() != '(' {
+			b.writeString(", ")
+		}
+		b.writeString("...")
+	}
+	b.writeByte(')')
+}
+
+// printFuncQuals prints the qualifiers that follow a function's
+// parameter list.
+func (t *msvcType) printFuncQuals(b *msvcBuf) {
+	if t.funcQuals&msvcConst != 0 {
+		b.writeString(" const")
+	}
+	if t.funcQuals&msvcVolatile != 0 {
+		b.writeString(" volatile")
+	}
+	if t.funcQuals&msvcRestrict != 0 {
+		b.writeString(" __restrict")
+	}
+	if t.funcQuals&msvcUnaligned != 0 {
+		b.writeString(" __unaligned")
+	}
+	if t.noexcept {
+		b.writeString(" noexcept")
+	}
+	if t.ref != "" {
+		b.writeByte(' ')
+		b.writeString(t.ref)
+	}
+}
+
+// msvcWriter adapts a msvcBuf to io.Writer for fmt.Fprintf.
+type msvcWriter struct {
+	b *msvcBuf
+}
+
+func (w *msvcWriter) Write(p []byte) (int, error) {
+	w.b.buf = append(w.b.buf, p...)
+	return len(p), nil
+}
+
+// symbol parses a complete symbol, starting with the leading '?',
+// and returns the demangled string.
+func (mst *msvcState) symbol() string {
+	mst.enter()
+	defer mst.leave()
+
+	if mst.consume("??@") {
+		// An MD5 name, used for very long names.
+		// There is nothing to demangle.
+		i := strings.IndexByte(mst.str, '@')
+		if i < 0 {
+			mst.fail("expected @ after MD5 name")
+		}
+		s := "??@" + mst.str[:i+1]
+		mst.advance(i + 1)
+		return s
+	}
+
+	mst.checkChar('?')
+
+	if s, ok := mst.specialIntrinsic(); ok {
+		return s
+	}
+
+	name, kind := mst.fullyQualifiedSymbolName()
+	return mst.encodedSymbol(name, kind)
+}
+
+// msvcNameKind describes the kind of the unqualified name of a symbol.
+type msvcNameKind int
+
+const (
+	msvcOrdinaryName   msvcNameKind = iota
+	msvcConversionName              // conversion operator
+)
+
+// msvcSpecialNames maps the codes for special intrinsic symbols,
+// which follow "??_", to the string used to describe them.
+var msvcSpecialNames = map[string]string{
+	"7": "`vftable'",
+	"8": "`vbtable'",
+	"S": "`local vftable'",
+}
+
+// specialIntrinsic handles special symbols such as virtual tables,
+// RTTI information, and string literals. It reports whether the
+// symbol was special. We have already skipped the leading '?'.
+func (mst *msvcState) specialIntrinsic() (string, bool) {
+	if !strings.HasPrefix(mst.str, "?_") {
+		return "", false
+	}
+	code := mst.str[2:]
+	switch {
+	case strings.HasPrefix(code, "C@_"):
+		mst.advance(5)
+		return mst.stringLiteral(), true
+
+	case strings.HasPrefix(code, "7"), strings.HasPrefix(code, "8"), strings.HasPrefix(code, "S"):
+		mst.advance(3)
+		return mst.vtableSymbol(msvcSpecialNames[code[:1]]), true
+
+	case strings.HasPrefix(code, "R0"):
+		mst.advance(4)
+		t := mst.demangleType(true)
+		mst.checkChar('@')
+		mst.checkChar('8')
+		return t.String() + " `RTTI Type Descriptor'", true
+
+	case strings.HasPrefix(code, "R1"):
+		mst.advance(4)
+		var nums [4]int64
+		for i := range nums {
+			nums[i] = mst.number()
+		}
+		name := mst.fullyQualifiedTypeName()
+		mst.checkChar('8')
+		return fmt.Sprintf("%s::`RTTI Base Class Descriptor at (%d, %d, %d, %d)'", name, nums[0], nums[1], nums[2], nums[3]), true
+
+	case strings.HasPrefix(code, "R2"), strings.HasPrefix(code, "R3"):
+		desc := "`RTTI Base Class Array'"
+		if code[1] == '3' {
+			desc = "`RTTI Class Hierarchy Descriptor'"
+		}
+		mst.advance(4)
+		name := mst.fullyQualifiedTypeName()
+		mst.checkChar('8')
+		return name + "::" + desc, true
+
+	case strings.HasPrefix(code, "R4"):
+		mst.advance(4)
+		return mst.vtableSymbol("`RTTI Complete Object Locator'"), true
+
+	case strings.HasPrefix(code, "_E"), strings.HasPrefix(code, "_F"):
+		desc := "dynamic initializer"
+		if code[1] == 'F' {
+			desc = "dynamic atexit destructor"
+		}
+		mst.advance(4)
+		var name string
+		if mst.peek() == '?' {
+			name = fmt.Sprintf("`%s for `%s''", desc, mst.symbol())
+			mst.checkChar('@')
+			mst.checkChar('@')
+		} else {
+			name = fmt.Sprintf("`%s for '%s''", desc, strings.Join(mst.fullyQualifiedName(), "::"))
+		}
+		return mst.encodedSymbol([]string{name}, msvcOrdinaryName), true
+	}
+	return "", false
+}
+
+// stringLiteral parses the name of a string literal, after "??_C@_":
+//
+//	<char width> <length> <checksum> @ <chars> @
+//
+// The mangled name holds at most 32 bytes of the string, so the
+// result ends with "..." if the string is longer than that.
+func (mst *msvcState) stringLiteral() string {
+	var width int
+	var prefix string
+	switch mst.next() {
+	case '0':
+		width = 1
+	case '1':
+		width = 2
+		prefix = "L"
+	default:
+		mst.failEarlier("unrecognized string literal character width", 1)
+	}
+	length := mst.number()
+	if i := strings.IndexByte(mst.str, '@'); i < 0 {
+		mst.fail("expected @ after string literal checksum")
+	} else {
+		mst.advance(i + 1)
+	}
+
+	var bytes []byte
+	for !mst.consume("@") {
+		bytes = append(bytes, mst.stringLiteralByte())
+	}
+	if len(bytes)%width != 0 {
+		mst.fail("string literal length is not a multiple of the character width")
+	}
+
+	chars := make([]rune, 0, len(bytes)/width)
+	for i := 0; i < len(bytes); i += width {
+		c := rune(bytes[i])
+		if width == 2 {
+			c = c<<8 | rune(bytes[i+1])
+		}
+		chars = append(chars, c)
+	}
+
+	complete := int64(len(bytes)) == length
+	if complete && len(chars) > 0 && chars[len(chars)-1] == 0 {
+		chars = chars[:len(chars)-1]
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('"')
+	for _, c := range chars {
+		switch c {
+		case 0:
+			b.WriteString(`\0`)
+		case '\'', '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			if c > 0x1f && c < 0x7f {
+				b.WriteRune(c)
+			} else {
+				fmt.Fprintf(&b, `\x%X`, c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	if !complete {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// stringLiteralByte parses a single encoded byte of a string literal.
+func (mst *msvcState) stringLiteralByte() byte {
+	c := mst.next()
+	if c != '?' {
+		return c
+	}
+	c = mst.next()
+	switch {
+	case c == '$':
+		if len(mst.str) < 2 || mst.str[0] < 'A' || mst.str[0] > 'P' || mst.str[1] < 'A' || mst.str[1] > 'P' {
+			mst.fail("invalid escaped byte in string literal")
+		}
+		v := (mst.str[0]-'A')<<4 | (mst.str[1] - 'A')
+		mst.advance(2)
+		return v
+	case isDigit(c):
+		return ",/\\:. \n\t'-"[c-'0']
+	case isLower(c):
+		return 0xe1 + c - 'a'
+	case isUpper(c):
+		return 0xc1 + c - 'A'
+	default:
+		mst.failEarlier("invalid escape in string literal", 1)
+		panic("not reached")
+	}
+}
+
+// vtableSymbol parses the rest of a virtual table symbol, which is a
+// class name, a storage class, and an optional list of base classes.
+func (mst *msvcState) vtableSymbol(desc string) string {
+	name := mst.fullyQualifiedName()
+	c := mst.next()
+	if c != '6' && c != '7' {
+		mst.failEarlier("expected virtual table storage class", 1)
+	}
+	quals := mst.cvQualifiers()
+	var b msvcBuf
+	b.writeQuals(quals, false)
+	if len(b.buf) > 0 {
+		b.writeByte(' ')
+	}
+	b.writeString(strings.Join(name, "::"))
+	b.writeString("::")
+	b.writeString(desc)
+	if !mst.consume("@") {
+		b.writeString("{for ")
+		for i := 0; mst.peek() != '@'; i++ {
+			if i > 0 {
+				b.writeString("s ")
+			}
+			b.writeByte('`')
+			b.writeString(strings.Join(mst.fullyQualifiedName(), "::"))
+			b.writeByte('\'')
+		}
+		mst.checkChar('@')
+		b.writeByte('}')
+	}
+	return string(b.buf)
+}
+
+// failEarlier is like fail, but decrements the offset to indicate
+// that the point of failure occurred earlier in the string.
+func (mst *msvcState) failEarlier(err string, dec int) {
+	if mst.off < dec {
+		panic("internal error")
+	}
+	panic(Error{Msg: err, Offset: mst.off - dec})
+}
+
+// fullyQualifiedSymbolName parses the name of a symbol. The
+// unqualified name may be an operator. It returns the name
+// components, outermost first.
+func (mst *msvcState) fullyQualifiedSymbolName() ([]string, msvcNameKind) {
+	first, kind := mst.unqualifiedSymbolName()
+	scope := mst.nameScope()
+	if kind == msvcStructorName {
+		// A constructor or destructor takes its name from
+		// the enclosing class.
+		if len(scope) == 0 {
+			mst.fail("constructor or destructor without class")
+		}
+		class := scope[len(scope)-1]
+		if i := strings.IndexByte(class, '<'); i >= 0 && mst.noTemplateParams {
+			class = class[:i]
+		}
+		// The class name goes after the "~" of a destructor
+		// and before the arguments of a constructor template.
+		tilde := ""
+		if strings.HasPrefix(first, "~") {
+			tilde, first = "~", first[1:]
+		}
+		first = tilde + class + first
+		kind = msvcOrdinaryName
+	}
+	return append(scope, first), kind
+}
+
+// msvcStructorName is an internal name kind used while parsing a
+// constructor or destructor.
+const msvcStructorName msvcNameKind = -1
+
+// fullyQualifiedName parses a name that may not be an operator,
+// terminated by "@".
+func (mst *msvcState) fullyQualifiedName() []string {
+	first := mst.unqualifiedTypeName()
+	return append(mst.nameScope(), first)
+}
+
+// fullyQualifiedTypeName is like fullyQualifiedName, but returns a
+// single string.
+func (mst *msvcState) fullyQualifiedTypeName() string {
+	return strings.Join(mst.fullyQualifiedName(), "::")
+}
+
+// nameScope parses the scope of a name, up to the terminating "@".
+// It returns the components outermost first.
+func (mst *msvcState) nameScope() []string {
+	var rev []string
+	for {
+		if len(mst.str) == 0 {
+			mst.fail("expected @ at end of name")
+		}
+		if mst.str[0] == '@' {
+			mst.advance(1)
+			break
+		}
+		rev = append(rev, mst.scopePiece()...)
+	}
+	ret := make([]string, len(rev))
+	for i, s := range rev {
+		ret[len(rev)-1-i] = s
+	}
+	return ret
+}
+
+// scopePiece parses one element of a name scope. It returns the
+// components of the piece, innermost first; a function local scope
+// returns two components.
+func (mst *msvcState) scopePiece() []string {
+	c := mst.str[0]
+	switch {
+	case isDigit(c):
+		return []string{mst.nameBackref()}
+	case strings.HasPrefix(mst.str, "?$"):
+		name, _ := mst.templateInstantiationName(true)
+		return []string{name}
+	case strings.HasPrefix(mst.str, "?A"):
+		// An anonymous namespace, followed by a unique
+		// identifier that we discard.
+		mst.advance(2)
+		i := strings.IndexByte(mst.str, '@')
+		if i < 0 {
+			mst.fail("expected @ after anonymous namespace")
+		}
+		mst.advance(i + 1)
+		mst.memorizeName("`anonymous namespace'")
+		return []string{"`anonymous namespace'"}
+	case c == '?' && len(mst.str) > 1 && (isDigit(mst.str[1]) || mst.str[1] == '?'):
+		// A name local to a function:
+		//	? <number> ? <function symbol>
+		mst.advance(1)
+		if mst.peek() == '?' {
+			// A nested symbol with no number.
+			fn := mst.symbol()
+			return []string{"`" + fn + "'"}
+		}
+		num := mst.number()
+		mst.checkChar('?')
+		saved := mst.backrefs
+		mst.backrefs = msvcBackrefs{}
+		fn := mst.symbol()
+		mst.backrefs = saved
+		return []string{fmt.Sprintf("`%d'", num), "`" + fn + "'"}
+	case c == '?':
+		// An operator name used as a scope, as for a local
+		// class in an operator function.
+		name, _ := mst.unqualifiedSymbolName()
+		return []string{name}
+	default:
+		return []string{mst.simpleName(true)}
+	}
+}
+
+// unqualifiedTypeName parses the first component of a name that may
+// not be an operator.
+func (mst *msvcState) unqualifiedTypeName() string {
+	if len(mst.str) == 0 {
+		mst.fail("expected name")
+	}
+	c := mst.str[0]
+	switch {
+	case isDigit(c):
+		return mst.nameBackref()
+	case strings.HasPrefix(mst.str, "?$"):
+		name, _ := mst.templateInstantiationName(true)
+		return name
+	default:
+		return mst.simpleName(true)
+	}
+}
+
+// unqualifiedSymbolName parses the first component of a symbol name,
+// which may be an operator.
+func (mst *msvcState) unqualifiedSymbolName() (string, msvcNameKind) {
+	if len(mst.str) == 0 {
+		mst.fail("expected name")
+	}
+	c := mst.str[0]
+	switch {
+	case isDigit(c):
+		return mst.nameBackref(), msvcOrdinaryName
+	case strings.HasPrefix(mst.str, "?$"):
+		return mst.templateInstantiationName(true)
+	case c == '?':
+		return mst.operatorName()
+	default:
+		return mst.simpleName(true), msvcOrdinaryName
+	}
+}
+
+// simpleName parses a name terminated by "@".
+func (mst *msvcState) simpleName(memorize bool) string {
+	i := strings.IndexByte(mst.str, '@')
+	if i <= 0 {
+		mst.fail("expected name terminated by @")
+	}
+	s := mst.str[:i]
+	mst.advance(i + 1)
+	if memorize {
+		mst.memorizeName(s)
+	}
+	return s
+}
+
+// memorizeName adds a name to the back reference table.
+func (mst *msvcState) memorizeName(s string) {
+	for _, n := range mst.backrefs.names {
+		if n == s {
+			return
+		}
+	}
+	if len(mst.backrefs.names) < 10 {
+		mst.backrefs.names = append(mst.backrefs.names, s)
+	}
+}
+
+// nameBackref parses a digit that refers to a previous name.
+func (mst *msvcState) nameBackref() string {
+	i := int(mst.next() - '0')
+	if i >= len(mst.backrefs.names) {
+		mst.failEarlier("name back reference out of range", 1)
+	}
+	return mst.backrefs.names[i]
+}
+
+// templateInstantiationName parses
+//
+//	?$ <name> <template-args> @
+//
+// For a constructor or destructor template the name is just the
+// template arguments, and the caller adds the class name.
+func (mst *msvcState) templateInstantiationName(memorize bool) (string, msvcNameKind) {
+	mst.checkChar('?')
+	mst.checkChar('$')
+
+	// Template arguments have their own back references.
+	saved := mst.backrefs
+	mst.backrefs = msvcBackrefs{}
+
+	var name string
+	kind := msvcOrdinaryName
+	if mst.peek() == '?' {
+		name, kind = mst.operatorName()
+	} else {
+		name = mst.simpleName(true)
+	}
+	args := mst.templateArgs()
+
+	mst.backrefs = saved
+
+	if !mst.noTemplateParams {
+		name += "<" + strings.Join(args, ", ") + ">"
+	} else if mst.elideTemplateParams {
+		name += "<...>"
+	}
+	if memorize && kind != msvcStructorName {
+		mst.memorizeName(name)
+	}
+	return name, kind
+}
+
+// templateArgs parses a list of template arguments terminated by "@".
+func (mst *msvcState) templateArgs() []string {
+	var args []string
+	for !mst.consume("@") {
+		if len(mst.str) == 0 {
+			mst.fail("expected @ after template arguments")
+		}
+		switch {
+		case mst.consume("$$V"), mst.consume("$$Z"), mst.consume("$S"):
+			// An empty parameter pack.
+		case mst.consume("$$$V"):
+			// An empty parameter pack.
+		case mst.consume("$0"):
+			args = append(args, fmt.Sprintf("%d", mst.number()))
+		case mst.consume("$1"):
+			args = append(args, "&"+mst.entitySymbol())
+		case mst.consume("$E"):
+			args = append(args, mst.entitySymbol())
+		case strings.HasPrefix(mst.str, "$H"), strings.HasPrefix(mst.str, "$I"), strings.HasPrefix(mst.str, "$J"):
+			// A pointer to member function, followed by
+			// one, two, or three offsets.
+			count := int(mst.str[1]-'H') + 1
+			mst.advance(2)
+			parts := []string{mst.entitySymbol()}
+			for i := 0; i < count; i++ {
+				parts = append(parts, fmt.Sprintf("%d", mst.number()))
+			}
+			args = append(args, "{"+strings.Join(parts, ", ")+"}")
+		case mst.consume("$F"):
+			a := mst.number()
+			b := mst.number()
+			args = append(args, fmt.Sprintf("{%d, %d}", a, b))
+		case mst.consume("$G"):
+			a := mst.number()
+			b := mst.number()
+			c := mst.number()
+			args = append(args, fmt.Sprintf("{%d, %d, %d}", a, b, c))
+		case mst.consume("$D"), mst.consume("$Q"):
+			args = append(args, fmt.Sprintf("`template-parameter%d'", mst.number()))
+		case mst.consume("$$A6"):
+			args = append(args, mst.functionType(false).String())
+		default:
+			args = append(args, mst.demangleType(true).String())
+		}
+	}
+	return args
+}
+
+// entitySymbol parses a symbol used as a template argument.
+func (mst *msvcState) entitySymbol() string {
+	if mst.peek() != '?' {
+		mst.fail("expected symbol")
+	}
+	return mst.symbol()
+}
+
+// msvcOperators maps operator codes to operator names.
+// Codes that start with "_" follow "?_" and codes that start with "__"
+// follow "?__".
+var msvcOperators = map[string]string{
+	"2":  "operator new",
+	"3":  "operator delete",
+	"4":  "operator=",
+	"5":  "operator>>",
+	"6":  "operator<<",
+	"7":  "operator!",
+	"8":  "operator==",
+	"9":  "operator!=",
+	"A":  "operator[]",
+	"C":  "operator->",
+	"D":  "operator*",
+	"E":  "operator++",
+	"F":  "operator--",
+	"G":  "operator-",
+	"H":  "operator+",
+	"I":  "operator&",
+	"J":  "operator->*",
+	"K":  "operator/",
+	"L":  "operator%",
+	"M":  "operator<",
+	"N":  "operator<=",
+	"O":  "operator>",
+	"P":  "operator>=",
+	"Q":  "operator,",
+	"R":  "operator()",
+	"S":  "operator~",
+	"T":  "operator^",
+	"U":  "operator|",
+	"V":  "operator&&",
+	"W":  "operator||",
+	"X":  "operator*=",
+	"Y":  "operator+=",
+	"Z":  "operator-=",
+	"_0": "operator/=",
+	"_1": "operator%=",
+	"_2": "operator>>=",
+	"_3": "operator<<=",
+	"_4": "operator&=",
+	"_5": "operator|=",
+	"_6": "operator^=",
+	"_7": "`vftable'",
+	"_8": "`vbtable'",
+	"_9": "`vcall'",
+	"_A": "`typeof'",
+	"_B": "`local static guard'",
+	"_C": "`string'",
+	"_D": "`vbase dtor'",
+	"_E": "`vector deleting dtor'",
+	"_F": "`default ctor closure'",
+	"_G": "`scalar deleting dtor'",
+	"_H": "`vector ctor iterator'",
+	"_I": "`vector dtor iterator'",
+	"_J": "`vector vbase ctor iterator'",
+	"_K": "`virtual displacement map'",
+	"_L": "`eh vector ctor iterator'",
+	"_M": "`eh vector dtor iterator'",
+	"_N": "`eh vector vbase ctor iterator'",
+	"_O": "`copy ctor closure'",
+	"_S": "`local vftable'",
+	"_T": "`local vftable ctor closure'",
+	"_U": "operator new[]",
+	"_V": "operator delete[]",
+	"_X": "`placement delete closure'",
+	"_Y": "`placement delete[] closure'",
+
+	"__A": "`managed vector ctor iterator'",
+	"__B": "`managed vector dtor iterator'",
+	"__C": "`EH vector copy ctor iterator'",
+	"__D": "`EH vector vbase copy ctor iterator'",
+	"__G": "`vector copy ctor iterator'",
+	"__H": "`vector vbase copy ctor iterator'",
+	"__I": "`managed vector copy ctor iterator'",
+	"__J": "`local static thread guard'",
+	"__L": "operator co_await",
+	"__M": "operator<=>",
+}
+
+// operatorName parses an operator name, which starts with "?".
+func (mst *msvcState) operatorName() (string, msvcNameKind) {
+	mst.checkChar('?')
+	switch {
+	case mst.consume("0"):
+		return "", msvcStructorName
+	case mst.consume("1"):
+		return "~", msvcStructorName
+	case mst.consume("B"):
+		return "operator", msvcConversionName
+	case mst.consume("__K"):
+		return `operator ""` + mst.simpleName(true), msvcOrdinaryName
+	}
+	code := ""
+	for i := 0; i < len(mst.str) && i < 3; i++ {
+		code += mst.str[i : i+1]
+		if mst.str[i] != '_' {
+			break
+		}
+	}
+	op, ok := msvcOperators[code]
+	if !ok {
+		mst.fail("unrecognized operator code")
+	}
+	mst.advance(len(code))
+	return op, msvcOrdinaryName
+}
+
+// number parses a number:
+//
+//	[?] <digit>          value is digit + 1
+//	[?] <hex digits> @   using A to P for 0 to 15
+func (mst *msvcState) number() int64 {
+	neg := mst.consume("?")
+	if len(mst.str) == 0 {
+		mst.fail("expected number")
+	}
+	var val int64
+	if c := mst.str[0]; isDigit(c) {
+		val = int64(c-'0') + 1
+		mst.advance(1)
+	} else {
+		for {
+			if len(mst.str) == 0 {
+				mst.fail("expected @ after number")
+			}
+			c := mst.str[0]
+			mst.advance(1)
+			if c == '@' {
+				break
+			}
+			if c < 'A' || c > 'P' {
+				mst.failEarlier("invalid character in number", 1)
+			}
+			if val >= 1<<59 {
+				mst.fail("numeric overflow")
+			}
+			val = val*16 + int64(c-'A')
+		}
+	}
+	if neg {
+		val = -val
+	}
+	return val
+}
+
+// msvcAccess describes the access and kind of a member function,
+// indexed by the letter following the name.
+var msvcAccess = map[byte]struct {
+	prefix string // access and kind, such as "public: virtual "
+	member bool   // non-static member function
+	thunk  bool   // adjustor thunk
+}{
+	'A': {"private: ", true, false},
+	'B': {"private: ", true, false},
+	'C': {"private: static ", false, false},
+	'D': {"private: static ", false, false},
+	'E': {"private: virtual ", true, false},
+	'F': {"private: virtual ", true, false},
+	'G': {"[thunk]: private: virtual ", true, true},
+	'H': {"[thunk]: private: virtual ", true, true},
+	'I': {"protected: ", true, false},
+	'J': {"protected: ", true, false},
+	'K': {"protected: static ", false, false},
+	'L': {"protected: static ", false, false},
+	'M': {"protected: virtual ", true, false},
+	'N': {"protected: virtual ", true, false},
+	'O': {"[thunk]: protected: virtual ", true, true},
+	'P': {"[thunk]: protected: virtual ", true, true},
+	'Q': {"public: ", true, false},
+	'R': {"public: ", true, false},
+	'S': {"public: static ", false, false},
+	'T': {"public: static ", false, false},
+	'U': {"public: virtual ", true, false},
+	'V': {"public: virtual ", true, false},
+	'W': {"[thunk]: public: virtual ", true, true},
+	'X': {"[thunk]: public: virtual ", true, true},
+	'Y': {"", false, false},
+	'Z': {"", false, false},
+}
+
+// msvcVtordisp describes vtordisp thunks, indexed by the digit
+// following "$".
+var msvcVtordisp = map[byte]string{
+	'0': "[thunk]: private: virtual ",
+	'1': "[thunk]: private: virtual ",
+	'2': "[thunk]: protected: virtual ",
+	'3': "[thunk]: protected: virtual ",
+	'4': "[thunk]: public: virtual ",
+	'5': "[thunk]: public: virtual ",
+}
+
+// msvcVariableAccess describes the storage class of a variable,
+// indexed by the digit following the name.
+var msvcVariableAccess = map[byte]string{
+	'0': "private: static ",
+	'1': "protected: static ",
+	'2': "public: static ",
+	'3': "",
+	'4': "",
+}
+
+// encodedSymbol parses the type information that follows a symbol
+// name, and returns the complete demangled string.
+func (mst *msvcState) encodedSymbol(name []string, kind msvcNameKind) string {
+	if len(mst.str) == 0 {
+		mst.fail("expected symbol type")
+	}
+
+	c := mst.str[0]
+	if prefix, ok := msvcVariableAccess[c]; ok {
+		mst.advance(1)
+		t := mst.demangleType(false)
+		quals := mst.storageQualifiers()
+		if t.kind == msvcPointer && t.class == "" {
+			// The qualifiers of a pointer variable are
+			// those of the pointed to type, which we
+			// already know.
+			t.base.quals |= quals
+		} else {
+			t.quals |= quals
+		}
+		if mst.noParams {
+			return strings.Join(name, "::")
+		}
+		var b msvcBuf
+		b.writeString(prefix)
+		t.printPre(&b, true)
+		b.spaceIfNecessary()
+		b.writeString(strings.Join(name, "::"))
+		t.printPost(&b)
+		return string(b.buf)
+	}
+	switch c {
+	case '6', '7':
+		mst.advance(1)
+		quals := mst.cvQualifiers()
+		var b msvcBuf
+		b.writeQuals(quals, false)
+		if len(b.buf) > 0 {
+			b.writeByte(' ')
+		}
+		b.writeString(strings.Join(name, "::"))
+		mst.checkChar('@')
+		return string(b.buf)
+	case '8':
+		mst.advance(1)
+		return strings.Join(name, "::")
+	}
+
+	if mst.consume("$B") {
+		// A virtual call thunk: the offset in the virtual
+		// table, the flat pointer model, and the calling
+		// convention.
+		off := mst.number()
+		mst.checkChar('A')
+		cc := mst.callingConvention()
+		fullName := fmt.Sprintf("%s{%d, {flat}}' }'", strings.Join(name, "::"), off)
+		if mst.noParams {
+			return fullName
+		}
+		return "[thunk]: " + cc + " " + fullName
+	}
+
+	var prefix, suffix string
+	member := false
+	if mst.consume("$$J0") {
+		prefix = "extern \"C\" "
+	}
+	if mst.consume("$") {
+		c := mst.next()
+		p, ok := msvcVtordisp[c]
+		if !ok {
+			mst.failEarlier("unrecognized vtordisp code", 1)
+		}
+		prefix = p + prefix
+		member = true
+		a := int32(mst.number())
+		b := int32(mst.number())
+		suffix = fmt.Sprintf("`vtordisp{%d, %d}'", a, b)
+	} else {
+		c := mst.next()
+		acc, ok := msvcAccess[c]
+		if !ok {
+			mst.failEarlier("unrecognized function access code", 1)
+		}
+		prefix = acc.prefix + prefix
+		member = acc.member
+		if acc.thunk {
+			suffix = fmt.Sprintf("`adjustor{%d}'", int32(mst.number()))
+		}
+	}
+
+	ft := mst.functionType(member)
+
+	if kind == msvcConversionName {
+		name[len(name)-1] = "operator " + ft.base.String()
+	}
+
+	fullName := strings.Join(name, "::") + suffix
+	if mst.noParams {
+		return fullName
+	}
+
+	var b msvcBuf
+	b.writeString(prefix)
+	ft.printPre(&b, true)
+	b.spaceIfNecessary()
+	b.writeString(fullName)
+	ft.printPost(&b)
+	return string(b.buf)
+}
+
+// functionType parses a function type:
+//
+//	[<this qualifiers>] <calling convention> <return type> <parameters> <throw spec>
+//
+// The this qualifiers are only present for a non-static member function.
+func (mst *msvcState) functionType(member bool) *msvcType {
+	ft := &msvcType{kind: msvcFunction}
+	if member {
+		ft.funcQuals, ft.ref = mst.thisQualifiers()
+	}
+	ft.cc = mst.callingConvention()
+	if !mst.consume("@") {
+		ft.base = mst.returnType()
+	}
+	ft.params, ft.variadic = mst.paramList()
+	if mst.consume("_E") {
+		ft.noexcept = true
+	} else {
+		mst.checkChar('Z')
+	}
+	return ft
+}
+
+// thisQualifiers parses the qualifiers of a member function:
+// pointer modifiers and ref-qualifiers followed by a cv-qualifier.
+func (mst *msvcState) thisQualifiers() (msvcQuals, string) {
+	var quals msvcQuals
+	ref := ""
+	for {
+		switch mst.peek() {
+		case 'E':
+			// __ptr64, not printed.
+		case 'F':
+			quals |= msvcUnaligned
+		case 'I':
+			quals |= msvcRestrict
+		case 'G':
+			ref = "&"
+		case 'H':
+			ref = "&&"
+		default:
+			return quals | mst.cvQualifiers(), ref
+		}
+		mst.advance(1)
+	}
+}
+
+// msvcCallingConventions maps calling convention codes to names.
+var msvcCallingConventions = map[byte]string{
+	'A': "__cdecl",
+	'B': "__cdecl",
+	'C': "__pascal",
+	'D': "__pascal",
+	'E': "__thiscall",
+	'F': "__thiscall",
+	'G': "__stdcall",
+	'H': "__stdcall",
+	'I': "__fastcall",
+	'J': "__fastcall",
+	'K': "",
+	'L': "",
+	'M': "__clrcall",
+	'N': "__clrcall",
+	'O': "__eabi",
+	'P': "__eabi",
+	'Q': "__vectorcall",
+	'S': "__attribute__((__swiftcall__))",
+	'T': "__attribute__((__swiftasynccall__))",
+	'W': "__regcall",
+}
+
+// callingConvention parses a calling convention.
+func (mst *msvcState) callingConvention() string {
+	c := mst.next()
+	cc, ok := msvcCallingConventions[c]
+	if !ok {
+		mst.failEarlier("unrecognized calling convention", 1)
+	}
+	return cc
+}
+
+// returnType parses a function return type, which may be preceded by
+// "?" and a cv-qualifier.
+func (mst *msvcState) returnType() *msvcType {
+	var quals msvcQuals
+	if mst.consume("?") {
+		quals = mst.cvQualifiers()
+	}
+	t := mst.demangleType(false)
+	t.quals |= quals
+	return t
+}
+
+// paramList parses a function parameter list. It returns the
+// parameters and whether the function is variadic.
+func (mst *msvcState) paramList() ([]*msvcType, bool) {
+	if mst.consume("X") {
+		return nil, false
+	}
+	var params []*msvcType
+	for {
+		if len(mst.str) == 0 {
+			mst.fail("expected end of parameter list")
+		}
+		c := mst.str[0]
+		if c == '@' {
+			mst.advance(1)
+			return params, false
+		}
+		if c == 'Z' {
+			mst.advance(1)
+			return params, true
+		}
+		if isDigit(c) {
+			mst.advance(1)
+			i := int(c - '0')
+			if i >= len(mst.backrefs.params) {
+				mst.failEarlier("parameter back reference out of range", 1)
+			}
+			params = append(params, mst.backrefs.params[i])
+			continue
+		}
+		start := mst.off
+		t := mst.demangleType(false)
+		if mst.off-start > 1 && len(mst.backrefs.params) < 10 {
+			mst.backrefs.params = append(mst.backrefs.params, t)
+		}
+		params = append(params, t)
+	}
+}
+
+// storageQualifiers parses the qualifiers that follow the type of a
+// variable: optional pointer modifiers and a cv-qualifier.
+func (mst *msvcState) storageQualifiers() msvcQuals {
+	var quals msvcQuals
+	for {
+		switch mst.peek() {
+		case 'E':
+			// __ptr64, not printed.
+		case 'F':
+			quals |= msvcUnaligned
+		case 'I':
+			quals |= msvcRestrict
+		default:
+			return quals | mst.cvQualifiers()
+		}
+		mst.advance(1)
+	}
+}
+
+// cvQualifiers parses a single cv-qualifier code:
+//
+//	A: none; B: const; C: volatile; D: const volatile
+func (mst *msvcState) cvQualifiers() msvcQuals {
+	switch mst.next() {
+	case 'A', 'Q':
+		return 0
+	case 'B', 'R':
+		return msvcConst
+	case 'C', 'S':
+		return msvcVolatile
+	case 'D', 'T':
+		return msvcConst | msvcVolatile
+	default:
+		mst.failEarlier("unrecognized cv-qualifier", 1)
+		panic("not reached")
+	}
+}
+
+// msvcBuiltinTypes maps single letter type codes to type names.
+var msvcBuiltinTypes = map[byte]string{
+	'C': "signed char",
+	'D': "char",
+	'E': "unsigned char",
+	'F': "short",
+	'G': "unsigned short",
+	'H': "int",
+	'I': "unsigned int",
+	'J': "long",
+	'K': "unsigned long",
+	'M': "float",
+	'N': "double",
+	'O': "long double",
+	'X': "void",
+}
+
+// msvcExtendedTypes maps type codes that follow "_" to type names.
+var msvcExtendedTypes = map[byte]string{
+	'D': "__int8",
+	'E': "unsigned __int8",
+	'F': "__int16",
+	'G': "unsigned __int16",
+	'H': "__int32",
+	'I': "unsigned __int32",
+	'J': "__int64",
+	'K': "unsigned __int64",
+	'L': "__int128",
+	'M': "unsigned __int128",
+	'N': "bool",
+	'Q': "char8_t",
+	'S': "char16_t",
+	'U': "char32_t",
+	'W': "wchar_t",
+}
+
+// demangleType parses a type. If qualified is true, the type may be
+// preceded by "?" and a cv-qualifier, as in template arguments.
+func (mst *msvcState) demangleType(qualified bool) *msvcType {
+	mst.enter()
+	defer mst.leave()
+
+	var quals msvcQuals
+	if qualified && mst.consume("?") {
+		quals = mst.cvQualifiers()
+	}
+
+	if len(mst.str) == 0 {
+		mst.fail("expected type")
+	}
+
+	var t *msvcType
+	c := mst.str[0]
+	if name, ok := msvcBuiltinTypes[c]; ok {
+		mst.advance(1)
+		t = &msvcType{kind: msvcSimple, name: name}
+	} else {
+		switch c {
+		case '_':
+			mst.advance(1)
+			c2 := mst.next()
+			name, ok := msvcExtendedTypes[c2]
+			if !ok {
+				mst.failEarlier("unrecognized extended type code", 1)
+			}
+			t = &msvcType{kind: msvcSimple, name: name}
+		case 'T', 'U', 'V':
+			mst.advance(1)
+			keyword := map[byte]string{'T': "union ", 'U': "struct ", 'V': "class "}[c]
+			t = &msvcType{kind: msvcSimple, name: keyword + mst.fullyQualifiedTypeName()}
+		case 'W':
+			mst.advance(1)
+			// The enum underlying type, which we ignore.
+			mst.next()
+			t = &msvcType{kind: msvcSimple, name: "enum " + mst.fullyQualifiedTypeName()}
+		case 'P', 'Q', 'R', 'S', 'A', 'B':
+			t = mst.pointerType()
+		case 'Y':
+			mst.advance(1)
+			t = mst.arrayType()
+		case '$':
+			switch {
+			case mst.consume("$$T"):
+				t = &msvcType{kind: msvcSimple, name: "std::nullptr_t"}
+			case mst.consume("$$Q"):
+				t = mst.pointerTo("&&", 0)
+			case mst.consume("$$R"):
+				t = mst.pointerTo("&&", msvcVolatile)
+			case mst.consume("$$A6"), mst.consume("$$A8@@"):
+				t = mst.functionType(false)
+			case mst.consume("$$BY"):
+				t = mst.arrayType()
+			case mst.consume("$$C"):
+				q := mst.cvQualifiers()
+				t = mst.demangleType(false)
+				t.quals |= q
+			default:
+				mst.fail("unrecognized $ type code")
+			}
+		case '?':
+			// A template parameter type name.
+			mst.advance(1)
+			t = &msvcType{kind: msvcSimple, name: fmt.Sprintf("`template-parameter%d'", mst.number())}
+		default:
+			mst.fail("unrecognized type code")
+		}
+	}
+	t.quals |= quals
+	return t
+}
+
+// pointerType parses a pointer or reference type.
+func (mst *msvcState) pointerType() *msvcType {
+	c := mst.next()
+	var ptr string
+	var quals msvcQuals
+	switch c {
+	case 'A':
+		ptr = "&"
+	case 'B':
+		ptr = "&"
+		quals = msvcVolatile
+	case 'P':
+		ptr = "*"
+	case 'Q':
+		ptr = "*"
+		quals = msvcConst
+	case 'R':
+		ptr = "*"
+		quals = msvcVolatile
+	case 'S':
+		ptr = "*"
+		quals = msvcConst | msvcVolatile
+	}
+	return mst.pointerTo(ptr, quals)
+}
+
+// pointerTo parses the pointer modifiers and pointed to type
+// following a pointer type code.
+func (mst *msvcState) pointerTo(ptr string, quals msvcQuals) *msvcType {
+	t := &msvcType{kind: msvcPointer, ptr: ptr}
+	for {
+		switch mst.peek() {
+		case 'E':
+			// __ptr64, not printed.
+			mst.advance(1)
+			continue
+		case 'F':
+			quals |= msvcUnaligned
+			mst.advance(1)
+			continue
+		case 'I':
+			quals |= msvcRestrict
+			mst.advance(1)
+			continue
+		}
+		break
+	}
+	t.quals = quals
+
+	switch c := mst.peek(); {
+	case c == '6':
+		mst.advance(1)
+		t.base = mst.functionType(false)
+	case c == '8':
+		mst.advance(1)
+		t.class = mst.fullyQualifiedTypeName()
+		t.base = mst.functionType(true)
+	case c >= 'Q' && c <= 'T':
+		q := mst.cvQualifiers()
+		t.class = mst.fullyQualifiedTypeName()
+		t.base = mst.demangleType(false)
+		t.base.quals |= q
+	default:
+		q := mst.cvQualifiers()
+		t.base = mst.demangleType(false)
+		t.base.quals |= q
+	}
+	return t
+}
+
+// arrayType parses an array type, after the "Y":
+//
+//	<number of dimensions> <dimension>+ <element type>
+func (mst *msvcState) arrayType() *msvcType {
+	n := mst.number()
+	if n <= 0 {
+		mst.fail("invalid number of array dimensions")
+	}
+	if n > int64(len(mst.str)) {
+		mst.fail("too many array dimensions")
+	}
+	dims := make([]int64, n)
+	for i := range dims {
+		dims[i] = mst.number()
+	}
+	elem := mst.demangleType(false)
+	return &msvcType{kind: msvcArray, base: elem, dims: dims}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestMSVC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"?f@@YAXXZ", "void __cdecl f(void)"},
+		{"?Fn@Class@@QEAAHXZ", "public: int __cdecl Class::Fn(void)"},
+		{"??0Class@@QEAA@XZ", "public: __cdecl Class::Class(void)"},
+		{"??1Class@@QEAA@XZ", "public: __cdecl Class::~Class(void)"},
+		{"??$?0H@A@@QAE@H@Z", "public: __thiscall A::A<int>(int)"},
+		{"??$?1H@A@@QAE@XZ", "public: __thiscall A::~A<int>(void)"},
+		{"??_9A@@$BA@AE", "[thunk]: __thiscall A::`vcall'{0, {flat}}' }'"},
+		{"??_9Base@@$B7AA", "[thunk]: __cdecl Base::`vcall'{8, {flat}}' }'"},
+		{"?f@C@@QBEXXZ", "public: void __thiscall C::f(void) const"},
+		{"?f@C@@QEGAAXXZ", "public: void __cdecl C::f(void) &"},
+		{"?s@C@@2HA", "public: static int C::s"},
+		{"?f@C@@SAXXZ", "public: static void __cdecl C::f(void)"},
+		{"?f@C@@UEAAXXZ", "public: virtual void __cdecl C::f(void)"},
+		{"?f@@YAXPEBD@Z", "void __cdecl f(char const *)"},
+		{"?f@@YAXPEAH0@Z", "void __cdecl f(int *, int *)"},
+		{"?f@@YAXHZZ", "void __cdecl f(int, ...)"},
+		{"?f@@YAX_N_W@Z", "void __cdecl f(bool, wchar_t)"},
+		{"?f@@YAX$$QEAH@Z", "void __cdecl f(int &&)"},
+		{"?x@@3QEAHEA", "int *const x"},
+		{"?a@@3PAY01HA", "int (*a)[2]"},
+		{"?f@@YAXP6AXH@Z@Z", "void __cdecl f(void (__cdecl *)(int))"},
+		{"?f@@YAXP8C@@EAAXXZ@Z", "void __cdecl f(void (__cdecl C::*)(void))"},
+		{"?f@@YAXPEQC@@H@Z", "void __cdecl f(int C::*)"},
+		{"??$f@H@@YAXH@Z", "void __cdecl f<int>(int)"},
+		{"??$f@$0?0@@YAXXZ", "void __cdecl f<-1>(void)"},
+		{"??$f@$1?x@@3HA@@YAXXZ", "void __cdecl f<&int x>(void)"},
+		{"??$f@$H?g@C@@QEAAXXZA@@@YAXXZ", "void __cdecl f<{public: void __cdecl C::g(void), 0}>(void)"},
+		{"?f@?$C@H@@QEAAXXZ", "public: void __cdecl C<int>::f(void)"},
+		{
+			"?f@@YAXAEBV?$basic_string@DU?$char_traits@D@std@@V?$allocator@D@2@@std@@@Z",
+			"void __cdecl f(class std::basic_string<char, struct std::char_traits<char>, class std::allocator<char>> const &)",
+		},
+		{"?f@?A0x12345678@@YAXXZ", "void __cdecl `anonymous namespace'::f(void)"},
+		{"?x@?1??f@@YAXXZ@4HA", "int `void __cdecl f(void)'::`2'::x"},
+		{"??4C@@QEAAAEAV0@AEBV0@@Z", "public: class C & __cdecl C::operator=(class C const &)"},
+		{"??BC@@QEBAHXZ", "public: int __cdecl C::operator int(void) const"},
+		{"?f@C@@W7EAAXXZ", "[thunk]: public: virtual void __cdecl C::f`adjustor{8}'(void)"},
+		{"??_7C@@6B@", "const C::`vftable'"},
+		{"??_7C@@6BA@@B@@@", "const C::`vftable'{for `A's `B'}"},
+		{"??_R0?AVA@@@8", "class A `RTTI Type Descriptor'"},
+		{"??_R4A@@6B@", "const A::`RTTI Complete Object Locator'"},
+		{"??_C@_05ABCDEFGH@hello?$AA@", `"hello"`},
+		{"??_C@_0CF@LABBIIMO@012345678901234567890123456789AB@", `"012345678901234567890123456789AB"...`},
+		{"??_C@_02ABCDEFGH@?$AN?6?$AA@", `"\r\n"`},
+		{"??_C@_13ABCDEFGH@?$AAh?$AA?$AA@", `L"h"`},
+		{"??_R1A@?0A@EA@B@@8", "B::`RTTI Base Class Descriptor at (0, -1, 0, 64)'"},
+		{"?f@C@@$4PPPPPPPM@A@EAAXXZ", "[thunk]: public: virtual void __cdecl C::f`vtordisp{-4, 0}'(void)"},
+		{"??__F?x@C@@2HA@@YAXXZ", "void __cdecl `dynamic atexit destructor for `public: static int C::x''(void)"},
+		{"??_GC@@UEAAPEAXI@Z", "public: virtual void * __cdecl C::`scalar deleting dtor'(unsigned int)"},
+		{"?x@@3PEBQEBDEB", "char const *const *x"},
+		{"??__Ex@@YAXXZ", "void __cdecl `dynamic initializer for 'x''(void)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestMSVCOptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"?Fn@Class@@QEAAHXZ", []Option{NoParams}, "Class::Fn"},
+		{"?f@?$C@H@@QEAAXXZ", []Option{NoTemplateParams}, "public: void __cdecl C::f(void)"},
+		{"??0?$C@H@@QEAA@XZ", []Option{NoTemplateParams}, "public: __cdecl C::C(void)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+
+	if _, err := ToString("?f@@YAXXZ", NoMSVC); err != ErrNotMangledName {
+		t.Errorf("demangling ?f@@YAXXZ with NoMSVC: got error %v, want %v", err, ErrNotMangledName)
+	}
+	if got := Filter("?f@@YAXXZ", NoMSVC); got != "?f@@YAXXZ" {
+		t.Errorf("filtering ?f@@YAXXZ with NoMSVC: got %s, want ?f@@YAXXZ", got)
+	}
+}
+
+func TestMSVCFailure(t *testing.T) {
+	for _, input := range []string{
+		"?",
+		"?f",
+		"?f@@",
+		"?f@@YAX",
+		"?f@@YAXXZextra",
+		"?f@@YAX9@Z",
+		"??0@QEAA@XZ",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package demangle
+
+import "iter"
+
+// Nodes returns an iterator over the nodes of an AST in preorder:
+// each node is visited before its children. The nodes are those
+// that Walk visits, in the same order as the calls to Enter.
+func Nodes(a AST) iter.Seq[AST] {
+	return func(yield func(AST) bool) {
+		Walk(a, &seqVisitor{yield: yield})
+	}
+}
+
+// NodesPostorder is like Nodes, but visits the nodes in postorder:
+// each node is visited after its children, in the same order as
+// the calls to the Exit method of a Visitor.
+func NodesPostorder(a AST) iter.Seq[AST] {
+	return func(yield func(AST) bool) {
+		Walk(a, &seqVisitor{yield: yield, post: true})
+	}
+}
+
+// seqVisitor is a Visitor that passes nodes to an iterator's yield
+// function.
+type seqVisitor struct {
+	yield func(AST) bool
+	post  bool // whether to yield after the children
+	done  bool // whether yield returned false
+}
+
+func (v *seqVisitor) Enter(a AST) bool {
+	if v.done {
+		return false
+	}
+	if !v.post && !v.yield(a) {
+		v.done = true
+		return false
+	}
+	return true
+}
+
+func (v *seqVisitor) Exit(a AST) {
+	if v.post && !v.done && !v.yield(a) {
+		v.done = true
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package demangle
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+	"testing"
+)
+
+// nodeNames returns the type names of nodes, for tests.
+func nodeNames(nodes []AST) string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, strings.TrimPrefix(fmt.Sprintf("%T", n), "*demangle."))
+	}
+	return strings.Join(names, " ")
+}
+
+func TestNodes(t *testing.T) {
+	a, err := ToAST("_ZN1A1fEi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pre []AST
+	for n := range Nodes(a) {
+		pre = append(pre, n)
+	}
+	if got, want := nodeNames(pre), "Typed Qualified Name Name FunctionType BuiltinType"; got != want {
+		t.Errorf("Nodes: got %s, want %s", got, want)
+	}
+
+	var post []AST
+	for n := range NodesPostorder(a) {
+		post = append(post, n)
+	}
+	if got, want := nodeNames(post), "Name Name Qualified BuiltinType FunctionType Typed"; got != want {
+		t.Errorf("NodesPostorder: got %s, want %s", got, want)
+	}
+}
+
+func TestNodesBreak(t *testing.T) {
+	a, err := ToAST("_ZN1A1fEi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seq := range []func(AST) iter.Seq[AST]{Nodes, NodesPostorder} {
+		count := 0
+		for n := range seq(a) {
+			count++
+			if _, ok := n.(*Name); ok {
+				break
+			}
+		}
+		if count > 3 {
+			t.Errorf("visited %d nodes after break", count)
+		}
+	}
+}
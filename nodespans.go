@@ -0,0 +1,96 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "sort"
+
+// A NodeSpan is the part of a demangled string that was printed for
+// a node of the AST. The part is the bytes from Start up to but not
+// including End.
+type NodeSpan struct {
+	Node       AST
+	Start, End int
+}
+
+// ToStringWithNodes is like ToString, but also returns the spans of
+// the demangled string printed for the nodes of the AST. This may be
+// used to map a position in the demangled string back to the node,
+// such as to describe the part of a name under the mouse.
+//
+// The spans nest: the span of a node contains the spans of the
+// nodes printed for it. The first span is the whole string, for the
+// root of the AST. The spans are sorted by Start, and spans with the
+// same Start are sorted from the outermost to the innermost. A node
+// that appears more than once in the AST, because of a substitution,
+// has a span for each time it is printed. Some nodes, notably types
+// that use the C++ declarator syntax such as pointers and functions,
+// print parts of their children directly, and those children have
+// no spans of their own. For the same reason the span of a function
+// type includes the function name. Nodes that print nothing have no
+// spans.
+//
+// Spans are only available for C++ symbol names and for Rust symbol
+// names that use the v0 mangling scheme. For other names the
+// returned spans are nil.
+func ToStringWithNodes(name string, options ...Option) (string, []NodeSpan, error) {
+	s, err := ToString(name, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	a, err := ToAST(name, options...)
+	if err != nil {
+		return s, nil, nil
+	}
+	t, spans := ASTToStringWithNodes(a, options...)
+	if t != s {
+		// ToString did not use the AST, as for an old-style
+		// Rust symbol.
+		return s, nil, nil
+	}
+	return s, spans, nil
+}
+
+// ASTToStringWithNodes is like ASTToString, but also returns the
+// spans of the demangled string printed for the nodes of the AST, as
+// described at ToStringWithNodes.
+func ASTToStringWithNodes(a AST, options ...Option) (string, []NodeSpan) {
+	ps := new(printState)
+	s, _ := ps.astToString(a, false, true, options, nil)
+	return s, ps.nodeSpans
+}
+
+// addNodeSpan records the output of node a from start to the end of
+// the output.
+func (ps *printState) addNodeSpan(a AST, start int) {
+	if ps.buf.Len() > start {
+		ps.nodeSpans = append(ps.nodeSpans, NodeSpan{Node: a, Start: start, End: ps.buf.Len()})
+	}
+}
+
+// finishNodeSpans is like finishSpans for node spans.
+func finishNodeSpans(spans []NodeSpan, max int) []NodeSpan {
+	if max > 0 {
+		keep := spans[:0]
+		for _, span := range spans {
+			if span.Start >= max {
+				continue
+			}
+			if span.End > max {
+				span.End = max
+			}
+			keep = append(keep, span)
+		}
+		spans = keep
+	}
+	// The spans were recorded as each node was finished,
+	// so children come before their parents.
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+	return spans
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestToStringWithNodes(t *testing.T) {
+	var tests = []struct {
+		input string
+		nodes []string // printed text of some expected spans
+	}{
+		{"_ZN1A1fEi", []string{"A", "f", "A::f", "int"}},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []string{"std::vector<int, std::allocator<int> >", "std::allocator<int>", "push_back"}},
+		{"_Z1fIiEvT_", []string{"f<int>", "int"}},
+	}
+	for _, test := range tests {
+		s, spans, err := ToStringWithNodes(test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.input, err)
+			continue
+		}
+		if want, _ := ToString(test.input); s != want {
+			t.Errorf("%s: got %q, want %q", test.input, s, want)
+		}
+		if len(spans) == 0 || spans[0].Start != 0 || spans[0].End != len(s) {
+			t.Errorf("%s: first span %v does not cover %q", test.input, spans, s)
+			continue
+		}
+		found := make(map[string]bool)
+		for i, span := range spans {
+			if span.Start < 0 || span.End > len(s) || span.Start >= span.End {
+				t.Errorf("%s: bad span %d: %d-%d", test.input, i, span.Start, span.End)
+				continue
+			}
+			if i > 0 && spans[i-1].Start > span.Start {
+				t.Errorf("%s: span %d is out of order", test.input, i)
+			}
+			found[s[span.Start:span.End]] = true
+		}
+		for _, n := range test.nodes {
+			if !found[n] {
+				t.Errorf("%s: no span for %q", test.input, n)
+			}
+		}
+	}
+}
+
+func TestToStringWithNodesText(t *testing.T) {
+	// For simple names each span is the printed form of its node.
+	s, spans, err := ToStringWithNodes("_ZN2ns1A1fEic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, span := range spans {
+		got := s[span.Start:span.End]
+		switch span.Node.(type) {
+		case *Typed, *FunctionType:
+			// The function type prints the name too.
+			continue
+		}
+		if want := ASTToString(span.Node); got != want {
+			t.Errorf("span for %T: got %q, want %q", span.Node, got, want)
+		}
+	}
+}
+
+func TestToStringWithNodesMaxLength(t *testing.T) {
+	const name = "_ZN12abcdefghijkl12mnopqrstuvwxEi"
+	s, spans, err := ToStringWithNodes(name, MaxLength(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) == 0 {
+		t.Fatalf("%s: no spans", name)
+	}
+	for _, span := range spans {
+		if span.End > len(s) {
+			t.Errorf("span %d-%d exceeds %q", span.Start, span.End, s)
+		}
+	}
+}
+
+func TestToStringWithNodesRust(t *testing.T) {
+	s, spans, err := ToStringWithNodes("_ZN5hello4main17h0123456789abcdefE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello::main" || spans != nil {
+		t.Errorf("got %q, %v; want %q, nil", s, spans, "hello::main")
+	}
+}
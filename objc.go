@@ -0,0 +1,79 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// parseObjCMethod parses an Objective-C method name, such as
+// "-[Class selector:]" or "+[Class(Category) selector]".
+// The second result reports whether s is such a name.
+func parseObjCMethod(s string) (*ObjCMethod, bool) {
+	if len(s) < 6 || (s[0] != '-' && s[0] != '+') || s[1] != '[' || s[len(s)-1] != ']' {
+		return nil, false
+	}
+	inner := s[2 : len(s)-1]
+	sp := strings.IndexByte(inner, ' ')
+	if sp <= 0 {
+		return nil, false
+	}
+	class, sel := inner[:sp], inner[sp+1:]
+	if !isObjCSelector(sel) {
+		return nil, false
+	}
+
+	category := ""
+	if i := strings.IndexByte(class, '('); i >= 0 {
+		if i == 0 || class[len(class)-1] != ')' {
+			return nil, false
+		}
+		category = class[i+1 : len(class)-1]
+		class = class[:i]
+		if !isObjCIdentifier(category) {
+			return nil, false
+		}
+	}
+	if !isObjCIdentifier(class) {
+		return nil, false
+	}
+
+	return &ObjCMethod{
+		ClassMethod: s[0] == '+',
+		Class:       class,
+		Category:    category,
+		Selector:    sel,
+	}, true
+}
+
+// isObjCIdentifier reports whether s is a valid Objective-C identifier.
+func isObjCIdentifier(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isLower(c) && !isUpper(c) && !isDigit(c) && c != '_' && c < 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// isObjCSelector reports whether s is a valid Objective-C selector:
+// either a single identifier, or a sequence of optional identifiers
+// each followed by a colon.
+func isObjCSelector(s string) bool {
+	if !strings.Contains(s, ":") {
+		return isObjCIdentifier(s)
+	}
+	if s[len(s)-1] != ':' {
+		return false
+	}
+	for _, part := range strings.Split(s[:len(s)-1], ":") {
+		if part != "" && !isObjCIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjCMethod(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *ObjCMethod
+	}{
+		{"-[Foo bar]", &ObjCMethod{Class: "Foo", Selector: "bar"}},
+		{"-[Foo bar:]", &ObjCMethod{Class: "Foo", Selector: "bar:"}},
+		{"+[Foo bar:baz:]", &ObjCMethod{ClassMethod: true, Class: "Foo", Selector: "bar:baz:"}},
+		{"-[Foo(Cat) bar::]", &ObjCMethod{Class: "Foo", Category: "Cat", Selector: "bar::"}},
+		{"-[DeploymentSetupController handleManualServerEntry:]", &ObjCMethod{Class: "DeploymentSetupController", Selector: "handleManualServerEntry:"}},
+		{"-[Foo bar", nil},
+		{"-[Foo]", nil},
+		{"-[ bar]", nil},
+		{"-[Foo bar:baz]", nil},
+		{"-[Foo bar baz]", nil},
+		{"-[(Cat) bar]", nil},
+		{"-[Foo(Cat bar]", nil},
+		{"*[Foo bar]", nil},
+	}
+
+	for _, test := range tests {
+		got, ok := parseObjCMethod(test.input)
+		if test.want == nil {
+			if ok {
+				t.Errorf("parseObjCMethod(%q) = %#v, want failure", test.input, got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("parseObjCMethod(%q) failed", test.input)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseObjCMethod(%q) = %#v, want %#v", test.input, got, test.want)
+		} else if s := ASTToString(got); s != test.input {
+			t.Errorf("ASTToString(parseObjCMethod(%q)) = %q", test.input, s)
+		}
+	}
+}
+
+func TestObjCLocalName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"_ZZ11-[Foo bar:]E1x", "-[Foo bar:]::x"},
+		{"_ZZ20+[Foo(Cat) bar:baz:]E1x", "+[Foo(Cat) bar:baz:]::x"},
+		{"_ZZ11-[Foo bar:]ENK3$_0clEv", "-[Foo bar:]::$_0::operator()() const"},
+		{"-[Foo bar:]", "-[Foo bar:]"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+
+	a, err := ToAST("_ZZ11-[Foo bar:]E1x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	a.Traverse(func(a AST) bool {
+		if om, ok := a.(*ObjCMethod); ok && om.Class == "Foo" && om.Selector == "bar:" {
+			found = true
+		}
+		return true
+	})
+	if !found {
+		t.Errorf("no ObjCMethod in %#v", a)
+	}
+}
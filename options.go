@@ -0,0 +1,224 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// DemangleOptions is an alternative to a list of Option values.
+// It also holds the settings that can't be expressed as an Option,
+// such as a function to call for unnamed types, which may be used
+// with ToStringOpts and ASTToStringOpts.
+// The zero value selects the default behavior.
+type DemangleOptions struct {
+	// Each of the following fields, if true, selects the
+	// Option with the same name. See the Option documentation.
+	NoParams                bool
+	NoTemplateParams        bool
+	NoEnclosingParams       bool
+	NoClones                bool
+	NoRust                  bool
+	Verbose                 bool
+	LLVMStyle               bool
+	NoVendorQualifiers      bool
+	LLVMUnnamed             bool
+	NoMSVC                  bool
+	GNUv2                   bool
+	RustHash                bool
+	Fortran                 bool
+	GoSymbols               bool
+	NoAngleSpace            bool
+	WestConst               bool
+	NoStdInlineNamespaces   bool
+	StdAbbreviations        bool
+	NoReturnType            bool
+	NoABITags               bool
+	BaseNameOnly            bool
+	ScopeOnly               bool
+	ShortAnonymousNamespace bool
+	NoAnonymousNamespace    bool
+	NoMethodQualifiers      bool
+	ElideTemplateParams     bool
+	LLVMLambdas             bool
+	LLVMLiterals            bool
+	LLVMClones              bool
+	LLVMSpecialNames        bool
+	CanonicalSpacing        bool
+	NoLiteralSuffixes       bool
+	HexLiterals             bool
+	FunctionalCastLiterals  bool
+	BoolLiterals            bool
+	EnumLiteralValues       bool
+	ShortEnumLiterals       bool
+	CharLiterals            bool
+	LocalNameOnly           bool
+	StableLambdaIDs         bool
+	ElideConstraints        bool
+	ThunkOffsets            bool
+	TemplateParamNames      bool
+	SkipExpressions         bool
+	Types                   bool
+	LLVMExpressions         bool
+	LLVMTypes               bool
+
+	// MaxLength, if not 0, limits the length of the demangled
+	// string to MaxLength bytes, as with MaxLengthBytes.
+	// A limit larger than 1<<26 is treated as 1<<26.
+	MaxLength int
+
+	// MaxTemplateArgLength, if not 0, prints template
+	// arguments that are longer than this number of bytes
+	// as "…", as with the MaxTemplateArgLength function.
+	// A limit larger than 1<<26 is treated as 1<<26.
+	MaxTemplateArgLength int
+
+	// MaxDepth and MaxExpansion, if not 0, limit the demangler
+	// as with the MaxDepth and MaxExpansion functions.
+	// Larger values than those functions accept are treated as
+	// the largest value that they accept.
+	MaxDepth     int
+	MaxExpansion int
+
+	// If TruncateAtToken is true, a string that is longer than
+	// MaxLength is not simply cut at that length. Instead it is
+	// cut between two tokens, so that it does not end in the
+	// middle of an identifier, and TruncationMarker is appended.
+	// The result, including the marker, is no longer than
+	// MaxLength. A common marker is "...". If the marker is empty
+	// the string is cut between tokens but nothing is appended.
+	TruncateAtToken  bool
+	TruncationMarker string
+
+	// NameUnnamed, if not nil, is called for each unnamed type,
+	// such as "{unnamed type#1}" or "$_0", and each lambda, such
+	// as "{lambda()#1}", and the name that it returns is printed
+	// instead. This permits a program with more information, such
+	// as debug information, to print the real name. It applies
+	// to C++ names.
+	NameUnnamed Namer
+
+	// PrintHook, if not nil, is called for each node printed,
+	// permitting a program to change how some kinds of nodes are
+	// printed without changing how others are. It applies to C++
+	// names. The hook is not called for every node: some nodes,
+	// notably types that use the C++ declarator syntax such as
+	// pointers and functions, print parts of their children
+	// directly.
+	PrintHook PrintHook
+}
+
+// options returns the list of Option values that o selects.
+// The settings that have no Option are returned by extra.
+func (o *DemangleOptions) options() []Option {
+	var ret []Option
+	flags := []struct {
+		set bool
+		opt Option
+	}{
+		{o.NoParams, NoParams},
+		{o.NoTemplateParams, NoTemplateParams},
+		{o.NoEnclosingParams, NoEnclosingParams},
+		{o.NoClones, NoClones},
+		{o.NoRust, NoRust},
+		{o.Verbose, Verbose},
+		{o.LLVMStyle, LLVMStyle},
+		{o.NoVendorQualifiers, NoVendorQualifiers},
+		{o.LLVMUnnamed, LLVMUnnamed},
+		{o.NoMSVC, NoMSVC},
+		{o.GNUv2, GNUv2},
+		{o.RustHash, RustHash},
+		{o.Fortran, Fortran},
+		{o.GoSymbols, GoSymbols},
+		{o.NoAngleSpace, NoAngleSpace},
+		{o.WestConst, WestConst},
+		{o.NoStdInlineNamespaces, NoStdInlineNamespaces},
+		{o.StdAbbreviations, StdAbbreviations},
+		{o.NoReturnType, NoReturnType},
+		{o.NoABITags, NoABITags},
+		{o.BaseNameOnly, BaseNameOnly},
+		{o.ScopeOnly, ScopeOnly},
+		{o.ShortAnonymousNamespace, ShortAnonymousNamespace},
+		{o.NoAnonymousNamespace, NoAnonymousNamespace},
+		{o.NoMethodQualifiers, NoMethodQualifiers},
+		{o.ElideTemplateParams, ElideTemplateParams},
+		{o.LLVMLambdas, LLVMLambdas},
+		{o.LLVMLiterals, LLVMLiterals},
+		{o.LLVMClones, LLVMClones},
+		{o.LLVMSpecialNames, LLVMSpecialNames},
+		{o.CanonicalSpacing, CanonicalSpacing},
+		{o.NoLiteralSuffixes, NoLiteralSuffixes},
+		{o.HexLiterals, HexLiterals},
+		{o.FunctionalCastLiterals, FunctionalCastLiterals},
+		{o.BoolLiterals, BoolLiterals},
+		{o.EnumLiteralValues, EnumLiteralValues},
+		{o.ShortEnumLiterals, ShortEnumLiterals},
+		{o.CharLiterals, CharLiterals},
+		{o.LocalNameOnly, LocalNameOnly},
+		{o.StableLambdaIDs, StableLambdaIDs},
+		{o.ElideConstraints, ElideConstraints},
+		{o.ThunkOffsets, ThunkOffsets},
+		{o.TemplateParamNames, TemplateParamNames},
+		{o.SkipExpressions, SkipExpressions},
+		{o.Types, Types},
+		{o.LLVMExpressions, LLVMExpressions},
+		{o.LLVMTypes, LLVMTypes},
+	}
+	for _, f := range flags {
+		if f.set {
+			ret = append(ret, f.opt)
+		}
+	}
+	values := []struct {
+		val, max int
+		fn       func(int) Option
+	}{
+		{o.MaxLength, maxOptionValue, MaxLengthBytes},
+		{o.MaxTemplateArgLength, maxOptionValue, MaxTemplateArgLength},
+		{o.MaxDepth, 1 << 20, MaxDepth},
+		{o.MaxExpansion, maxOptionValue, MaxExpansion},
+	}
+	for _, v := range values {
+		if v.val > v.max {
+			v.val = v.max
+		}
+		if v.val > 0 {
+			ret = append(ret, v.fn(v.val))
+		}
+	}
+	return ret
+}
+
+// extraOptions holds the settings of a DemangleOptions that can't
+// be expressed as an Option, because they are not small integers.
+type extraOptions struct {
+	namer    Namer
+	hook     PrintHook
+	truncate bool
+	marker   string
+}
+
+// extra returns the settings of o that have no Option,
+// or nil if there are none.
+func (o *DemangleOptions) extra() *extraOptions {
+	if o.NameUnnamed == nil && o.PrintHook == nil && !o.TruncateAtToken {
+		return nil
+	}
+	return &extraOptions{
+		namer:    o.NameUnnamed,
+		hook:     o.PrintHook,
+		truncate: o.TruncateAtToken,
+		marker:   o.TruncationMarker,
+	}
+}
+
+// ToStringOpts is like ToString, but takes the options as a
+// DemangleOptions value rather than as a list.
+func ToStringOpts(name string, opts DemangleOptions) (string, error) {
+	return toString(name, opts.options(), opts.extra())
+}
+
+// ASTToStringOpts is like ASTToString, but takes the options as a
+// DemangleOptions value rather than as a list.
+func ASTToStringOpts(a AST, opts DemangleOptions) string {
+	s, _ := new(printState).astToString(a, false, false, opts.options(), opts.extra())
+	return s
+}
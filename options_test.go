@@ -0,0 +1,38 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestToStringOpts(t *testing.T) {
+	var tests = []struct {
+		input   string
+		opts    DemangleOptions
+		options []Option
+	}{
+		{"_ZN1AIiE1fEi", DemangleOptions{}, nil},
+		{"_ZN1AIiE1fEi", DemangleOptions{NoParams: true}, []Option{NoParams}},
+		{"_ZN1AIiE1fEi", DemangleOptions{NoParams: true, NoTemplateParams: true}, []Option{NoParams, NoTemplateParams}},
+		{"_ZZ1fvENKUlvE_clEv", DemangleOptions{LLVMStyle: true}, []Option{LLVMStyle}},
+		{"_ZN1AIiE1fEi", DemangleOptions{MaxLength: 7}, []Option{MaxLengthBytes(7)}},
+		{"_ZN1AIiE1fEi", DemangleOptions{MaxLength: 1 << 30}, []Option{MaxLengthBytes(1 << 26)}},
+		{"_ZN1AISt6vectorIiSaIiEEE1fEv", DemangleOptions{MaxTemplateArgLength: 4}, []Option{MaxTemplateArgLength(4)}},
+		{"_Z1fPPi", DemangleOptions{MaxDepth: 1 << 30, MaxExpansion: 1 << 30}, []Option{MaxDepth(1 << 20), MaxExpansion(1 << 26)}},
+		{"_Z1fIiEvT_", DemangleOptions{TemplateParamNames: true, NoReturnType: true}, []Option{TemplateParamNames, NoReturnType}},
+	}
+	for _, test := range tests {
+		want, err := ToString(test.input, test.options...)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			continue
+		}
+		got, err := ToStringOpts(test.input, test.opts)
+		if err != nil {
+			t.Errorf("demangling %s with %+v: unexpected error %v", test.input, test.opts, err)
+		} else if got != want {
+			t.Errorf("demangling %s with %+v: got %q, want %q", test.input, test.opts, got, want)
+		}
+	}
+}
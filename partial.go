@@ -0,0 +1,78 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// ToStringPartial is like ToString, but when a C++ symbol name can't
+// be demangled it returns as much of the name as could be demangled.
+// This is intended for symbolizers and debuggers that would rather
+// show part of a name than a long mangled string.
+//
+// If the name can be demangled, ToStringPartial returns the demangled
+// name, an empty rest, and a nil error. Otherwise it returns the
+// demangled form of the longest leading name that was parsed before
+// the failure, the rest of the mangled string, which was not
+// demangled, and the error from ToString. If the leading name is a
+// scope of the full name, the demangled string ends with "::".
+// If no leading name was parsed, or the name is not a C++ symbol
+// name, ToStringPartial returns an empty string, the whole name as
+// the rest, and the error.
+func ToStringPartial(name string, options ...Option) (demangled, rest string, err error) {
+	s, err := ToString(name, options...)
+	if err == nil {
+		return s, "", nil
+	}
+	if !strings.HasPrefix(name, "_Z") {
+		return "", name, err
+	}
+
+	st := &state{recordPartial: true}
+	if _, perr := st.demangle(name[2:], options); perr == nil || st.partial == nil || !resolvedTemplateParams(st.partial) {
+		return "", name, err
+	}
+
+	demangled = ASTToString(st.partial, options...)
+	rest = name[2+st.partialOff:]
+	if st.partialScope && rest != "" {
+		switch rest[0] {
+		case 'I', 'J', 'E', 'M':
+			// Template arguments or the end of the name,
+			// rather than another name component.
+		default:
+			demangled += "::"
+		}
+	}
+	return demangled, rest, err
+}
+
+// notePartial records a as a leading name, for ToStringPartial.
+// The start parameter is the offset at which a starts, and scope
+// reports whether a is a scope of the name being parsed.
+// We keep the name that starts earliest, which is the outermost
+// name, and of those the one that ends latest.
+func (st *state) notePartial(a AST, start int, scope bool) {
+	if st.partial != nil && (start > st.partialStart || (start == st.partialStart && st.off < st.partialOff)) {
+		return
+	}
+	st.partial = a
+	st.partialStart = start
+	st.partialOff = st.off
+	st.partialScope = scope
+}
+
+// resolvedTemplateParams reports whether all the template parameters
+// in a refer to a template, so that a can be printed. A leading name
+// may refer to template arguments that were never parsed.
+func resolvedTemplateParams(a AST) bool {
+	ok := true
+	a.Traverse(func(a AST) bool {
+		if tp, isTP := a.(*TemplateParam); isTP && (tp.Template == nil || tp.Index >= len(tp.Template.Args)) {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestToStringPartial(t *testing.T) {
+	var tests = []struct {
+		input     string
+		demangled string
+		rest      string
+	}{
+		{
+			"_ZNSt6vectorIiSaIiEE9push_backERKi",
+			"std::vector<int, std::allocator<int> >::push_back(int const&)",
+			"",
+		},
+		{
+			"_ZNSt6vectorIiSaIiEE4pu",
+			"std::vector<int, std::allocator<int> >::",
+			"4pu",
+		},
+		{
+			"_ZNSt6vectorIiSaIiEE9push_backER$",
+			"std::vector<int, std::allocator<int> >::push_back",
+			"R$",
+		},
+		{
+			"_ZN2ns1AIN1B1CE$E1fEv",
+			"ns::A",
+			"IN1B1CE$E1fEv",
+		},
+		{
+			"_Z1fv$",
+			"f",
+			"v$",
+		},
+		{
+			"_Z$",
+			"",
+			"_Z$",
+		},
+		{
+			"_RNvC5hello4mainx",
+			"",
+			"_RNvC5hello4mainx",
+		},
+	}
+	for _, test := range tests {
+		demangled, rest, err := ToStringPartial(test.input)
+		if demangled != test.demangled || rest != test.rest {
+			t.Errorf("%s: got %q, %q; want %q, %q", test.input, demangled, rest, test.demangled, test.rest)
+		}
+		if (err == nil) != (test.rest == "") {
+			t.Errorf("%s: unexpected error result %v", test.input, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// A Pattern matches symbol names against a demangled name with
+// wildcards, as in "boost::asio::*::run" or "std::**::push_back(int
+// const&)". A Pattern is created by CompilePattern.
+//
+// A pattern is a list of components separated by "::", optionally
+// followed by a parameter list in parentheses. The components are
+// matched against the enclosing scopes and the name of the symbol,
+// as returned by SplitName. A component "*" matches any one
+// component, and "**" matches any number of components, including
+// none. A component that has no template arguments matches a
+// component with any template arguments, so "vector" matches
+// "vector<int, std::allocator<int> >". ABI tags are ignored.
+//
+// If there is no parameter list the pattern matches a symbol
+// whatever its parameters, and matches names that are not
+// functions. Otherwise the pattern only matches functions, and each
+// parameter is matched against the parameter type as printed by
+// ToString. A parameter "*" matches any one parameter, and "**"
+// matches any number of parameters.
+//
+// A Pattern may be used concurrently by multiple goroutines.
+type Pattern struct {
+	components []string
+	params     []string // nil if any parameters match
+	options    []Option
+
+	// The idents field is a list of identifiers, in mangled
+	// form, that must appear in a C++ symbol name that matches.
+	// This lets us reject most names without demangling them.
+	idents []string
+}
+
+// CompilePattern parses a pattern and returns a Pattern that may be
+// used to match symbol names. The options are used to demangle the
+// names.
+func CompilePattern(pattern string, options ...Option) (*Pattern, error) {
+	p := &Pattern{
+		options: append([]Option{NoABITags}, options...),
+	}
+
+	name := strings.TrimSpace(pattern)
+	if strings.HasSuffix(name, ")") {
+		i := matchingParen(name)
+		if i < 0 {
+			return nil, errors.New("unbalanced parentheses in pattern")
+		}
+		// A name such as "operator()" has no parameter list.
+		if !strings.HasSuffix(strings.TrimSpace(name[:i]), "operator") {
+			params := strings.TrimSpace(name[i+1 : len(name)-1])
+			p.params = []string{}
+			if params != "" {
+				p.params = splitPattern(params, ",")
+			}
+			name = strings.TrimSpace(name[:i])
+		}
+	}
+
+	if name == "" {
+		return nil, errors.New("empty pattern")
+	}
+	p.components = splitPattern(name, "::")
+	for _, c := range p.components {
+		if c == "" {
+			return nil, errors.New("empty component in pattern")
+		}
+		if id := untemplatedName(c); isIdentifier(id) && !stdNames[id] {
+			p.idents = append(p.idents, strconv.Itoa(len(id))+id)
+		}
+	}
+	for _, param := range p.params {
+		if param == "" {
+			return nil, errors.New("empty parameter in pattern")
+		}
+	}
+	return p, nil
+}
+
+// stdNames is the set of identifiers that may appear in a mangled
+// name as a standard abbreviation, rather than spelled out.
+var stdNames = map[string]bool{
+	"std":            true,
+	"allocator":      true,
+	"basic_string":   true,
+	"string":         true,
+	"istream":        true,
+	"ostream":        true,
+	"iostream":       true,
+	"basic_istream":  true,
+	"basic_ostream":  true,
+	"basic_iostream": true,
+	"char_traits":    true,
+}
+
+// Match reports whether a symbol name matches the pattern.
+func (p *Pattern) Match(name string) bool {
+	if strings.HasPrefix(name, "_Z") {
+		for _, id := range p.idents {
+			if !strings.Contains(name, id) {
+				return false
+			}
+		}
+	}
+
+	components, params, ok := p.split(name)
+	if !ok {
+		return false
+	}
+	if !matchList(p.components, components, matchComponent) {
+		return false
+	}
+	if p.params == nil {
+		return true
+	}
+	return params != nil && matchList(p.params, params, matchParam)
+}
+
+// split returns the components and the parameters of a symbol name.
+// The parameters are nil if the name is not a function.
+func (p *Pattern) split(name string) (components, params []string, ok bool) {
+	a, err := cppToAST(name, p.options...)
+	if err != nil {
+		s, err := ToString(name, p.options...)
+		if err != nil {
+			// Match an unmangled name as is.
+			s = name
+		}
+		return strings.Split(s, "::"), nil, true
+	}
+
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+			continue
+		case *Special:
+			a = n.Val
+			continue
+		case *Typed:
+			typ := n.Type
+			if mwq, ok := typ.(*MethodWithQualifiers); ok {
+				typ = mwq.Method
+			}
+			if ft, ok := typ.(*FunctionType); ok && params == nil {
+				params = make([]string, len(ft.Args))
+				for i, arg := range ft.Args {
+					params[i] = ASTToString(arg, p.options...)
+				}
+			}
+			a = n.Name
+			continue
+		}
+		break
+	}
+
+	for _, c := range splitComponents(a) {
+		components = append(components, ASTToString(c, p.options...))
+	}
+	return components, params, true
+}
+
+// matchComponent reports whether a pattern component matches a
+// name component.
+func matchComponent(pat, s string) bool {
+	if pat == "*" || pat == s {
+		return true
+	}
+	return !strings.Contains(pat, "<") && pat == untemplatedName(s)
+}
+
+// matchParam reports whether a pattern parameter matches a
+// parameter type.
+func matchParam(pat, s string) bool {
+	return pat == "*" || pat == s
+}
+
+// matchList matches a list of patterns, which may include "**",
+// against a list of strings.
+func matchList(pats, strs []string, match func(pat, s string) bool) bool {
+	for len(pats) > 0 {
+		if pats[0] == "**" {
+			for i := len(strs); i >= 0; i-- {
+				if matchList(pats[1:], strs[i:], match) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(strs) == 0 || !match(pats[0], strs[0]) {
+			return false
+		}
+		pats = pats[1:]
+		strs = strs[1:]
+	}
+	return len(strs) == 0
+}
+
+// splitPattern splits s at each sep that is not inside angle
+// brackets or parentheses, and trims spaces from the results.
+func splitPattern(s, sep string) []string {
+	var ret []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(s[i:], sep) {
+				ret = append(ret, strings.TrimSpace(s[start:i]))
+				i += len(sep) - 1
+				start = i + 1
+			}
+		}
+	}
+	return append(ret, strings.TrimSpace(s[start:]))
+}
+
+// matchingParen returns the index of the parenthesis that matches
+// the one at the end of s, or -1 if there is none.
+func matchingParen(s string) int {
+	depth := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		switch s[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// untemplatedName returns a name component without its template
+// arguments.
+func untemplatedName(s string) string {
+	if strings.HasPrefix(s, "operator") && (len(s) == len("operator") || !isIdentifier(s[:len("operator")+1])) {
+		// An operator such as operator<.
+		return s
+	}
+	if i := strings.Index(s, "<"); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// isIdentifier reports whether s is a C++ identifier.
+func isIdentifier(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isLower(c) && !isUpper(c) && !isDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
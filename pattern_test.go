@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestPattern(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"boost::asio::*::run", "_ZN5boost4asio6detail9scheduler3runERNS_6system10error_codeE", false},
+		{"boost::asio::*::*::run", "_ZN5boost4asio6detail9scheduler3runERNS_6system10error_codeE", true},
+		{"boost::asio::*::run", "_ZN5boost4asio10io_context3runEv", true},
+		{"boost::asio::*::run", "_ZN5boost4asio3runEv", false},
+		{"boost::asio::*::run", "_ZN5boost4asio10io_context4stopEv", false},
+		{"boost::**::run", "_ZN5boost4asio6detail9scheduler3runERNS_6system10error_codeE", true},
+		{"boost::**::run", "_ZN5boost3runEv", true},
+		{"**::run", "_Z3runv", true},
+		{"run", "_ZN1A3runEv", false},
+		{"f()", "_Z1fv", true},
+		{"f()", "_Z1fi", false},
+		{"f(int)", "_Z1fi", true},
+		{"f(*)", "_Z1fi", true},
+		{"f(*)", "_Z1fii", false},
+		{"f(**)", "_Z1fii", true},
+		{"f(int, **)", "_Z1fidd", true},
+		{"f(double, **)", "_Z1fidd", false},
+		{"f()", "_Z1f", false},
+		{"f", "_Z1fidd", true},
+		{"std::vector::push_back(int const&)", "_ZNSt6vectorIiSaIiEE9push_backERKi", true},
+		{"std::vector<int, std::allocator<int> >::push_back", "_ZNSt6vectorIiSaIiEE9push_backERKi", true},
+		{"std::vector<long, std::allocator<long> >::push_back", "_ZNSt6vectorIiSaIiEE9push_backERKi", false},
+		{"std::string::size", "_ZNKSs4sizeEv", true},
+		{"A::operator()()", "_ZN1AclEv", true},
+		{"A::operator()", "_ZN1AclEi", true},
+		{"A::operator<", "_ZN1AltERKS_", true},
+		{"A::f", "_ZN1AB5cxx111fEv", true},
+		{"A::f", "_ZThn8_N1A1fEv", true},
+		{"A::f", "_ZN1A1fEv.cold", true},
+		{"f::a", "_ZZ1fvE1a", false},
+		{"f()::a", "_ZZ1fvE1a", true},
+		{"main", "main", true},
+		{"main", "_Z4mainv", true},
+	}
+	for _, test := range tests {
+		p, err := CompilePattern(test.pattern)
+		if err != nil {
+			t.Errorf("CompilePattern(%q): unexpected error %v", test.pattern, err)
+			continue
+		}
+		if got := p.Match(test.name); got != test.want {
+			t.Errorf("%q.Match(%q) = %t, want %t", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	for _, pattern := range []string{"", "A::::f", "f(int, )", "f)"} {
+		if _, err := CompilePattern(pattern); err == nil {
+			t.Errorf("CompilePattern(%q): got nil error", pattern)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// QualifiedName is a C++ name split into its components,
+// as returned by SplitName.
+type QualifiedName struct {
+	// Scope is the namespaces, classes, and functions that
+	// enclose the entity, starting with the outermost. Each is
+	// printed as by ToString, including any template arguments,
+	// as in "vector<int, std::allocator<int> >". An enclosing
+	// function includes its parameters, as in "f(int)".
+	Scope []string
+
+	// Name is the unqualified name of the entity, including
+	// any template arguments but not its parameters.
+	Name string
+
+	// Discriminator distinguishes entities with the same name
+	// defined in the same function. It is the number of earlier
+	// such entities, so it is 0 for the first one.
+	Discriminator int
+}
+
+// SplitName demangles a C++ symbol name and splits the name of the
+// entity into its components. Unlike splitting the demangled string
+// at "::", this is not confused by template arguments that contain
+// "::". For "std::__detail::_Hashtable<int>::_M_assign()" it returns
+// the scope "std", "__detail", "_Hashtable<int>" and the name
+// "_M_assign". For a special name, such as "vtable for A", it splits
+// the name of the entity that the special name refers to.
+// If the name does not appear to be a C++ symbol name at all,
+// the error will be ErrNotMangledName.
+func SplitName(name string, options ...Option) (*QualifiedName, error) {
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+			continue
+		case *Special:
+			a = n.Val
+			continue
+		case *Typed:
+			a = n.Name
+			continue
+		}
+		break
+	}
+
+	ret := new(QualifiedName)
+	if q, ok := a.(*Qualified); ok {
+		ret.Discriminator = q.Discriminator
+	}
+	parts := splitComponents(a)
+	for _, p := range parts[:len(parts)-1] {
+		ret.Scope = append(ret.Scope, ASTToString(p, options...))
+	}
+	ret.Name = ASTToString(parts[len(parts)-1], options...)
+	return ret, nil
+}
+
+// splitComponents returns the components of the qualified name a,
+// for SplitName.
+func splitComponents(a AST) []AST {
+	switch n := a.(type) {
+	case *Qualified:
+		return append(splitComponents(n.Scope), splitComponents(n.Name)...)
+	case *Template:
+		if q, ok := n.Name.(*Qualified); ok {
+			return append(splitComponents(q.Scope), &Template{Name: q.Name, Args: n.Args})
+		}
+	case *Typed:
+		// The enclosing function of a local name.
+		parts := splitComponents(n.Name)
+		last := len(parts) - 1
+		parts[last] = &Typed{Name: parts[last], Type: withoutReturnType(n.Type)}
+		return parts
+	}
+	return []AST{a}
+}
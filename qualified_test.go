@@ -0,0 +1,40 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitName(t *testing.T) {
+	var tests = []struct {
+		input   string
+		options []Option
+		want    QualifiedName
+	}{
+		{"_Z1fv", nil, QualifiedName{Name: "f"}},
+		{"_ZNSt8__detail10_HashtableIiE9_M_assignEv", nil, QualifiedName{Scope: []string{"std", "__detail", "_Hashtable<int>"}, Name: "_M_assign"}},
+		{"_ZNSt8__detail10_HashtableIiE9_M_assignEv", []Option{NoTemplateParams}, QualifiedName{Scope: []string{"std", "__detail", "_Hashtable"}, Name: "_M_assign"}},
+		{"_ZN1AIN2ns1BEE1fIS1_EEvv", nil, QualifiedName{Scope: []string{"A<ns::B>"}, Name: "f<ns::B>"}},
+		{"_ZTVN2ns1AE", nil, QualifiedName{Scope: []string{"ns"}, Name: "A"}},
+		{"_ZZN2ns1fEiE1x", nil, QualifiedName{Scope: []string{"ns", "f(int)"}, Name: "x"}},
+		{"_ZZN2ns1fEiE1x_0", nil, QualifiedName{Scope: []string{"ns", "f(int)"}, Name: "x", Discriminator: 1}},
+		{"_ZZN2ns1fIiEEvvE1x__12_", nil, QualifiedName{Scope: []string{"ns", "f<int>()"}, Name: "x", Discriminator: 13}},
+		{"_ZZ1fvENKUlvE_clEv", nil, QualifiedName{Scope: []string{"f()", "{lambda()#1}"}, Name: "operator()"}},
+	}
+	for _, test := range tests {
+		got, err := SplitName(test.input, test.options...)
+		if err != nil {
+			t.Errorf("SplitName(%s): unexpected error %v", test.input, err)
+		} else if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("SplitName(%s) = %+v, want %+v", test.input, *got, test.want)
+		}
+	}
+
+	if _, err := SplitName("f"); err != ErrNotMangledName {
+		t.Errorf("SplitName(f) = %v, want ErrNotMangledName", err)
+	}
+}
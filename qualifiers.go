@@ -0,0 +1,102 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// SymbolQualifiers describes the qualifiers of a C++ symbol,
+// as returned by ParseQualifiers.
+type SymbolQualifiers struct {
+	// Const and Volatile report whether a member function has
+	// the const or volatile qualifier.
+	Const    bool
+	Volatile bool
+
+	// LValueRef and RValueRef report whether a member function
+	// has the & or && reference qualifier.
+	LValueRef bool
+	RValueRef bool
+
+	// Static reports whether the entity has internal linkage,
+	// as for a function or variable declared static outside of
+	// a class. A static member function is mangled like any other
+	// function, so Static is false for it.
+	Static bool
+
+	// AnonymousNamespace reports whether the entity is declared
+	// in an anonymous namespace, directly or in a class or
+	// namespace that is.
+	AnonymousNamespace bool
+}
+
+// ParseQualifiers demangles a C++ symbol name and reports the
+// qualifiers of the entity that it names. For a special name, such
+// as a thunk, it reports the qualifiers of the entity that the
+// special name refers to.
+// If the name does not appear to be a C++ symbol name at all,
+// the error will be ErrNotMangledName.
+func ParseQualifiers(name string) (*SymbolQualifiers, error) {
+	a, err := cppToAST(name)
+	if err != nil {
+		return nil, err
+	}
+	ret := new(SymbolQualifiers)
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+			continue
+		case *Special:
+			a = n.Val
+			continue
+		case *Typed:
+			if mwq, ok := n.Type.(*MethodWithQualifiers); ok {
+				ret.LValueRef = mwq.RefQualifier == "&"
+				ret.RValueRef = mwq.RefQualifier == "&&"
+				if qs, ok := mwq.Qualifiers.(*Qualifiers); ok {
+					for _, q := range qs.Qualifiers {
+						if q, ok := q.(*Qualifier); ok {
+							switch q.Name {
+							case "const":
+								ret.Const = true
+							case "volatile":
+								ret.Volatile = true
+							}
+						}
+					}
+				}
+			}
+			a = n.Name
+			continue
+		}
+		break
+	}
+
+	ret.setName(a, true)
+	return ret, nil
+}
+
+// setName sets the Static and AnonymousNamespace fields from the
+// name a. The last parameter reports whether a is the last component
+// of the name of the entity.
+func (sq *SymbolQualifiers) setName(a AST, last bool) {
+	switch n := a.(type) {
+	case *Template:
+		sq.setName(n.Name, last)
+	case *TaggedName:
+		sq.setName(n.Name, last)
+	case *Qualified:
+		sq.setName(n.Scope, false)
+		sq.setName(n.Name, last)
+	case *Typed:
+		// The enclosing function of a local name.
+		sq.setName(n.Name, false)
+	case *Name:
+		if last && n.Internal {
+			sq.Static = true
+		}
+		if n.Name == anonymousNamespace {
+			sq.AnonymousNamespace = true
+		}
+	}
+}
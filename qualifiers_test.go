@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestParseQualifiers(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  SymbolQualifiers
+	}{
+		{"_Z1fv", SymbolQualifiers{}},
+		{"_ZNK1A1fEv", SymbolQualifiers{Const: true}},
+		{"_ZNVK1A1fEv", SymbolQualifiers{Const: true, Volatile: true}},
+		{"_ZNKR1A1fEv", SymbolQualifiers{Const: true, LValueRef: true}},
+		{"_ZNO1A1fEv", SymbolQualifiers{RValueRef: true}},
+		{"_ZNK1A1fIiEEvT_", SymbolQualifiers{Const: true}},
+		{"_ZNK1A1fEv.cold", SymbolQualifiers{Const: true}},
+		{"_ZThn8_NK1A1fEv", SymbolQualifiers{Const: true}},
+		{"_ZL1fv", SymbolQualifiers{Static: true}},
+		{"_ZL1x", SymbolQualifiers{Static: true}},
+		{"_ZN2nsL1fEv", SymbolQualifiers{Static: true}},
+		{"_ZN12_GLOBAL__N_11fEv", SymbolQualifiers{AnonymousNamespace: true}},
+		{"_ZNK12_GLOBAL__N_11A1fEv", SymbolQualifiers{Const: true, AnonymousNamespace: true}},
+		{"_Z1fIN12_GLOBAL__N_11AEEvv", SymbolQualifiers{}},
+		{"_ZZL1fvE1x", SymbolQualifiers{}},
+	}
+	for _, test := range tests {
+		got, err := ParseQualifiers(test.input)
+		if err != nil {
+			t.Errorf("ParseQualifiers(%s): unexpected error %v", test.input, err)
+		} else if *got != test.want {
+			t.Errorf("ParseQualifiers(%s) = %+v, want %+v", test.input, *got, test.want)
+		}
+	}
+
+	if _, err := ParseQualifiers("f"); err != ErrNotMangledName {
+		t.Errorf("ParseQualifiers(f) = %v, want ErrNotMangledName", err)
+	}
+}
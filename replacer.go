@@ -0,0 +1,145 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// A Replacer copies text from a reader to a writer, replacing each
+// mangled C++ or Rust symbol name that it finds with the demangled
+// name, as the c++filt program does. This is intended for use on
+// logs and other text that mentions symbol names.
+//
+// A symbol name is a word that starts with _Z or _R, where a word is
+// a sequence of ASCII letters and digits, non-ASCII bytes, and the
+// characters '_', '$', and '.'. Trailing periods are not part of a
+// name if the word can't be demangled with them, so the name at the
+// end of a sentence is demangled. A word that can't be demangled is
+// copied unchanged.
+type Replacer struct {
+	r       io.Reader
+	w       io.Writer
+	options []Option
+	d       Demangler
+}
+
+// NewReplacer returns a Replacer that reads from r and writes to w,
+// demangling names using the options.
+func NewReplacer(r io.Reader, w io.Writer, options ...Option) *Replacer {
+	return &Replacer{
+		r:       r,
+		w:       w,
+		options: options,
+	}
+}
+
+// replacerState is the state of a Replacer scanning the input.
+type replacerState int
+
+const (
+	// Between words.
+	betweenWords replacerState = iota
+	// In a word that may be a symbol name, which is being
+	// collected.
+	inSymbol
+	// In a word that is not a symbol name, which is copied as is.
+	inWord
+)
+
+// Run copies the text from the reader to the writer, replacing
+// symbol names, until the reader returns io.EOF. It returns the
+// first error from the reader, other than io.EOF, or from the writer.
+// The output is written after each read, so a Replacer reading from
+// a pipe writes each line as it arrives, rather than waiting for the
+// pipe to be closed.
+func (rp *Replacer) Run() error {
+	bw := bufio.NewWriter(rp.w)
+	buf := make([]byte, 32<<10)
+	var word []byte
+	state := betweenWords
+	for {
+		n, rerr := rp.r.Read(buf)
+		data := buf[:n]
+		for len(data) > 0 {
+			i := 0
+			if state == betweenWords {
+				for i < len(data) && !isSymbolByte(data[i]) {
+					i++
+				}
+				bw.Write(data[:i])
+				data = data[i:]
+				if len(data) > 0 {
+					if data[0] == '_' {
+						state = inSymbol
+					} else {
+						state = inWord
+					}
+				}
+				continue
+			}
+
+			for i < len(data) && isSymbolByte(data[i]) {
+				i++
+			}
+			if state == inWord {
+				bw.Write(data[:i])
+			} else {
+				word = append(word, data[:i]...)
+				if len(word) >= 2 && word[1] != 'Z' && word[1] != 'R' {
+					bw.Write(word)
+					word = word[:0]
+					state = inWord
+				}
+			}
+			data = data[i:]
+			if len(data) > 0 {
+				// We reached the end of the word.
+				if state == inSymbol {
+					rp.writeSymbol(bw, word)
+					word = word[:0]
+				}
+				state = betweenWords
+			}
+		}
+
+		if rerr != nil && state == inSymbol {
+			rp.writeSymbol(bw, word)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if rerr == io.EOF {
+			return nil
+		} else if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+// writeSymbol writes a word that may be a symbol name.
+func (rp *Replacer) writeSymbol(bw *bufio.Writer, word []byte) {
+	s := string(word)
+	r, err := rp.d.ToString(s, rp.options...)
+	if err != nil {
+		if t := strings.TrimRight(s, "."); len(t) < len(s) {
+			if r, err = rp.d.ToString(t, rp.options...); err == nil {
+				r += s[len(t):]
+			}
+		}
+	}
+	if err != nil {
+		r = s
+	}
+	bw.WriteString(r)
+}
+
+// isSymbolByte reports whether b may be part of a symbol name,
+// for a Replacer.
+func isSymbolByte(b byte) bool {
+	return isLower(b) || isUpper(b) || isDigit(b) || b == '_' || b == '$' || b == '.' || b >= 0x80
+}
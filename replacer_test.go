@@ -0,0 +1,77 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReplacer(t *testing.T) {
+	var tests = []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"no symbols here\n", "no symbols here\n"},
+		{"_Z1fv", "f()"},
+		{"call _Z1fv failed\n", "call f() failed\n"},
+		{"at _ZN1A1fEi.\n", "at A::f(int).\n"},
+		{"at _ZN1A1fEi.cold\n", "at A::f(int) [clone .cold]\n"},
+		{"(_Z1fv,_Z1gv)", "(f(),g())"},
+		{"x_Z1fv _Zbad _foo __Z1fv", "x_Z1fv _Zbad _foo __Z1fv"},
+		{"_RNvCs15kBYyAo9fc_7mycrate7example\n", "mycrate::example\n"},
+		{"first _Z1fv\nsecond _Z1gi\n", "first f()\nsecond g(int)\n"},
+	}
+	for _, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			var r = strings.NewReader(test.in)
+			var in = iotest.DataErrReader(r)
+			if oneByte {
+				in = iotest.OneByteReader(r)
+			}
+			var out bytes.Buffer
+			if err := NewReplacer(in, &out).Run(); err != nil {
+				t.Errorf("%q: unexpected error %v", test.in, err)
+			} else if got := out.String(); got != test.want {
+				t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+			}
+		}
+	}
+}
+
+func TestReplacerOptions(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewReplacer(strings.NewReader("at _ZN1A1fEi\n"), &out, NoParams).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "at A::f\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReplacerError(t *testing.T) {
+	errRead := errors.New("read error")
+	in := io.MultiReader(strings.NewReader("at _Z1fv"), errReader{errRead})
+	var out bytes.Buffer
+	if err := NewReplacer(in, &out).Run(); err != errRead {
+		t.Errorf("got error %v, want %v", err, errRead)
+	}
+	if got, want := out.String(), "at f()"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// errReader is an io.Reader that always returns an error.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// A Result is a demangled symbol name, as returned by Demangle, that
+// can be printed in several forms without demangling the name again.
+// A Result may be used concurrently by multiple goroutines.
+type Result struct {
+	name    string
+	options []Option
+	full    string
+	a       AST // nil if the name was not demangled into an AST
+}
+
+// Demangle demangles a symbol name, and returns a Result that prints
+// the demangled name in the forms that a symbolizer or debugger may
+// want, such as with and without the function parameters. For a C++
+// name each form is printed from a single parse of the name; for
+// other names each form calls ToString. Each form is the same as the
+// string returned by ToString with the options passed to Demangle and
+// the options of the form. The errors are the errors of ToString.
+func Demangle(name string, options ...Option) (*Result, error) {
+	r := &Result{
+		name:    name,
+		options: options,
+	}
+	s, a, err := toStringOrAST(name, options)
+	if err != nil {
+		return nil, err
+	}
+	if a != nil {
+		s = ASTToString(a, options...)
+	}
+	r.full = s
+	r.a = a
+	return r, nil
+}
+
+// Mangled returns the mangled name.
+func (r *Result) Mangled() string {
+	return r.name
+}
+
+// AST returns the AST of the demangled name, or nil if the name was
+// not demangled into an AST, as for the names of languages other
+// than C++.
+func (r *Result) AST() AST {
+	return r.a
+}
+
+// Full returns the demangled name, as returned by ToString.
+func (r *Result) Full() string {
+	return r.full
+}
+
+// NoParams returns the demangled name without function parameters,
+// as returned by ToString with the NoParams option.
+func (r *Result) NoParams() string {
+	return r.Format(NoParams)
+}
+
+// NoTemplateParams returns the demangled name without template
+// arguments, as returned by ToString with the NoTemplateParams
+// option.
+func (r *Result) NoTemplateParams() string {
+	return r.Format(NoTemplateParams)
+}
+
+// NoEnclosingParams returns the demangled name without the
+// parameters of enclosing functions, as returned by ToString with
+// the NoEnclosingParams option.
+func (r *Result) NoEnclosingParams() string {
+	return r.Format(NoEnclosingParams)
+}
+
+// Minimal returns the shortest form of the demangled name, as
+// returned by ToString with the NoParams, NoTemplateParams, and
+// NoEnclosingParams options.
+func (r *Result) Minimal() string {
+	return r.Format(NoParams, NoTemplateParams, NoEnclosingParams)
+}
+
+// Format returns the demangled name as returned by ToString with
+// additional options. If the name can't be demangled with those
+// options, it returns the result of Full.
+func (r *Result) Format(options ...Option) string {
+	if len(options) == 0 {
+		return r.full
+	}
+	all := append(append([]Option(nil), r.options...), options...)
+	if r.a == nil {
+		s, err := ToString(r.name, all...)
+		if err != nil {
+			return r.full
+		}
+		return s
+	}
+
+	// NoParams and NoClones are used when demangling, not when
+	// printing, so apply them to the AST.
+	a := r.a
+	for _, o := range options {
+		if o == NoParams || o == NoClones {
+			a = Simplify(a, o)
+		}
+	}
+	return ASTToString(a, all...)
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDemangle(t *testing.T) {
+	r, err := Demangle("_ZZN1A1fIiEEvT_EN1B1gEv.cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		got, want string
+	}{
+		{r.Mangled(), "_ZZN1A1fIiEEvT_EN1B1gEv.cold"},
+		{r.Full(), "A::f<int>(int)::B::g() [clone .cold]"},
+		{r.NoParams(), "A::f<int>(int)::B::g"},
+		{r.NoTemplateParams(), "A::f(int)::B::g() [clone .cold]"},
+		{r.NoEnclosingParams(), "A::f<int>()::B::g() [clone .cold]"},
+		{r.Minimal(), "A::f()::B::g"},
+		{r.Format(LLVMStyle, NoClones), "void A::f<int>(int)::B::g()"},
+	}
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%d: got %q, want %q", i, test.got, test.want)
+		}
+	}
+	if r.AST() == nil {
+		t.Error("AST returned nil")
+	}
+
+	// Names in C++20 modules.
+	for _, test := range []struct {
+		input, want string
+	}{
+		{"_ZW3foo1fv", "f@foo"},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", "Outer::Inner::Fn@FOO"},
+	} {
+		r, err := Demangle(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if got := r.NoParams(); got != test.want {
+			t.Errorf("%s: NoParams = %q, want %q", test.input, got, test.want)
+		}
+		if got := r.Format(NoParams); got != test.want {
+			t.Errorf("%s: Format(NoParams) = %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	if _, err := Demangle("_Z1"); err == nil {
+		t.Error("invalid name: got nil, want error")
+	}
+	if _, err := Demangle("main"); err != ErrNotMangledName {
+		t.Errorf("unmangled name: got %v, want ErrNotMangledName", err)
+	}
+}
+
+// TestDemangleExpected checks that for the names in the testdata
+// files and in cases the forms of a Result are the same as ToString.
+func TestDemangleExpected(t *testing.T) {
+	t.Parallel()
+
+	forms := []struct {
+		options []Option
+		get     func(*Result) string
+	}{
+		{nil, (*Result).Full},
+		{[]Option{NoParams}, (*Result).NoParams},
+		{[]Option{NoTemplateParams}, (*Result).NoTemplateParams},
+		{[]Option{NoEnclosingParams}, (*Result).NoEnclosingParams},
+		{[]Option{NoParams, NoTemplateParams, NoEnclosingParams}, (*Result).Minimal},
+		{[]Option{NoClones, LLVMStyle}, func(r *Result) string { return r.Format(NoClones, LLVMStyle) }},
+	}
+	var inputs []string
+	for _, file := range []string{filename, rustFilename} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			inputs = append(inputs, strings.TrimSpace(scanner.Text()))
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	for _, test := range cases {
+		inputs = append(inputs, test[0])
+	}
+	for _, input := range inputs {
+		r, err := Demangle(input)
+		if _, err2 := ToString(input); (err == nil) != (err2 == nil) {
+			t.Errorf("%s: Demangle error %v, ToString error %v", input, err, err2)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		for _, form := range forms {
+			want, err := ToString(input, form.options...)
+			if err != nil {
+				continue
+			}
+			if got := form.get(r); got != want {
+				t.Errorf("%s %v:\ngot  %s\nwant %s", input, form.options, got, want)
+			}
+		}
+	}
+}
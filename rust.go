@@ -19,10 +19,10 @@ func rustToString(name string, options []Option) (ret string, err error) {
 	}
 
 	// When the demangling routines encounter an error, they panic
-	// with a value of type demangleErr.
+	// with a value of type Error.
 	defer func() {
 		if r := recover(); r != nil {
-			if de, ok := r.(demangleErr); ok {
+			if de, ok := r.(Error); ok {
 				ret = ""
 				err = de
 				return
@@ -39,26 +39,30 @@ func rustToString(name string, options []Option) (ret string, err error) {
 	}
 
 	name = name[2:]
-	rst := &rustState{orig: name, str: name}
+	rst := &rustState{orig: name, str: name, maxDepth: defaultMaxDepth, maxExpansion: defaultMaxExpansion}
 
 	for _, o := range options {
 		if o == NoTemplateParams {
 			rst.noGenericArgs = true
 		} else if isMaxLength(o) {
 			rst.max = maxLength(o)
+		} else if isMaxDepth(o) {
+			rst.maxDepth = optionValue(o)
+		} else if isMaxExpansion(o) {
+			rst.maxExpansion = optionValue(o)
 		}
 	}
 
 	rst.symbolName()
 
 	if len(rst.str) > 0 {
-		rst.fail("unparsed characters at end of mangled name")
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: rst.off})
 	}
 
 	if suffix != "" {
 		llvmStyle := false
 		for _, o := range options {
-			if o == LLVMStyle {
+			if o == LLVMStyle || o == LLVMClones {
 				llvmStyle = true
 				break
 			}
@@ -89,11 +93,42 @@ type rustState struct {
 	last          byte            // last byte written to buffer
 	noGenericArgs bool            // don't demangle generic arguments
 	max           int             // maximum output length
+	depth         int             // current nesting depth
+	deepest       int             // deepest nesting depth reached
+	maxDepth      int             // maximum nesting depth
+	inBackref     int             // number of back references being followed
+	expansion     int             // number of nodes parsed by following back references
+	maxExpansion  int             // maximum expansion
 }
 
-// fail panics with demangleErr, to be caught in rustToString.
+// fail panics with Error, to be caught in rustToString.
 func (rst *rustState) fail(err string) {
-	panic(demangleErr{err: err, off: rst.off})
+	panic(Error{Msg: err, Offset: rst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply, or if back
+// references have expanded the name too much. Each call is matched
+// by a call to leave.
+func (rst *rustState) enter() {
+	rst.depth++
+	if rst.depth > rst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: rst.off})
+	}
+	if rst.depth > rst.deepest {
+		rst.deepest = rst.depth
+	}
+	if rst.inBackref > 0 {
+		rst.expansion++
+		if rst.expansion > rst.maxExpansion {
+			panic(Error{Code: ErrExpansionLimit, Msg: "back references expand the name too much", Offset: rst.off})
+		}
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (rst *rustState) leave() {
+	rst.depth--
 }
 
 // advance advances the current string offset.
@@ -182,6 +217,9 @@ func (rst *rustState) symbolName() {
 // needsSeparator is true if we need to write out :: for a generic;
 // it is passed as false if we are in the middle of a type.
 func (rst *rustState) path(needsSeparator bool) {
+	rst.enter()
+	defer rst.leave()
+
 	if len(rst.str) < 1 {
 		rst.fail("expected path")
 	}
@@ -535,6 +573,9 @@ func (rst *rustState) binder() {
 //	       | "D" <dyn-bounds> <lifetime> // dyn Trait<Assoc = X> + Send + 'a
 //	       | <backref>
 func (rst *rustState) demangleType() {
+	rst.enter()
+	defer rst.leave()
+
 	if len(rst.str) < 1 {
 		rst.fail("expected type")
 	}
@@ -775,23 +816,21 @@ func (rst *rustState) pathStartGenerics() bool {
 
 // writeLifetime writes out a lifetime binding.
 func (rst *rustState) writeLifetime(lifetime int64) {
-	rst.writeByte('\'')
+	rst.writeString(rst.lifetimeName(lifetime))
+}
+
+// lifetimeName returns the name of a lifetime binding.
+func (rst *rustState) lifetimeName(lifetime int64) string {
 	if lifetime == 0 {
-		rst.writeByte('_')
-		return
+		return "'_"
 	}
 	depth := rst.lifetimes - lifetime
 	if depth < 0 {
 		rst.fail("invalid lifetime")
 	} else if depth < 26 {
-		rst.writeByte('a' + byte(depth))
-	} else {
-		rst.writeByte('z')
-		if !rst.skip {
-			fmt.Fprintf(&rst.buf, "%d", depth-26+1)
-			rst.last = '0'
-		}
+		return "'" + string(rune('a'+depth))
 	}
+	return fmt.Sprintf("'z%d", depth-26+1)
 }
 
 // demangleConst parses:
@@ -801,6 +840,9 @@ func (rst *rustState) writeLifetime(lifetime int64) {
 //	        | <backref>
 //	<const-data> = ["n"] {<hex-digit>} "_"
 func (rst *rustState) demangleConst() {
+	rst.enter()
+	defer rst.leave()
+
 	if len(rst.str) < 1 {
 		rst.fail("expected constant")
 	}
@@ -810,10 +852,21 @@ func (rst *rustState) demangleConst() {
 		return
 	}
 
+	_, val := rst.constValue()
+	rst.writeString(val)
+}
+
+// constValue parses a <const> that is not a <backref>. It returns
+// the type character, or 0 for a placeholder, and the value as it
+// should be printed.
+func (rst *rustState) constValue() (byte, string) {
+	if len(rst.str) < 1 {
+		rst.fail("expected constant")
+	}
+
 	if rst.str[0] == 'p' {
 		rst.advance(1)
-		rst.writeByte('_')
-		return
+		return 0, "_"
 	}
 
 	typ := rst.str[0]
@@ -842,9 +895,10 @@ func (rst *rustState) demangleConst() {
 
 	rst.advance(1)
 
+	neg := ""
 	if kind == signedInt && len(rst.str) > 0 && rst.str[0] == 'n' {
 		rst.advance(1)
-		rst.writeByte('-')
+		neg = "-"
 	}
 
 	start := rst.str
@@ -882,21 +936,16 @@ digitLoop:
 	case signedInt, unsignedInt:
 		if digits > 16 {
 			// Value too big, just write out the string.
-			rst.writeString("0x")
-			rst.writeString(start[:digits])
-		} else {
-			if !rst.skip {
-				fmt.Fprintf(&rst.buf, "%d", val)
-				rst.last = '0'
-			}
+			return typ, neg + "0x" + start[:digits]
 		}
+		return typ, fmt.Sprintf("%s%d", neg, val)
 	case boolean:
 		if digits > 1 {
 			rst.fail("boolean value too large")
 		} else if val == 0 {
-			rst.writeString("false")
+			return typ, "false"
 		} else if val == 1 {
-			rst.writeString("true")
+			return typ, "true"
 		} else {
 			rst.fail("invalid boolean value")
 		}
@@ -904,30 +953,25 @@ digitLoop:
 		if digits > 6 {
 			rst.fail("character value too large")
 		}
-		rst.writeByte('\'')
-		if val == '\t' {
-			rst.writeString(`\t`)
-		} else if val == '\r' {
-			rst.writeString(`\r`)
-		} else if val == '\n' {
-			rst.writeString(`\n`)
-		} else if val == '\\' {
-			rst.writeString(`\\`)
-		} else if val == '\'' {
-			rst.writeString(`\'`)
-		} else if val >= ' ' && val <= '~' {
+		switch {
+		case val == '\t':
+			return typ, `'\t'`
+		case val == '\r':
+			return typ, `'\r'`
+		case val == '\n':
+			return typ, `'\n'`
+		case val == '\\':
+			return typ, `'\\'`
+		case val == '\'':
+			return typ, `'\''`
+		case val >= ' ' && val <= '~':
 			// printable ASCII character
-			rst.writeByte(byte(val))
-		} else {
-			if !rst.skip {
-				fmt.Fprintf(&rst.buf, `\u{%x}`, val)
-				rst.last = '}'
-			}
+			return typ, "'" + string(rune(val)) + "'"
+		default:
+			return typ, fmt.Sprintf(`'\u{%x}'`, val)
 		}
-		rst.writeByte('\'')
-	default:
-		panic("internal error")
 	}
+	panic("internal error")
 }
 
 // base62Number parses:
@@ -978,19 +1022,21 @@ func (rst *rustState) backref(demangle func()) {
 
 	idx := int(idx64)
 	if int64(idx) != idx64 {
-		rst.fail("backref index overflow")
+		panic(Error{Code: ErrBadSubstitution, Msg: "backref index overflow", Offset: rst.off})
 	}
 	if idx < 0 || idx >= backoff {
-		rst.fail("invalid backref index")
+		panic(Error{Code: ErrBadSubstitution, Msg: "invalid backref index", Offset: rst.off})
 	}
 
 	holdStr := rst.str
 	holdOff := rst.off
 	rst.str = rst.orig[idx:backoff]
 	rst.off = idx
+	rst.inBackref++
 	defer func() {
 		rst.str = holdStr
 		rst.off = holdOff
+		rst.inBackref--
 	}()
 
 	demangle()
@@ -1014,13 +1060,34 @@ func (rst *rustState) decimalNumber() int {
 	return val
 }
 
+// oldRustName reports whether name looks like an old-style Rust
+// mangled name. It starts with _ZN and ends with "17h" followed by
+// 16 hex digits followed by "E" followed by an optional suffix
+// starting with "." (which we ignore). It returns the name without
+// the suffix.
+func oldRustName(name string) (string, bool) {
+	if !strings.HasPrefix(name, "_ZN") {
+		return "", false
+	}
+	if pos := strings.LastIndex(name, "E."); pos > 0 {
+		name = name[:pos+1]
+	}
+	if strings.HasSuffix(name, "E") && len(name) > 23 && name[len(name)-20:len(name)-17] == "17h" {
+		return name, true
+	}
+	return "", false
+}
+
 // oldRustToString demangles a Rust symbol using the old demangling.
 // The second result reports whether this is a valid Rust mangled name.
 func oldRustToString(name string, options []Option) (string, bool) {
 	max := 0
+	keepHash := false
 	for _, o := range options {
 		if isMaxLength(o) {
 			max = maxLength(o)
+		} else if o == RustHash {
+			keepHash = true
 		}
 	}
 
@@ -1052,6 +1119,7 @@ func oldRustToString(name string, options []Option) (string, bool) {
 	if bits.OnesCount16(seen) < 5 {
 		return "", false
 	}
+	hash := name[len(name)-18 : len(name)-1]
 	name = name[:len(name)-20]
 
 	// The name is a sequence of length-preceded identifiers.
@@ -1157,6 +1225,11 @@ func oldRustToString(name string, options []Option) (string, bool) {
 		}
 	}
 
+	if keepHash {
+		sb.WriteString("::")
+		sb.WriteString(hash)
+	}
+
 	s := sb.String()
 	if max > 0 && len(s) > max {
 		s = s[:max]
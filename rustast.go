@@ -0,0 +1,1424 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// This file defines the AST nodes for Rust symbol names that use the
+// v0 mangling scheme, which start with "_R", and the code that parses
+// them. The names are printed as by rustToString, which does not use
+// the AST.
+
+// rustToAST demangles a Rust symbol into an AST.
+func rustToAST(name string, options []Option) (ret AST, err error) {
+	if !strings.HasPrefix(name, "_R") {
+		return nil, ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = nil
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	suffix := ""
+	dot := strings.Index(name, ".")
+	if dot >= 0 {
+		suffix = name[dot:]
+		name = name[:dot]
+	}
+
+	name = name[2:]
+	rst := &rustASTState{rustState: rustState{orig: name, str: name, maxDepth: defaultMaxDepth, maxExpansion: defaultMaxExpansion}}
+	for _, o := range options {
+		if isMaxDepth(o) {
+			rst.maxDepth = optionValue(o)
+		} else if isMaxExpansion(o) {
+			rst.maxExpansion = optionValue(o)
+		}
+	}
+
+	if len(rst.str) < 1 {
+		rst.fail("expected symbol-name")
+	}
+	if isDigit(rst.str[0]) {
+		rst.fail("unsupported Rust encoding version")
+	}
+
+	sym := &RustSymbol{Path: rst.pathAST(true), Suffix: suffix}
+
+	// Skip the instantiating crate, which is not printed.
+	if len(rst.str) > 0 {
+		rst.skip = true
+		rst.pathAST(false)
+	}
+
+	if len(rst.str) > 0 {
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: rst.off})
+	}
+
+	return sym, nil
+}
+
+// maxRustBackrefs is the number of back references that we follow
+// when building the AST of a Rust symbol. Each back reference
+// adds the nodes that it refers to to the printed name, so without
+// a limit a short name could print as a very long string.
+const maxRustBackrefs = 1 << 16
+
+// rustASTState holds the state of parsing a Rust symbol into an AST.
+// It uses the methods of rustState to parse the parts of the name
+// that are not nodes; it never writes to the buffer of rustState.
+type rustASTState struct {
+	rustState
+	backrefs int // number of back references followed
+
+	// The nodes built for back references, so that each
+	// back reference to the same part of the name shares them.
+	backrefCache map[rustBackrefKey]rustBackref
+}
+
+// rustBackrefKey identifies what a back reference refers to.
+type rustBackrefKey struct {
+	off       int   // offset in the name
+	kind      byte  // what is parsed there, a rustBackref constant
+	lifetimes int64 // number of bound lifetimes, which affects their names
+}
+
+// The kinds of rustBackrefKey.
+const (
+	rustBackrefPath          = 'p' // path without separator
+	rustBackrefPathSeparator = 'P' // path with separator
+	rustBackrefType          = 't' // type
+	rustBackrefConst         = 'k' // const
+	rustBackrefDynTrait      = 'd' // dyn trait path
+)
+
+// rustBackref is the result of parsing the part of a name that a
+// back reference refers to.
+type rustBackref struct {
+	ast   AST
+	nodes int // number of nodes parsed, for the expansion limit
+	depth int // nesting depth of the nodes, for the depth limit
+}
+
+// pathAST parses a <path>, as described at rustState.path.
+func (rst *rustASTState) pathAST(needsSeparator bool) AST {
+	rst.enter()
+	defer rst.leave()
+
+	if len(rst.str) < 1 {
+		rst.fail("expected path")
+	}
+	switch c := rst.str[0]; c {
+	case 'C':
+		rst.advance(1)
+		dis, ident := rst.identifier()
+		return &RustCrate{Name: ident, Disambiguator: dis}
+	case 'M', 'X':
+		rst.advance(1)
+		// The impl path is not printed.
+		hold := rst.skip
+		rst.skip = true
+		rst.disambiguator()
+		rst.pathAST(false)
+		rst.skip = hold
+
+		typ := rst.typeAST()
+		var trait AST
+		if c == 'X' {
+			trait = rst.pathAST(false)
+		}
+		return &RustImpl{Type: typ, Trait: trait}
+	case 'Y':
+		rst.advance(1)
+		typ := rst.typeAST()
+		trait := rst.pathAST(false)
+		return &RustImpl{Type: typ, Trait: trait}
+	case 'N':
+		rst.advance(1)
+
+		if len(rst.str) < 1 {
+			rst.fail("expected namespace")
+		}
+		ns := rst.str[0]
+		switch {
+		case ns >= 'a' && ns <= 'z':
+		case ns >= 'A' && ns <= 'Z':
+		default:
+			rst.fail("invalid namespace character")
+		}
+		rst.advance(1)
+
+		prefix := rst.pathAST(needsSeparator)
+		dis, ident := rst.identifier()
+		return &RustNested{Prefix: prefix, Namespace: ns, Name: ident, Disambiguator: dis}
+	case 'I':
+		rst.advance(1)
+		path := rst.pathAST(needsSeparator)
+		args := rst.genericArgsAST()
+		rst.checkChar('E')
+		return &RustGenerics{Path: path, Args: args, Turbofish: needsSeparator}
+	case 'B':
+		kind := byte(rustBackrefPath)
+		if needsSeparator {
+			kind = rustBackrefPathSeparator
+		}
+		return rst.backrefAST(kind, func() AST { return rst.pathAST(needsSeparator) })
+	default:
+		rst.fail("unrecognized letter in path")
+		panic("not reached")
+	}
+}
+
+// genericArgsAST parses a list of <generic-arg>, up to but not
+// including the closing E.
+func (rst *rustASTState) genericArgsAST() []AST {
+	args := []AST{}
+	for len(rst.str) > 0 && rst.str[0] != 'E' {
+		args = append(args, rst.genericArgAST())
+	}
+	return args
+}
+
+// genericArgAST parses a <generic-arg>, as described at
+// rustState.genericArg.
+func (rst *rustASTState) genericArgAST() AST {
+	if len(rst.str) < 1 {
+		rst.fail("expected generic-arg")
+	}
+	switch rst.str[0] {
+	case 'L':
+		rst.advance(1)
+		return &RustLifetime{Name: rst.lifetimeName(rst.base62Number())}
+	case 'K':
+		rst.advance(1)
+		return rst.constAST()
+	default:
+		return rst.typeAST()
+	}
+}
+
+// binderAST parses an optional <binder>, as described at
+// rustState.binder, and returns the lifetimes that it binds.
+func (rst *rustASTState) binderAST() []AST {
+	if len(rst.str) < 1 || rst.str[0] != 'G' {
+		return nil
+	}
+	rst.advance(1)
+
+	binderLifetimes := rst.base62Number() + 1
+
+	// Every bound lifetime should be referenced later.
+	if binderLifetimes >= int64(len(rst.str))-rst.lifetimes {
+		rst.fail("binder lifetimes overflow")
+	}
+
+	var lifetimes []AST
+	for i := int64(0); i < binderLifetimes; i++ {
+		rst.lifetimes++
+		lifetimes = append(lifetimes, &RustLifetime{Name: rst.lifetimeName(1)})
+	}
+	return lifetimes
+}
+
+// typeAST parses a <type>, as described at rustState.demangleType.
+func (rst *rustASTState) typeAST() AST {
+	rst.enter()
+	defer rst.leave()
+
+	if len(rst.str) < 1 {
+		rst.fail("expected type")
+	}
+	c := rst.str[0]
+	if c >= 'a' && c <= 'z' {
+		str, ok := rustBasicTypes[c]
+		if !ok {
+			rst.fail("unrecognized basic type character")
+		}
+		rst.advance(1)
+		return &RustBasicType{Name: str}
+	}
+	switch c {
+	case 'C', 'M', 'X', 'Y', 'N', 'I':
+		return rst.pathAST(false)
+	case 'A', 'S':
+		rst.advance(1)
+		elem := rst.typeAST()
+		var ln AST
+		if c == 'A' {
+			ln = rst.constAST()
+		}
+		return &RustArray{Elem: elem, Len: ln}
+	case 'T':
+		rst.advance(1)
+		elems := []AST{}
+		for len(rst.str) > 0 && rst.str[0] != 'E' {
+			elems = append(elems, rst.typeAST())
+		}
+		rst.checkChar('E')
+		return &RustTuple{Elems: elems}
+	case 'R', 'Q':
+		rst.advance(1)
+		var lifetime AST
+		if len(rst.str) > 0 && rst.str[0] == 'L' {
+			rst.advance(1)
+			if lt := rst.base62Number(); lt > 0 {
+				lifetime = &RustLifetime{Name: rst.lifetimeName(lt)}
+			}
+		}
+		return &RustReference{Lifetime: lifetime, Mut: c == 'Q', Elem: rst.typeAST()}
+	case 'P', 'O':
+		rst.advance(1)
+		return &RustPointer{Mut: c == 'O', Elem: rst.typeAST()}
+	case 'F':
+		rst.advance(1)
+		hold := rst.lifetimes
+		fn := rst.fnSigAST()
+		rst.lifetimes = hold
+		return fn
+	case 'D':
+		rst.advance(1)
+		hold := rst.lifetimes
+		dyn := rst.dynBoundsAST()
+		rst.lifetimes = hold
+		if len(rst.str) == 0 || rst.str[0] != 'L' {
+			rst.fail("expected L")
+		}
+		rst.advance(1)
+		if lt := rst.base62Number(); lt > 0 {
+			dyn.Lifetime = &RustLifetime{Name: rst.lifetimeName(lt)}
+		}
+		return dyn
+	case 'B':
+		return rst.backrefAST(rustBackrefType, rst.typeAST)
+	default:
+		rst.fail("unrecognized character in type")
+		panic("not reached")
+	}
+}
+
+// fnSigAST parses a <fn-sig>, as described at rustState.fnSig.
+func (rst *rustASTState) fnSigAST() AST {
+	fn := &RustFnSig{Lifetimes: rst.binderAST()}
+	if len(rst.str) > 0 && rst.str[0] == 'U' {
+		rst.advance(1)
+		fn.Unsafe = true
+	}
+	if len(rst.str) > 0 && rst.str[0] == 'K' {
+		rst.advance(1)
+		if len(rst.str) > 0 && rst.str[0] == 'C' {
+			rst.advance(1)
+			fn.ABI = "C"
+		} else {
+			id, isPunycode := rst.undisambiguatedIdentifier()
+			if isPunycode {
+				rst.fail("punycode used in ABI string")
+			}
+			fn.ABI = strings.ReplaceAll(id, "_", "-")
+		}
+	}
+	fn.Params = []AST{}
+	for len(rst.str) > 0 && rst.str[0] != 'E' {
+		fn.Params = append(fn.Params, rst.typeAST())
+	}
+	rst.checkChar('E')
+	if len(rst.str) > 0 && rst.str[0] == 'u' {
+		rst.advance(1)
+	} else {
+		fn.Return = rst.typeAST()
+	}
+	return fn
+}
+
+// dynBoundsAST parses a <dyn-bounds>, as described at
+// rustState.dynBounds.
+func (rst *rustASTState) dynBoundsAST() *RustDyn {
+	dyn := &RustDyn{Lifetimes: rst.binderAST()}
+	for len(rst.str) > 0 && rst.str[0] != 'E' {
+		dyn.Traits = append(dyn.Traits, rst.dynTraitAST())
+	}
+	rst.checkChar('E')
+	return dyn
+}
+
+// dynTraitAST parses a <dyn-trait>, as described at
+// rustState.dynTrait.
+func (rst *rustASTState) dynTraitAST() AST {
+	trait := rst.dynTraitPathAST()
+	for len(rst.str) > 0 && rst.str[0] == 'p' {
+		rst.advance(1)
+		id, _ := rst.undisambiguatedIdentifier()
+		trait.Bindings = append(trait.Bindings, &RustAssocBinding{Name: id, Type: rst.typeAST()})
+	}
+	return trait
+}
+
+// dynTraitPathAST parses the path of a <dyn-trait>, keeping its
+// generic arguments separate so that the associated type bindings
+// can be printed with them.
+func (rst *rustASTState) dynTraitPathAST() *RustDynTrait {
+	if len(rst.str) < 1 {
+		rst.fail("expected path")
+	}
+	switch rst.str[0] {
+	case 'I':
+		rst.advance(1)
+		path := rst.pathAST(false)
+		args := rst.genericArgsAST()
+		rst.checkChar('E')
+		return &RustDynTrait{Path: path, Args: args}
+	case 'B':
+		trait, _ := rst.backrefAST(rustBackrefDynTrait, func() AST {
+			return rst.dynTraitPathAST()
+		}).(*RustDynTrait)
+		if trait == nil {
+			return &RustDynTrait{}
+		}
+		// The caller adds bindings to the result, so don't
+		// return the shared node.
+		c := *trait
+		return &c
+	default:
+		return &RustDynTrait{Path: rst.pathAST(false)}
+	}
+}
+
+// constAST parses a <const>, as described at rustState.demangleConst.
+func (rst *rustASTState) constAST() AST {
+	rst.enter()
+	defer rst.leave()
+
+	if len(rst.str) < 1 {
+		rst.fail("expected constant")
+	}
+	if rst.str[0] == 'B' {
+		return rst.backrefAST(rustBackrefConst, rst.constAST)
+	}
+	typ, val := rst.constValue()
+	c := &RustConst{Value: val}
+	if typ != 0 {
+		c.Type = &RustBasicType{Name: rustBasicTypes[typ]}
+	}
+	return c
+}
+
+// backrefAST parses a <backref>, as described at rustState.backref,
+// and returns the result of calling parse at the position that it
+// refers to. The kind says what parse parses; the result is shared
+// by back references to the same position of the same kind.
+// If we are skipping, it returns nil.
+func (rst *rustASTState) backrefAST(kind byte, parse func() AST) AST {
+	backoff := rst.off
+
+	rst.checkChar('B')
+	idx64 := rst.base62Number()
+
+	if rst.skip {
+		return nil
+	}
+
+	idx := int(idx64)
+	if int64(idx) != idx64 {
+		panic(Error{Code: ErrBadSubstitution, Msg: "backref index overflow", Offset: rst.off})
+	}
+	if idx < 0 || idx >= backoff {
+		panic(Error{Code: ErrBadSubstitution, Msg: "invalid backref index", Offset: rst.off})
+	}
+
+	rst.backrefs++
+	if rst.backrefs > maxRustBackrefs {
+		panic(Error{Code: ErrExpansionLimit, Msg: "too many back references", Offset: rst.off})
+	}
+
+	key := rustBackrefKey{off: idx, kind: kind, lifetimes: rst.lifetimes}
+	if br, ok := rst.backrefCache[key]; ok {
+		if rst.depth+br.depth > rst.maxDepth {
+			panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: rst.off})
+		}
+		rst.expansion += br.nodes
+		if rst.expansion > rst.maxExpansion {
+			panic(Error{Code: ErrExpansionLimit, Msg: "back references expand the name too much", Offset: rst.off})
+		}
+		return br.ast
+	}
+
+	holdStr := rst.str
+	holdOff := rst.off
+	holdDeepest := rst.deepest
+	rst.str = rst.orig[idx:backoff]
+	rst.off = idx
+	rst.deepest = rst.depth
+	rst.inBackref++
+	expansion := rst.expansion
+
+	a := parse()
+
+	br := rustBackref{
+		ast:   a,
+		nodes: rst.expansion - expansion,
+		depth: rst.deepest - rst.depth,
+	}
+	if rst.backrefCache == nil {
+		rst.backrefCache = make(map[rustBackrefKey]rustBackref)
+	}
+	rst.backrefCache[key] = br
+
+	rst.str = holdStr
+	rst.off = holdOff
+	if rst.deepest < holdDeepest {
+		rst.deepest = holdDeepest
+	}
+	rst.inBackref--
+
+	return a
+}
+
+// printRustList prints a list of nodes separated by sep.
+func printRustList(ps *printState, list []AST, sep string) {
+	for i, a := range list {
+		if i > 0 {
+			ps.writeString(sep)
+		}
+		ps.print(a)
+	}
+}
+
+// copyRustList copies a list of nodes. It returns nil if no node
+// changed.
+func copyRustList(list []AST, fn func(AST) AST, skip func(AST) bool) []AST {
+	var ret []AST
+	for i, a := range list {
+		if ac := a.Copy(fn, skip); ac != nil {
+			if ret == nil {
+				ret = append([]AST{}, list...)
+			}
+			ret[i] = ac
+		}
+	}
+	return ret
+}
+
+// goStringRustList returns the GoString of a list field.
+func goStringRustList(indent int, field string, list []AST) string {
+	if len(list) == 0 {
+		return fmt.Sprintf("%*s%s: nil", indent, "", field)
+	}
+	s := fmt.Sprintf("%*s%s:", indent, "", field)
+	for i, a := range list {
+		s += "\n"
+		s += a.goString(indent+2, fmt.Sprintf("%d: ", i))
+	}
+	return s
+}
+
+// RustSymbol is a Rust symbol name.
+type RustSymbol struct {
+	Path AST
+	// Suffix is a suffix added by the compiler, starting with
+	// a period, such as ".llvm.123". It is only printed with
+	// the LLVMStyle or LLVMClones option.
+	Suffix string
+}
+
+func (rs *RustSymbol) print(ps *printState) {
+	ps.print(rs.Path)
+	if rs.Suffix != "" && ps.llvmClones {
+		ps.writeString(" (")
+		ps.writeString(rs.Suffix)
+		ps.writeByte(')')
+	}
+}
+
+func (rs *RustSymbol) Traverse(fn func(AST) bool) {
+	if fn(rs) {
+		rs.Path.Traverse(fn)
+	}
+}
+
+func (rs *RustSymbol) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rs) {
+		return nil
+	}
+	path := rs.Path.Copy(fn, skip)
+	if path == nil {
+		return fn(rs)
+	}
+	rs = &RustSymbol{Path: path, Suffix: rs.Suffix}
+	if r := fn(rs); r != nil {
+		return r
+	}
+	return rs
+}
+
+func (rs *RustSymbol) GoString() string {
+	return rs.goString(0, "")
+}
+
+func (rs *RustSymbol) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustSymbol: Suffix: %q\n%s", indent, "", field,
+		rs.Suffix, rs.Path.goString(indent+2, "Path: "))
+}
+
+// RustCrate is the root of a Rust path: the name of a crate.
+type RustCrate struct {
+	Name          string
+	Disambiguator int64
+}
+
+func (rc *RustCrate) print(ps *printState) {
+	ps.writeString(rc.Name)
+}
+
+func (rc *RustCrate) Traverse(fn func(AST) bool) {
+	fn(rc)
+}
+
+func (rc *RustCrate) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rc) {
+		return nil
+	}
+	return fn(rc)
+}
+
+func (rc *RustCrate) GoString() string {
+	return rc.goString(0, "")
+}
+
+func (rc *RustCrate) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustCrate: %s Disambiguator: %d", indent, "", field, rc.Name, rc.Disambiguator)
+}
+
+// RustNested is a Rust path nested in another path, as in a::b.
+type RustNested struct {
+	Prefix AST
+	// Namespace is the namespace of the name. An upper case
+	// letter is a special namespace, such as 'C' for a closure
+	// or 'S' for a shim. A lower case letter is an ordinary name.
+	Namespace     byte
+	Name          string
+	Disambiguator int64
+}
+
+func (rn *RustNested) print(ps *printState) {
+	ps.print(rn.Prefix)
+	if rn.Namespace >= 'A' && rn.Namespace <= 'Z' {
+		ps.writeString("::{")
+		switch rn.Namespace {
+		case 'C':
+			ps.writeString("closure")
+		case 'S':
+			ps.writeString("shim")
+		default:
+			ps.writeByte(rn.Namespace)
+		}
+		if rn.Name != "" {
+			ps.writeByte(':')
+			ps.writeString(rn.Name)
+		}
+		ps.writeString(fmt.Sprintf("#%d}", rn.Disambiguator))
+	} else {
+		ps.writeString("::")
+		ps.writeString(rn.Name)
+	}
+}
+
+func (rn *RustNested) Traverse(fn func(AST) bool) {
+	if fn(rn) {
+		rn.Prefix.Traverse(fn)
+	}
+}
+
+func (rn *RustNested) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rn) {
+		return nil
+	}
+	prefix := rn.Prefix.Copy(fn, skip)
+	if prefix == nil {
+		return fn(rn)
+	}
+	rn = &RustNested{Prefix: prefix, Namespace: rn.Namespace, Name: rn.Name, Disambiguator: rn.Disambiguator}
+	if r := fn(rn); r != nil {
+		return r
+	}
+	return rn
+}
+
+func (rn *RustNested) GoString() string {
+	return rn.goString(0, "")
+}
+
+func (rn *RustNested) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustNested: Namespace: %c Name: %s Disambiguator: %d\n%s", indent, "", field,
+		rn.Namespace, rn.Name, rn.Disambiguator,
+		rn.Prefix.goString(indent+2, "Prefix: "))
+}
+
+// RustImpl is a Rust path to the contents of an impl block, as in
+// <T> or <T as Trait>.
+type RustImpl struct {
+	Type  AST
+	Trait AST // may be nil
+}
+
+func (ri *RustImpl) print(ps *printState) {
+	ps.writeByte('<')
+	ps.print(ri.Type)
+	if ri.Trait != nil {
+		ps.writeString(" as ")
+		ps.print(ri.Trait)
+	}
+	ps.writeByte('>')
+}
+
+func (ri *RustImpl) Traverse(fn func(AST) bool) {
+	if fn(ri) {
+		ri.Type.Traverse(fn)
+		if ri.Trait != nil {
+			ri.Trait.Traverse(fn)
+		}
+	}
+}
+
+func (ri *RustImpl) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(ri) {
+		return nil
+	}
+	typ := ri.Type.Copy(fn, skip)
+	var trait AST
+	if ri.Trait != nil {
+		trait = ri.Trait.Copy(fn, skip)
+	}
+	if typ == nil && trait == nil {
+		return fn(ri)
+	}
+	if typ == nil {
+		typ = ri.Type
+	}
+	if trait == nil {
+		trait = ri.Trait
+	}
+	ri = &RustImpl{Type: typ, Trait: trait}
+	if r := fn(ri); r != nil {
+		return r
+	}
+	return ri
+}
+
+func (ri *RustImpl) GoString() string {
+	return ri.goString(0, "")
+}
+
+func (ri *RustImpl) goString(indent int, field string) string {
+	var trait string
+	if ri.Trait == nil {
+		trait = fmt.Sprintf("%*sTrait: nil", indent+2, "")
+	} else {
+		trait = ri.Trait.goString(indent+2, "Trait: ")
+	}
+	return fmt.Sprintf("%*s%sRustImpl:\n%s\n%s", indent, "", field,
+		ri.Type.goString(indent+2, "Type: "), trait)
+}
+
+// RustGenerics is a Rust path with generic arguments, as in
+// Vec<u8> or, in an expression context, Vec::<u8>.
+type RustGenerics struct {
+	Path AST
+	Args []AST
+	// Turbofish is whether to print :: before the arguments.
+	Turbofish bool
+}
+
+func (rg *RustGenerics) print(ps *printState) {
+	ps.print(rg.Path)
+	if rg.Turbofish {
+		ps.writeString("::")
+	}
+	ps.writeByte('<')
+	if ps.tparams {
+		printRustList(ps, rg.Args, ", ")
+	}
+	ps.writeByte('>')
+}
+
+func (rg *RustGenerics) Traverse(fn func(AST) bool) {
+	if fn(rg) {
+		rg.Path.Traverse(fn)
+		for _, a := range rg.Args {
+			a.Traverse(fn)
+		}
+	}
+}
+
+func (rg *RustGenerics) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rg) {
+		return nil
+	}
+	path := rg.Path.Copy(fn, skip)
+	args := copyRustList(rg.Args, fn, skip)
+	if path == nil && args == nil {
+		return fn(rg)
+	}
+	if path == nil {
+		path = rg.Path
+	}
+	if args == nil {
+		args = rg.Args
+	}
+	rg = &RustGenerics{Path: path, Args: args, Turbofish: rg.Turbofish}
+	if r := fn(rg); r != nil {
+		return r
+	}
+	return rg
+}
+
+func (rg *RustGenerics) GoString() string {
+	return rg.goString(0, "")
+}
+
+func (rg *RustGenerics) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustGenerics: Turbofish: %t\n%s\n%s", indent, "", field,
+		rg.Turbofish, rg.Path.goString(indent+2, "Path: "),
+		goStringRustList(indent+2, "Args", rg.Args))
+}
+
+// RustBasicType is a Rust basic type, such as u8 or str.
+type RustBasicType struct {
+	Name string
+}
+
+func (rb *RustBasicType) print(ps *printState) {
+	ps.writeString(rb.Name)
+}
+
+func (rb *RustBasicType) Traverse(fn func(AST) bool) {
+	fn(rb)
+}
+
+func (rb *RustBasicType) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rb) {
+		return nil
+	}
+	return fn(rb)
+}
+
+func (rb *RustBasicType) GoString() string {
+	return rb.goString(0, "")
+}
+
+func (rb *RustBasicType) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustBasicType: %s", indent, "", field, rb.Name)
+}
+
+// RustArray is a Rust array type, as in [T; N], or, if Len is nil,
+// a slice type, as in [T].
+type RustArray struct {
+	Elem AST
+	Len  AST
+}
+
+func (ra *RustArray) print(ps *printState) {
+	ps.writeByte('[')
+	ps.print(ra.Elem)
+	if ra.Len != nil {
+		ps.writeString("; ")
+		ps.print(ra.Len)
+	}
+	ps.writeByte(']')
+}
+
+func (ra *RustArray) Traverse(fn func(AST) bool) {
+	if fn(ra) {
+		ra.Elem.Traverse(fn)
+		if ra.Len != nil {
+			ra.Len.Traverse(fn)
+		}
+	}
+}
+
+func (ra *RustArray) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(ra) {
+		return nil
+	}
+	elem := ra.Elem.Copy(fn, skip)
+	var ln AST
+	if ra.Len != nil {
+		ln = ra.Len.Copy(fn, skip)
+	}
+	if elem == nil && ln == nil {
+		return fn(ra)
+	}
+	if elem == nil {
+		elem = ra.Elem
+	}
+	if ln == nil {
+		ln = ra.Len
+	}
+	ra = &RustArray{Elem: elem, Len: ln}
+	if r := fn(ra); r != nil {
+		return r
+	}
+	return ra
+}
+
+func (ra *RustArray) GoString() string {
+	return ra.goString(0, "")
+}
+
+func (ra *RustArray) goString(indent int, field string) string {
+	var ln string
+	if ra.Len == nil {
+		ln = fmt.Sprintf("%*sLen: nil", indent+2, "")
+	} else {
+		ln = ra.Len.goString(indent+2, "Len: ")
+	}
+	return fmt.Sprintf("%*s%sRustArray:\n%s\n%s", indent, "", field,
+		ra.Elem.goString(indent+2, "Elem: "), ln)
+}
+
+// RustTuple is a Rust tuple type, as in (T, U).
+type RustTuple struct {
+	Elems []AST
+}
+
+func (rt *RustTuple) print(ps *printState) {
+	ps.writeByte('(')
+	printRustList(ps, rt.Elems, ", ")
+	if len(rt.Elems) == 1 {
+		ps.writeByte(',')
+	}
+	ps.writeByte(')')
+}
+
+func (rt *RustTuple) Traverse(fn func(AST) bool) {
+	if fn(rt) {
+		for _, a := range rt.Elems {
+			a.Traverse(fn)
+		}
+	}
+}
+
+func (rt *RustTuple) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rt) {
+		return nil
+	}
+	elems := copyRustList(rt.Elems, fn, skip)
+	if elems == nil {
+		return fn(rt)
+	}
+	rt = &RustTuple{Elems: elems}
+	if r := fn(rt); r != nil {
+		return r
+	}
+	return rt
+}
+
+func (rt *RustTuple) GoString() string {
+	return rt.goString(0, "")
+}
+
+func (rt *RustTuple) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustTuple:\n%s", indent, "", field,
+		goStringRustList(indent+2, "Elems", rt.Elems))
+}
+
+// RustReference is a Rust reference type, as in &'a mut T.
+type RustReference struct {
+	Lifetime AST // may be nil
+	Mut      bool
+	Elem     AST
+}
+
+func (rr *RustReference) print(ps *printState) {
+	ps.writeByte('&')
+	if rr.Lifetime != nil {
+		ps.print(rr.Lifetime)
+		ps.writeByte(' ')
+	}
+	if rr.Mut {
+		ps.writeString("mut ")
+	}
+	ps.print(rr.Elem)
+}
+
+func (rr *RustReference) Traverse(fn func(AST) bool) {
+	if fn(rr) {
+		if rr.Lifetime != nil {
+			rr.Lifetime.Traverse(fn)
+		}
+		rr.Elem.Traverse(fn)
+	}
+}
+
+func (rr *RustReference) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rr) {
+		return nil
+	}
+	var lifetime AST
+	if rr.Lifetime != nil {
+		lifetime = rr.Lifetime.Copy(fn, skip)
+	}
+	elem := rr.Elem.Copy(fn, skip)
+	if lifetime == nil && elem == nil {
+		return fn(rr)
+	}
+	if lifetime == nil {
+		lifetime = rr.Lifetime
+	}
+	if elem == nil {
+		elem = rr.Elem
+	}
+	rr = &RustReference{Lifetime: lifetime, Mut: rr.Mut, Elem: elem}
+	if r := fn(rr); r != nil {
+		return r
+	}
+	return rr
+}
+
+func (rr *RustReference) GoString() string {
+	return rr.goString(0, "")
+}
+
+func (rr *RustReference) goString(indent int, field string) string {
+	var lifetime string
+	if rr.Lifetime == nil {
+		lifetime = fmt.Sprintf("%*sLifetime: nil", indent+2, "")
+	} else {
+		lifetime = rr.Lifetime.goString(indent+2, "Lifetime: ")
+	}
+	return fmt.Sprintf("%*s%sRustReference: Mut: %t\n%s\n%s", indent, "", field,
+		rr.Mut, lifetime, rr.Elem.goString(indent+2, "Elem: "))
+}
+
+// RustPointer is a Rust raw pointer type, as in *const T.
+type RustPointer struct {
+	Mut  bool
+	Elem AST
+}
+
+func (rp *RustPointer) print(ps *printState) {
+	if rp.Mut {
+		ps.writeString("*mut ")
+	} else {
+		ps.writeString("*const ")
+	}
+	ps.print(rp.Elem)
+}
+
+func (rp *RustPointer) Traverse(fn func(AST) bool) {
+	if fn(rp) {
+		rp.Elem.Traverse(fn)
+	}
+}
+
+func (rp *RustPointer) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rp) {
+		return nil
+	}
+	elem := rp.Elem.Copy(fn, skip)
+	if elem == nil {
+		return fn(rp)
+	}
+	rp = &RustPointer{Mut: rp.Mut, Elem: elem}
+	if r := fn(rp); r != nil {
+		return r
+	}
+	return rp
+}
+
+func (rp *RustPointer) GoString() string {
+	return rp.goString(0, "")
+}
+
+func (rp *RustPointer) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustPointer: Mut: %t\n%s", indent, "", field,
+		rp.Mut, rp.Elem.goString(indent+2, "Elem: "))
+}
+
+// RustFnSig is a Rust function pointer type, as in
+// for<'a> unsafe extern "C" fn(&'a u8) -> bool.
+type RustFnSig struct {
+	Lifetimes []AST // lifetimes bound by for<...>
+	Unsafe    bool
+	ABI       string // empty for the default Rust ABI
+	Params    []AST
+	Return    AST // nil if the function returns ()
+}
+
+func (rf *RustFnSig) print(ps *printState) {
+	if len(rf.Lifetimes) > 0 {
+		ps.writeString("for<")
+		printRustList(ps, rf.Lifetimes, ", ")
+		ps.writeString("> ")
+	}
+	if rf.Unsafe {
+		ps.writeString("unsafe ")
+	}
+	if rf.ABI != "" {
+		ps.writeString(`extern "`)
+		ps.writeString(rf.ABI)
+		ps.writeString(`" `)
+	}
+	ps.writeString("fn(")
+	printRustList(ps, rf.Params, ", ")
+	ps.writeByte(')')
+	if rf.Return != nil {
+		ps.writeString(" -> ")
+		ps.print(rf.Return)
+	}
+}
+
+func (rf *RustFnSig) Traverse(fn func(AST) bool) {
+	if fn(rf) {
+		for _, a := range rf.Lifetimes {
+			a.Traverse(fn)
+		}
+		for _, a := range rf.Params {
+			a.Traverse(fn)
+		}
+		if rf.Return != nil {
+			rf.Return.Traverse(fn)
+		}
+	}
+}
+
+func (rf *RustFnSig) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rf) {
+		return nil
+	}
+	lifetimes := copyRustList(rf.Lifetimes, fn, skip)
+	params := copyRustList(rf.Params, fn, skip)
+	var ret AST
+	if rf.Return != nil {
+		ret = rf.Return.Copy(fn, skip)
+	}
+	if lifetimes == nil && params == nil && ret == nil {
+		return fn(rf)
+	}
+	if lifetimes == nil {
+		lifetimes = rf.Lifetimes
+	}
+	if params == nil {
+		params = rf.Params
+	}
+	if ret == nil {
+		ret = rf.Return
+	}
+	rf = &RustFnSig{Lifetimes: lifetimes, Unsafe: rf.Unsafe, ABI: rf.ABI, Params: params, Return: ret}
+	if r := fn(rf); r != nil {
+		return r
+	}
+	return rf
+}
+
+func (rf *RustFnSig) GoString() string {
+	return rf.goString(0, "")
+}
+
+func (rf *RustFnSig) goString(indent int, field string) string {
+	var ret string
+	if rf.Return == nil {
+		ret = fmt.Sprintf("%*sReturn: nil", indent+2, "")
+	} else {
+		ret = rf.Return.goString(indent+2, "Return: ")
+	}
+	return fmt.Sprintf("%*s%sRustFnSig: Unsafe: %t ABI: %q\n%s\n%s\n%s", indent, "", field,
+		rf.Unsafe, rf.ABI,
+		goStringRustList(indent+2, "Lifetimes", rf.Lifetimes),
+		goStringRustList(indent+2, "Params", rf.Params), ret)
+}
+
+// RustDyn is a Rust trait object type, as in dyn Trait + Send + 'a.
+type RustDyn struct {
+	Lifetimes []AST // lifetimes bound by for<...>
+	Traits    []AST
+	Lifetime  AST // may be nil
+}
+
+func (rd *RustDyn) print(ps *printState) {
+	ps.writeString("dyn ")
+	if len(rd.Lifetimes) > 0 {
+		ps.writeString("for<")
+		printRustList(ps, rd.Lifetimes, ", ")
+		ps.writeString("> ")
+	}
+	printRustList(ps, rd.Traits, " + ")
+	if rd.Lifetime != nil {
+		if len(rd.Traits) > 0 {
+			ps.writeByte(' ')
+		}
+		ps.writeString("+ ")
+		ps.print(rd.Lifetime)
+	}
+}
+
+func (rd *RustDyn) Traverse(fn func(AST) bool) {
+	if fn(rd) {
+		for _, a := range rd.Lifetimes {
+			a.Traverse(fn)
+		}
+		for _, a := range rd.Traits {
+			a.Traverse(fn)
+		}
+		if rd.Lifetime != nil {
+			rd.Lifetime.Traverse(fn)
+		}
+	}
+}
+
+func (rd *RustDyn) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rd) {
+		return nil
+	}
+	lifetimes := copyRustList(rd.Lifetimes, fn, skip)
+	traits := copyRustList(rd.Traits, fn, skip)
+	var lifetime AST
+	if rd.Lifetime != nil {
+		lifetime = rd.Lifetime.Copy(fn, skip)
+	}
+	if lifetimes == nil && traits == nil && lifetime == nil {
+		return fn(rd)
+	}
+	if lifetimes == nil {
+		lifetimes = rd.Lifetimes
+	}
+	if traits == nil {
+		traits = rd.Traits
+	}
+	if lifetime == nil {
+		lifetime = rd.Lifetime
+	}
+	rd = &RustDyn{Lifetimes: lifetimes, Traits: traits, Lifetime: lifetime}
+	if r := fn(rd); r != nil {
+		return r
+	}
+	return rd
+}
+
+func (rd *RustDyn) GoString() string {
+	return rd.goString(0, "")
+}
+
+func (rd *RustDyn) goString(indent int, field string) string {
+	var lifetime string
+	if rd.Lifetime == nil {
+		lifetime = fmt.Sprintf("%*sLifetime: nil", indent+2, "")
+	} else {
+		lifetime = rd.Lifetime.goString(indent+2, "Lifetime: ")
+	}
+	return fmt.Sprintf("%*s%sRustDyn:\n%s\n%s\n%s", indent, "", field,
+		goStringRustList(indent+2, "Lifetimes", rd.Lifetimes),
+		goStringRustList(indent+2, "Traits", rd.Traits), lifetime)
+}
+
+// RustDynTrait is a trait in a Rust trait object type, with generic
+// arguments and associated type bindings, as in
+// Iterator<Item = u8>.
+type RustDynTrait struct {
+	Path     AST
+	Args     []AST // nil if the path has no generic arguments
+	Bindings []AST // associated type bindings
+}
+
+func (rd *RustDynTrait) print(ps *printState) {
+	ps.print(rd.Path)
+	started := false
+	if rd.Args != nil {
+		ps.writeByte('<')
+		if ps.tparams {
+			printRustList(ps, rd.Args, ", ")
+		}
+		started = true
+	}
+	for _, b := range rd.Bindings {
+		if started {
+			ps.writeString(", ")
+		} else {
+			ps.writeByte('<')
+			started = true
+		}
+		ps.print(b)
+	}
+	if started {
+		ps.writeByte('>')
+	}
+}
+
+func (rd *RustDynTrait) Traverse(fn func(AST) bool) {
+	if fn(rd) {
+		rd.Path.Traverse(fn)
+		for _, a := range rd.Args {
+			a.Traverse(fn)
+		}
+		for _, a := range rd.Bindings {
+			a.Traverse(fn)
+		}
+	}
+}
+
+func (rd *RustDynTrait) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rd) {
+		return nil
+	}
+	path := rd.Path.Copy(fn, skip)
+	args := copyRustList(rd.Args, fn, skip)
+	bindings := copyRustList(rd.Bindings, fn, skip)
+	if path == nil && args == nil && bindings == nil {
+		return fn(rd)
+	}
+	if path == nil {
+		path = rd.Path
+	}
+	if args == nil {
+		args = rd.Args
+	}
+	if bindings == nil {
+		bindings = rd.Bindings
+	}
+	rd = &RustDynTrait{Path: path, Args: args, Bindings: bindings}
+	if r := fn(rd); r != nil {
+		return r
+	}
+	return rd
+}
+
+func (rd *RustDynTrait) GoString() string {
+	return rd.goString(0, "")
+}
+
+func (rd *RustDynTrait) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustDynTrait:\n%s\n%s\n%s", indent, "", field,
+		rd.Path.goString(indent+2, "Path: "),
+		goStringRustList(indent+2, "Args", rd.Args),
+		goStringRustList(indent+2, "Bindings", rd.Bindings))
+}
+
+// RustAssocBinding is an associated type binding in a Rust trait
+// object type, as in Item = u8.
+type RustAssocBinding struct {
+	Name string
+	Type AST
+}
+
+func (rb *RustAssocBinding) print(ps *printState) {
+	ps.writeString(rb.Name)
+	ps.writeString(" = ")
+	ps.print(rb.Type)
+}
+
+func (rb *RustAssocBinding) Traverse(fn func(AST) bool) {
+	if fn(rb) {
+		rb.Type.Traverse(fn)
+	}
+}
+
+func (rb *RustAssocBinding) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rb) {
+		return nil
+	}
+	typ := rb.Type.Copy(fn, skip)
+	if typ == nil {
+		return fn(rb)
+	}
+	rb = &RustAssocBinding{Name: rb.Name, Type: typ}
+	if r := fn(rb); r != nil {
+		return r
+	}
+	return rb
+}
+
+func (rb *RustAssocBinding) GoString() string {
+	return rb.goString(0, "")
+}
+
+func (rb *RustAssocBinding) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustAssocBinding: Name: %s\n%s", indent, "", field,
+		rb.Name, rb.Type.goString(indent+2, "Type: "))
+}
+
+// RustLifetime is a Rust lifetime, such as 'a or '_.
+type RustLifetime struct {
+	Name string // including the leading quote
+}
+
+func (rl *RustLifetime) print(ps *printState) {
+	ps.writeString(rl.Name)
+}
+
+func (rl *RustLifetime) Traverse(fn func(AST) bool) {
+	fn(rl)
+}
+
+func (rl *RustLifetime) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rl) {
+		return nil
+	}
+	return fn(rl)
+}
+
+func (rl *RustLifetime) GoString() string {
+	return rl.goString(0, "")
+}
+
+func (rl *RustLifetime) goString(indent int, field string) string {
+	return fmt.Sprintf("%*s%sRustLifetime: %s", indent, "", field, rl.Name)
+}
+
+// RustConst is a Rust constant used as a generic argument or an
+// array length, such as 3, true, or 'x'.
+type RustConst struct {
+	Type  AST    // nil for the placeholder _
+	Value string // the value as printed
+}
+
+func (rc *RustConst) print(ps *printState) {
+	ps.writeString(rc.Value)
+}
+
+func (rc *RustConst) Traverse(fn func(AST) bool) {
+	if fn(rc) && rc.Type != nil {
+		rc.Type.Traverse(fn)
+	}
+}
+
+func (rc *RustConst) Copy(fn func(AST) AST, skip func(AST) bool) AST {
+	if skip(rc) {
+		return nil
+	}
+	if rc.Type == nil {
+		return fn(rc)
+	}
+	typ := rc.Type.Copy(fn, skip)
+	if typ == nil {
+		return fn(rc)
+	}
+	rc = &RustConst{Type: typ, Value: rc.Value}
+	if r := fn(rc); r != nil {
+		return r
+	}
+	return rc
+}
+
+func (rc *RustConst) GoString() string {
+	return rc.goString(0, "")
+}
+
+func (rc *RustConst) goString(indent int, field string) string {
+	if rc.Type == nil {
+		return fmt.Sprintf("%*s%sRustConst: %s", indent, "", field, rc.Value)
+	}
+	return fmt.Sprintf("%*s%sRustConst: %s\n%s", indent, "", field,
+		rc.Value, rc.Type.goString(indent+2, "Type: "))
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestRustAST checks that for the Rust names in the test data the
+// AST prints as ToString does.
+func TestRustAST(t *testing.T) {
+	t.Parallel()
+	count := 0
+	for _, file := range []string{rustFilename, rustCheckFilename} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			input := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(input, "_R") {
+				continue
+			}
+			for _, options := range [][]Option{nil, {LLVMStyle}, {NoTemplateParams}} {
+				want, wantErr := ToString(input, options...)
+				a, gotErr := ToAST(input, options...)
+				if gotErr != nil && wantErr == nil && len(options) > 0 && options[0] == NoTemplateParams {
+					// ToString does not parse the generic
+					// arguments that it does not print, so it
+					// does not see errors in back references
+					// that they use.
+					continue
+				}
+				if (wantErr == nil) != (gotErr == nil) {
+					t.Errorf("%s %v: ToString error %v, ToAST error %v", input, options, wantErr, gotErr)
+					continue
+				}
+				if wantErr != nil {
+					if gotErr.Error() != wantErr.Error() {
+						t.Errorf("%s %v: ToString error %v, ToAST error %v", input, options, wantErr, gotErr)
+					}
+					continue
+				}
+				if got := ASTToString(a, options...); got != want {
+					t.Errorf("%s %v:\ngot  %s\nwant %s", input, options, got, want)
+				}
+				count++
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if count < 100 {
+		t.Errorf("only tested %d names", count)
+	}
+
+	want, _ := ToString(rustMangledTemplates, NoTemplateParams)
+	a, err := ToAST(rustMangledTemplates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ASTToString(a, NoTemplateParams); got != want {
+		t.Errorf("NoTemplateParams: got %q, want %q", got, want)
+	}
+}
+
+func TestRustASTNodes(t *testing.T) {
+	a, err := ToAST("_RINvNtC4core3mem7replaceRShE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sym, ok := a.(*RustSymbol)
+	if !ok {
+		t.Fatalf("got %T, want *RustSymbol", a)
+	}
+	g, ok := sym.Path.(*RustGenerics)
+	if !ok || !g.Turbofish || len(g.Args) != 1 {
+		t.Fatalf("got path %#v", sym.Path)
+	}
+	n, ok := g.Path.(*RustNested)
+	if !ok || n.Name != "replace" || n.Namespace != 'v' {
+		t.Fatalf("got generic path %#v", g.Path)
+	}
+	if crate, ok := n.Prefix.(*RustNested).Prefix.(*RustCrate); !ok || crate.Name != "core" {
+		t.Errorf("got crate %#v", n.Prefix)
+	}
+	ref, ok := g.Args[0].(*RustReference)
+	if !ok || ref.Mut {
+		t.Fatalf("got argument %#v", g.Args[0])
+	}
+	if arr, ok := ref.Elem.(*RustArray); !ok || arr.Len != nil || arr.Elem.(*RustBasicType).Name != "u8" {
+		t.Errorf("got element %#v", ref.Elem)
+	}
+	if got, want := ASTToString(a), "core::mem::replace::<&[u8]>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A copy that renames the crate.
+	b := a.Copy(func(a AST) AST {
+		if c, ok := a.(*RustCrate); ok {
+			return &RustCrate{Name: "std", Disambiguator: c.Disambiguator}
+		}
+		return nil
+	}, func(AST) bool { return false })
+	if got, want := ASTToString(b), "std::mem::replace::<&[u8]>"; got != want {
+		t.Errorf("after Copy got %q, want %q", got, want)
+	}
+	if got, want := ASTToString(a), "core::mem::replace::<&[u8]>"; got != want {
+		t.Errorf("original after Copy got %q, want %q", got, want)
+	}
+}
+
+func TestRustASTBackrefs(t *testing.T) {
+	// Back references to the same type share the nodes.
+	a, err := ToAST("_RINvC1a1fTNtC1b1SB8_B8_EE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuple, ok := a.(*RustSymbol).Path.(*RustGenerics).Args[0].(*RustTuple)
+	if !ok || len(tuple.Elems) != 3 {
+		t.Fatalf("got %#v", a)
+	}
+	if tuple.Elems[1] != tuple.Elems[2] {
+		t.Errorf("two back references to a type do not share nodes")
+	}
+
+	// The bindings of a dyn trait are not part of what a back
+	// reference to the trait refers to.
+	const dyn = "_RINvC1a1fTDNtC1b1Tp1XhEL_DB9_p1YtEL_DB9_p1ZmEL_EE"
+	want := "a::f::<(dyn b::T<X = u8>, dyn b::T<Y = u16>, dyn b::T<Z = u32>)>"
+	if got, err := ToString(dyn); err != nil || got != want {
+		t.Errorf("ToString(%q) = %q, %v, want %q", dyn, got, err, want)
+	}
+	a, err = ToAST(dyn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ASTToString(a); got != want {
+		t.Errorf("ASTToString = %q, want %q", got, want)
+	}
+}
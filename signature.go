@@ -0,0 +1,228 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"errors"
+	"strings"
+)
+
+// OverloadSignature demangles a C++ symbol name and returns a
+// canonical form of its signature, suitable for deciding whether two
+// mangled names refer to the same overload.
+//
+// The canonical form differs from the output of ToString:
+// the return type of the function is omitted, except for a
+// specialization of a function template, for which it is part of
+// the signature;
+// top-level const and volatile qualifiers on parameter types are
+// dropped, as they do not affect the function type;
+// ABI tags are removed;
+// clone suffixes are removed;
+// the std::__cxx11 and std::__1 inline namespaces of the GNU and
+// LLVM standard libraries are removed;
+// and the standard library typedefs such as std::string are used in
+// place of their template expansions.
+//
+// Two names with the same canonical signature declare the same
+// overload. The options are passed to ASTToString.
+func OverloadSignature(name string, options ...Option) (string, error) {
+	a, err := cppToAST(name)
+	if err != nil {
+		return "", err
+	}
+	a = canonicalSignature(a)
+	return ASTToString(a, options...), nil
+}
+
+// ErrNotFunction is returned by GDBSignature if the string is a C++
+// symbol name, but not the name of a function.
+var ErrNotFunction = errors.New("not the name of a C++ function")
+
+// GDBSignature demangles the name of a C++ function and returns a
+// string that GDB accepts as the location of a breakpoint, as in
+// "break A::f(int) const". The result is like the output of ToString,
+// but omits the return type of the function, ABI tags, and clone
+// suffixes, none of which GDB expects. The options are passed to
+// ASTToString; options that change the spelling of types, such as
+// StdAbbreviations or WestConst, will produce a string that GDB
+// does not accept.
+//
+// If the name is not a C++ symbol name this returns
+// ErrNotMangledName. If it is not the name of a function, such as the
+// name of a variable or a vtable, this returns ErrNotFunction.
+func GDBSignature(name string, options ...Option) (string, error) {
+	a, err := cppToAST(name)
+	if err != nil {
+		return "", err
+	}
+	for {
+		c, ok := a.(*Clone)
+		if !ok {
+			break
+		}
+		a = c.Base
+	}
+	t, ok := a.(*Typed)
+	if !ok {
+		return "", ErrNotFunction
+	}
+	typ := t.Type
+	if m, ok := typ.(*MethodWithQualifiers); ok {
+		typ = m.Method
+	}
+	if _, ok := typ.(*FunctionType); !ok {
+		return "", ErrNotFunction
+	}
+	options = append([]Option{NoReturnType, NoABITags}, options...)
+	return ASTToString(a, options...), nil
+}
+
+// canonicalSignature returns the canonical form of a, as described
+// at OverloadSignature.
+func canonicalSignature(a AST) AST {
+	for {
+		c, ok := a.(*Clone)
+		if !ok {
+			break
+		}
+		a = c.Base
+	}
+
+	seen := make(map[AST]bool)
+	skip := func(a AST) bool {
+		if seen[a] {
+			return true
+		}
+		seen[a] = true
+		return false
+	}
+	if c := a.Copy(canonicalOne, skip); c != nil {
+		a = c
+	}
+
+	if t, ok := a.(*Typed); ok {
+		typ := t.Type
+		var mwq *MethodWithQualifiers
+		if m, ok := typ.(*MethodWithQualifiers); ok {
+			mwq = m
+			typ = m.Method
+		}
+		if ft, ok := typ.(*FunctionType); ok {
+			args := make([]AST, len(ft.Args))
+			for i, arg := range ft.Args {
+				args[i] = dropTopLevelCV(arg)
+			}
+			// The return type is only mangled for a template
+			// specialization, and is then part of its signature.
+			typ = &FunctionType{Return: ft.Return, Args: args, ForLocalName: ft.ForLocalName}
+			if mwq != nil {
+				typ = &MethodWithQualifiers{Method: typ, Qualifiers: mwq.Qualifiers, RefQualifier: mwq.RefQualifier}
+			}
+			a = &Typed{Name: t.Name, Type: typ}
+		}
+	}
+
+	return a
+}
+
+// canonicalOne is the copy function used by canonicalSignature.
+// It returns nil if there is nothing to change.
+func canonicalOne(a AST) AST {
+	switch a := a.(type) {
+	case *TaggedName:
+		return a.Name
+	case *Qualified:
+		// Remove the std::__cxx11 and std::__1 inline namespaces.
+		if q, ok := a.Scope.(*Qualified); ok && isStdName(q.Scope) {
+			if n, ok := q.Name.(*Name); ok && (n.Name == "__cxx11" || n.Name == "__1") {
+				return &Qualified{Scope: q.Scope, Name: a.Name, LocalName: a.LocalName, Discriminator: a.Discriminator}
+			}
+		}
+	case *Template:
+		if isStdName(templateScope(a)) {
+			if short, ok := stdTypedefs[ASTToString(a)]; ok {
+				return &Qualified{Scope: &Name{Name: "std"}, Name: &Name{Name: short}}
+			}
+		}
+	}
+	return nil
+}
+
+// templateScope returns the scope of the name of a template,
+// or nil if the name is not qualified.
+func templateScope(t *Template) AST {
+	if q, ok := t.Name.(*Qualified); ok {
+		return q.Scope
+	}
+	return nil
+}
+
+// isStdName reports whether a is the name "std".
+func isStdName(a AST) bool {
+	n, ok := a.(*Name)
+	return ok && n.Name == "std"
+}
+
+// dropTopLevelCV removes const and volatile qualifiers from the top
+// level of a parameter type.
+func dropTopLevelCV(a AST) AST {
+	twq, ok := a.(*TypeWithQualifiers)
+	if !ok {
+		return a
+	}
+	qs, ok := twq.Qualifiers.(*Qualifiers)
+	if !ok {
+		return a
+	}
+	var keep []AST
+	for _, q := range qs.Qualifiers {
+		if q, ok := q.(*Qualifier); ok && len(q.Exprs) == 0 && (q.Name == "const" || q.Name == "volatile") {
+			continue
+		}
+		keep = append(keep, q)
+	}
+	if len(keep) == 0 {
+		return twq.Base
+	}
+	return &TypeWithQualifiers{Base: twq.Base, Qualifiers: &Qualifiers{Qualifiers: keep}}
+}
+
+// stdTypedefs maps the demangled expansion of a standard library
+// template to the name of the std typedef for it.
+var stdTypedefs = make(map[string]string)
+
+func init() {
+	for _, ch := range []struct {
+		char, prefix string
+		streams      bool // whether there are stream typedefs
+	}{
+		{"char", "", true},
+		{"wchar_t", "w", true},
+		{"char8_t", "u8", false},
+		{"char16_t", "u16", false},
+		{"char32_t", "u32", false},
+	} {
+		traits := "std::char_traits<" + ch.char + ">"
+		alloc := "std::allocator<" + ch.char + ">"
+		add := func(tmpl string, args []string, short string) {
+			stdTypedefs["std::"+tmpl+"<"+ch.char+", "+strings.Join(args, ", ")+" >"] = ch.prefix + short
+		}
+		add("basic_string", []string{traits, alloc}, "string")
+		add("basic_string_view", []string{traits}, "string_view")
+		if !ch.streams {
+			continue
+		}
+		add("basic_istream", []string{traits}, "istream")
+		add("basic_ostream", []string{traits}, "ostream")
+		add("basic_iostream", []string{traits}, "iostream")
+		add("basic_stringstream", []string{traits, alloc}, "stringstream")
+		add("basic_istringstream", []string{traits, alloc}, "istringstream")
+		add("basic_ostringstream", []string{traits, alloc}, "ostringstream")
+		add("basic_fstream", []string{traits}, "fstream")
+		add("basic_ifstream", []string{traits}, "ifstream")
+		add("basic_ofstream", []string{traits}, "ofstream")
+	}
+}
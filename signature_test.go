@@ -0,0 +1,74 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestOverloadSignature(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_Z1fi", "f(int)"},
+		{"_Z1fKi", "f(int)"},
+		{"_Z1fPKc", "f(char const*)"},
+		{"_ZN1A1fB5cxx11Ev", "A::f()"},
+		{"_Z1fIiEvT_", "void f<int>(int)"},
+		{"_Z1fIiEiT_", "int f<int>(int)"},
+		{"_Z1fIiEvKT_", "void f<int>(int)"},
+		{"_Z1fv.cold", "f()"},
+		{"_ZNK1A1fEPKcRKSs", "A::f(char const*, std::string const&) const"},
+		{"_Z1fNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEE", "f(std::string)"},
+		{"_Z1fSbIwSt11char_traitsIwESaIwEE", "f(std::wstring)"},
+		{"_Z1fSo", "f(std::ostream)"},
+		{"_Z1fNSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEE", "f(std::string)"},
+		{"_Z1fNSt3__16vectorIiNS_9allocatorIiEEEE", "f(std::vector<int, std::allocator<int> >)"},
+		{"_ZNSt7__cxx114listIiSaIiEE4sizeEv", "std::list<int, std::allocator<int> >::size()"},
+	}
+
+	for _, test := range tests {
+		got, err := OverloadSignature(test.input)
+		if err != nil {
+			t.Errorf("OverloadSignature(%s): unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("OverloadSignature(%s) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestGDBSignature(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"_Z1fi", "f(int)"},
+		{"_ZNK1A1fEPKc", "A::f(char const*) const"},
+		{"_Z1fIiEvT_", "f<int>(int)"},
+		{"_ZN1A1fB5cxx11Ev", "A::f()"},
+		{"_Z1fv.cold", "f()"},
+		{"_ZN12_GLOBAL__N_11fEv", "(anonymous namespace)::f()"},
+		{"_ZNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEE5clearEv", "std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> >::clear()"},
+		{"_ZZ4mainENKUlvE_clEv", "main::{lambda()#1}::operator()() const"},
+		{"_ZN1AC2Ev", "A::A()"},
+	}
+
+	for _, test := range tests {
+		got, err := GDBSignature(test.input)
+		if err != nil {
+			t.Errorf("GDBSignature(%s): unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("GDBSignature(%s) = %s, want %s", test.input, got, test.want)
+		}
+	}
+
+	for _, input := range []string{"_ZN1A1xE", "_ZTV1A"} {
+		if _, err := GDBSignature(input); err != ErrNotFunction {
+			t.Errorf("GDBSignature(%s): got error %v, want %v", input, err, ErrNotFunction)
+		}
+	}
+	if _, err := GDBSignature("f"); err != ErrNotMangledName {
+		t.Errorf("GDBSignature(f): got error %v, want %v", err, ErrNotMangledName)
+	}
+}
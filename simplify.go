@@ -0,0 +1,168 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// Simplify returns a copy of an AST without the parts that the
+// options say to omit, so that a program can demangle a name once
+// and derive several reduced forms from the AST. Printing the result
+// with ASTToString gives the same string as printing the original
+// AST with the options. For an AST returned by ToAST without any of
+// the options, printing the result is the same as calling ToString
+// with the options, except that an expression that refers to a
+// template may print with fewer parentheses with NoTemplateParams.
+//
+// Simplify supports the options NoParams, NoClones, NoTemplateParams,
+// NoEnclosingParams, NoReturnType, NoMethodQualifiers, and
+// NoABITags. Other options only change how an AST is printed, and
+// are ignored; they may be passed to ASTToString. The original AST
+// is not changed.
+func Simplify(a AST, options ...Option) AST {
+	params := true
+	clones := true
+	sp := &simplifier{
+		tparams:         true,
+		enclosingParams: true,
+		returnType:      true,
+		methodQuals:     true,
+		abiTags:         true,
+		args:            make(map[*Template][]AST),
+	}
+	for _, o := range options {
+		switch o {
+		case NoParams:
+			params = false
+			clones = false
+		case NoClones:
+			clones = false
+		case NoTemplateParams:
+			sp.tparams = false
+		case NoEnclosingParams:
+			sp.enclosingParams = false
+		case NoReturnType:
+			sp.returnType = false
+		case NoMethodQualifiers:
+			sp.methodQuals = false
+		case NoABITags:
+			sp.abiTags = false
+		}
+	}
+
+	if !clones {
+		for {
+			c, ok := a.(*Clone)
+			if !ok {
+				break
+			}
+			a = c.Base
+		}
+	}
+	if !params {
+		a = withoutParams(a)
+	}
+
+	return sp.transform(a)
+}
+
+// simplifier holds the options used by Simplify.
+type simplifier struct {
+	tparams         bool
+	enclosingParams bool
+	returnType      bool
+	methodQuals     bool
+	abiTags         bool
+
+	// The simplified template arguments that template
+	// parameters refer to.
+	args map[*Template][]AST
+}
+
+// transform returns a simplified copy of a.
+func (sp *simplifier) transform(a AST) AST {
+	return Transform(a, func(n AST) AST {
+		switch n := n.(type) {
+		case *Template:
+			if !sp.tparams {
+				return n.Name
+			}
+		case *TemplateParam:
+			// Transform doesn't change the template
+			// arguments that a TemplateParam refers to, so
+			// replace the TemplateParam with the argument
+			// if simplifying changes it.
+			if n.Template == nil || n.Index >= len(n.Template.Args) {
+				return nil
+			}
+			args, ok := sp.args[n.Template]
+			if !ok {
+				args = make([]AST, len(n.Template.Args))
+				sp.args[n.Template] = args
+			}
+			if args[n.Index] == nil {
+				args[n.Index] = sp.transform(n.Template.Args[n.Index])
+			}
+			if arg := args[n.Index]; arg != n.Template.Args[n.Index] {
+				return arg
+			}
+		case *RustGenerics:
+			if !sp.tparams && len(n.Args) > 0 {
+				return &RustGenerics{Path: n.Path, Turbofish: n.Turbofish}
+			}
+		case *FunctionType:
+			if !sp.enclosingParams && n.ForLocalName && (n.Return != nil || len(n.Args) > 0) {
+				return &FunctionType{ForLocalName: true}
+			}
+		case *Typed:
+			typ := n.Type
+			if !sp.methodQuals {
+				if mwq, ok := typ.(*MethodWithQualifiers); ok {
+					typ = mwq.Method
+				}
+			}
+			if !sp.returnType {
+				typ = withoutReturnType(typ)
+			}
+			if typ != n.Type {
+				return &Typed{Name: n.Name, Type: typ}
+			}
+		case *TaggedName:
+			if !sp.abiTags {
+				return n.Name
+			}
+		}
+		return nil
+	})
+}
+
+// withoutParams returns the name of a function without its
+// parameters, as the demangler does for the NoParams option. Other
+// ASTs are returned unchanged.
+func withoutParams(a AST) AST {
+	for {
+		switch n := a.(type) {
+		case *Constraint:
+			a = n.Name
+		case *EnableIf:
+			a = n.Type
+		case *Typed:
+			switch n.Type.(type) {
+			case *FunctionType, *MethodWithQualifiers:
+				return n.Name
+			}
+			return a
+		case *Special:
+			// ToString with NoParams demangles the function
+			// of a clang block invocation function without
+			// its parameters.
+			if n.Prefix == blockInvokePrefix {
+				if v := withoutParams(n.Val); v != n.Val {
+					return &Special{Prefix: n.Prefix, Val: v}
+				}
+			}
+			return a
+		default:
+			return a
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	a, err := ToAST("_ZNK1A1fB3abcIiEEvPFivE.cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		options []Option
+		want    string
+	}{
+		{nil, "void A::f[abi:abc]<int>(int (*)()) const [clone .cold]"},
+		{[]Option{NoParams}, "A::f[abi:abc]<int>"},
+		{[]Option{NoClones}, "void A::f[abi:abc]<int>(int (*)()) const"},
+		{[]Option{NoTemplateParams}, "void A::f[abi:abc](int (*)()) const [clone .cold]"},
+		{[]Option{NoReturnType, NoMethodQualifiers}, "A::f[abi:abc]<int>(int (*)()) [clone .cold]"},
+		{[]Option{NoABITags, NoParams, NoTemplateParams}, "A::f"},
+	}
+	for _, test := range tests {
+		if got := ASTToString(Simplify(a, test.options...)); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.options, got, test.want)
+		}
+	}
+	if got, want := ASTToString(a), tests[0].want; got != want {
+		t.Errorf("original changed: got %q, want %q", got, want)
+	}
+
+	// Names in C++20 modules.
+	for _, test := range []struct {
+		input, want string
+	}{
+		{"_ZW3foo1fv", "f@foo"},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", "Outer::Inner::Fn@FOO"},
+	} {
+		a, err := ToAST(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if got := ASTToString(Simplify(a, NoParams)); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	// Copying a requires expression keeps noexcept.
+	a, err = ToAST("_Z1fIiEviQrqXplcvT__ELi1ENR11SmallerThanILi1234EEE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ASTToString(Simplify(a, NoTemplateParams)), "void f(int) requires requires { {((T)())+(1)} noexcept -> SmallerThan; }"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestSimplifyExpected checks that for the names in the testdata
+// files simplifying the AST prints the same as demangling or
+// printing it with the option, and that for the names in cases
+// that is so for the options used when demangling.
+func TestSimplifyExpected(t *testing.T) {
+	t.Parallel()
+
+	options := []Option{NoParams, NoClones, NoTemplateParams, NoEnclosingParams, NoReturnType, NoMethodQualifiers, NoABITags}
+	check := func(input string, options []Option) {
+		a, err := ToAST(input)
+		if err != nil {
+			return
+		}
+		for _, o := range options {
+			if o == NoTemplateParams && strings.Contains(input, "DT") {
+				// An expression may print with fewer
+				// parentheses.
+				continue
+			}
+			var want string
+			if o == NoParams || o == NoClones {
+				// These options are used when
+				// demangling, not when printing.
+				b, err := ToAST(input, o)
+				if err != nil {
+					continue
+				}
+				want = ASTToString(b)
+			} else {
+				want = ASTToString(a, o)
+			}
+			if got := ASTToString(Simplify(a, o)); got != want {
+				t.Errorf("%s %v:\ngot  %s\nwant %s", input, o, got, want)
+			}
+		}
+	}
+
+	for _, file := range []string{filename, rustFilename} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			check(strings.TrimSpace(scanner.Text()), options)
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+
+	for _, test := range cases {
+		check(test[0], []Option{NoParams, NoClones})
+	}
+}
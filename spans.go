@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"sort"
+	"strconv"
+)
+
+// A SpanKind classifies a part of a demangled name.
+type SpanKind int
+
+const (
+	// SpanName is the unqualified name of the demangled entity,
+	// such as "push_back" in
+	// "std::vector<int>::push_back(int const&)".
+	SpanName SpanKind = iota
+
+	// SpanScope is the namespaces and classes that qualify a
+	// name, such as "std::vector<int>", not including the final
+	// "::".
+	SpanScope
+
+	// SpanTemplateArgs is a list of template arguments,
+	// including the angle brackets.
+	SpanTemplateArgs
+
+	// SpanParams is a list of function parameter types,
+	// including the parentheses.
+	SpanParams
+
+	// SpanQualifiers is the qualifiers of a method, such as
+	// "const" or "const &&".
+	SpanQualifiers
+
+	// SpanOperator is the name of an operator or conversion
+	// function, such as "operator()" or "operator int".
+	SpanOperator
+
+	// SpanReturnType is the return type of a function.
+	SpanReturnType
+)
+
+// spanKindNames is used by SpanKind.String.
+var spanKindNames = [...]string{
+	SpanName:         "name",
+	SpanScope:        "scope",
+	SpanTemplateArgs: "template-args",
+	SpanParams:       "params",
+	SpanQualifiers:   "qualifiers",
+	SpanOperator:     "operator",
+	SpanReturnType:   "return-type",
+}
+
+// String returns a short name for the kind of span.
+func (k SpanKind) String() string {
+	if k >= 0 && int(k) < len(spanKindNames) {
+		return spanKindNames[k]
+	}
+	return "SpanKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A Span is a part of a demangled string.
+// The part is the bytes from Start up to but not including End.
+type Span struct {
+	Kind       SpanKind
+	Start, End int
+}
+
+// ToStringWithSpans is like ToString, but also returns the spans of
+// the demangled string, which may be used to highlight its parts.
+// Spans may nest: for example, a SpanParams span may contain the
+// SpanTemplateArgs span of a parameter type. The spans are sorted
+// by Start, and spans with the same Start are sorted from the
+// outermost to the innermost. Spans that cover the same bytes are
+// sorted by Kind.
+//
+// Spans are only available for C++ symbol names. For other names
+// the returned spans are nil.
+func ToStringWithSpans(name string, options ...Option) (string, []Span, error) {
+	s, err := ToString(name, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return s, nil, nil
+	}
+	t, spans := astToString(a, true, options)
+	if t != s {
+		// ToString did not use the AST, as for an old-style
+		// Rust symbol.
+		return s, nil, nil
+	}
+	return s, spans, nil
+}
+
+// ASTToStringWithSpans is like ASTToString, but also returns the
+// spans of the demangled string, as described at ToStringWithSpans.
+func ASTToStringWithSpans(a AST, options ...Option) (string, []Span) {
+	return astToString(a, true, options)
+}
+
+// addSpan records a span of the given kind from start to the end of
+// the output, if we are recording spans.
+func (ps *printState) addSpan(kind SpanKind, start int) {
+	if ps.recordSpans && ps.buf.Len() > start {
+		ps.spans = append(ps.spans, Span{Kind: kind, Start: start, End: ps.buf.Len()})
+	}
+}
+
+// finishSpans returns spans sorted as described at ToStringWithSpans,
+// after discarding or shortening spans that are beyond the end of a
+// string of length max. If max is 0 the string was not truncated.
+func finishSpans(spans []Span, max int) []Span {
+	if max > 0 {
+		keep := spans[:0]
+		for _, span := range spans {
+			if span.Start >= max {
+				continue
+			}
+			if span.End > max {
+				span.End = max
+			}
+			keep = append(keep, span)
+		}
+		spans = keep
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		if spans[i].End != spans[j].End {
+			return spans[i].End > spans[j].End
+		}
+		return spans[i].Kind < spans[j].Kind
+	})
+	return spans
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToStringWithSpans(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+		spans   []string // kind:text
+	}{
+		{
+			"_ZNSt6vectorIiSaIiEE9push_backERKi",
+			nil,
+			"std::vector<int, std::allocator<int> >::push_back(int const&)",
+			[]string{
+				"scope:std::vector<int, std::allocator<int> >",
+				"template-args:<int, std::allocator<int> >",
+				"scope:std",
+				"template-args:<int>",
+				"name:push_back",
+				"params:(int const&)",
+			},
+		},
+		{
+			"_ZNK1AclEv",
+			nil,
+			"A::operator()() const",
+			[]string{
+				"scope:A",
+				"name:operator()",
+				"operator:operator()",
+				"params:()",
+				"qualifiers:const",
+			},
+		},
+		{
+			"_ZNO1A1fEv",
+			nil,
+			"A::f() &&",
+			[]string{
+				"scope:A",
+				"name:f",
+				"params:()",
+				"qualifiers:&&",
+			},
+		},
+		{
+			"_Z1fIiEvT_",
+			nil,
+			"void f<int>(int)",
+			[]string{
+				"return-type:void",
+				"name:f",
+				"template-args:<int>",
+				"params:(int)",
+			},
+		},
+		{
+			"_ZN1AcvPiEv",
+			nil,
+			"A::operator int*()",
+			[]string{
+				"scope:A",
+				"name:operator int*",
+				"operator:operator int*",
+				"params:()",
+			},
+		},
+		{
+			"_Z1fPFviE",
+			nil,
+			"f(void (*)(int))",
+			[]string{
+				"name:f",
+				"params:(void (*)(int))",
+				"return-type:void",
+				"params:(int)",
+			},
+		},
+		{
+			"_ZTVN2ns1AE",
+			nil,
+			"vtable for ns::A",
+			[]string{
+				"scope:ns",
+				"name:A",
+			},
+		},
+		{
+			"_ZN2ns1fEv",
+			[]Option{MaxLength(2)},
+			"ns::",
+			[]string{
+				"scope:ns",
+			},
+		},
+		{
+			"_ZN4core3fmt9Arguments17h1234567890abcdefE",
+			nil,
+			"core::fmt::Arguments",
+			nil,
+		},
+		{
+			"_RNvCs1234_4main4main",
+			nil,
+			"main::main",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		got, spans, err := ToStringWithSpans(test.input, test.options...)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+			continue
+		}
+		var gotSpans []string
+		for _, span := range spans {
+			gotSpans = append(gotSpans, span.Kind.String()+":"+got[span.Start:span.End])
+		}
+		if !reflect.DeepEqual(gotSpans, test.spans) {
+			t.Errorf("spans for %s: got %q, want %q", test.input, gotSpans, test.spans)
+		}
+	}
+}
+
+func TestToStringWithSpansFailure(t *testing.T) {
+	if got, spans, err := ToStringWithSpans("_Z"); err == nil {
+		t.Errorf("demangling _Z: got %s %v, want error", got, spans)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// Structured is a demangled symbol name broken into its parts,
+// as returned by ToStructured. Each part is a string printed as by
+// ToString. The field tags describe how the value is encoded by
+// the encoding/json package.
+type Structured struct {
+	// Kind is the kind of symbol: "function", "data",
+	// "special" for data or code generated by the compiler,
+	// such as a vtable or a thunk, or "unknown" if the name
+	// could not be broken into parts.
+	Kind string `json:"kind"`
+
+	// Special is the description of a special symbol,
+	// such as "vtable for ". The other fields then describe
+	// the entity that the description refers to.
+	Special string `json:"special,omitempty"`
+
+	// Name is the unqualified name of the entity,
+	// without template arguments.
+	Name string `json:"name"`
+
+	// Scope is the namespaces and classes that qualify Name,
+	// such as "std::vector<int, std::allocator<int> >".
+	Scope string `json:"scope,omitempty"`
+
+	// TemplateArgs is the template arguments of Name.
+	TemplateArgs []string `json:"template_args,omitempty"`
+
+	// Params is the parameter types of a function.
+	// It is nil if the symbol is not a function.
+	Params []string `json:"params"`
+
+	// Qualifiers is the qualifiers of a member function,
+	// such as "const" or "const &&".
+	Qualifiers string `json:"qualifiers,omitempty"`
+
+	// ReturnType is the return type of a function,
+	// if it is part of the mangled name.
+	ReturnType string `json:"return_type,omitempty"`
+
+	// Demangled is the whole demangled name, as returned by
+	// ToString.
+	Demangled string `json:"demangled"`
+}
+
+// ToStructured demangles a symbol name and breaks the result into
+// parts, rather than returning a single string.
+// This only breaks C++ symbol names into parts; for other names
+// the Kind field is "unknown" and only the Demangled field is set.
+// The options are as for ToString. With the NoParams option a
+// function name can not be distinguished from a data name.
+func ToStructured(name string, options ...Option) (*Structured, error) {
+	s, err := ToString(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	ret := &Structured{Kind: "unknown", Demangled: s}
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return ret, nil
+	}
+	if t, _ := astToString(a, false, options); t != s {
+		// ToString did not use the AST.
+		return ret, nil
+	}
+	structureAST(ret, a, options)
+	return ret, nil
+}
+
+// structureAST fills in the fields of st from a.
+func structureAST(st *Structured, a AST, options []Option) {
+	st.Kind = "data"
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+			continue
+		case *Special:
+			st.Kind = "special"
+			st.Special = n.Prefix
+			a = n.Val
+			continue
+		}
+		break
+	}
+
+	if typed, ok := a.(*Typed); ok {
+		a = typed.Name
+		ft := typed.Type
+		if mwq, ok := ft.(*MethodWithQualifiers); ok {
+			ft = mwq.Method
+			if mwq.Qualifiers != nil {
+				st.Qualifiers = ASTToString(mwq.Qualifiers, options...)
+			}
+			if mwq.RefQualifier != "" {
+				if st.Qualifiers != "" {
+					st.Qualifiers += " "
+				}
+				st.Qualifiers += mwq.RefQualifier
+			}
+		}
+		if ft, ok := ft.(*FunctionType); ok {
+			if st.Kind == "data" {
+				st.Kind = "function"
+			}
+			if ft.Return != nil {
+				st.ReturnType = ASTToString(ft.Return, options...)
+			}
+			st.Params = []string{}
+			for _, arg := range ft.Args {
+				st.Params = append(st.Params, ASTToString(arg, options...))
+			}
+		}
+	}
+
+	if t, ok := a.(*Template); ok {
+		a = t.Name
+		st.TemplateArgs = structureArgs(t.Args, options)
+	}
+	if q, ok := a.(*Qualified); ok {
+		st.Scope = ASTToString(q.Scope, options...)
+		a = q.Name
+	}
+	if t, ok := a.(*Template); ok {
+		a = t.Name
+		st.TemplateArgs = structureArgs(t.Args, options)
+	}
+	st.Name = ASTToString(a, options...)
+}
+
+// structureArgs returns template arguments as strings.
+func structureArgs(args []AST, options []Option) []string {
+	ret := make([]string, 0, len(args))
+	for _, arg := range args {
+		ret = append(ret, ASTToString(arg, options...))
+	}
+	return ret
+}
+
+// TemplateArgs demangles a C++ symbol name and returns the template
+// arguments of the entity that it names, printed as by ToString.
+// For "void f<int, char>(int)" it returns "int" and "char". It
+// returns nil if the entity is not a template.
+// If the name does not appear to be a C++ symbol name at all,
+// the error will be ErrNotMangledName.
+func TemplateArgs(name string, options ...Option) ([]string, error) {
+	lists, err := ScopeTemplateArgs(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	return lists[len(lists)-1], nil
+}
+
+// ScopeTemplateArgs is like TemplateArgs, but also returns the
+// template arguments of the enclosing classes. It returns a list
+// for each component of the qualified name of the entity, starting
+// with the outermost. The list is nil for a component that is not
+// a template. For "std::vector<int>::push_back(int const&)" it
+// returns nil for "std", "int" for "vector", and nil for "push_back".
+func ScopeTemplateArgs(name string, options ...Option) ([][]string, error) {
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+			continue
+		case *Special:
+			a = n.Val
+			continue
+		case *Typed:
+			a = n.Name
+			continue
+		}
+		break
+	}
+	return templateArgLists(a, options), nil
+}
+
+// templateArgLists returns the template arguments of each component
+// of the qualified name a.
+func templateArgLists(a AST, options []Option) [][]string {
+	switch n := a.(type) {
+	case *Template:
+		if q, ok := n.Name.(*Qualified); ok {
+			return append(templateArgLists(q.Scope, options), structureArgs(n.Args, options))
+		}
+		return [][]string{structureArgs(n.Args, options)}
+	case *Qualified:
+		return append(templateArgLists(n.Scope, options), templateArgLists(n.Name, options)...)
+	case *Typed:
+		// The enclosing function of a local name.
+		return templateArgLists(n.Name, options)
+	case *TaggedName:
+		return templateArgLists(n.Name, options)
+	default:
+		return [][]string{nil}
+	}
+}
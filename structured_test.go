@@ -0,0 +1,171 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToStructured(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  Structured
+	}{
+		{
+			"_ZNSt6vectorIiSaIiEE9push_backERKi",
+			Structured{
+				Kind:      "function",
+				Name:      "push_back",
+				Scope:     "std::vector<int, std::allocator<int> >",
+				Params:    []string{"int const&"},
+				Demangled: "std::vector<int, std::allocator<int> >::push_back(int const&)",
+			},
+		},
+		{
+			"_Z1fv",
+			Structured{
+				Kind:      "function",
+				Name:      "f",
+				Params:    []string{},
+				Demangled: "f()",
+			},
+		},
+		{
+			"_ZNKR1A1fEv",
+			Structured{
+				Kind:       "function",
+				Name:       "f",
+				Scope:      "A",
+				Params:     []string{},
+				Qualifiers: "const &",
+				Demangled:  "A::f() const &",
+			},
+		},
+		{
+			"_ZN2ns1fIiEET_S1_",
+			Structured{
+				Kind:         "function",
+				Name:         "f",
+				Scope:        "ns",
+				TemplateArgs: []string{"int"},
+				Params:       []string{"int"},
+				ReturnType:   "int",
+				Demangled:    "int ns::f<int>(int)",
+			},
+		},
+		{
+			"_ZN1A1xE",
+			Structured{
+				Kind:      "data",
+				Name:      "x",
+				Scope:     "A",
+				Demangled: "A::x",
+			},
+		},
+		{
+			"_ZTV1A",
+			Structured{
+				Kind:      "special",
+				Special:   "vtable for ",
+				Name:      "A",
+				Demangled: "vtable for A",
+			},
+		},
+		{
+			"_ZThn8_N1A1fEv",
+			Structured{
+				Kind:      "special",
+				Special:   "non-virtual thunk to ",
+				Name:      "f",
+				Scope:     "A",
+				Params:    []string{},
+				Demangled: "non-virtual thunk to A::f()",
+			},
+		},
+		{
+			"_Z1fv.cold",
+			Structured{
+				Kind:      "function",
+				Name:      "f",
+				Params:    []string{},
+				Demangled: "f() [clone .cold]",
+			},
+		},
+		{
+			"_RNvCs1234_4main4main",
+			Structured{
+				Kind:      "unknown",
+				Demangled: "main::main",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ToStructured(test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.input, *got, test.want)
+		}
+	}
+
+	if _, err := ToStructured("_Z"); err == nil {
+		t.Error("ToStructured(_Z) succeeded unexpectedly")
+	}
+}
+
+func TestStructuredJSON(t *testing.T) {
+	st, err := ToStructured("_ZNK1A1fIiEEvT_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"function","name":"f","scope":"A","template_args":["int"],"params":["int"],"qualifiers":"const","return_type":"void","demangled":"void A::f\u003cint\u003e(int) const"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestTemplateArgs(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  [][]string
+	}{
+		{"_Z1fv", [][]string{nil}},
+		{"_Z1fIicEvi", [][]string{{"int", "char"}}},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", [][]string{nil, {"int", "std::allocator<int>"}, nil}},
+		{"_ZN1AIiE1fIlEEvT_", [][]string{{"int"}, {"long"}}},
+		{"_ZN1AIiE1fIlEEvT_.cold", [][]string{{"int"}, {"long"}}},
+		{"_ZTV1AIiE", [][]string{{"int"}}},
+		{"_ZZ1fIiEvvE1x", [][]string{{"int"}, nil}},
+	}
+	for _, test := range tests {
+		got, err := ScopeTemplateArgs(test.input)
+		if err != nil {
+			t.Errorf("ScopeTemplateArgs(%s): unexpected error %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ScopeTemplateArgs(%s) = %q, want %q", test.input, got, test.want)
+		}
+		args, err := TemplateArgs(test.input)
+		if err != nil {
+			t.Errorf("TemplateArgs(%s): unexpected error %v", test.input, err)
+		} else if want := test.want[len(test.want)-1]; !reflect.DeepEqual(args, want) {
+			t.Errorf("TemplateArgs(%s) = %q, want %q", test.input, args, want)
+		}
+	}
+
+	if _, err := TemplateArgs("f"); err != ErrNotMangledName {
+		t.Errorf("TemplateArgs(f) = %v, want ErrNotMangledName", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Substitutions returns the substitution table that is built while
+// demangling a C++ symbol name. The mangling ABI refers back to an
+// earlier component of a name using a substitution such as S_ or
+// S0_, which is an index into this table. Entry i of the returned
+// slice is the component referred to by SubstitutionCode(i).
+//
+// This is intended for debugging mangling problems and for learning
+// how the ABI works. The entries may share nodes with each other,
+// and should not be modified. Use ASTToString to print an entry.
+//
+// If the name is not a C++ symbol name, this returns
+// ErrNotMangledName.
+func Substitutions(name string, options ...Option) ([]AST, error) {
+	if !strings.HasPrefix(name, "_Z") {
+		return nil, ErrNotMangledName
+	}
+	st := new(state)
+	if _, err := st.demangle(name[2:], options); err != nil {
+		return nil, adjustErr(err, 2)
+	}
+	return st.subs, nil
+}
+
+// SubstitutionCode returns the code that refers to entry i of the
+// substitution table returned by Substitutions: S_ for entry 0,
+// S0_ for entry 1, and so forth, using base 36 with the digits 0-9
+// and the upper case letters A-Z.
+func SubstitutionCode(i int) string {
+	if i == 0 {
+		return "S_"
+	}
+	return "S" + strings.ToUpper(strconv.FormatInt(int64(i-1), 36)) + "_"
+}
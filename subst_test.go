@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestSubstitutions(t *testing.T) {
+	var tests = []struct {
+		input string
+		subs  []string
+	}{
+		{"_Z1fv", nil},
+		{"_Z1fPKcS0_", []string{"char const", "char const*"}},
+		{"_ZN2ns1A1fERKS0_", []string{"ns", "ns::A", "ns::A const", "ns::A const&"}},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", []string{"std::vector", "std::allocator<int>", "std::vector<int, std::allocator<int> >", "int const", "int const&"}},
+	}
+	for _, test := range tests {
+		subs, err := Substitutions(test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.input, err)
+			continue
+		}
+		var got []string
+		for _, s := range subs {
+			got = append(got, ASTToString(s))
+		}
+		if len(got) != len(test.subs) {
+			t.Errorf("%s: got %q, want %q", test.input, got, test.subs)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.subs[i] {
+				t.Errorf("%s: %s: got %q, want %q", test.input, SubstitutionCode(i), got[i], test.subs[i])
+			}
+		}
+	}
+
+	if _, err := Substitutions("_Z1fS0_"); err == nil {
+		t.Error("_Z1fS0_: unexpected success")
+	} else if de, ok := err.(Error); !ok || de.Offset != 5 || de.Code != ErrBadSubstitution {
+		t.Errorf("_Z1fS0_: unexpected error %v", err)
+	}
+	if _, err := Substitutions("f"); err != ErrNotMangledName {
+		t.Errorf("f: got error %v, want ErrNotMangledName", err)
+	}
+}
+
+func TestSubstitutionCode(t *testing.T) {
+	var tests = []struct {
+		i    int
+		want string
+	}{
+		{0, "S_"},
+		{1, "S0_"},
+		{10, "S9_"},
+		{11, "SA_"},
+		{36, "SZ_"},
+		{37, "S10_"},
+	}
+	for _, test := range tests {
+		if got := SubstitutionCode(test.i); got != test.want {
+			t.Errorf("SubstitutionCode(%d) = %q, want %q", test.i, got, test.want)
+		}
+	}
+}
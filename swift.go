@@ -0,0 +1,2914 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file demangles Swift symbols, as described at
+// https://github.com/swiftlang/swift/blob/main/docs/ABI/Mangling.rst.
+// The Swift mangling is a postfix notation: operators follow their
+// operands, which are kept on a stack. We build a tree of nodes and
+// then print it, following the swift-demangle program.
+// Only the parts of the mangling that appear in ordinary symbol
+// tables are handled; SIL-specific manglings are rejected.
+
+// swiftPrefixes are the prefixes used by Swift symbols.
+// The underscore is added on Darwin.
+// $s is Swift 5, $S is Swift 4.2, and _T0 is Swift 4.0 and 4.1.
+var swiftPrefixes = []string{"_$s", "$s", "_$S", "$S", "_T0"}
+
+// swiftOldPrefix is the prefix of Swift 4.0 symbols, in which the
+// argument labels of a function are part of its parameter tuple.
+const swiftOldPrefix = "_T0"
+
+// swiftPrefix returns the length of the Swift prefix of name,
+// or 0 if name is not a Swift symbol.
+func swiftPrefix(name string) int {
+	for _, p := range swiftPrefixes {
+		if strings.HasPrefix(name, p) {
+			return len(p)
+		}
+	}
+	return 0
+}
+
+// swiftToString demangles a Swift symbol.
+func swiftToString(name string, options []Option) (ret string, err error) {
+	plen := swiftPrefix(name)
+	if plen == 0 {
+		return "", ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	sst := &swiftState{
+		str:              name[plen:],
+		off:              plen,
+		oldFunctionTypes: strings.HasPrefix(name, swiftOldPrefix),
+		maxDepth:         defaultMaxDepth,
+	}
+	for _, o := range options {
+		if isMaxDepth(o) {
+			sst.maxDepth = optionValue(o)
+		}
+	}
+	root := sst.symbol()
+
+	// A node may be printed at a nesting depth of up to two
+	// levels for each level of the mangled name.
+	sp := &swiftPrinter{maxDepth: 2 * sst.maxDepth}
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			sp.noTypes = true
+		case o == NoTemplateParams:
+			sp.noGenericArgs = true
+		case isMaxLength(o):
+			sp.max = maxLength(o)
+		}
+	}
+	sp.print(root, false)
+
+	s := sp.buf.String()
+	if sp.max > 0 && len(s) > sp.max {
+		s = s[:sp.max]
+	}
+	return s, nil
+}
+
+// swiftKind is the kind of a swiftNode.
+type swiftKind int
+
+const (
+	swiftGlobal swiftKind = iota
+	swiftSuffix
+	swiftIdentifier
+	swiftModule
+	swiftType
+	swiftTypeMangling
+	swiftEmptyList
+	swiftFirstElementMarker
+	swiftVariadicMarker
+	swiftLabelList
+	swiftNumber
+
+	// Declaration names.
+	swiftLocalDeclName
+	swiftPrivateDeclName
+	swiftPrefixOperator
+	swiftPostfixOperator
+	swiftInfixOperator
+
+	// Nominal types.
+	swiftStructure
+	swiftClass
+	swiftEnum
+	swiftProtocol
+	swiftTypeAlias
+	swiftBoundGenericStructure
+	swiftBoundGenericClass
+	swiftBoundGenericEnum
+	swiftBoundGenericProtocol
+	swiftBoundGenericTypeAlias
+	swiftTypeList
+	swiftExtension
+
+	// Other types.
+	swiftBuiltinTypeName
+	swiftTuple
+	swiftTupleElement
+	swiftTupleElementName
+	swiftFunctionType
+	swiftNoEscapeFunctionType
+	swiftThinFunctionType
+	swiftCFunctionPointer
+	swiftObjCBlock
+	swiftEscapingObjCBlock
+	swiftAutoClosureType
+	swiftEscapingAutoClosureType
+	swiftArgumentTuple
+	swiftReturnType
+	swiftThrowsAnnotation
+	swiftTypedThrowsAnnotation
+	swiftAsyncAnnotation
+	swiftConcurrentFunctionType
+	swiftGlobalActorFunctionType
+	swiftInOut
+	swiftShared
+	swiftOwned
+	swiftMetatype
+	swiftMetatypeRepresentation
+	swiftExistentialMetatype
+	swiftProtocolList
+	swiftProtocolListWithClass
+	swiftProtocolListWithAnyObject
+	swiftWeak
+	swiftUnowned
+	swiftUnmanaged
+	swiftDynamicSelf
+	swiftIsolated
+
+	// Generics.
+	swiftDependentGenericParamType
+	swiftDependentMemberType
+	swiftDependentAssociatedTypeRef
+	swiftDependentGenericType
+	swiftDependentGenericSignature
+	swiftDependentGenericParamCount
+	swiftDependentGenericConformanceRequirement
+	swiftDependentGenericSameTypeRequirement
+	swiftDependentGenericLayoutRequirement
+
+	// Entities.
+	swiftFunction
+	swiftVariable
+	swiftSubscript
+	swiftStatic
+	swiftAllocator
+	swiftConstructor
+	swiftDeallocator
+	swiftDestructor
+	swiftIVarInitializer
+	swiftIVarDestroyer
+	swiftInitializer
+	swiftExplicitClosure
+	swiftImplicitClosure
+	swiftDefaultArgumentInitializer
+	swiftGetter
+	swiftSetter
+	swiftGlobalGetter
+	swiftMaterializeForSet
+	swiftWillSet
+	swiftDidSet
+	swiftReadAccessor
+	swiftModifyAccessor
+	swiftInitAccessor
+	swiftUnsafeAddressor
+	swiftUnsafeMutableAddressor
+
+	// Metadata, witnesses, and thunks.
+	swiftProtocolConformance
+	swiftProtocolWitness
+	swiftFieldOffset
+	swiftDirectness
+	swiftLazyProtocolWitnessTableAccessor
+	swiftLazyProtocolWitnessTableCacheVariable
+	swiftVTableThunk
+	swiftAssociatedTypeMetadataAccessor
+	swiftAsyncAwaitResumePartialFunction
+	swiftAsyncSuspendResumePartialFunction
+
+	// Nodes that print a fixed string followed by their child.
+	// These must come last; see swiftPrefixNames.
+	swiftTypeMetadata
+	swiftTypeMetadataAccessFunction
+	swiftFullTypeMetadata
+	swiftMetaclass
+	swiftNominalTypeDescriptor
+	swiftProtocolDescriptor
+	swiftProtocolConformanceDescriptor
+	swiftPropertyDescriptor
+	swiftMethodLookupFunction
+	swiftClassMetadataBaseOffset
+	swiftTypeMetadataInstantiationCache
+	swiftTypeMetadataInstantiationFunction
+	swiftTypeMetadataCompletionFunction
+	swiftTypeMetadataSingletonInitializationCache
+	swiftTypeMetadataLazyCache
+	swiftReflectionMetadataFieldDescriptor
+	swiftProtocolWitnessTable
+	swiftProtocolWitnessTablePattern
+	swiftGenericProtocolWitnessTable
+	swiftGenericProtocolWitnessTableInstantiationFunction
+	swiftResilientProtocolWitnessTable
+	swiftProtocolWitnessTableAccessor
+	swiftValueWitnessTable
+	swiftEnumCase
+	swiftDispatchThunk
+	swiftMethodDescriptor
+	swiftCurryThunk
+	swiftProtocolSelfConformanceWitness
+	swiftProtocolSelfConformanceWitnessTable
+
+	// Function attributes, which have no children and are
+	// printed before the entity they apply to.
+	swiftObjCAttribute
+	swiftNonObjCAttribute
+	swiftDynamicAttribute
+	swiftDirectMethodReferenceAttribute
+	swiftMergedFunction
+	swiftAsyncFunctionPointer
+	swiftPartialApplyForwarder
+	swiftPartialApplyObjCForwarder
+)
+
+// swiftPrefixNames maps node kinds that are printed as a fixed
+// string followed by their only child to that string.
+var swiftPrefixNames = map[swiftKind]string{
+	swiftTypeMetadata:                                     "type metadata for ",
+	swiftTypeMetadataAccessFunction:                       "type metadata accessor for ",
+	swiftFullTypeMetadata:                                 "full type metadata for ",
+	swiftMetaclass:                                        "metaclass for ",
+	swiftNominalTypeDescriptor:                            "nominal type descriptor for ",
+	swiftProtocolDescriptor:                               "protocol descriptor for ",
+	swiftProtocolConformanceDescriptor:                    "protocol conformance descriptor for ",
+	swiftPropertyDescriptor:                               "property descriptor for ",
+	swiftMethodLookupFunction:                             "method lookup function for ",
+	swiftClassMetadataBaseOffset:                          "class metadata base offset for ",
+	swiftTypeMetadataInstantiationCache:                   "type metadata instantiation cache for ",
+	swiftTypeMetadataInstantiationFunction:                "type metadata instantiation function for ",
+	swiftTypeMetadataCompletionFunction:                   "type metadata completion function for ",
+	swiftTypeMetadataSingletonInitializationCache:         "type metadata singleton initialization cache for ",
+	swiftTypeMetadataLazyCache:                            "lazy cache variable for type metadata for ",
+	swiftReflectionMetadataFieldDescriptor:                "reflection metadata field descriptor ",
+	swiftProtocolWitnessTable:                             "protocol witness table for ",
+	swiftProtocolWitnessTablePattern:                      "protocol witness table pattern for ",
+	swiftGenericProtocolWitnessTable:                      "generic protocol witness table for ",
+	swiftGenericProtocolWitnessTableInstantiationFunction: "instantiation function for generic protocol witness table for ",
+	swiftResilientProtocolWitnessTable:                    "resilient protocol witness table for ",
+	swiftProtocolWitnessTableAccessor:                     "protocol witness table accessor for ",
+	swiftValueWitnessTable:                                "value witness table for ",
+	swiftEnumCase:                                         "enum case for ",
+	swiftDispatchThunk:                                    "dispatch thunk of ",
+	swiftMethodDescriptor:                                 "method descriptor for ",
+	swiftCurryThunk:                                       "curry thunk of ",
+	swiftProtocolSelfConformanceWitness:                   "protocol self-conformance witness for ",
+	swiftProtocolSelfConformanceWitnessTable:              "protocol self-conformance witness table for ",
+
+	swiftObjCAttribute:                  "@objc ",
+	swiftNonObjCAttribute:               "@nonobjc ",
+	swiftDynamicAttribute:               "dynamic ",
+	swiftDirectMethodReferenceAttribute: "super ",
+	swiftMergedFunction:                 "merged ",
+	swiftAsyncFunctionPointer:           "async function pointer to ",
+}
+
+// swiftAccessorNames maps accessor node kinds to the name printed
+// after the storage they access.
+var swiftAccessorNames = map[swiftKind]string{
+	swiftGetter:                 "getter",
+	swiftSetter:                 "setter",
+	swiftGlobalGetter:           "getter",
+	swiftMaterializeForSet:      "materializeForSet",
+	swiftWillSet:                "willset",
+	swiftDidSet:                 "didset",
+	swiftReadAccessor:           "read",
+	swiftModifyAccessor:         "modify",
+	swiftInitAccessor:           "init",
+	swiftUnsafeAddressor:        "unsafeAddressor",
+	swiftUnsafeMutableAddressor: "unsafeMutableAddressor",
+}
+
+// A swiftNode is a node in the tree built while demangling a Swift
+// symbol.
+type swiftNode struct {
+	kind     swiftKind
+	text     string
+	index    uint64
+	children []*swiftNode
+}
+
+// child returns the i'th child of n, or nil.
+func (n *swiftNode) child(i int) *swiftNode {
+	if n == nil || i >= len(n.children) {
+		return nil
+	}
+	return n.children[i]
+}
+
+// childOfKind returns the first child of n with kind k, or nil.
+func (n *swiftNode) childOfKind(k swiftKind) *swiftNode {
+	for _, c := range n.children {
+		if c.kind == k {
+			return c
+		}
+	}
+	return nil
+}
+
+// isSwiftFunctionAttr reports whether k is a function attribute,
+// which is printed before the entity at the top level.
+func isSwiftFunctionAttr(k swiftKind) bool {
+	switch k {
+	case swiftObjCAttribute, swiftNonObjCAttribute, swiftDynamicAttribute,
+		swiftDirectMethodReferenceAttribute, swiftMergedFunction,
+		swiftAsyncFunctionPointer, swiftPartialApplyForwarder,
+		swiftPartialApplyObjCForwarder,
+		swiftAsyncAwaitResumePartialFunction, swiftAsyncSuspendResumePartialFunction:
+		return true
+	}
+	return false
+}
+
+// isSwiftDeclName reports whether k is a declaration name.
+func isSwiftDeclName(k swiftKind) bool {
+	switch k {
+	case swiftIdentifier, swiftLocalDeclName, swiftPrivateDeclName,
+		swiftPrefixOperator, swiftPostfixOperator, swiftInfixOperator:
+		return true
+	}
+	return false
+}
+
+// isSwiftContext reports whether k may be the context of a
+// declaration.
+func isSwiftContext(k swiftKind) bool {
+	switch k {
+	case swiftModule, swiftStructure, swiftClass, swiftEnum, swiftProtocol,
+		swiftTypeAlias, swiftExtension, swiftFunction, swiftVariable,
+		swiftSubscript, swiftStatic, swiftAllocator, swiftConstructor,
+		swiftDeallocator, swiftDestructor, swiftIVarInitializer,
+		swiftIVarDestroyer, swiftInitializer, swiftExplicitClosure,
+		swiftImplicitClosure, swiftDefaultArgumentInitializer:
+		return true
+	}
+	_, ok := swiftAccessorNames[k]
+	return ok
+}
+
+// isSwiftEntity reports whether k is an entity.
+func isSwiftEntity(k swiftKind) bool {
+	return k == swiftType || isSwiftContext(k)
+}
+
+// isSwiftRequirement reports whether k is a generic requirement.
+func isSwiftRequirement(k swiftKind) bool {
+	switch k {
+	case swiftDependentGenericConformanceRequirement,
+		swiftDependentGenericSameTypeRequirement,
+		swiftDependentGenericLayoutRequirement:
+		return true
+	}
+	return false
+}
+
+// swiftMaxRepeatCount limits the repeat count of a substitution.
+const swiftMaxRepeatCount = 2048
+
+// swiftMaxWords is the maximum number of words that may be used for
+// word substitutions in identifiers.
+const swiftMaxWords = 26
+
+// A swiftState holds the current state of demangling a Swift string.
+type swiftState struct {
+	str      string       // remainder of string to demangle
+	off      int          // offset of str within original string
+	stack    []*swiftNode // operand stack
+	subs     []*swiftNode // substitutions
+	words    []string     // words for word substitutions
+	depth    int          // current nesting depth
+	maxDepth int          // maximum nesting depth
+
+	// oldFunctionTypes is set for the Swift 4.0 mangling, in which
+	// argument labels are tuple element names.
+	oldFunctionTypes bool
+}
+
+// fail panics with Error, to be caught in swiftToString.
+func (sst *swiftState) fail(err string) {
+	panic(Error{Msg: err, Offset: sst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (sst *swiftState) enter() {
+	sst.depth++
+	if sst.depth > sst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: sst.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (sst *swiftState) leave() {
+	sst.depth--
+}
+
+// failEarlier is like fail, but decrements the offset to indicate
+// that the point of failure occurred earlier in the string.
+func (sst *swiftState) failEarlier(err string, dec int) {
+	if sst.off < dec {
+		panic("internal error")
+	}
+	panic(Error{Msg: err, Offset: sst.off - dec})
+}
+
+// advance advances the current string offset.
+func (sst *swiftState) advance(add int) {
+	if len(sst.str) < add {
+		panic("internal error")
+	}
+	sst.str = sst.str[add:]
+	sst.off += add
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (sst *swiftState) peek() byte {
+	if len(sst.str) == 0 {
+		return 0
+	}
+	return sst.str[0]
+}
+
+// next returns the next character and advances past it.
+func (sst *swiftState) next() byte {
+	if len(sst.str) == 0 {
+		sst.fail("unexpected end of mangled name")
+	}
+	c := sst.str[0]
+	sst.advance(1)
+	return c
+}
+
+// nextIf advances past c if it is the next character,
+// and reports whether it did.
+func (sst *swiftState) nextIf(c byte) bool {
+	if len(sst.str) == 0 || sst.str[0] != c {
+		return false
+	}
+	sst.advance(1)
+	return true
+}
+
+// push pushes a node on the operand stack.
+func (sst *swiftState) push(n *swiftNode) {
+	sst.stack = append(sst.stack, n)
+}
+
+// pop pops a node from the stack if it satisfies pred.
+// It returns nil if it does not.
+func (sst *swiftState) pop(pred func(swiftKind) bool) *swiftNode {
+	if len(sst.stack) == 0 {
+		return nil
+	}
+	n := sst.stack[len(sst.stack)-1]
+	if pred != nil && !pred(n.kind) {
+		return nil
+	}
+	sst.stack = sst.stack[:len(sst.stack)-1]
+	return n
+}
+
+// popKind pops a node of kind k from the stack, or returns nil.
+func (sst *swiftState) popKind(k swiftKind) *swiftNode {
+	return sst.pop(func(nk swiftKind) bool { return nk == k })
+}
+
+// mustPop is like pop but fails if there is no suitable node.
+func (sst *swiftState) mustPop(pred func(swiftKind) bool) *swiftNode {
+	n := sst.pop(pred)
+	if n == nil {
+		sst.fail("missing operand")
+	}
+	return n
+}
+
+// mustPopKind is like popKind but fails if there is no suitable node.
+func (sst *swiftState) mustPopKind(k swiftKind) *swiftNode {
+	n := sst.popKind(k)
+	if n == nil {
+		sst.fail("missing operand")
+	}
+	return n
+}
+
+// addSubst adds a node to the substitution table.
+func (sst *swiftState) addSubst(n *swiftNode) {
+	sst.subs = append(sst.subs, n)
+}
+
+// newSwiftNode creates a new node.
+func newSwiftNode(k swiftKind, children ...*swiftNode) *swiftNode {
+	return &swiftNode{kind: k, children: children}
+}
+
+// swiftTypeNode wraps a node in a Type node.
+func swiftTypeNode(n *swiftNode) *swiftNode {
+	return newSwiftNode(swiftType, n)
+}
+
+// symbol demangles a complete symbol, after the prefix.
+// Function attributes are moved to the front; a partial apply
+// forwarder holds the entity it forwards to.
+func (sst *swiftState) symbol() *swiftNode {
+	for len(sst.str) > 0 {
+		sst.push(sst.operator())
+	}
+
+	top := newSwiftNode(swiftGlobal)
+	parent := top
+	for {
+		attr := sst.pop(isSwiftFunctionAttr)
+		if attr == nil {
+			break
+		}
+		parent.children = append(parent.children, attr)
+		if attr.kind == swiftPartialApplyForwarder || attr.kind == swiftPartialApplyObjCForwarder {
+			parent = attr
+		}
+	}
+	for _, n := range sst.stack {
+		switch n.kind {
+		case swiftType:
+			n = n.children[0]
+		case swiftEmptyList, swiftFirstElementMarker, swiftVariadicMarker, swiftLabelList:
+			sst.fail("unused operand at end of mangled name")
+		}
+		parent.children = append(parent.children, n)
+	}
+	if len(top.children) == 0 {
+		sst.fail("empty symbol")
+	}
+	return top
+}
+
+// operator demangles a single operator and returns the resulting
+// node, which the caller pushes on the stack.
+func (sst *swiftState) operator() *swiftNode {
+	if isDigit(sst.peek()) {
+		return sst.identifier()
+	}
+	c := sst.next()
+	switch c {
+	case 'A':
+		return sst.multiSubstitutions()
+	case 'B':
+		return sst.builtinType()
+	case 'C':
+		return sst.nominalType(swiftClass)
+	case 'D':
+		return newSwiftNode(swiftTypeMangling, sst.mustPopKind(swiftType))
+	case 'E':
+		return sst.extensionContext()
+	case 'F':
+		return sst.plainFunction()
+	case 'G':
+		return sst.boundGenericType()
+	case 'K':
+		return newSwiftNode(swiftThrowsAnnotation)
+	case 'L':
+		return sst.localIdentifier()
+	case 'M':
+		return sst.metatype()
+	case 'N':
+		return newSwiftNode(swiftTypeMetadata, sst.mustPopKind(swiftType))
+	case 'O':
+		return sst.nominalType(swiftEnum)
+	case 'P':
+		return sst.nominalType(swiftProtocol)
+	case 'Q':
+		return sst.archetype()
+	case 'R':
+		return sst.genericRequirement()
+	case 'S':
+		return sst.standardSubstitution()
+	case 'T':
+		return sst.thunk()
+	case 'V':
+		return sst.nominalType(swiftStructure)
+	case 'W':
+		return sst.witness()
+	case 'X':
+		return sst.specialType()
+	case 'Y':
+		return sst.typeAnnotation()
+	case 'Z':
+		return newSwiftNode(swiftStatic, sst.mustPop(isSwiftEntity))
+	case 'a':
+		return sst.nominalType(swiftTypeAlias)
+	case 'c':
+		return sst.popFunctionType(swiftFunctionType)
+	case 'd':
+		return newSwiftNode(swiftVariadicMarker)
+	case 'f':
+		return sst.functionEntity()
+	case 'h':
+		return swiftTypeNode(newSwiftNode(swiftShared, sst.popTypeAndGetChild()))
+	case 'i':
+		return sst.subscript()
+	case 'l':
+		return sst.genericSignature(false)
+	case 'm':
+		return swiftTypeNode(newSwiftNode(swiftMetatype, sst.mustPopKind(swiftType)))
+	case 'n':
+		return swiftTypeNode(newSwiftNode(swiftOwned, sst.popTypeAndGetChild()))
+	case 'o':
+		return sst.operatorIdentifier()
+	case 'p':
+		return swiftTypeNode(sst.protocolList())
+	case 'q':
+		return swiftTypeNode(sst.genericParamIndex())
+	case 'r':
+		return sst.genericSignature(true)
+	case 's':
+		return &swiftNode{kind: swiftModule, text: "Swift"}
+	case 't':
+		return sst.tupleType()
+	case 'u':
+		sig := sst.mustPopKind(swiftDependentGenericSignature)
+		t := sst.mustPopKind(swiftType)
+		return swiftTypeNode(newSwiftNode(swiftDependentGenericType, sig, t))
+	case 'v':
+		return sst.accessor(sst.typedEntity(swiftVariable))
+	case 'x':
+		return swiftTypeNode(swiftGenericParam(0, 0))
+	case 'y':
+		return newSwiftNode(swiftEmptyList)
+	case 'z':
+		return swiftTypeNode(newSwiftNode(swiftInOut, sst.popTypeAndGetChild()))
+	case '_':
+		return newSwiftNode(swiftFirstElementMarker)
+	case '.':
+		n := &swiftNode{kind: swiftSuffix, text: "." + sst.str}
+		sst.advance(len(sst.str))
+		return n
+	default:
+		sst.failEarlier("unrecognized operator", 1)
+		panic("not reached")
+	}
+}
+
+// natural parses a decimal number. It returns -1 if there is none.
+func (sst *swiftState) natural() int {
+	if !isDigit(sst.peek()) {
+		return -1
+	}
+	n := 0
+	for isDigit(sst.peek()) {
+		if n >= (math.MaxInt32-9)/10 {
+			sst.fail("numeric overflow")
+		}
+		n = n*10 + int(sst.next()-'0')
+	}
+	return n
+}
+
+// index parses an index:
+//
+//	_           0
+//	<natural> _ natural + 1
+func (sst *swiftState) index() int {
+	if sst.nextIf('_') {
+		return 0
+	}
+	if n := sst.natural(); n >= 0 && sst.nextIf('_') {
+		return n + 1
+	}
+	sst.fail("invalid index")
+	panic("not reached")
+}
+
+// indexNode parses an index and returns it as a Number node.
+func (sst *swiftState) indexNode() *swiftNode {
+	return &swiftNode{kind: swiftNumber, index: uint64(sst.index())}
+}
+
+// identifier parses an identifier. It is called with the string
+// positioned at the first digit.
+func (sst *swiftState) identifier() *swiftNode {
+	wordSubsts := false
+	punycoded := false
+	if sst.nextIf('0') {
+		if sst.nextIf('0') {
+			punycoded = true
+		} else {
+			wordSubsts = true
+		}
+	}
+
+	// With word substitutions, the identifier is a sequence of
+	// lower case word indexes and literal strings, ending with
+	// either an upper case word index or '0'.
+	var id strings.Builder
+	done := false
+	for !done {
+		for wordSubsts && (isLower(sst.peek()) || isUpper(sst.peek())) {
+			c := sst.next()
+			var idx int
+			if isLower(c) {
+				idx = int(c - 'a')
+			} else {
+				idx = int(c - 'A')
+				done = true
+			}
+			if idx >= len(sst.words) {
+				panic(Error{Code: ErrBadSubstitution, Msg: "word substitution out of range", Offset: sst.off - 1})
+			}
+			id.WriteString(sst.words[idx])
+			if done {
+				break
+			}
+		}
+		if done || sst.nextIf('0') {
+			break
+		}
+		n := sst.natural()
+		if n <= 0 {
+			sst.fail("expected identifier length")
+		}
+		if punycoded {
+			sst.nextIf('_')
+		}
+		if n > len(sst.str) {
+			sst.fail("identifier length exceeds string")
+		}
+		s := sst.str[:n]
+		if punycoded {
+			id.WriteString(sst.expandPunycode(s))
+		} else {
+			id.WriteString(s)
+			sst.addWords(s)
+		}
+		sst.advance(n)
+		if !wordSubsts {
+			break
+		}
+	}
+	if id.Len() == 0 {
+		sst.fail("empty identifier")
+	}
+	n := &swiftNode{kind: swiftIdentifier, text: id.String()}
+	sst.addSubst(n)
+	return n
+}
+
+// addWords records the words in s for later word substitutions.
+// A word starts with a non-digit other than '_', and ends before
+// a '_' or before an upper case letter that follows a non-upper
+// case letter. Words of a single character are not recorded.
+func (sst *swiftState) addWords(s string) {
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		var c byte
+		if i < len(s) {
+			c = s[i]
+		}
+		if start >= 0 && (c == '_' || c == 0 || (!isUpper(s[i-1]) && isUpper(c))) {
+			if i-start >= 2 && len(sst.words) < swiftMaxWords {
+				sst.words = append(sst.words, s[start:i])
+			}
+			start = -1
+		}
+		if start < 0 && c != 0 && c != '_' && !isDigit(c) {
+			start = i
+		}
+	}
+}
+
+// expandPunycode decodes the Swift version of punycode, which uses
+// a different set of digits from RFC 3492 and maps characters that
+// are not valid in identifiers to 0xD800 plus the character.
+func (sst *swiftState) expandPunycode(s string) string {
+	const (
+		base        = 36
+		tmin        = 1
+		tmax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+
+	var output []rune
+	encoding := s
+	if idx := strings.LastIndex(s, "_"); idx >= 0 {
+		output = []rune(s[:idx])
+		encoding = s[idx+1:]
+	}
+
+	i := 0
+	n := initialN
+	bias := initialBias
+	pos := 0
+	for pos < len(encoding) {
+		oldI := i
+		w := 1
+		for k := base; ; k += base {
+			if pos == len(encoding) {
+				sst.fail("unterminated punycode")
+			}
+			var digit int
+			d := encoding[pos]
+			pos++
+			switch {
+			case 'a' <= d && d <= 'z':
+				digit = int(d - 'a')
+			case 'A' <= d && d <= 'J':
+				digit = int(d-'A') + 26
+			default:
+				sst.fail("invalid punycode digit")
+			}
+			i += digit * w
+			if i < 0 || i > math.MaxInt32 {
+				sst.fail("punycode number overflow")
+			}
+			t := k - bias
+			if t < tmin {
+				t = tmin
+			} else if t > tmax {
+				t = tmax
+			}
+			if digit < t {
+				break
+			}
+			if w >= math.MaxInt32/base {
+				sst.fail("punycode number overflow")
+			}
+			w *= base - t
+		}
+
+		delta := i - oldI
+		if oldI == 0 {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / (len(output) + 1)
+		k := 0
+		for delta > ((base-tmin)*tmax)/2 {
+			delta /= base - tmin
+			k += base
+		}
+		bias = k + ((base-tmin+1)*delta)/(delta+skew)
+
+		n += i / (len(output) + 1)
+		if n > utf8.MaxRune {
+			sst.fail("punycode rune overflow")
+		}
+		i %= len(output) + 1
+		r := rune(n)
+		if r >= 0xd800 && r < 0xd880 {
+			r -= 0xd800
+		} else if !utf8.ValidRune(r) {
+			sst.fail("punycode invalid code point")
+		}
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = r
+		i++
+	}
+	return string(output)
+}
+
+// multiSubstitutions parses a substitution reference after 'A':
+//
+//	A <lower>* <upper>         indexes 0 to 25, lower case letters continue
+//	A <natural> <letter>       repeated substitution
+//	A <natural>? _             index natural + 27, or 26
+func (sst *swiftState) multiSubstitutions() *swiftNode {
+	repeat := -1
+	for {
+		if isDigit(sst.peek()) {
+			repeat = sst.natural()
+			if repeat > swiftMaxRepeatCount {
+				sst.fail("substitution repeat count too large")
+			}
+			continue
+		}
+		c := sst.next()
+		switch {
+		case isLower(c):
+			sst.push(sst.pushRepeated(repeat, int(c-'a')))
+			repeat = -1
+		case isUpper(c):
+			return sst.pushRepeated(repeat, int(c-'A'))
+		case c == '_':
+			idx := repeat + 27
+			if idx >= len(sst.subs) {
+				panic(Error{Code: ErrBadSubstitution, Msg: "substitution index out of range", Offset: sst.off - 1})
+			}
+			return sst.subs[idx]
+		default:
+			panic(Error{Code: ErrBadSubstitution, Msg: "invalid substitution", Offset: sst.off - 1})
+		}
+	}
+}
+
+// pushRepeated pushes repeat-1 copies of substitution idx and
+// returns the substitution.
+func (sst *swiftState) pushRepeated(repeat, idx int) *swiftNode {
+	if idx >= len(sst.subs) {
+		panic(Error{Code: ErrBadSubstitution, Msg: "substitution index out of range", Offset: sst.off - 1})
+	}
+	n := sst.subs[idx]
+	for ; repeat > 1; repeat-- {
+		sst.push(n)
+	}
+	return n
+}
+
+// popType pops a Type node, or returns nil.
+func (sst *swiftState) popType() *swiftNode {
+	return sst.popKind(swiftType)
+}
+
+// mustPopType pops a Type node, failing if there is none.
+func (sst *swiftState) mustPopType() *swiftNode {
+	return sst.mustPopKind(swiftType)
+}
+
+// popTypeAndGetChild pops a Type node and returns its child.
+func (sst *swiftState) popTypeAndGetChild() *swiftNode {
+	return sst.mustPopType().children[0]
+}
+
+// isSwiftNominal reports whether k is a nominal type that may have
+// generic arguments or extensions.
+func isSwiftNominal(k swiftKind) bool {
+	switch k {
+	case swiftStructure, swiftClass, swiftEnum, swiftProtocol, swiftTypeAlias:
+		return true
+	}
+	return false
+}
+
+// popTypeAndGetNominal pops a Type node whose child is a nominal type
+// and returns the child.
+func (sst *swiftState) popTypeAndGetNominal() *swiftNode {
+	n := sst.popTypeAndGetChild()
+	if !isSwiftNominal(n.kind) {
+		sst.fail("expected nominal type")
+	}
+	return n
+}
+
+// popModule pops a module. An identifier is turned into a module.
+func (sst *swiftState) popModule() *swiftNode {
+	if id := sst.popKind(swiftIdentifier); id != nil {
+		return &swiftNode{kind: swiftModule, text: id.text}
+	}
+	return sst.popKind(swiftModule)
+}
+
+// popContext pops the context of a declaration.
+func (sst *swiftState) popContext() *swiftNode {
+	if m := sst.popModule(); m != nil {
+		return m
+	}
+	if t := sst.popType(); t != nil {
+		c := t.children[0]
+		if !isSwiftContext(c.kind) {
+			sst.fail("type is not a valid context")
+		}
+		return c
+	}
+	return sst.mustPop(isSwiftContext)
+}
+
+// popDeclName pops a declaration name.
+func (sst *swiftState) popDeclName() *swiftNode {
+	return sst.mustPop(isSwiftDeclName)
+}
+
+// isSwiftProtocolType reports whether n is a Type node for a protocol.
+func isSwiftProtocolType(n *swiftNode) bool {
+	return n.kind == swiftType && n.children[0].kind == swiftProtocol
+}
+
+// popProtocol pops a protocol, returned as a Type node. The protocol
+// may be a type or a name and context.
+func (sst *swiftState) popProtocol() *swiftNode {
+	if t := sst.popType(); t != nil {
+		if !isSwiftProtocolType(t) {
+			sst.fail("expected protocol")
+		}
+		return t
+	}
+	name := sst.popDeclName()
+	ctx := sst.popContext()
+	return swiftTypeNode(newSwiftNode(swiftProtocol, ctx, name))
+}
+
+// popProtocolConformance pops a protocol conformance:
+//
+//	type protocol module generic-signature?
+func (sst *swiftState) popProtocolConformance() *swiftNode {
+	sig := sst.popKind(swiftDependentGenericSignature)
+	module := sst.popModule()
+	if module == nil {
+		sst.fail("missing conformance module")
+	}
+	proto := sst.popProtocol()
+	t := sst.mustPopType()
+	if sig != nil {
+		t = swiftTypeNode(newSwiftNode(swiftDependentGenericType, sig, t))
+	}
+	return newSwiftNode(swiftProtocolConformance, t, proto, module)
+}
+
+// nominalType parses a nominal type of kind k, whose name and context
+// are on the stack.
+func (sst *swiftState) nominalType(k swiftKind) *swiftNode {
+	name := sst.popDeclName()
+	ctx := sst.popContext()
+	t := swiftTypeNode(newSwiftNode(k, ctx, name))
+	sst.addSubst(t)
+	return t
+}
+
+// swiftBuiltinTypes maps the character after 'B' to a builtin type.
+var swiftBuiltinTypes = map[byte]string{
+	'b': "Builtin.BridgeObject",
+	'B': "Builtin.UnsafeValueBuffer",
+	'c': "Builtin.RawUnsafeContinuation",
+	'D': "Builtin.DefaultActorStorage",
+	'd': "Builtin.NonDefaultDistributedActorStorage",
+	'e': "Builtin.Executor",
+	'I': "Builtin.IntLiteral",
+	'j': "Builtin.Job",
+	'O': "Builtin.UnknownObject",
+	'o': "Builtin.NativeObject",
+	'p': "Builtin.RawPointer",
+	't': "Builtin.SILToken",
+	'w': "Builtin.Word",
+}
+
+// builtinType parses a builtin type after 'B'.
+func (sst *swiftState) builtinType() *swiftNode {
+	c := sst.next()
+	var name string
+	switch c {
+	case 'f', 'i':
+		size := sst.index() - 1
+		if size <= 0 {
+			sst.fail("invalid builtin type size")
+		}
+		if c == 'f' {
+			name = fmt.Sprintf("Builtin.FPIEEE%d", size)
+		} else {
+			name = fmt.Sprintf("Builtin.Int%d", size)
+		}
+	case 'v':
+		elts := sst.index() - 1
+		if elts <= 0 {
+			sst.fail("invalid builtin vector size")
+		}
+		elt := sst.popTypeAndGetChild()
+		if elt.kind != swiftBuiltinTypeName || !strings.HasPrefix(elt.text, "Builtin.") {
+			sst.fail("invalid builtin vector element")
+		}
+		name = fmt.Sprintf("Builtin.Vec%dx%s", elts, elt.text[len("Builtin."):])
+	default:
+		var ok bool
+		name, ok = swiftBuiltinTypes[c]
+		if !ok {
+			sst.failEarlier("unrecognized builtin type", 1)
+		}
+	}
+	t := swiftTypeNode(&swiftNode{kind: swiftBuiltinTypeName, text: name})
+	sst.addSubst(t)
+	return t
+}
+
+// extensionContext parses an extension after 'E':
+//
+//	module nominal-type generic-signature? 'E'
+func (sst *swiftState) extensionContext() *swiftNode {
+	sig := sst.popKind(swiftDependentGenericSignature)
+	module := sst.popModule()
+	if module == nil {
+		sst.fail("missing extension module")
+	}
+	t := sst.popTypeAndGetNominal()
+	ext := newSwiftNode(swiftExtension, module, t)
+	if sig != nil {
+		ext.children = append(ext.children, sig)
+	}
+	return ext
+}
+
+// plainFunction parses a function after 'F':
+//
+//	context decl-name label-list? function-type generic-signature? 'F'
+func (sst *swiftState) plainFunction() *swiftNode {
+	sig := sst.popKind(swiftDependentGenericSignature)
+	t := sst.popFunctionType(swiftFunctionType)
+	labels := sst.popFunctionParamLabels(t)
+	if sig != nil {
+		t = swiftTypeNode(newSwiftNode(swiftDependentGenericType, sig, t))
+	}
+	name := sst.popDeclName()
+	ctx := sst.popContext()
+	if labels != nil {
+		return newSwiftNode(swiftFunction, ctx, name, labels, t)
+	}
+	return newSwiftNode(swiftFunction, ctx, name, t)
+}
+
+// popFunctionType pops the parts of a function type and returns a
+// Type node holding a function type of kind k:
+//
+//	result-type params-type async? sendable? throws? global-actor?
+func (sst *swiftState) popFunctionType(k swiftKind) *swiftNode {
+	ft := newSwiftNode(k)
+	add := func(n *swiftNode) {
+		if n != nil {
+			ft.children = append(ft.children, n)
+		}
+	}
+	add(sst.popKind(swiftGlobalActorFunctionType))
+	add(sst.pop(func(k swiftKind) bool {
+		return k == swiftThrowsAnnotation || k == swiftTypedThrowsAnnotation
+	}))
+	add(sst.popKind(swiftConcurrentFunctionType))
+	add(sst.popKind(swiftAsyncAnnotation))
+	ft.children = append(ft.children, sst.popFunctionParams(swiftArgumentTuple))
+	ft.children = append(ft.children, sst.popFunctionParams(swiftReturnType))
+	return swiftTypeNode(ft)
+}
+
+// popFunctionParams pops the parameter or result type of a function.
+// An empty list is the empty tuple.
+func (sst *swiftState) popFunctionParams(k swiftKind) *swiftNode {
+	var t *swiftNode
+	if sst.popKind(swiftEmptyList) != nil {
+		t = swiftTypeNode(newSwiftNode(swiftTuple))
+	} else {
+		t = sst.mustPopType()
+	}
+	return newSwiftNode(k, t)
+}
+
+// popFunctionParamLabels pops the argument labels of a function with
+// type t, if there are any. The labels are identifiers, or '_' for
+// a parameter with no label; an empty list means no labels.
+// In the old mangling the labels are instead taken from the
+// element names of the parameter tuple.
+func (sst *swiftState) popFunctionParamLabels(t *swiftNode) *swiftNode {
+	if !sst.oldFunctionTypes && sst.popKind(swiftEmptyList) != nil {
+		return newSwiftNode(swiftLabelList)
+	}
+	if t == nil || t.kind != swiftType {
+		return nil
+	}
+	ft := t.children[0]
+	if ft.kind == swiftDependentGenericType {
+		ft = ft.children[1].children[0]
+	}
+	if ft.kind != swiftFunctionType && ft.kind != swiftNoEscapeFunctionType {
+		return nil
+	}
+	params := ft.childOfKind(swiftArgumentTuple).children[0].children[0]
+	if sst.oldFunctionTypes {
+		return oldSwiftParamLabels(params)
+	}
+	count := 1
+	if params.kind == swiftTuple {
+		count = len(params.children)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	labels := newSwiftNode(swiftLabelList)
+	hasLabels := false
+	for i := 0; i < count; i++ {
+		l := sst.pop(func(k swiftKind) bool {
+			return k == swiftIdentifier || k == swiftFirstElementMarker
+		})
+		if l == nil {
+			sst.fail("missing argument label")
+		}
+		if l.kind == swiftIdentifier {
+			hasLabels = true
+		}
+		labels.children = append(labels.children, l)
+	}
+	if !hasLabels {
+		return newSwiftNode(swiftLabelList)
+	}
+	reverseSwiftNodes(labels.children)
+	return labels
+}
+
+// oldSwiftParamLabels returns the argument labels of a function
+// whose parameters are params, using the old mangling. The labels
+// are removed from the tuple elements, so that they are not printed
+// twice.
+func oldSwiftParamLabels(params *swiftNode) *swiftNode {
+	labels := newSwiftNode(swiftLabelList)
+	if params.kind != swiftTuple {
+		return labels
+	}
+	hasLabels := false
+	for i, p := range params.children {
+		name := p.childOfKind(swiftTupleElementName)
+		if name == nil {
+			labels.children = append(labels.children, newSwiftNode(swiftFirstElementMarker))
+			continue
+		}
+		hasLabels = true
+		labels.children = append(labels.children, &swiftNode{kind: swiftIdentifier, text: name.text})
+		elt := newSwiftNode(swiftTupleElement)
+		for _, c := range p.children {
+			if c != name {
+				elt.children = append(elt.children, c)
+			}
+		}
+		params.children[i] = elt
+	}
+	if !hasLabels {
+		return newSwiftNode(swiftLabelList)
+	}
+	return labels
+}
+
+// reverseSwiftNodes reverses a slice of nodes in place.
+func reverseSwiftNodes(s []*swiftNode) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// tupleType parses a tuple after 't'. Each element is a type,
+// optionally followed by a label and a variadic marker; the first
+// element is followed by '_'.
+func (sst *swiftState) tupleType() *swiftNode {
+	tuple := newSwiftNode(swiftTuple)
+	if sst.popKind(swiftEmptyList) == nil {
+		for {
+			first := sst.popKind(swiftFirstElementMarker) != nil
+			elt := newSwiftNode(swiftTupleElement)
+			if v := sst.popKind(swiftVariadicMarker); v != nil {
+				elt.children = append(elt.children, v)
+			}
+			if id := sst.popKind(swiftIdentifier); id != nil {
+				elt.children = append(elt.children, &swiftNode{kind: swiftTupleElementName, text: id.text})
+			}
+			elt.children = append(elt.children, sst.mustPopType())
+			tuple.children = append(tuple.children, elt)
+			if first {
+				break
+			}
+		}
+		reverseSwiftNodes(tuple.children)
+	}
+	return swiftTypeNode(tuple)
+}
+
+// protocolList parses a list of protocols, used for an existential
+// type. The first protocol is followed by '_'.
+func (sst *swiftState) protocolList() *swiftNode {
+	list := newSwiftNode(swiftTypeList)
+	if sst.popKind(swiftEmptyList) == nil {
+		for {
+			first := sst.popKind(swiftFirstElementMarker) != nil
+			list.children = append(list.children, sst.popProtocol())
+			if first {
+				break
+			}
+		}
+		reverseSwiftNodes(list.children)
+	}
+	return newSwiftNode(swiftProtocolList, list)
+}
+
+// boundGenericType parses a generic type with arguments after 'G':
+//
+//	type 'y' (type* '_')* type* 'G'
+//
+// Each list of types is the arguments for one level of nesting,
+// innermost first.
+func (sst *swiftState) boundGenericType() *swiftNode {
+	var lists []*swiftNode
+	for {
+		list := newSwiftNode(swiftTypeList)
+		for {
+			t := sst.popType()
+			if t == nil {
+				break
+			}
+			list.children = append(list.children, t)
+		}
+		reverseSwiftNodes(list.children)
+		lists = append(lists, list)
+		if sst.popKind(swiftEmptyList) != nil {
+			break
+		}
+		if sst.popKind(swiftFirstElementMarker) == nil {
+			sst.fail("malformed generic arguments")
+		}
+	}
+	nominal := sst.popTypeAndGetNominal()
+	t := swiftTypeNode(sst.boundGenericArgs(nominal, lists, 0))
+	sst.addSubst(t)
+	return t
+}
+
+// boundGenericArgs applies the generic argument lists, starting at
+// lists[idx], to a nominal type and its enclosing types.
+func (sst *swiftState) boundGenericArgs(nominal *swiftNode, lists []*swiftNode, idx int) *swiftNode {
+	sst.enter()
+	defer sst.leave()
+
+	if nominal == nil || idx >= len(lists) {
+		sst.fail("malformed generic type")
+	}
+
+	args := lists[idx]
+	consumes := true
+	switch nominal.kind {
+	case swiftVariable, swiftSubscript, swiftImplicitClosure, swiftExplicitClosure,
+		swiftDefaultArgumentInitializer, swiftInitializer, swiftModule:
+		consumes = false
+	}
+	if consumes {
+		idx++
+	}
+
+	if idx < len(lists) {
+		ctx := nominal.child(0)
+		var parent *swiftNode
+		if ctx != nil && ctx.kind == swiftExtension {
+			parent = newSwiftNode(swiftExtension, ctx.children[0], sst.boundGenericArgs(ctx.child(1), lists, idx))
+			parent.children = append(parent.children, ctx.children[2:]...)
+		} else {
+			parent = sst.boundGenericArgs(ctx, lists, idx)
+		}
+		n := newSwiftNode(nominal.kind, parent)
+		n.text = nominal.text
+		n.children = append(n.children, nominal.children[1:]...)
+		nominal = n
+	}
+
+	if !consumes || len(args.children) == 0 {
+		return nominal
+	}
+
+	var k swiftKind
+	switch nominal.kind {
+	case swiftClass:
+		k = swiftBoundGenericClass
+	case swiftStructure:
+		k = swiftBoundGenericStructure
+	case swiftEnum:
+		k = swiftBoundGenericEnum
+	case swiftProtocol:
+		k = swiftBoundGenericProtocol
+	case swiftTypeAlias:
+		k = swiftBoundGenericTypeAlias
+	default:
+		sst.fail("generic arguments for non-generic type")
+	}
+	return newSwiftNode(k, swiftTypeNode(nominal), args)
+}
+
+// localIdentifier parses a local or private declaration name after
+// 'L':
+//
+//	decl-name identifier 'LL'   private name with discriminator
+//	identifier 'Ll'             private discriminator alone
+//	decl-name 'L' index         local name
+func (sst *swiftState) localIdentifier() *swiftNode {
+	if sst.nextIf('L') {
+		disc := sst.mustPopKind(swiftIdentifier)
+		name := sst.popDeclName()
+		return newSwiftNode(swiftPrivateDeclName, disc, name)
+	}
+	if sst.nextIf('l') {
+		return newSwiftNode(swiftPrivateDeclName, sst.mustPopKind(swiftIdentifier))
+	}
+	disc := sst.indexNode()
+	name := sst.popDeclName()
+	return newSwiftNode(swiftLocalDeclName, disc, name)
+}
+
+// metatype parses a metadata symbol after 'M'.
+func (sst *swiftState) metatype() *swiftNode {
+	c := sst.next()
+	var k swiftKind
+	switch c {
+	case 'a':
+		k = swiftTypeMetadataAccessFunction
+	case 'f':
+		k = swiftFullTypeMetadata
+	case 'F':
+		k = swiftReflectionMetadataFieldDescriptor
+	case 'i':
+		k = swiftTypeMetadataInstantiationFunction
+	case 'I':
+		k = swiftTypeMetadataInstantiationCache
+	case 'l':
+		k = swiftTypeMetadataSingletonInitializationCache
+	case 'L':
+		k = swiftTypeMetadataLazyCache
+	case 'm':
+		k = swiftMetaclass
+	case 'n':
+		k = swiftNominalTypeDescriptor
+	case 'o':
+		k = swiftClassMetadataBaseOffset
+	case 'r':
+		k = swiftTypeMetadataCompletionFunction
+	case 'u':
+		k = swiftMethodLookupFunction
+	case 'c':
+		return newSwiftNode(swiftProtocolConformanceDescriptor, sst.popProtocolConformance())
+	case 'p':
+		return newSwiftNode(swiftProtocolDescriptor, sst.popProtocol())
+	case 'V':
+		return newSwiftNode(swiftPropertyDescriptor, sst.mustPop(isSwiftEntity))
+	default:
+		sst.failEarlier("unrecognized metadata kind", 1)
+	}
+	return newSwiftNode(k, sst.mustPopType())
+}
+
+// swiftGenericParam returns a generic parameter type with the given
+// depth and index. Parameters are named A through Z, then AA and so
+// forth, with the depth appended if it is not zero.
+func swiftGenericParam(depth, index int) *swiftNode {
+	var name []byte
+	for {
+		name = append(name, byte('A'+index%26))
+		index /= 26
+		if index == 0 {
+			break
+		}
+	}
+	s := string(name)
+	if depth != 0 {
+		s += fmt.Sprint(depth)
+	}
+	return &swiftNode{kind: swiftDependentGenericParamType, text: s}
+}
+
+// genericParamIndex parses a generic parameter reference:
+//
+//	'z'                 depth 0, index 0
+//	'd' index index     depth + 1, index
+//	index               depth 0, index + 1
+func (sst *swiftState) genericParamIndex() *swiftNode {
+	if sst.nextIf('d') {
+		depth := sst.index() + 1
+		index := sst.index()
+		return swiftGenericParam(depth, index)
+	}
+	if sst.nextIf('z') {
+		return swiftGenericParam(0, 0)
+	}
+	return swiftGenericParam(0, sst.index()+1)
+}
+
+// popAssocTypeName pops the name of an associated type, optionally
+// qualified by a protocol.
+func (sst *swiftState) popAssocTypeName() *swiftNode {
+	proto := sst.popType()
+	if proto != nil && !isSwiftProtocolType(proto) {
+		sst.fail("expected protocol for associated type")
+	}
+	id := sst.mustPopKind(swiftIdentifier)
+	ref := newSwiftNode(swiftDependentAssociatedTypeRef, id)
+	if proto != nil {
+		ref.children = append(ref.children, proto)
+	}
+	return ref
+}
+
+// assocTypeSimple parses a single associated type of base, or of a
+// type popped from the stack if base is nil.
+func (sst *swiftState) assocTypeSimple(base *swiftNode) *swiftNode {
+	name := sst.popAssocTypeName()
+	var bt *swiftNode
+	if base != nil {
+		bt = swiftTypeNode(base)
+	} else {
+		bt = sst.mustPopType()
+	}
+	return swiftTypeNode(newSwiftNode(swiftDependentMemberType, bt, name))
+}
+
+// assocTypeCompound parses a path of associated types, as in A.B.C.
+// The first name is followed by '_'.
+func (sst *swiftState) assocTypeCompound(base *swiftNode) *swiftNode {
+	var names []*swiftNode
+	for {
+		first := sst.popKind(swiftFirstElementMarker) != nil
+		names = append(names, sst.popAssocTypeName())
+		if first {
+			break
+		}
+	}
+	var bt *swiftNode
+	if base != nil {
+		bt = swiftTypeNode(base)
+	} else {
+		bt = sst.mustPopType()
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		bt = swiftTypeNode(newSwiftNode(swiftDependentMemberType, bt, names[i]))
+	}
+	return bt
+}
+
+// archetype parses an associated type reference after 'Q'.
+func (sst *swiftState) archetype() *swiftNode {
+	var t *swiftNode
+	switch sst.next() {
+	case 'x':
+		t = sst.assocTypeSimple(nil)
+	case 'X':
+		t = sst.assocTypeCompound(nil)
+	case 'y':
+		t = sst.assocTypeSimple(sst.genericParamIndex())
+	case 'Y':
+		t = sst.assocTypeCompound(sst.genericParamIndex())
+	case 'z':
+		t = sst.assocTypeSimple(swiftGenericParam(0, 0))
+	case 'Z':
+		t = sst.assocTypeCompound(swiftGenericParam(0, 0))
+	default:
+		sst.failEarlier("unrecognized associated type", 1)
+	}
+	sst.addSubst(t)
+	return t
+}
+
+// genericRequirement parses a requirement of a generic signature
+// after 'R'. The next character gives the kind of requirement and
+// how the constrained type is written.
+func (sst *swiftState) genericRequirement() *swiftNode {
+	const (
+		generic = iota
+		assoc
+		compoundAssoc
+		substitution
+	)
+	const (
+		protocol = iota
+		baseClass
+		sameType
+		layout
+	)
+
+	var typeKind, constraint int
+	consumed := true
+	switch sst.peek() {
+	case 'c':
+		constraint, typeKind = baseClass, assoc
+	case 'C':
+		constraint, typeKind = baseClass, compoundAssoc
+	case 'b':
+		constraint, typeKind = baseClass, generic
+	case 'B':
+		constraint, typeKind = baseClass, substitution
+	case 't':
+		constraint, typeKind = sameType, assoc
+	case 'T':
+		constraint, typeKind = sameType, compoundAssoc
+	case 's':
+		constraint, typeKind = sameType, generic
+	case 'S':
+		constraint, typeKind = sameType, substitution
+	case 'm':
+		constraint, typeKind = layout, assoc
+	case 'M':
+		constraint, typeKind = layout, compoundAssoc
+	case 'l':
+		constraint, typeKind = layout, generic
+	case 'L':
+		constraint, typeKind = layout, substitution
+	case 'p':
+		constraint, typeKind = protocol, assoc
+	case 'P':
+		constraint, typeKind = protocol, compoundAssoc
+	case 'Q':
+		constraint, typeKind = protocol, substitution
+	default:
+		// A protocol requirement on a generic parameter,
+		// with no kind character.
+		constraint, typeKind = protocol, generic
+		consumed = false
+	}
+	if consumed {
+		sst.advance(1)
+	}
+
+	var t *swiftNode
+	switch typeKind {
+	case generic:
+		t = swiftTypeNode(sst.genericParamIndex())
+	case assoc:
+		t = sst.assocTypeSimple(sst.genericParamIndex())
+		sst.addSubst(t)
+	case compoundAssoc:
+		t = sst.assocTypeCompound(sst.genericParamIndex())
+		sst.addSubst(t)
+	case substitution:
+		t = sst.mustPopType()
+	}
+
+	switch constraint {
+	case protocol:
+		return newSwiftNode(swiftDependentGenericConformanceRequirement, t, sst.popProtocol())
+	case baseClass:
+		return newSwiftNode(swiftDependentGenericConformanceRequirement, t, sst.mustPopType())
+	case sameType:
+		return newSwiftNode(swiftDependentGenericSameTypeRequirement, t, sst.mustPopType())
+	}
+
+	c := sst.next()
+	req := newSwiftNode(swiftDependentGenericLayoutRequirement, t, &swiftNode{kind: swiftIdentifier, text: string(c)})
+	switch c {
+	case 'U', 'R', 'N', 'C', 'D', 'T':
+	case 'E', 'M':
+		req.children = append(req.children, sst.indexNode(), sst.indexNode())
+	case 'e', 'm':
+		req.children = append(req.children, sst.indexNode())
+	default:
+		sst.failEarlier("unrecognized layout constraint", 1)
+	}
+	return req
+}
+
+// genericSignature parses a generic signature after 'l' or 'r'.
+// For 'r' the number of parameters at each depth is given explicitly,
+// terminated by 'l'; for 'l' there is a single parameter.
+// The requirements precede the signature on the stack.
+func (sst *swiftState) genericSignature(hasCounts bool) *swiftNode {
+	sig := newSwiftNode(swiftDependentGenericSignature)
+	if hasCounts {
+		for !sst.nextIf('l') {
+			count := 0
+			if !sst.nextIf('z') {
+				count = sst.index() + 1
+			}
+			sig.children = append(sig.children, &swiftNode{kind: swiftDependentGenericParamCount, index: uint64(count)})
+		}
+	} else {
+		sig.children = append(sig.children, &swiftNode{kind: swiftDependentGenericParamCount, index: 1})
+	}
+	counts := len(sig.children)
+	for {
+		req := sst.pop(isSwiftRequirement)
+		if req == nil {
+			break
+		}
+		sig.children = append(sig.children, req)
+	}
+	reverseSwiftNodes(sig.children[counts:])
+	return sig
+}
+
+// swiftStandardTypes are the standard substitutions following 'S'.
+var swiftStandardTypes = map[byte]struct {
+	kind swiftKind
+	name string
+}{
+	'A': {swiftStructure, "AutoreleasingUnsafeMutablePointer"},
+	'a': {swiftStructure, "Array"},
+	'b': {swiftStructure, "Bool"},
+	'D': {swiftStructure, "Dictionary"},
+	'd': {swiftStructure, "Double"},
+	'f': {swiftStructure, "Float"},
+	'h': {swiftStructure, "Set"},
+	'I': {swiftStructure, "DefaultIndices"},
+	'i': {swiftStructure, "Int"},
+	'J': {swiftStructure, "Character"},
+	'N': {swiftStructure, "ClosedRange"},
+	'n': {swiftStructure, "Range"},
+	'O': {swiftStructure, "ObjectIdentifier"},
+	'P': {swiftStructure, "UnsafePointer"},
+	'p': {swiftStructure, "UnsafeMutablePointer"},
+	'R': {swiftStructure, "UnsafeBufferPointer"},
+	'r': {swiftStructure, "UnsafeMutableBufferPointer"},
+	'S': {swiftStructure, "String"},
+	's': {swiftStructure, "Substring"},
+	'u': {swiftStructure, "UInt"},
+	'V': {swiftStructure, "UnsafeRawPointer"},
+	'v': {swiftStructure, "UnsafeMutableRawPointer"},
+	'W': {swiftStructure, "UnsafeRawBufferPointer"},
+	'w': {swiftStructure, "UnsafeMutableRawBufferPointer"},
+
+	'q': {swiftEnum, "Optional"},
+
+	'B': {swiftProtocol, "BinaryFloatingPoint"},
+	'E': {swiftProtocol, "Encodable"},
+	'e': {swiftProtocol, "Decodable"},
+	'F': {swiftProtocol, "FloatingPoint"},
+	'G': {swiftProtocol, "RandomNumberGenerator"},
+	'H': {swiftProtocol, "Hashable"},
+	'j': {swiftProtocol, "Numeric"},
+	'K': {swiftProtocol, "BidirectionalCollection"},
+	'k': {swiftProtocol, "RandomAccessCollection"},
+	'L': {swiftProtocol, "Comparable"},
+	'l': {swiftProtocol, "Collection"},
+	'M': {swiftProtocol, "MutableCollection"},
+	'm': {swiftProtocol, "RangeReplaceableCollection"},
+	'Q': {swiftProtocol, "Equatable"},
+	'T': {swiftProtocol, "Sequence"},
+	't': {swiftProtocol, "IteratorProtocol"},
+	'U': {swiftProtocol, "UnsignedInteger"},
+	'X': {swiftProtocol, "RangeExpression"},
+	'x': {swiftProtocol, "Strideable"},
+	'Y': {swiftProtocol, "RawRepresentable"},
+	'y': {swiftProtocol, "StringProtocol"},
+	'Z': {swiftProtocol, "SignedInteger"},
+	'z': {swiftProtocol, "BinaryInteger"},
+}
+
+// swiftConcurrencyTypes are the standard substitutions following
+// 'Sc'.
+var swiftConcurrencyTypes = map[byte]struct {
+	kind swiftKind
+	name string
+}{
+	'A': {swiftProtocol, "Actor"},
+	'C': {swiftStructure, "CheckedContinuation"},
+	'c': {swiftStructure, "UnsafeContinuation"},
+	'E': {swiftStructure, "CancellationError"},
+	'e': {swiftStructure, "UnownedSerialExecutor"},
+	'F': {swiftProtocol, "Executor"},
+	'f': {swiftProtocol, "SerialExecutor"},
+	'G': {swiftStructure, "TaskGroup"},
+	'g': {swiftStructure, "ThrowingTaskGroup"},
+	'h': {swiftProtocol, "TaskExecutor"},
+	'I': {swiftProtocol, "AsyncIteratorProtocol"},
+	'i': {swiftProtocol, "AsyncSequence"},
+	'J': {swiftStructure, "UnownedJob"},
+	'M': {swiftClass, "MainActor"},
+	'P': {swiftStructure, "TaskPriority"},
+	'S': {swiftStructure, "AsyncStream"},
+	's': {swiftStructure, "AsyncThrowingStream"},
+	'T': {swiftStructure, "Task"},
+	't': {swiftStructure, "UnsafeCurrentTask"},
+}
+
+// swiftStdlibType returns a Type node for a type in the Swift module.
+func swiftStdlibType(k swiftKind, name string) *swiftNode {
+	return swiftTypeNode(newSwiftNode(k,
+		&swiftNode{kind: swiftModule, text: "Swift"},
+		&swiftNode{kind: swiftIdentifier, text: name}))
+}
+
+// standardSubstitution parses a standard substitution after 'S'.
+func (sst *swiftState) standardSubstitution() *swiftNode {
+	switch {
+	case sst.nextIf('o'):
+		return &swiftNode{kind: swiftModule, text: "__C"}
+	case sst.nextIf('C'):
+		return &swiftNode{kind: swiftModule, text: "__C_Synthesized"}
+	case sst.nextIf('g'):
+		t := swiftTypeNode(newSwiftNode(swiftBoundGenericEnum,
+			swiftStdlibType(swiftEnum, "Optional"),
+			newSwiftNode(swiftTypeList, sst.mustPopType())))
+		sst.addSubst(t)
+		return t
+	}
+
+	repeat := sst.natural()
+	if repeat > swiftMaxRepeatCount {
+		sst.fail("substitution repeat count too large")
+	}
+	table := swiftStandardTypes
+	if sst.nextIf('c') {
+		table = swiftConcurrencyTypes
+	}
+	std, ok := table[sst.next()]
+	if !ok {
+		panic(Error{Code: ErrBadSubstitution, Msg: "unrecognized standard substitution", Offset: sst.off - 1})
+	}
+	n := swiftStdlibType(std.kind, std.name)
+	for ; repeat > 1; repeat-- {
+		sst.push(n)
+	}
+	return n
+}
+
+// thunk parses a thunk or function attribute after 'T'.
+func (sst *swiftState) thunk() *swiftNode {
+	c := sst.next()
+	switch c {
+	case 'c':
+		return newSwiftNode(swiftCurryThunk, sst.mustPop(isSwiftEntity))
+	case 'j':
+		return newSwiftNode(swiftDispatchThunk, sst.mustPop(isSwiftEntity))
+	case 'q':
+		return newSwiftNode(swiftMethodDescriptor, sst.mustPop(isSwiftEntity))
+	case 'S':
+		return newSwiftNode(swiftProtocolSelfConformanceWitness, sst.mustPop(isSwiftEntity))
+	case 'o':
+		return newSwiftNode(swiftObjCAttribute)
+	case 'O':
+		return newSwiftNode(swiftNonObjCAttribute)
+	case 'D':
+		return newSwiftNode(swiftDynamicAttribute)
+	case 'd':
+		return newSwiftNode(swiftDirectMethodReferenceAttribute)
+	case 'm':
+		return newSwiftNode(swiftMergedFunction)
+	case 'u':
+		return newSwiftNode(swiftAsyncFunctionPointer)
+	case 'A':
+		return newSwiftNode(swiftPartialApplyForwarder)
+	case 'a':
+		return newSwiftNode(swiftPartialApplyObjCForwarder)
+	case 'Q':
+		return newSwiftNode(swiftAsyncAwaitResumePartialFunction, sst.indexNode())
+	case 'Y':
+		return newSwiftNode(swiftAsyncSuspendResumePartialFunction, sst.indexNode())
+	case 'V':
+		base := sst.mustPop(isSwiftEntity)
+		derived := sst.mustPop(isSwiftEntity)
+		return newSwiftNode(swiftVTableThunk, derived, base)
+	case 'W':
+		entity := sst.mustPop(isSwiftEntity)
+		conf := sst.popProtocolConformance()
+		return newSwiftNode(swiftProtocolWitness, conf, entity)
+	default:
+		sst.failEarlier("unrecognized thunk", 1)
+		panic("not reached")
+	}
+}
+
+// witness parses a witness table or related symbol after 'W'.
+func (sst *swiftState) witness() *swiftNode {
+	c := sst.next()
+	switch c {
+	case 'C':
+		return newSwiftNode(swiftEnumCase, sst.mustPop(isSwiftEntity))
+	case 'V':
+		return newSwiftNode(swiftValueWitnessTable, sst.mustPopType())
+	case 'v':
+		var d string
+		switch sst.next() {
+		case 'd':
+			d = "direct"
+		case 'i':
+			d = "indirect"
+		default:
+			sst.failEarlier("unrecognized field offset directness", 1)
+		}
+		return newSwiftNode(swiftFieldOffset, &swiftNode{kind: swiftDirectness, text: d}, sst.mustPop(isSwiftEntity))
+	case 'S':
+		return newSwiftNode(swiftProtocolSelfConformanceWitnessTable, sst.popProtocol())
+	case 'P':
+		return newSwiftNode(swiftProtocolWitnessTable, sst.popProtocolConformance())
+	case 'p':
+		return newSwiftNode(swiftProtocolWitnessTablePattern, sst.popProtocolConformance())
+	case 'G':
+		return newSwiftNode(swiftGenericProtocolWitnessTable, sst.popProtocolConformance())
+	case 'I':
+		return newSwiftNode(swiftGenericProtocolWitnessTableInstantiationFunction, sst.popProtocolConformance())
+	case 'r':
+		return newSwiftNode(swiftResilientProtocolWitnessTable, sst.popProtocolConformance())
+	case 'a':
+		return newSwiftNode(swiftProtocolWitnessTableAccessor, sst.popProtocolConformance())
+	case 'l', 'L':
+		conf := sst.popProtocolConformance()
+		t := sst.mustPopType()
+		k := swiftLazyProtocolWitnessTableAccessor
+		if c == 'L' {
+			k = swiftLazyProtocolWitnessTableCacheVariable
+		}
+		return newSwiftNode(k, t, conf)
+	case 't':
+		name := sst.popDeclName()
+		conf := sst.popProtocolConformance()
+		return newSwiftNode(swiftAssociatedTypeMetadataAccessor, conf, name)
+	default:
+		sst.failEarlier("unrecognized witness", 1)
+		panic("not reached")
+	}
+}
+
+// specialType parses a special type after 'X'.
+func (sst *swiftState) specialType() *swiftNode {
+	c := sst.next()
+	switch c {
+	case 'E':
+		return sst.popFunctionType(swiftNoEscapeFunctionType)
+	case 'A':
+		return sst.popFunctionType(swiftEscapingAutoClosureType)
+	case 'f':
+		return sst.popFunctionType(swiftThinFunctionType)
+	case 'K':
+		return sst.popFunctionType(swiftAutoClosureType)
+	case 'L':
+		return sst.popFunctionType(swiftEscapingObjCBlock)
+	case 'B':
+		return sst.popFunctionType(swiftObjCBlock)
+	case 'C':
+		return sst.popFunctionType(swiftCFunctionPointer)
+	case 'o':
+		return swiftTypeNode(newSwiftNode(swiftUnowned, sst.mustPopType()))
+	case 'u':
+		return swiftTypeNode(newSwiftNode(swiftUnmanaged, sst.mustPopType()))
+	case 'w':
+		return swiftTypeNode(newSwiftNode(swiftWeak, sst.mustPopType()))
+	case 'D':
+		return swiftTypeNode(newSwiftNode(swiftDynamicSelf, sst.mustPopType()))
+	case 'M', 'm':
+		var repr string
+		switch sst.next() {
+		case 't':
+			repr = "@thin"
+		case 'T':
+			repr = "@thick"
+		case 'o':
+			repr = "@objc_metatype"
+		default:
+			sst.failEarlier("unrecognized metatype representation", 1)
+		}
+		k := swiftMetatype
+		if c == 'm' {
+			k = swiftExistentialMetatype
+		}
+		r := &swiftNode{kind: swiftMetatypeRepresentation, text: repr}
+		return swiftTypeNode(newSwiftNode(k, r, sst.mustPopType()))
+	case 'p':
+		return swiftTypeNode(newSwiftNode(swiftExistentialMetatype, sst.mustPopType()))
+	case 'c':
+		super := sst.mustPopType()
+		protos := sst.protocolList()
+		return swiftTypeNode(newSwiftNode(swiftProtocolListWithClass, protos, super))
+	case 'l':
+		return swiftTypeNode(newSwiftNode(swiftProtocolListWithAnyObject, sst.protocolList()))
+	default:
+		sst.failEarlier("unrecognized special type", 1)
+		panic("not reached")
+	}
+}
+
+// typeAnnotation parses a type annotation after 'Y'.
+func (sst *swiftState) typeAnnotation() *swiftNode {
+	switch sst.next() {
+	case 'a':
+		return newSwiftNode(swiftAsyncAnnotation)
+	case 'b':
+		return newSwiftNode(swiftConcurrentFunctionType)
+	case 'c':
+		return newSwiftNode(swiftGlobalActorFunctionType, sst.popTypeAndGetChild())
+	case 'i':
+		return swiftTypeNode(newSwiftNode(swiftIsolated, sst.popTypeAndGetChild()))
+	case 'K':
+		return newSwiftNode(swiftTypedThrowsAnnotation, sst.popTypeAndGetChild())
+	default:
+		sst.failEarlier("unrecognized type annotation", 1)
+		panic("not reached")
+	}
+}
+
+// functionEntity parses a function-like entity after 'f', such as
+// a constructor or closure.
+func (sst *swiftState) functionEntity() *swiftNode {
+	const (
+		none = iota
+		typeAndMaybePrivateName
+		typeAndIndex
+		index
+	)
+	var args int
+	var k swiftKind
+	switch sst.next() {
+	case 'D':
+		args, k = none, swiftDeallocator
+	case 'd':
+		args, k = none, swiftDestructor
+	case 'E':
+		args, k = none, swiftIVarDestroyer
+	case 'e':
+		args, k = none, swiftIVarInitializer
+	case 'i':
+		args, k = none, swiftInitializer
+	case 'C':
+		args, k = typeAndMaybePrivateName, swiftAllocator
+	case 'c':
+		args, k = typeAndMaybePrivateName, swiftConstructor
+	case 'U':
+		args, k = typeAndIndex, swiftExplicitClosure
+	case 'u':
+		args, k = typeAndIndex, swiftImplicitClosure
+	case 'A':
+		args, k = index, swiftDefaultArgumentInitializer
+	default:
+		sst.failEarlier("unrecognized function entity", 1)
+	}
+
+	var nameOrIndex, t, labels *swiftNode
+	switch args {
+	case typeAndMaybePrivateName:
+		nameOrIndex = sst.popKind(swiftPrivateDeclName)
+		t = sst.mustPopType()
+		labels = sst.popFunctionParamLabels(t)
+	case typeAndIndex:
+		nameOrIndex = sst.indexNode()
+		t = sst.mustPopType()
+	case index:
+		nameOrIndex = sst.indexNode()
+	}
+
+	e := newSwiftNode(k, sst.popContext())
+	switch args {
+	case index:
+		e.children = append(e.children, nameOrIndex)
+	case typeAndMaybePrivateName:
+		if labels != nil {
+			e.children = append(e.children, labels)
+		}
+		e.children = append(e.children, t)
+		if nameOrIndex != nil {
+			e.children = append(e.children, nameOrIndex)
+		}
+	case typeAndIndex:
+		e.children = append(e.children, nameOrIndex, t)
+	}
+	return e
+}
+
+// typedEntity parses an entity of kind k with a name and type:
+//
+//	context decl-name label-list? type
+func (sst *swiftState) typedEntity(k swiftKind) *swiftNode {
+	t := sst.mustPopType()
+	labels := sst.popFunctionParamLabels(t)
+	name := sst.popDeclName()
+	ctx := sst.popContext()
+	if labels != nil {
+		return newSwiftNode(k, ctx, name, labels, t)
+	}
+	return newSwiftNode(k, ctx, name, t)
+}
+
+// subscript parses a subscript after 'i', which is followed by an
+// accessor.
+func (sst *swiftState) subscript() *swiftNode {
+	private := sst.popKind(swiftPrivateDeclName)
+	t := sst.mustPopType()
+	labels := sst.popFunctionParamLabels(t)
+	ctx := sst.popContext()
+	s := newSwiftNode(swiftSubscript, ctx)
+	if labels != nil {
+		s.children = append(s.children, labels)
+	}
+	s.children = append(s.children, t)
+	if private != nil {
+		s.children = append(s.children, private)
+	}
+	return sst.accessor(s)
+}
+
+// accessor parses the accessor of a variable or subscript.
+// 'p' refers to the storage itself.
+func (sst *swiftState) accessor(storage *swiftNode) *swiftNode {
+	var k swiftKind
+	switch sst.next() {
+	case 'm':
+		k = swiftMaterializeForSet
+	case 's':
+		k = swiftSetter
+	case 'g':
+		k = swiftGetter
+	case 'G':
+		k = swiftGlobalGetter
+	case 'w':
+		k = swiftWillSet
+	case 'W':
+		k = swiftDidSet
+	case 'r':
+		k = swiftReadAccessor
+	case 'M':
+		k = swiftModifyAccessor
+	case 'i':
+		k = swiftInitAccessor
+	case 'a':
+		if !sst.nextIf('u') {
+			sst.fail("unrecognized addressor")
+		}
+		k = swiftUnsafeMutableAddressor
+	case 'l':
+		if !sst.nextIf('u') {
+			sst.fail("unrecognized addressor")
+		}
+		k = swiftUnsafeAddressor
+	case 'p':
+		return storage
+	default:
+		sst.failEarlier("unrecognized accessor", 1)
+	}
+	return newSwiftNode(k, storage)
+}
+
+// swiftOperatorChars maps the lower case letters used to mangle
+// operators to the operator characters; a space is invalid.
+const swiftOperatorChars = "& @/= >    <*!|+?%-~   ^ ."
+
+// operatorIdentifier parses an operator name after 'o'.
+// The identifier on the stack encodes the operator characters.
+func (sst *swiftState) operatorIdentifier() *swiftNode {
+	id := sst.mustPopKind(swiftIdentifier)
+	var op strings.Builder
+	for i := 0; i < len(id.text); i++ {
+		c := id.text[i]
+		if c >= 0x80 {
+			// Pass through Unicode characters.
+			op.WriteByte(c)
+			continue
+		}
+		if !isLower(c) || swiftOperatorChars[c-'a'] == ' ' {
+			sst.fail("invalid operator character")
+		}
+		op.WriteByte(swiftOperatorChars[c-'a'])
+	}
+	var k swiftKind
+	switch sst.next() {
+	case 'i':
+		k = swiftInfixOperator
+	case 'p':
+		k = swiftPrefixOperator
+	case 'P':
+		k = swiftPostfixOperator
+	default:
+		sst.failEarlier("unrecognized operator fixity", 1)
+	}
+	return &swiftNode{kind: k, text: op.String()}
+}
+
+// swiftMaxOutput is the maximum length of a demangled Swift symbol.
+// Substitutions can make the output exponentially larger than the
+// input, so we give up on absurdly long results.
+const swiftMaxOutput = 1 << 20
+
+// How the type of an entity is printed.
+const (
+	swiftNoType        = iota // don't print the type
+	swiftWithColon            // print " : type"
+	swiftFunctionStyle        // print the type after the name, as for a function
+)
+
+// A swiftPrinter prints a tree of swiftNode values.
+type swiftPrinter struct {
+	buf           strings.Builder
+	noTypes       bool // don't print the types of entities
+	noGenericArgs bool // don't print generic arguments and signatures
+	max           int  // stop printing after this many bytes if not 0
+	depth         int  // current nesting depth
+	maxDepth      int  // maximum nesting depth
+}
+
+// full reports whether we should stop printing.
+func (sp *swiftPrinter) full() bool {
+	if sp.buf.Len() > swiftMaxOutput {
+		panic(Error{Code: ErrTooLarge, Msg: "demangled output too large", Offset: 0})
+	}
+	return sp.max > 0 && sp.buf.Len() >= sp.max
+}
+
+// writeString adds a string to the output.
+func (sp *swiftPrinter) writeString(s string) {
+	sp.buf.WriteString(s)
+}
+
+// printChildren prints the children of n, separated by sep.
+func (sp *swiftPrinter) printChildren(n *swiftNode, sep string) {
+	for i, c := range n.children {
+		if i > 0 {
+			sp.writeString(sep)
+		}
+		sp.print(c, false)
+	}
+}
+
+// printPrefixed prints a string followed by the only child of n.
+func (sp *swiftPrinter) printPrefixed(prefix string, n *swiftNode) {
+	sp.writeString(prefix)
+	sp.print(n.children[0], false)
+}
+
+// print prints a node. If asPrefix is true, the node is the context
+// of an entity being printed in the form context.name; if the node
+// can't be printed that way, print returns it without printing it,
+// and the caller prints it later as "name in context".
+func (sp *swiftPrinter) print(n *swiftNode, asPrefix bool) *swiftNode {
+	if sp.full() {
+		return nil
+	}
+
+	// The parser builds the tree with an operand stack, so only
+	// the printer sees how deeply it is nested.
+	sp.depth++
+	defer func() { sp.depth-- }()
+	if sp.depth > sp.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: 0})
+	}
+
+	if prefix, ok := swiftPrefixNames[n.kind]; ok {
+		sp.writeString(prefix)
+		if len(n.children) > 0 {
+			sp.print(n.children[0], false)
+		}
+		return nil
+	}
+	if name, ok := swiftAccessorNames[n.kind]; ok {
+		return sp.printAbstractStorage(n.children[0], asPrefix, name)
+	}
+
+	switch n.kind {
+	case swiftGlobal:
+		sp.printChildren(n, "")
+	case swiftSuffix:
+		sp.writeString(" with unmangled suffix ")
+		sp.writeString(strconv.Quote(n.text))
+	case swiftIdentifier, swiftModule, swiftBuiltinTypeName,
+		swiftDependentGenericParamType, swiftMetatypeRepresentation:
+		sp.writeString(n.text)
+	case swiftType, swiftTypeMangling:
+		sp.print(n.children[0], false)
+	case swiftNumber:
+		sp.writeString(fmt.Sprint(n.index))
+	case swiftLocalDeclName:
+		sp.print(n.children[1], false)
+		sp.writeString(fmt.Sprintf(" #%d", n.children[0].index+1))
+	case swiftPrivateDeclName:
+		if len(n.children) == 1 {
+			sp.writeString("(in " + n.children[0].text + ")")
+		} else {
+			sp.writeString("(")
+			sp.print(n.children[1], false)
+			sp.writeString(" in " + n.children[0].text + ")")
+		}
+	case swiftPrefixOperator:
+		sp.writeString(n.text + " prefix")
+	case swiftPostfixOperator:
+		sp.writeString(n.text + " postfix")
+	case swiftInfixOperator:
+		sp.writeString(n.text + " infix")
+
+	case swiftStructure, swiftClass, swiftEnum, swiftProtocol, swiftTypeAlias:
+		return sp.printEntity(n, asPrefix, swiftNoType, true, "", -1, "")
+	case swiftExtension:
+		sp.writeString("(extension in ")
+		sp.print(n.children[0], true)
+		sp.writeString("):")
+		sp.print(n.children[1], false)
+		if len(n.children) > 2 {
+			sp.print(n.children[2], false)
+		}
+	case swiftFunction:
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, true, "", -1, "")
+	case swiftVariable:
+		return sp.printEntity(n, asPrefix, swiftWithColon, true, "", -1, "")
+	case swiftSubscript:
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, false, "", -1, "subscript")
+	case swiftStatic:
+		sp.writeString("static ")
+		sp.print(n.children[0], false)
+	case swiftAllocator:
+		name := "init"
+		if n.children[0].kind == swiftClass {
+			name = "__allocating_init"
+		}
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, false, name, -1, "")
+	case swiftConstructor:
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, false, "init", -1, "")
+	case swiftDestructor:
+		return sp.printEntity(n, asPrefix, swiftNoType, false, "deinit", -1, "")
+	case swiftDeallocator:
+		name := "deinit"
+		if n.children[0].kind == swiftClass {
+			name = "__deallocating_deinit"
+		}
+		return sp.printEntity(n, asPrefix, swiftNoType, false, name, -1, "")
+	case swiftIVarInitializer:
+		return sp.printEntity(n, asPrefix, swiftNoType, false, "__ivar_initializer", -1, "")
+	case swiftIVarDestroyer:
+		return sp.printEntity(n, asPrefix, swiftNoType, false, "__ivar_destroyer", -1, "")
+	case swiftInitializer:
+		return sp.printEntity(n, asPrefix, swiftNoType, false, "variable initialization expression", -1, "")
+	case swiftDefaultArgumentInitializer:
+		return sp.printEntity(n, asPrefix, swiftNoType, false, "default argument ", int(n.children[1].index), "")
+	case swiftExplicitClosure:
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, false, "closure #", int(n.children[1].index)+1, "")
+	case swiftImplicitClosure:
+		return sp.printEntity(n, asPrefix, swiftFunctionStyle, false, "implicit closure #", int(n.children[1].index)+1, "")
+
+	case swiftProtocolConformance:
+		sp.print(n.children[0], false)
+		sp.writeString(" : ")
+		sp.print(n.children[1], false)
+		sp.writeString(" in ")
+		sp.print(n.children[2], false)
+	case swiftProtocolWitness:
+		sp.writeString("protocol witness for ")
+		sp.print(n.children[1], false)
+		sp.writeString(" in conformance ")
+		sp.print(n.children[0], false)
+	case swiftFieldOffset:
+		sp.writeString(n.children[0].text + " field offset for ")
+		sp.print(n.children[1], false)
+	case swiftLazyProtocolWitnessTableAccessor, swiftLazyProtocolWitnessTableCacheVariable:
+		if n.kind == swiftLazyProtocolWitnessTableAccessor {
+			sp.writeString("lazy protocol witness table accessor for type ")
+		} else {
+			sp.writeString("lazy protocol witness table cache variable for type ")
+		}
+		sp.print(n.children[0], false)
+		sp.writeString(" and conformance ")
+		sp.print(n.children[1], false)
+	case swiftAssociatedTypeMetadataAccessor:
+		sp.writeString("associated type metadata accessor for ")
+		sp.print(n.children[1], false)
+		sp.writeString(" in ")
+		sp.print(n.children[0], false)
+	case swiftVTableThunk:
+		sp.writeString("vtable thunk for ")
+		sp.print(n.children[1], false)
+		sp.writeString(" dispatching to ")
+		sp.print(n.children[0], false)
+	case swiftPartialApplyForwarder, swiftPartialApplyObjCForwarder:
+		if n.kind == swiftPartialApplyForwarder {
+			sp.writeString("partial apply forwarder")
+		} else {
+			sp.writeString("partial apply ObjC forwarder")
+		}
+		if len(n.children) > 0 {
+			sp.writeString(" for ")
+			sp.printChildren(n, "")
+		}
+	case swiftAsyncAwaitResumePartialFunction, swiftAsyncSuspendResumePartialFunction:
+		sp.writeString("(")
+		sp.print(n.children[0], false)
+		if n.kind == swiftAsyncAwaitResumePartialFunction {
+			sp.writeString(") await resume partial function for ")
+		} else {
+			sp.writeString(") suspend resume partial function for ")
+		}
+
+	default:
+		sp.printType(n)
+	}
+	return nil
+}
+
+// printType prints a node that is part of a type.
+func (sp *swiftPrinter) printType(n *swiftNode) {
+	switch n.kind {
+	case swiftTuple:
+		sp.writeString("(")
+		sp.printChildren(n, ", ")
+		sp.writeString(")")
+	case swiftTupleElement:
+		if name := n.childOfKind(swiftTupleElementName); name != nil {
+			sp.writeString(name.text + ": ")
+		}
+		sp.print(n.childOfKind(swiftType), false)
+		if n.childOfKind(swiftVariadicMarker) != nil {
+			sp.writeString("...")
+		}
+	case swiftFunctionType, swiftNoEscapeFunctionType, swiftThinFunctionType,
+		swiftCFunctionPointer, swiftObjCBlock, swiftEscapingObjCBlock,
+		swiftAutoClosureType, swiftEscapingAutoClosureType:
+		sp.printFunctionType(nil, n)
+	case swiftReturnType:
+		sp.writeString(" -> ")
+		sp.printChildren(n, "")
+	case swiftThrowsAnnotation:
+		sp.writeString(" throws")
+	case swiftTypedThrowsAnnotation:
+		sp.writeString(" throws(")
+		sp.print(n.children[0], false)
+		sp.writeString(")")
+	case swiftGlobalActorFunctionType:
+		sp.writeString("@")
+		sp.print(n.children[0], false)
+	case swiftInOut:
+		sp.printPrefixed("inout ", n)
+	case swiftShared:
+		sp.printPrefixed("__shared ", n)
+	case swiftOwned:
+		sp.printPrefixed("__owned ", n)
+	case swiftIsolated:
+		sp.printPrefixed("isolated ", n)
+	case swiftWeak:
+		sp.printPrefixed("weak ", n)
+	case swiftUnowned:
+		sp.printPrefixed("unowned ", n)
+	case swiftUnmanaged:
+		sp.printPrefixed("unowned(unsafe) ", n)
+	case swiftDynamicSelf:
+		sp.writeString("Self")
+	case swiftMetatype, swiftExistentialMetatype:
+		t := n.children[0]
+		if t.kind == swiftMetatypeRepresentation {
+			sp.print(t, false)
+			sp.writeString(" ")
+			t = n.children[1]
+		}
+		if n.kind == swiftExistentialMetatype {
+			sp.print(t, false)
+			sp.writeString(".Type")
+			break
+		}
+		t = t.children[0]
+		if isSwiftSimpleType(t) {
+			sp.print(t, false)
+		} else {
+			sp.writeString("(")
+			sp.print(t, false)
+			sp.writeString(")")
+		}
+		switch t.kind {
+		case swiftExistentialMetatype, swiftProtocolList, swiftProtocolListWithClass, swiftProtocolListWithAnyObject:
+			sp.writeString(".Protocol")
+		default:
+			sp.writeString(".Type")
+		}
+	case swiftProtocolList:
+		list := n.children[0]
+		if len(list.children) == 0 {
+			sp.writeString("Any")
+		} else {
+			sp.printChildren(list, " & ")
+		}
+	case swiftProtocolListWithClass:
+		sp.print(n.children[1], false)
+		sp.writeString(" & ")
+		sp.printChildren(n.children[0].children[0], " & ")
+	case swiftProtocolListWithAnyObject:
+		list := n.children[0].children[0]
+		if len(list.children) > 0 {
+			sp.printChildren(list, " & ")
+			sp.writeString(" & ")
+		}
+		sp.writeString("Swift.AnyObject")
+	case swiftBoundGenericStructure, swiftBoundGenericClass, swiftBoundGenericEnum,
+		swiftBoundGenericProtocol, swiftBoundGenericTypeAlias:
+		sp.printBoundGeneric(n)
+	case swiftTypeList:
+		sp.printChildren(n, ", ")
+	case swiftDependentGenericType:
+		sp.print(n.children[0], false)
+		if needSwiftSpaceBeforeType(n.children[1]) {
+			sp.writeString(" ")
+		}
+		sp.print(n.children[1], false)
+	case swiftDependentGenericSignature:
+		sp.printGenericSignature(n)
+	case swiftDependentGenericConformanceRequirement:
+		sp.print(n.children[0], false)
+		sp.writeString(": ")
+		sp.print(n.children[1], false)
+	case swiftDependentGenericSameTypeRequirement:
+		sp.print(n.children[0], false)
+		sp.writeString(" == ")
+		sp.print(n.children[1], false)
+	case swiftDependentGenericLayoutRequirement:
+		sp.printLayoutRequirement(n)
+	case swiftDependentMemberType:
+		sp.print(n.children[0], false)
+		sp.writeString(".")
+		sp.print(n.children[1], false)
+	case swiftDependentAssociatedTypeRef:
+		if len(n.children) > 1 {
+			sp.print(n.children[1], false)
+			sp.writeString(".")
+		}
+		sp.print(n.children[0], false)
+	}
+}
+
+// printEntity prints an entity: its context, its name, and its type,
+// as selected by typePr. extraName and extraIndex, if not empty and
+// not negative, follow the name; overwriteName, if not empty,
+// replaces it. If the entity is the context of another entity and
+// can't be printed as a prefix, it is returned without being printed.
+func (sp *swiftPrinter) printEntity(n *swiftNode, asPrefix bool, typePr int, hasName bool, extraName string, extraIndex int, overwriteName string) *swiftNode {
+	if sp.noTypes {
+		typePr = swiftNoType
+	}
+
+	// The context is printed either as a prefix, "context.name",
+	// or as a suffix, "name in context".
+	multiWord := strings.Contains(extraName, " ")
+	if hasName && n.children[1].kind == swiftLocalDeclName {
+		multiWord = true
+	}
+	if asPrefix && (typePr != swiftNoType || multiWord) {
+		return n
+	}
+
+	var postfix *swiftNode
+	ctx := n.children[0]
+	if multiWord {
+		postfix = ctx
+	} else {
+		pos := sp.buf.Len()
+		postfix = sp.print(ctx, true)
+		if sp.buf.Len() != pos {
+			sp.writeString(".")
+		}
+	}
+
+	if hasName || overwriteName != "" {
+		if extraName != "" && multiWord {
+			sp.writeString(extraName)
+			if extraIndex >= 0 {
+				sp.writeString(fmt.Sprint(extraIndex))
+			}
+			sp.writeString(" of ")
+			extraName = ""
+			extraIndex = -1
+		}
+		pos := sp.buf.Len()
+		if overwriteName != "" {
+			sp.writeString(overwriteName)
+		} else {
+			if name := n.children[1]; name.kind != swiftPrivateDeclName {
+				sp.print(name, false)
+			}
+			if private := n.childOfKind(swiftPrivateDeclName); private != nil {
+				sp.print(private, false)
+			}
+		}
+		if sp.buf.Len() != pos && extraName != "" {
+			sp.writeString(".")
+		}
+	}
+	if extraName != "" {
+		sp.writeString(extraName)
+		if extraIndex >= 0 {
+			sp.writeString(fmt.Sprint(extraIndex))
+		}
+	}
+
+	if typePr != swiftNoType {
+		t := n.childOfKind(swiftType).children[0]
+		if typePr == swiftFunctionStyle {
+			// Use the colon form if this is not a function type.
+			ft := t
+			for ft.kind == swiftDependentGenericType {
+				ft = ft.children[1].children[0]
+			}
+			if !isSwiftFunctionType(ft.kind) {
+				typePr = swiftWithColon
+			}
+		}
+		if typePr == swiftWithColon {
+			sp.writeString(" : ")
+		} else if multiWord || needSwiftSpaceBeforeType(t) {
+			sp.writeString(" ")
+		}
+		sp.printEntityType(n, t)
+	}
+
+	if !asPrefix && postfix != nil {
+		switch n.kind {
+		case swiftDefaultArgumentInitializer, swiftInitializer:
+			sp.writeString(" of ")
+		default:
+			sp.writeString(" in ")
+		}
+		sp.print(postfix, false)
+		postfix = nil
+	}
+	return postfix
+}
+
+// printAbstractStorage prints a variable or subscript followed by
+// the name of an accessor.
+func (sp *swiftPrinter) printAbstractStorage(n *swiftNode, asPrefix bool, extraName string) *swiftNode {
+	switch n.kind {
+	case swiftVariable:
+		return sp.printEntity(n, asPrefix, swiftWithColon, true, extraName, -1, "")
+	case swiftSubscript:
+		return sp.printEntity(n, asPrefix, swiftWithColon, false, extraName, -1, "subscript")
+	default:
+		panic(Error{Msg: "accessor of unexpected storage", Offset: 0})
+	}
+}
+
+// printEntityType prints the type t of an entity, using the argument
+// labels of the entity if there are any.
+func (sp *swiftPrinter) printEntityType(n, t *swiftNode) {
+	labels := n.childOfKind(swiftLabelList)
+	if labels == nil {
+		sp.print(t, false)
+		return
+	}
+	if t.kind == swiftDependentGenericType {
+		sp.print(t.children[0], false)
+		dt := t.children[1]
+		if needSwiftSpaceBeforeType(dt) {
+			sp.writeString(" ")
+		}
+		t = dt.children[0]
+	}
+	if !isSwiftFunctionType(t.kind) {
+		sp.print(t, false)
+		return
+	}
+	sp.printFunctionType(labels, t)
+}
+
+// isSwiftFunctionType reports whether k is a function type.
+func isSwiftFunctionType(k swiftKind) bool {
+	switch k {
+	case swiftFunctionType, swiftNoEscapeFunctionType, swiftThinFunctionType, swiftCFunctionPointer:
+		return true
+	}
+	return false
+}
+
+// needSwiftSpaceBeforeType reports whether a space separates a name
+// from a following type n.
+func needSwiftSpaceBeforeType(n *swiftNode) bool {
+	switch n.kind {
+	case swiftType:
+		return needSwiftSpaceBeforeType(n.children[0])
+	case swiftFunctionType, swiftNoEscapeFunctionType, swiftDependentGenericType:
+		return false
+	}
+	return true
+}
+
+// isSwiftSimpleType reports whether n can be printed without
+// parentheses before a suffix such as "?" or ".Type".
+func isSwiftSimpleType(n *swiftNode) bool {
+	switch n.kind {
+	case swiftType:
+		return isSwiftSimpleType(n.children[0])
+	case swiftProtocolList:
+		return len(n.children[0].children) <= 1
+	case swiftFunctionType, swiftNoEscapeFunctionType, swiftThinFunctionType,
+		swiftCFunctionPointer, swiftObjCBlock, swiftEscapingObjCBlock,
+		swiftAutoClosureType, swiftEscapingAutoClosureType,
+		swiftInOut, swiftShared, swiftOwned, swiftIsolated,
+		swiftWeak, swiftUnowned, swiftUnmanaged,
+		swiftDependentGenericType, swiftProtocolListWithClass,
+		swiftProtocolListWithAnyObject:
+		return false
+	}
+	return true
+}
+
+// printFunctionType prints a function type, using argument labels
+// if labels is not nil.
+func (sp *swiftPrinter) printFunctionType(labels, n *swiftNode) {
+	switch n.kind {
+	case swiftAutoClosureType, swiftEscapingAutoClosureType:
+		sp.writeString("@autoclosure ")
+	case swiftThinFunctionType:
+		sp.writeString("@convention(thin) ")
+	case swiftCFunctionPointer:
+		sp.writeString("@convention(c) ")
+	case swiftEscapingObjCBlock:
+		sp.writeString("@escaping @convention(block) ")
+	case swiftObjCBlock:
+		sp.writeString("@convention(block) ")
+	}
+
+	var globalActor, throws *swiftNode
+	sendable, async := false, false
+	for _, c := range n.children {
+		switch c.kind {
+		case swiftGlobalActorFunctionType:
+			globalActor = c
+		case swiftThrowsAnnotation, swiftTypedThrowsAnnotation:
+			throws = c
+		case swiftConcurrentFunctionType:
+			sendable = true
+		case swiftAsyncAnnotation:
+			async = true
+		}
+	}
+	if globalActor != nil {
+		sp.print(globalActor, false)
+		sp.writeString(" ")
+	}
+	if sendable {
+		sp.writeString("@Sendable ")
+	}
+
+	sp.printFunctionParameters(labels, n.childOfKind(swiftArgumentTuple))
+	if async {
+		sp.writeString(" async")
+	}
+	if throws != nil {
+		sp.print(throws, false)
+	}
+	sp.print(n.childOfKind(swiftReturnType), false)
+}
+
+// printFunctionParameters prints the parameters of a function,
+// in parentheses, with labels if there are any.
+func (sp *swiftPrinter) printFunctionParameters(labels, args *swiftNode) {
+	params := args.children[0].children[0]
+	if params.kind != swiftTuple {
+		sp.writeString("(")
+		sp.print(params, false)
+		sp.writeString(")")
+		return
+	}
+
+	hasLabels := labels != nil && len(labels.children) > 0
+	sp.writeString("(")
+	for i, p := range params.children {
+		if i > 0 {
+			sp.writeString(", ")
+		}
+		if hasLabels {
+			label := "_"
+			if i < len(labels.children) && labels.children[i].kind == swiftIdentifier {
+				label = labels.children[i].text
+			}
+			sp.writeString(label + ": ")
+		}
+		sp.print(p, false)
+	}
+	sp.writeString(")")
+}
+
+// printBoundGeneric prints a generic type with its arguments,
+// using the syntactic sugar for optionals, arrays, and dictionaries.
+func (sp *swiftPrinter) printBoundGeneric(n *swiftNode) {
+	base, args := n.children[0], n.children[1]
+	if sp.noGenericArgs {
+		sp.print(base, false)
+		return
+	}
+	if n.kind == swiftBoundGenericProtocol {
+		sp.printChildren(args, ", ")
+		sp.writeString(" as ")
+		sp.print(base, false)
+		return
+	}
+
+	switch swiftSugar(n) {
+	case "Optional":
+		t := args.children[0]
+		if isSwiftSimpleType(t) {
+			sp.print(t, false)
+		} else {
+			sp.writeString("(")
+			sp.print(t, false)
+			sp.writeString(")")
+		}
+		sp.writeString("?")
+	case "Array":
+		sp.writeString("[")
+		sp.print(args.children[0], false)
+		sp.writeString("]")
+	case "Dictionary":
+		sp.writeString("[")
+		sp.print(args.children[0], false)
+		sp.writeString(" : ")
+		sp.print(args.children[1], false)
+		sp.writeString("]")
+	default:
+		sp.print(base, false)
+		sp.writeString("<")
+		sp.printChildren(args, ", ")
+		sp.writeString(">")
+	}
+}
+
+// swiftSugar returns the name of the standard library type that n
+// instantiates, if that type has syntactic sugar.
+func swiftSugar(n *swiftNode) string {
+	t := n.children[0].children[0]
+	if len(t.children) < 2 {
+		return ""
+	}
+	if m := t.children[0]; m.kind != swiftModule || m.text != "Swift" {
+		return ""
+	}
+	name := t.children[1]
+	if name.kind != swiftIdentifier {
+		return ""
+	}
+	nargs := len(n.children[1].children)
+	switch {
+	case t.kind == swiftEnum && name.text == "Optional" && nargs == 1,
+		t.kind == swiftStructure && name.text == "Array" && nargs == 1,
+		t.kind == swiftStructure && name.text == "Dictionary" && nargs == 2:
+		return name.text
+	}
+	return ""
+}
+
+// printGenericSignature prints a generic signature, as in
+// <A, B where A: Swift.Hashable>.
+func (sp *swiftPrinter) printGenericSignature(n *swiftNode) {
+	if sp.noGenericArgs {
+		return
+	}
+	sp.writeString("<")
+	depth := 0
+	for ; depth < len(n.children) && n.children[depth].kind == swiftDependentGenericParamCount; depth++ {
+		if depth > 0 {
+			sp.writeString("><")
+		}
+		count := n.children[depth].index
+		for i := uint64(0); i < count; i++ {
+			if i > 0 {
+				sp.writeString(", ")
+			}
+			// Don't let a malformed count produce huge output.
+			if i >= 128 {
+				sp.writeString("...")
+				break
+			}
+			sp.writeString(swiftGenericParam(depth, int(i)).text)
+		}
+	}
+	if depth < len(n.children) {
+		sp.writeString(" where ")
+		for i, r := range n.children[depth:] {
+			if i > 0 {
+				sp.writeString(", ")
+			}
+			sp.print(r, false)
+		}
+	}
+	sp.writeString(">")
+}
+
+// swiftLayouts maps layout constraint codes to names.
+var swiftLayouts = map[string]string{
+	"U": "_UnknownLayout",
+	"R": "_RefCountedObject",
+	"N": "_NativeRefCountedObject",
+	"C": "AnyObject",
+	"D": "_NativeClass",
+	"T": "_Trivial",
+	"E": "_Trivial",
+	"e": "_Trivial",
+	"M": "_TrivialAtMost",
+	"m": "_TrivialAtMost",
+}
+
+// printLayoutRequirement prints a layout requirement, such as
+// A: AnyObject.
+func (sp *swiftPrinter) printLayoutRequirement(n *swiftNode) {
+	sp.print(n.children[0], false)
+	sp.writeString(": ")
+	sp.writeString(swiftLayouts[n.children[1].text])
+	if len(n.children) > 2 {
+		sp.writeString("(")
+		sp.print(n.children[2], false)
+		if len(n.children) > 3 {
+			sp.writeString(", ")
+			sp.print(n.children[3], false)
+		}
+		sp.writeString(")")
+	}
+}
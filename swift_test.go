@@ -0,0 +1,117 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestSwift(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"$s4main3fooyyF", "main.foo() -> ()"},
+		{"_$s4main3fooyyF", "main.foo() -> ()"},
+		{"$S4main3fooyyF", "main.foo() -> ()"},
+		{"$s4main3add1a1bS2i_SitF", "main.add(a: Swift.Int, b: Swift.Int) -> Swift.Int"},
+		{"$s4main3fooyyxlF", "main.foo<A>(A) -> ()"},
+		{"$s4main3fooyyxSHRzlF", "main.foo<A where A: Swift.Hashable>(A) -> ()"},
+		{"$s4main3fooyyYaKF", "main.foo() async throws -> ()"},
+		{"$s4main3fooyySiSgF", "main.foo(Swift.Int?) -> ()"},
+		{"$s4main3fooyySaySiGzF", "main.foo(inout [Swift.Int]) -> ()"},
+		{"$s4main3fooyySDySSSiGF", "main.foo([Swift.String : Swift.Int]) -> ()"},
+		{"$s4main3fooyyyyXEF", "main.foo(() -> ()) -> ()"},
+		{"$s4main3fooyySimF", "main.foo(Swift.Int.Type) -> ()"},
+		{"$s4main3fooyyypF", "main.foo(Any) -> ()"},
+		{"$s4main3fooyyyXlF", "main.foo(Swift.AnyObject) -> ()"},
+		{"$s4main2eeoiySbSi_SitF", "main.== infix(Swift.Int, Swift.Int) -> Swift.Bool"},
+		{"$s4main3FooV3bar1aySi_tFZ", "static main.Foo.bar(a: Swift.Int) -> ()"},
+		{"$sSS7cStringSSSPys4Int8VG_tcfC", "Swift.String.init(cString: Swift.UnsafePointer<Swift.Int8>) -> Swift.String"},
+		{"$sSTsE3mapySayqd__Gqd__7ElementQzKXEKlF", "(extension in Swift):Swift.Sequence.map<A>((A.Element) throws -> A1) throws -> [A1]"},
+		{"$s4main3FooCACycfC", "main.Foo.__allocating_init() -> main.Foo"},
+		{"$s4main3FooCfD", "main.Foo.__deallocating_deinit"},
+		{"$s4main3fooyyFyycfU_", "closure #1 () -> () in main.foo() -> ()"},
+		{"$s4main1xSivp", "main.x : Swift.Int"},
+		{"$s4main5HelloV5worldSSvg", "main.Hello.world.getter : Swift.String"},
+		{"$s4main3FooV1xSivs", "main.Foo.x.setter : Swift.Int"},
+		{"$s4main3FooV3barSiSgvM", "main.Foo.bar.modify : Swift.Int?"},
+		{"$s4main3FooVyS2icig", "main.Foo.subscript.getter : (Swift.Int) -> Swift.Int"},
+		{"$sSiD", "Swift.Int"},
+		{"$s4main3FooVMn", "nominal type descriptor for main.Foo"},
+		{"$s4main3FooVMa", "type metadata accessor for main.Foo"},
+		{"$s4main3FooV1xSivpMV", "property descriptor for main.Foo.x : Swift.Int"},
+		{"$s4main3FooVAA1PAAMc", "protocol conformance descriptor for main.Foo : main.P in main"},
+		{"$s4main3FooCAA1PAAWP", "protocol witness table for main.Foo : main.P in main"},
+		{"$s4main3FooVAA1PA2aDP3baryyFTW", "protocol witness for main.P.bar() -> () in conformance main.Foo : main.P in main"},
+		{"$s4main3FooC3baryyFTj", "dispatch thunk of main.Foo.bar() -> ()"},
+		{"$s4main3fooyyF3BarL_VN", "type metadata for Bar #1 in main.foo() -> ()"},
+		{"$s4main3Foo33_0123456789ABCDEF0123456789ABCDEFLLVN", "type metadata for main.(Foo in _0123456789ABCDEF0123456789ABCDEF)"},
+		{"$s4main12SomeLongNameV0c4Type0VN", "type metadata for main.SomeLongName.LongType"},
+		{"$s4main12SomeLongNameV0cDVN", "type metadata for main.SomeLongName.LongName"},
+		{"$s4main007caf_dmaVN", "type metadata for main.café"},
+		{"$s4main3FooCMa.cold", `type metadata accessor for main.Foo with unmangled suffix ".cold"`},
+
+		// Swift 4.0 names, with argument labels in the parameter tuple.
+		{"_T04main3fooyyF", "main.foo() -> ()"},
+		{"_T04main3fooySiF", "main.foo(Swift.Int) -> ()"},
+		{"_T04main3fooySi_SitF", "main.foo(Swift.Int, Swift.Int) -> ()"},
+		{"_T04main3addS2i1a_Si1btF", "main.add(a: Swift.Int, b: Swift.Int) -> Swift.Int"},
+		{"_T0s5printySayypGd_SS9separatorSS10terminatortF", "Swift.print(_: [Any]..., separator: Swift.String, terminator: Swift.String) -> ()"},
+		{"_T04main3FooV1xSivg", "main.Foo.x.getter : Swift.Int"},
+		{"_T04main3FooVAA1PA2aDP3barySi1x_tFTW", "protocol witness for main.P.bar(x: Swift.Int) -> () in conformance main.Foo : main.P in main"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+		if got := Filter(test.input); got != test.want {
+			t.Errorf("filtering %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestSwiftOptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		options []Option
+		want    string
+	}{
+		{"$s4main3add1a1bS2i_SitF", []Option{NoParams}, "main.add"},
+		{"$s4main5HelloV5worldSSvg", []Option{NoParams}, "main.Hello.world.getter"},
+		{"$s4main3fooyyFyycfU_", []Option{NoParams}, "closure #1 in main.foo"},
+		{"$s4main3fooyyxSHRzlF", []Option{NoTemplateParams}, "main.foo(A) -> ()"},
+		{"$sSS7cStringSSSPys4Int8VG_tcfC", []Option{NoTemplateParams}, "Swift.String.init(cString: Swift.UnsafePointer) -> Swift.String"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input, test.options...); err != nil {
+			t.Errorf("demangling %s with %v: unexpected error %v", test.input, test.options, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %v: got %s, want %s", test.input, test.options, got, test.want)
+		}
+	}
+}
+
+func TestSwiftFailure(t *testing.T) {
+	for _, input := range []string{
+		"$s",
+		"$s4mainF",
+		"$s4main3fooyy",
+		"$s4main3fooyyFy",
+		"$s9main",
+		"$sA",
+		"$sSiSi!",
+		"$s4main3FooV0zVN",
+		"$s4main2jjoi",
+		"_T0",
+		"_T04main3fooyyFy",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A Symbol is a symbol name whose demangled forms are computed when
+// they are first needed, and then remembered. This suits programs
+// such as debuggers that read many symbol names but only display a
+// few of them, and may display those in several forms.
+//
+// The methods of a Symbol never fail: if the name can't be
+// demangled, they return the name unchanged, as Filter does.
+// Use Err to see whether the name can be demangled.
+//
+// A Symbol may be used concurrently by multiple goroutines.
+type Symbol struct {
+	mangled string
+	options []Option
+
+	strOnce sync.Once
+	res     *Result
+	str     string
+	err     error
+	done    uint32 // set atomically after strOnce has run
+
+	noParamsOnce sync.Once
+	noParams     string
+
+	baseNameOnce sync.Once
+	baseName     string
+
+	kindOnce sync.Once
+	kind     string
+}
+
+// NewSymbol returns a Symbol for a mangled name. The options are
+// used for all the demangled forms of the name.
+func NewSymbol(mangled string, options ...Option) *Symbol {
+	return &Symbol{
+		mangled: mangled,
+		options: options,
+	}
+}
+
+// Mangled returns the mangled name.
+func (s *Symbol) Mangled() string {
+	return s.mangled
+}
+
+// String returns the demangled name, as returned by ToString.
+func (s *Symbol) String() string {
+	s.demangle()
+	return s.str
+}
+
+// Err returns the error from demangling the name, or nil if it can
+// be demangled.
+func (s *Symbol) Err() error {
+	s.demangle()
+	return s.err
+}
+
+// demangle demangles the name, once.
+func (s *Symbol) demangle() {
+	s.strOnce.Do(func() {
+		s.res, s.err = Demangle(s.mangled, s.options...)
+		if s.err != nil {
+			s.str = s.mangled
+		} else {
+			s.str = s.res.Full()
+		}
+		atomic.StoreUint32(&s.done, 1)
+	})
+}
+
+// NoParams returns the demangled name without function parameters,
+// as returned by ToString with the NoParams option. If the full name
+// has not been demangled, as by String, this demangles it with the
+// NoParams option, which stops before the parameters; for the names
+// in the tests that takes about half the time of demangling the
+// full name. As with ToString and NoParams, the result does not
+// depend on whether the parameters can be demangled.
+func (s *Symbol) NoParams() string {
+	s.noParamsOnce.Do(func() {
+		if atomic.LoadUint32(&s.done) != 0 && s.err == nil {
+			s.noParams = s.res.Format(NoParams)
+			return
+		}
+		opts := append(append([]Option(nil), s.options...), NoParams)
+		str, err := ToString(s.mangled, opts...)
+		if err != nil {
+			str = s.mangled
+		}
+		s.noParams = str
+	})
+	return s.noParams
+}
+
+// BaseName returns the unqualified name of the entity, as printed
+// with the BaseNameOnly option.
+func (s *Symbol) BaseName() string {
+	s.baseNameOnce.Do(func() {
+		s.baseName = s.variant(BaseNameOnly)
+	})
+	return s.baseName
+}
+
+// variant returns the demangled name printed with an additional
+// option.
+func (s *Symbol) variant(o Option) string {
+	if s.Err() != nil {
+		return s.mangled
+	}
+	return s.res.Format(o)
+}
+
+// Kind returns the kind of symbol, as in the Kind field of
+// Structured: "function", "data", "special", or "unknown".
+func (s *Symbol) Kind() string {
+	s.kindOnce.Do(func() {
+		s.kind = "unknown"
+		if s.Err() == nil {
+			if st, err := ToStructured(s.mangled, s.options...); err == nil {
+				s.kind = st.Kind
+			}
+		}
+	})
+	return s.kind
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSymbol(t *testing.T) {
+	var tests = []struct {
+		mangled  string
+		str      string
+		noParams string
+		baseName string
+		kind     string
+		err      bool
+	}{
+		{
+			"_ZNSt6vectorIiSaIiEE9push_backERKi",
+			"std::vector<int, std::allocator<int> >::push_back(int const&)",
+			"std::vector<int, std::allocator<int> >::push_back",
+			"push_back",
+			"function",
+			false,
+		},
+		{
+			"_ZN2ns1xE",
+			"ns::x",
+			"ns::x",
+			"x",
+			"data",
+			false,
+		},
+		{
+			"_ZTVN2ns1AE",
+			"vtable for ns::A",
+			"vtable for ns::A",
+			"vtable for A",
+			"special",
+			false,
+		},
+		{
+			"_ZW3foo1fv",
+			"f@foo()",
+			"f@foo",
+			"f",
+			"function",
+			false,
+		},
+		{
+			"_ZN5Outer5InnerW3FOO2FnERNS0_1XE",
+			"Outer::Inner::Fn@FOO(Outer::Inner::X&)",
+			"Outer::Inner::Fn@FOO",
+			"Fn",
+			"function",
+			false,
+		},
+		{
+			"main",
+			"main",
+			"main",
+			"main",
+			"unknown",
+			true,
+		},
+	}
+	for _, test := range tests {
+		s := NewSymbol(test.mangled)
+		// Ask twice to check the cached values.
+		for i := 0; i < 2; i++ {
+			if got := s.Mangled(); got != test.mangled {
+				t.Errorf("%s: Mangled = %q", test.mangled, got)
+			}
+			if got := s.String(); got != test.str {
+				t.Errorf("%s: String = %q, want %q", test.mangled, got, test.str)
+			}
+			if got := s.NoParams(); got != test.noParams {
+				t.Errorf("%s: NoParams = %q, want %q", test.mangled, got, test.noParams)
+			}
+			if got := s.BaseName(); got != test.baseName {
+				t.Errorf("%s: BaseName = %q, want %q", test.mangled, got, test.baseName)
+			}
+			if got := s.Kind(); got != test.kind {
+				t.Errorf("%s: Kind = %q, want %q", test.mangled, got, test.kind)
+			}
+			if err := s.Err(); (err != nil) != test.err {
+				t.Errorf("%s: Err = %v", test.mangled, err)
+			}
+		}
+	}
+}
+
+func TestSymbolNoParams(t *testing.T) {
+	// The parameters of this name can't be demangled, but NoParams
+	// doesn't look at them, whether or not String is called first.
+	const mangled = "_ZN2ns1fEv$"
+	for _, first := range []bool{false, true} {
+		s := NewSymbol(mangled)
+		if first {
+			if got := s.String(); got != mangled {
+				t.Errorf("String = %q, want %q", got, mangled)
+			}
+		}
+		if got, want := s.NoParams(), "ns::f"; got != want {
+			t.Errorf("NoParams after String %t = %q, want %q", first, got, want)
+		}
+		if s.Err() == nil {
+			t.Errorf("Err = nil, want error")
+		}
+	}
+}
+
+// TestSymbolNoParamsExpected checks that for the names in cases
+// NoParams is the same as ToString with NoParams, whether or not
+// String is called first.
+func TestSymbolNoParamsExpected(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range cases {
+		want, err := ToString(test[0], NoParams)
+		if err != nil {
+			want = test[0]
+		}
+		for _, first := range []bool{false, true} {
+			s := NewSymbol(test[0])
+			if first {
+				_ = s.String()
+			}
+			if got := s.NoParams(); got != want {
+				t.Errorf("%s: NoParams after String %t = %q, want %q", test[0], first, got, want)
+			}
+		}
+	}
+}
+
+func TestSymbolConcurrent(t *testing.T) {
+	s := NewSymbol("_ZN1A1fEv")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := s.String(); got != "A::f()" {
+				t.Errorf("String = %q", got)
+			}
+			if got := s.BaseName(); got != "f" {
+				t.Errorf("BaseName = %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strconv"
+
+// TemplateParamBindings returns the template arguments of a C++
+// function or variable template instantiation, keyed by the template
+// parameter that refers to them in the mangled name: T_ for the
+// first argument, T0_ for the second, and so forth, as returned by
+// TemplateParamCode. For "_Z1fIiPcEvT_T0_", which is
+// "void f<int, char*>(int, char*)", it maps T_ to int and T0_ to
+// char*. An argument pack is an *ArgumentPack. For a member of a
+// class template the arguments are those of the member, not the
+// class. Unlike TemplateArgs, this returns the ASTs of the
+// arguments.
+//
+// This is intended for tools that relate the parameter types of a
+// function template to its arguments, and for debugging mangling
+// problems. The arguments may share nodes with each other, and
+// should not be modified. If the name is not a template
+// instantiation, TemplateParamBindings returns an empty map.
+// If the name does not appear to be a C++ symbol name at all, the
+// error will be ErrNotMangledName.
+func TemplateParamBindings(name string, options ...Option) (map[string]AST, error) {
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]AST)
+	if t := instantiatedTemplate(a); t != nil {
+		for i, arg := range t.Args {
+			ret[TemplateParamCode(i)] = arg
+		}
+	}
+	return ret, nil
+}
+
+// TemplateParamCode returns the code that refers to template
+// argument i in a mangled name: T_ for argument 0, T0_ for argument
+// 1, and so forth.
+func TemplateParamCode(i int) string {
+	if i == 0 {
+		return "T_"
+	}
+	return "T" + strconv.Itoa(i-1) + "_"
+}
+
+// instantiatedTemplate returns the template whose parameters the
+// template parameters in the type of a symbol refer to, or nil if
+// there is none. This follows the findTemplate function in
+// (*state).encoding.
+func instantiatedTemplate(a AST) *Template {
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+		case *EnableIf:
+			a = n.Type
+		case *Constraint:
+			a = n.Name
+		case *Typed:
+			a = n.Name
+		case *Template:
+			return n
+		case *Qualified:
+			if n.LocalName {
+				a = n.Name
+			} else if _, ok := n.Name.(*Constructor); ok {
+				a = n.Name
+			} else {
+				return nil
+			}
+		case *MethodWithQualifiers:
+			a = n.Method
+		case *Constructor:
+			if n.Base == nil {
+				return nil
+			}
+			a = n.Base
+		default:
+			return nil
+		}
+	}
+}
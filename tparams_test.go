@@ -0,0 +1,58 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateParamBindings(t *testing.T) {
+	var tests = []struct {
+		name string
+		want map[string]string
+	}{
+		{"_Z1fIiPcEvT_T0_", map[string]string{"T_": "int", "T0_": "char*"}},
+		{"_ZN2ns1fIiEEvT_", map[string]string{"T_": "int"}},
+		{"_ZN1AIcE1fIiEEvT_", map[string]string{"T_": "int"}},
+		{"_ZNK1A1fIlEEvv", map[string]string{"T_": "long"}},
+		{"_Z1fIJidEEvDpT_", map[string]string{"T_": "int, double"}},
+		{"_Z1vIiE", map[string]string{"T_": "int"}},
+		{"_ZZ1fIiEvT_E1x", map[string]string{}},
+		{"_ZZ1fvEN1BIiE1gIcEEvT_", map[string]string{"T_": "char"}},
+		{"_Z1fIiEvT_.cold", map[string]string{"T_": "int"}},
+		{"_Z1fi", map[string]string{}},
+		{"_ZTV1AIiE", map[string]string{}},
+	}
+	for _, test := range tests {
+		args, err := TemplateParamBindings(test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got := make(map[string]string)
+		for k, v := range args {
+			got[k] = ASTToString(v)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if _, err := TemplateParamBindings("_Z1"); err == nil {
+		t.Error("invalid name: got nil, want error")
+	}
+	if _, err := TemplateParamBindings("main"); err != ErrNotMangledName {
+		t.Errorf("unmangled name: got %v, want ErrNotMangledName", err)
+	}
+}
+
+func TestTemplateParamCode(t *testing.T) {
+	for i, want := range []string{"T_", "T0_", "T1_", "T2_", "T3_", "T4_", "T5_", "T6_", "T7_", "T8_", "T9_", "T10_"} {
+		if got := TemplateParamCode(i); got != want {
+			t.Errorf("TemplateParamCode(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// Transform returns a copy of an AST with nodes replaced by fn.
+// The fn function is called for each node after its children have
+// been transformed, so a node passed to fn may be a new copy that
+// refers to the transformed children. If fn returns nil the node is
+// kept; otherwise the node is replaced by the result. The original
+// AST is not changed, and parts of it that don't change may be
+// shared with the result. The result may be printed by ASTToString.
+//
+// A node that appears more than once in the AST, because of a
+// substitution, is transformed the same way each time.
+// Transform doesn't change the template arguments that a
+// TemplateParam refers to.
+func Transform(a AST, fn func(AST) AST) AST {
+	// Record the original nodes, so that we can tell which
+	// nodes were not changed by fn.
+	orig := make(map[AST]bool)
+	a.Traverse(func(n AST) bool {
+		if orig[n] {
+			return false
+		}
+		orig[n] = true
+		return true
+	})
+
+	unchanged := make(map[AST]bool)
+	skip := func(n AST) bool {
+		return unchanged[n]
+	}
+	copy := func(n AST) AST {
+		r := fn(n)
+		if r == nil && orig[n] {
+			unchanged[n] = true
+		}
+		return r
+	}
+	if r := a.Copy(copy, skip); r != nil {
+		return r
+	}
+	return a
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+// renameNamespace returns a function for Transform that renames the
+// namespace from to the namespace to.
+func renameNamespace(from, to string) func(AST) AST {
+	return func(a AST) AST {
+		if n, ok := a.(*Name); ok && n.Name == from {
+			return &Name{Name: to}
+		}
+		return nil
+	}
+}
+
+// dropInline is a function for Transform that removes the std::__1
+// inline namespace.
+func dropInline(a AST) AST {
+	if q, ok := a.(*Qualified); ok {
+		if n, ok := q.Name.(*Name); ok && n.Name == "__1" {
+			return q.Scope
+		}
+	}
+	return nil
+}
+
+func TestTransform(t *testing.T) {
+	var tests = []struct {
+		input string
+		fn    func(AST) AST
+		want  string
+	}{
+		{"_ZN8internal1fENS_1AE", renameNamespace("internal", "pub"), "pub::f(pub::A)"},
+		{"_ZN8internal1fENS_1AES0_", renameNamespace("internal", "pub"), "pub::f(pub::A, pub::A)"},
+		{"_ZN2ns1fEv", renameNamespace("internal", "pub"), "ns::f()"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEE9push_backEOi", dropInline, "std::vector<int, std::allocator<int> >::push_back(int&&)"},
+		{"_ZW3foo1fv", renameNamespace("foo", "bar"), "f@bar()"},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", renameNamespace("Outer", "Top"), "Top::Inner::Fn@FOO(Top::Inner::X&)"},
+	}
+	for _, test := range tests {
+		a, err := ToAST(test.input)
+		if err != nil {
+			t.Errorf("ToAST(%s): unexpected error %v", test.input, err)
+			continue
+		}
+		before := ASTToString(a)
+		got := ASTToString(Transform(a, test.fn))
+		if got != test.want {
+			t.Errorf("Transform(%s) = %q, want %q", test.input, got, test.want)
+		}
+		if after := ASTToString(a); after != before {
+			t.Errorf("Transform(%s) changed original from %q to %q", test.input, before, after)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "unicode/utf8"
+
+// markerOptions looks for the MaxLength option and, in ex, the
+// TruncationMarker field of DemangleOptions. If ex asks for the
+// string to be cut between tokens and there is a maximum length,
+// it returns the maximum length, the marker, and a copy of options
+// with a larger maximum length, so that the caller can tell whether
+// the string was truncated. Otherwise it returns 0.
+func markerOptions(options []Option, ex *extraOptions) (int, string, []Option) {
+	if ex == nil || !ex.truncate {
+		return 0, "", nil
+	}
+	max := 0
+	for _, o := range options {
+		if isMaxLength(o) {
+			max = maxLength(o)
+		}
+	}
+	if max == 0 {
+		return 0, "", nil
+	}
+
+	maxPow := 1
+	for maxPow < 30 && 1<<maxPow <= max {
+		maxPow++
+	}
+	nopts := make([]Option, 0, len(options))
+	for _, o := range options {
+		if isMaxLength(o) {
+			o = MaxLength(maxPow)
+		}
+		nopts = append(nopts, o)
+	}
+	return max, ex.marker, nopts
+}
+
+// truncateAtToken shortens s to at most max bytes, if necessary,
+// cutting it between tokens and appending marker.
+func truncateAtToken(s string, max int, marker string) string {
+	if len(s) <= max {
+		return s
+	}
+	limit := max - len(marker)
+	if limit <= 0 {
+		// The marker doesn't fit.
+		return s[:max]
+	}
+	i := limit
+	for i > 0 && !isTokenBoundary(s, i) {
+		i--
+	}
+	if i == 0 {
+		// A single long token; we have to cut it.
+		i = limit
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+	}
+	for i > 0 && s[i-1] == ' ' {
+		i--
+	}
+	return s[:i] + marker
+}
+
+// isTokenBoundary reports whether s may be cut before s[i].
+func isTokenBoundary(s string, i int) bool {
+	a, b := s[i-1], s[i]
+	if isTokenChar(a) && isTokenChar(b) {
+		return false
+	}
+	if a == ':' && b == ':' {
+		return false
+	}
+	return true
+}
+
+// isTokenChar reports whether c may be part of an identifier or
+// number. Bytes of non-ASCII characters are treated as identifier
+// characters, so that we don't cut inside a character.
+func isTokenChar(c byte) bool {
+	return isLower(c) || isUpper(c) || isDigit(c) || c == '_' || c == '$' || c >= utf8.RuneSelf
+}
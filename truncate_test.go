@@ -0,0 +1,74 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestTruncateAtToken(t *testing.T) {
+	tests := []struct {
+		input  string
+		max    int
+		marker string
+		want   string
+	}{
+		{"std::vector<int>", 16, "...", "std::vector<int>"},
+		{"std::vector<int>", 15, "...", "std::vector<..."},
+		{"std::vector<int>", 10, "...", "std::..."},
+		{"std::vector<int>", 7, "...", "std..."},
+		{"std::vector<int>", 5, "...", "st..."},
+		{"std::vector<int>", 3, "...", "std"},
+		{"std::vector<int>", 10, "", "std::"},
+		{"f(int, char)", 10, "…", "f(int,…"},
+		{"f(int, char)", 8, "…", "f(int…"},
+		{"éééé", 6, "..", "éé.."},
+		{"éééé", 5, "..", "é.."},
+	}
+
+	for _, test := range tests {
+		got := truncateAtToken(test.input, test.max, test.marker)
+		if got != test.want {
+			t.Errorf("truncateAtToken(%q, %d, %q) = %q, want %q", test.input, test.max, test.marker, got, test.want)
+		}
+		if len(got) > test.max {
+			t.Errorf("truncateAtToken(%q, %d, %q) = %q, longer than %d", test.input, test.max, test.marker, got, test.max)
+		}
+	}
+}
+
+func TestTruncationMarker(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  DemangleOptions
+		want  string
+	}{
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 16}, "std::vector<int,"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 16, TruncateAtToken: true, TruncationMarker: "..."}, "std::vector<..."},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 32, TruncateAtToken: true, TruncationMarker: "..."}, "std::vector<int, std::..."},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 64, TruncateAtToken: true, TruncationMarker: "..."}, "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{TruncateAtToken: true, TruncationMarker: "..."}, "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 20, TruncateAtToken: true, TruncationMarker: "..."}, "std::vector<int,..."},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", DemangleOptions{MaxLength: 16, TruncateAtToken: true}, "std::vector<int,"},
+		{"_ZN4core3fmt9Arguments17h1234567890abcdefE", DemangleOptions{MaxLength: 16, TruncateAtToken: true, TruncationMarker: "…"}, "core::fmt::…"},
+		{"_RNvCs1234_4main4main", DemangleOptions{MaxLength: 8, TruncateAtToken: true, TruncationMarker: "~"}, "main::~"},
+		{"?foo@bar@@YAXXZ", DemangleOptions{MaxLength: 8, TruncateAtToken: true, TruncationMarker: "..."}, "void..."},
+	}
+
+	for _, test := range tests {
+		if got, err := ToStringOpts(test.input, test.opts); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s with %+v: got %q, want %q", test.input, test.opts, got, test.want)
+		}
+	}
+
+	a, err := ToAST("_ZNSt6vectorIiSaIiEE9push_backERKi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DemangleOptions{MaxLength: 16, TruncateAtToken: true, TruncationMarker: "..."}
+	if got, want := ASTToStringOpts(a, opts), "std::vector<..."; got != want {
+		t.Errorf("ASTToStringOpts with TruncationMarker: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "strings"
+
+// ReferencesType demangles a C++ symbol name and reports whether it
+// mentions the type typeName, such as "foo::OldThing", anywhere in
+// its parameter types, its return type, or its template arguments,
+// including the template arguments of the enclosing scopes.
+// A type is found inside other types, so "foo::OldThing" is found
+// in "std::vector<foo::OldThing*>", and is found if it is the scope
+// of a type, as in "foo::OldThing::iterator". The name of the symbol
+// itself is not a reference, so the methods of foo::OldThing do not
+// reference it unless they also use it as a type. Names are compared
+// as printed by ToString with the options. If typeName has no
+// template arguments it matches the type with any template
+// arguments, so "std::vector" matches "std::vector<int>". ABI tags
+// are ignored.
+// If the name does not appear to be a C++ symbol name at all,
+// the error will be ErrNotMangledName.
+func ReferencesType(name, typeName string, options ...Option) (bool, error) {
+	options = append([]Option{NoABITags}, options...)
+	a, err := cppToAST(name, options...)
+	if err != nil {
+		return false, err
+	}
+	tr := &typeRefs{
+		typeName: strings.TrimPrefix(strings.TrimSpace(typeName), "::"),
+		options:  options,
+	}
+	if !strings.Contains(tr.typeName, "<") {
+		tr.options = append(tr.options, NoTemplateParams)
+	}
+	return tr.symbol(a), nil
+}
+
+// typeRefs holds the state of ReferencesType.
+type typeRefs struct {
+	typeName string
+	options  []Option
+}
+
+// symbol reports whether the parameters, return type, or template
+// arguments of a symbol mention the type.
+func (tr *typeRefs) symbol(a AST) bool {
+	for {
+		switch n := a.(type) {
+		case *Clone:
+			a = n.Base
+		case *Special:
+			a = n.Val
+		case *Special2:
+			return tr.symbol(n.Val1) || tr.symbol(n.Val2)
+		case *EnableIf:
+			a = n.Type
+		case *Constraint:
+			a = n.Name
+		case *Typed:
+			return tr.args(n.Name) || tr.typ(n.Type)
+		default:
+			return tr.args(a)
+		}
+	}
+}
+
+// args reports whether the template arguments that appear in a name
+// mention the type. For a local name this looks at the enclosing
+// function.
+func (tr *typeRefs) args(a AST) bool {
+	found := false
+	a.Traverse(func(n AST) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *Qualified:
+			if n.LocalName {
+				found = tr.symbol(n.Scope) || tr.args(n.Name)
+				return false
+			}
+		case *Template:
+			for _, arg := range n.Args {
+				if tr.typ(arg) {
+					found = true
+					break
+				}
+			}
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// typ reports whether a type mentions the type.
+func (tr *typeRefs) typ(a AST) bool {
+	found := false
+	a.Traverse(func(n AST) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *Name:
+			found = tr.match(n)
+		case *Template:
+			found = tr.match(n)
+		case *Qualified:
+			if n.LocalName {
+				found = tr.symbol(n.Scope) || tr.args(n.Name)
+				return false
+			}
+			// The last component of the name is only a
+			// reference as part of the qualified name,
+			// but the scope may be a type by itself.
+			found = tr.match(n) || tr.typ(n.Scope) || tr.args(n.Name)
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// match reports whether a is the type.
+func (tr *typeRefs) match(a AST) bool {
+	return ASTToString(a, tr.options...) == tr.typeName
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestReferencesType(t *testing.T) {
+	var tests = []struct {
+		name     string
+		typeName string
+		want     bool
+	}{
+		// f(foo::OldThing const&)
+		{"_Z1fRKN3foo8OldThingE", "foo::OldThing", true},
+		{"_Z1fRKN3foo8OldThingE", "::foo::OldThing", true},
+		{"_Z1fRKN3foo8OldThingE", "OldThing", false},
+		{"_Z1fRKN3foo8OldThingE", "foo", true},
+		{"_Z1fRKN3foo8OldThingE", "foo::Old", false},
+		// foo::OldThing::get() const
+		{"_ZNK3foo8OldThing3getEv", "foo::OldThing", false},
+		// foo::OldThing g<foo::OldThing>()
+		{"_Z1gIN3foo8OldThingEET_v", "foo::OldThing", true},
+		// h(std::vector<foo::OldThing*, std::allocator<foo::OldThing*> >)
+		{"_Z1hSt6vectorIPN3foo8OldThingESaIS2_EE", "foo::OldThing", true},
+		{"_Z1hSt6vectorIPN3foo8OldThingESaIS2_EE", "std::vector", true},
+		{"_Z1hSt6vectorIPN3foo8OldThingESaIS2_EE", "std::allocator<foo::OldThing*>", true},
+		{"_Z1hSt6vectorIPN3foo8OldThingESaIS2_EE", "std::allocator<int>", false},
+		// A<foo::OldThing>::run()
+		{"_ZN1AIN3foo8OldThingEE3runEv", "foo::OldThing", true},
+		// k(foo::OldThing::iterator)
+		{"_Z1kN3foo8OldThing8iteratorE", "foo::OldThing", true},
+		// m(int)::x, a local name in a function
+		{"_ZZ1mN3foo8OldThingEE1x", "foo::OldThing", true},
+		{"_ZZ1miE1x", "foo::OldThing", false},
+		// n(std::string)
+		{"_Z1nSs", "std::string", true},
+		// p(void (*)(foo::OldThing))
+		{"_Z1pPFvN3foo8OldThingEE", "foo::OldThing", true},
+		// Outer::Inner::Fn@FOO(Outer::Inner::X&)
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", "Outer::Inner::X", true},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", "Outer::Inner::Fn", false},
+		// f@foo()
+		{"_ZW3foo1fv", "foo", false},
+	}
+	for _, test := range tests {
+		got, err := ReferencesType(test.name, test.typeName)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if got != test.want {
+			t.Errorf("ReferencesType(%q, %q) = %t, want %t", test.name, test.typeName, got, test.want)
+		}
+	}
+
+	if _, err := ReferencesType("_Z1", "int"); err == nil {
+		t.Error("invalid name: got nil, want error")
+	}
+	if _, err := ReferencesType("main", "int"); err != ErrNotMangledName {
+		t.Errorf("unmangled name: got %v, want ErrNotMangledName", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+// A Visitor is called by Walk for each node of an AST.
+// A Visitor will normally use a type switch on the node to look for
+// the kinds of nodes that it is interested in, such as *Template or
+// *Name.
+type Visitor interface {
+	// Enter is called for a node before walking its children.
+	// If Enter returns false, the children are not walked.
+	Enter(a AST) bool
+
+	// Exit is called for a node after walking its children.
+	// Exit is called for every node for which Enter was called,
+	// even if Enter returned false.
+	Exit(a AST)
+}
+
+// Walk walks an AST in depth-first order, calling v.Enter for each
+// node before walking its children and v.Exit afterward.
+// The children of a node are the nodes visited by its Traverse
+// method. A node that appears more than once in the AST, because
+// of a substitution, is walked each time it appears, but a node is
+// not walked again while walking its own children.
+func Walk(a AST, v Visitor) {
+	w := walker{v: v}
+	w.walk(a)
+}
+
+// walker holds the state of Walk.
+type walker struct {
+	v Visitor
+
+	// The walking field is a list of the nodes we are currently
+	// walking. This avoids endless recursion if a substitution
+	// reference creates a cycle in the graph.
+	walking []AST
+}
+
+// walk walks a and its children.
+func (w *walker) walk(a AST) {
+	for _, n := range w.walking {
+		if n == a {
+			return
+		}
+	}
+	w.walking = append(w.walking, a)
+	defer func() { w.walking = w.walking[:len(w.walking)-1] }()
+
+	if w.v.Enter(a) {
+		// The Traverse method calls the function first for a
+		// itself, and then for each child. We walk each child
+		// ourselves, so that we see when it is finished.
+		self := true
+		a.Traverse(func(c AST) bool {
+			if self {
+				self = false
+				return true
+			}
+			w.walk(c)
+			return false
+		})
+	}
+	w.v.Exit(a)
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"reflect"
+	"testing"
+)
+
+// templateDepth is a Visitor that records the maximum nesting depth
+// of templates, and the names that it sees.
+type templateDepth struct {
+	depth, max int
+	names      []string
+	enters     int
+	exits      int
+}
+
+func (td *templateDepth) Enter(a AST) bool {
+	td.enters++
+	switch a := a.(type) {
+	case *Template:
+		td.depth++
+		if td.depth > td.max {
+			td.max = td.depth
+		}
+	case *Name:
+		td.names = append(td.names, a.Name)
+	}
+	return true
+}
+
+func (td *templateDepth) Exit(a AST) {
+	td.exits++
+	if _, ok := a.(*Template); ok {
+		td.depth--
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var tests = []struct {
+		input string
+		depth int
+		names []string
+	}{
+		{"_Z1fi", 0, []string{"f"}},
+		{"_ZN1AIiE1fEv", 1, []string{"A", "f"}},
+		{"_Z1fI1AIS0_IiEEEvv", 3, []string{"f", "A", "A"}},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", 2, []string{"std", "vector", "std", "allocator", "push_back"}},
+		{"_ZW3foo1fv", 0, []string{"foo", "f"}},
+		{"_ZN5Outer5InnerW3FOO2FnERNS0_1XE", 0, []string{"Outer", "Inner", "FOO", "Fn", "Outer", "Inner", "X"}},
+	}
+	for _, test := range tests {
+		a, err := ToAST(test.input)
+		if err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+			continue
+		}
+		var td templateDepth
+		Walk(a, &td)
+		if td.max != test.depth {
+			t.Errorf("%s: got template depth %d, want %d", test.input, td.max, test.depth)
+		}
+		if !reflect.DeepEqual(td.names, test.names) {
+			t.Errorf("%s: got names %q, want %q", test.input, td.names, test.names)
+		}
+		if td.depth != 0 || td.enters != td.exits {
+			t.Errorf("%s: unbalanced walk: depth %d, %d enters, %d exits", test.input, td.depth, td.enters, td.exits)
+		}
+	}
+}
+
+// skipTemplates is a Visitor that doesn't walk templates.
+type skipTemplates struct {
+	names []string
+	exits int
+}
+
+func (st *skipTemplates) Enter(a AST) bool {
+	if n, ok := a.(*Name); ok {
+		st.names = append(st.names, n.Name)
+	}
+	_, ok := a.(*Template)
+	return !ok
+}
+
+func (st *skipTemplates) Exit(a AST) {
+	if _, ok := a.(*Template); ok {
+		st.exits++
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	a, err := ToAST("_ZN1AIiE1fEPS_IlE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st skipTemplates
+	Walk(a, &st)
+	if want := []string{"f"}; !reflect.DeepEqual(st.names, want) {
+		t.Errorf("got names %q, want %q", st.names, want)
+	}
+	if st.exits != 2 {
+		t.Errorf("got %d template exits, want 2", st.exits)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"strings"
+)
+
+// This file demangles names mangled by the Watcom C++ compiler,
+// such as "W?fn$n(i)v". After the "W?" prefix comes the name, which
+// ends with '$', then any enclosing classes, each of which is ':',
+// the class name, and '$', and finally the type. A function type is
+// a memory model letter, the argument types in parentheses, and the
+// return type.
+//
+// This handles the common cases: functions, variables, and members
+// of classes, including constructors and destructors. Operator
+// names and templates are not supported.
+
+// watcomToString demangles a Watcom C++ symbol.
+func watcomToString(name string, options []Option) (ret string, err error) {
+	if !strings.HasPrefix(name, "W?") {
+		return "", ErrNotMangledName
+	}
+
+	// When the demangling routines encounter an error, they panic
+	// with a value of type Error.
+	defer func() {
+		if r := recover(); r != nil {
+			if de, ok := r.(Error); ok {
+				ret = ""
+				err = de
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	wst := &watcomState{str: name[2:], off: 2, maxDepth: defaultMaxDepth}
+	max := 0
+	for _, o := range options {
+		switch {
+		case o == NoParams:
+			wst.noParams = true
+		case isMaxLength(o):
+			max = maxLength(o)
+		case isMaxDepth(o):
+			wst.maxDepth = optionValue(o)
+		}
+	}
+
+	wst.symbol()
+	if len(wst.str) > 0 {
+		panic(Error{Code: ErrUnparsedSuffix, Msg: "unparsed characters at end of mangled name", Offset: wst.off})
+	}
+
+	s := string(wst.buf)
+	if max > 0 && len(s) > max {
+		s = s[:max]
+	}
+	return s, nil
+}
+
+// A watcomState holds the current state of demangling a Watcom
+// string.
+type watcomState struct {
+	str      string // remainder of string to demangle
+	off      int    // offset of str within original string
+	buf      []byte // the demangled output
+	depth    int    // current nesting depth
+	maxDepth int    // maximum nesting depth
+
+	noParams bool // don't demangle function parameters
+}
+
+// fail panics with Error, to be caught in watcomToString.
+func (wst *watcomState) fail(err string) {
+	panic(Error{Msg: err, Offset: wst.off})
+}
+
+// enter is called when starting to parse a part of a name that may
+// be nested. It fails if the parts are nested too deeply. Each call
+// is matched by a call to leave.
+func (wst *watcomState) enter() {
+	wst.depth++
+	if wst.depth > wst.maxDepth {
+		panic(Error{Code: ErrTooLarge, Msg: "name nested too deeply", Offset: wst.off})
+	}
+}
+
+// leave is called when finishing parsing a part of a name.
+func (wst *watcomState) leave() {
+	wst.depth--
+}
+
+// advance advances the current string offset.
+func (wst *watcomState) advance(add int) {
+	if len(wst.str) < add {
+		panic("internal error")
+	}
+	wst.str = wst.str[add:]
+	wst.off += add
+}
+
+// peek returns the next character, or 0 at the end of the string.
+func (wst *watcomState) peek() byte {
+	if len(wst.str) == 0 {
+		return 0
+	}
+	return wst.str[0]
+}
+
+// writeString adds a string to the output.
+func (wst *watcomState) writeString(s string) {
+	wst.buf = append(wst.buf, s...)
+}
+
+// capture runs f and returns the output that it wrote,
+// removing it from the output.
+func (wst *watcomState) capture(f func()) string {
+	n := len(wst.buf)
+	f()
+	s := string(wst.buf[n:])
+	wst.buf = wst.buf[:n]
+	return s
+}
+
+// upTo returns the string up to the next '$', and advances past
+// the '$'.
+func (wst *watcomState) upTo() string {
+	i := strings.IndexByte(wst.str, '$')
+	if i <= 0 {
+		wst.fail("invalid name")
+	}
+	s := wst.str[:i]
+	wst.advance(i + 1)
+	return s
+}
+
+// symbol parses a complete symbol.
+func (wst *watcomState) symbol() {
+	special := ""
+	var name string
+	switch {
+	case strings.HasPrefix(wst.str, "$ct"):
+		special = "ct"
+		wst.advance(3)
+	case strings.HasPrefix(wst.str, "$dt"):
+		special = "dt"
+		wst.advance(3)
+	default:
+		name = wst.upTo()
+	}
+
+	var scopes []string
+	for wst.peek() == ':' {
+		wst.advance(1)
+		scopes = append(scopes, wst.upTo())
+	}
+
+	switch special {
+	case "ct", "dt":
+		if len(scopes) == 0 {
+			wst.fail("constructor or destructor outside of class")
+		}
+		name = scopes[0]
+		if special == "dt" {
+			name = "~" + name
+		}
+	}
+
+	// The innermost scope comes first.
+	qualifiedName := func() {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			wst.writeString(scopes[i])
+			wst.writeString("::")
+		}
+		wst.writeString(name)
+	}
+
+	model := ""
+	switch wst.peek() {
+	case 'n':
+		model = "near"
+	case 'f':
+		model = "far"
+	}
+	if model != "" && len(wst.str) > 1 && wst.str[1] == '(' {
+		wst.advance(2)
+		args := wst.capture(wst.argList)
+		start := len(wst.buf)
+		if wst.peek() == '_' {
+			// Constructors and destructors have no
+			// return type.
+			wst.advance(1)
+		} else {
+			wst.typ()
+			wst.writeString(" ")
+		}
+		if wst.noParams {
+			wst.buf = wst.buf[:start]
+			qualifiedName()
+			return
+		}
+		wst.writeString(model + " ")
+		qualifiedName()
+		wst.writeString("(" + args + ")")
+		return
+	}
+
+	// A variable, whose memory model we don't print.
+	if model != "" {
+		wst.advance(1)
+	}
+	wst.typ()
+	wst.writeString(" ")
+	qualifiedName()
+}
+
+// argList parses the argument types of a function, up to and
+// including the closing parenthesis.
+func (wst *watcomState) argList() {
+	start := len(wst.buf)
+	n := 0
+	for ; wst.peek() != ')'; n++ {
+		if len(wst.str) == 0 {
+			wst.fail("unterminated argument list")
+		}
+		if n > 0 {
+			wst.writeString(", ")
+		}
+		if wst.peek() == 'e' {
+			wst.advance(1)
+			wst.writeString("...")
+			continue
+		}
+		wst.typ()
+	}
+	wst.advance(1)
+	if n == 1 && string(wst.buf[start:]) == "void" {
+		wst.buf = wst.buf[:start]
+	}
+}
+
+// watcomBuiltinTypes maps a character to a builtin type.
+var watcomBuiltinTypes = map[byte]string{
+	'a': "char",
+	'b': "float",
+	'c': "signed char",
+	'd': "double",
+	'i': "int",
+	'j': "__int64",
+	'l': "long",
+	'q': "bool",
+	's': "short",
+	't': "long double",
+	'v': "void",
+	'w': "wchar_t",
+}
+
+// typ parses a type. Watcom writes qualifiers after what they
+// qualify, as in "char const near *".
+func (wst *watcomState) typ() {
+	// Each modifier is a level of nesting, as is the type itself.
+	defer func(depth int) { wst.depth = depth }(wst.depth)
+	wst.enter()
+
+	var mods []string
+	for {
+		switch c := wst.peek(); c {
+		case 'p', 'r':
+			// A pointer or reference, with its memory model.
+			wst.enter()
+			wst.advance(1)
+			sym := "*"
+			if c == 'r' {
+				sym = "&"
+			}
+			switch wst.peek() {
+			case 'n':
+				wst.advance(1)
+				sym = "near " + sym
+			case 'f':
+				wst.advance(1)
+				sym = "far " + sym
+			case 'h':
+				wst.advance(1)
+				sym = "huge " + sym
+			}
+			mods = append(mods, sym)
+		case 'x':
+			wst.enter()
+			wst.advance(1)
+			mods = append(mods, "const")
+		case 'y':
+			wst.enter()
+			wst.advance(1)
+			mods = append(mods, "volatile")
+		default:
+			// Modifiers are written outermost first, and
+			// printed innermost first.
+			wst.baseType()
+			for i := len(mods) - 1; i >= 0; i-- {
+				wst.writeString(" ")
+				wst.writeString(mods[i])
+			}
+			return
+		}
+	}
+}
+
+// baseType parses a builtin type or a class name.
+func (wst *watcomState) baseType() {
+	c := wst.peek()
+	switch c {
+	case 'u':
+		wst.advance(1)
+		switch wst.peek() {
+		case 'a', 's', 'i', 'l', 'j':
+			wst.writeString("unsigned ")
+			wst.baseType()
+			return
+		}
+		wst.fail("invalid unsigned type")
+	case '$':
+		// A class name, possibly followed by enclosing scopes,
+		// innermost first.
+		wst.advance(1)
+		names := []string{wst.upTo()}
+		for wst.peek() == ':' {
+			wst.advance(1)
+			names = append(names, wst.upTo())
+		}
+		for i := len(names) - 1; i >= 0; i-- {
+			wst.writeString(names[i])
+			if i > 0 {
+				wst.writeString("::")
+			}
+		}
+		return
+	}
+	s, ok := watcomBuiltinTypes[c]
+	if !ok {
+		wst.fail("unrecognized type")
+	}
+	wst.advance(1)
+	wst.writeString(s)
+}
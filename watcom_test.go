@@ -0,0 +1,53 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "testing"
+
+func TestWatcom(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"W?printf$n(pnxae)i", "int near printf(char const near *, ...)"},
+		{"W?foo$n(i)v", "void near foo(int)"},
+		{"W?foo$n()v", "void near foo()"},
+		{"W?f$n(v)v", "void near f()"},
+		{"W?bar$:Foo$n(ua)v", "void near Foo::bar(unsigned char)"},
+		{"W?$ct:Foo$n()_", "near Foo::Foo()"},
+		{"W?$dt:Foo$n()_", "near Foo::~Foo()"},
+		{"W?x$ni", "int x"},
+		{"W?foo$:Bar$:Ns$f(rnx$Bar$:Ns$)l", "long far Ns::Bar::foo(Ns::Bar const near &)"},
+	}
+
+	for _, test := range tests {
+		if got, err := ToString(test.input); err != nil {
+			t.Errorf("demangling %s: unexpected error %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("demangling %s: got %s, want %s", test.input, got, test.want)
+		}
+	}
+
+	if got, err := ToString("W?bar$:Foo$n(ua)v", NoParams); err != nil {
+		t.Errorf("demangling with NoParams: unexpected error %v", err)
+	} else if want := "Foo::bar"; got != want {
+		t.Errorf("demangling with NoParams: got %s, want %s", got, want)
+	}
+}
+
+func TestWatcomFailure(t *testing.T) {
+	for _, input := range []string{
+		"W?",
+		"W?foo",
+		"W?foo$n(i",
+		"W?foo$n(k)v",
+		"W?$ct$n()_",
+		"W?foo$n(uv)v",
+	} {
+		if got, err := ToString(input); err == nil {
+			t.Errorf("demangling %s: got %s, want error", input, got)
+		}
+	}
+}
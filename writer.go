@@ -0,0 +1,87 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import "io"
+
+// flushSize is how much output we collect before writing it out
+// when printing an AST for ToWriter.
+const flushSize = 4096
+
+// ToWriter demangles a symbol name and writes the demangled name to w.
+// What it writes is the same as the string that ToString returns
+// with the same options. It returns an error if the name can not be
+// demangled, in which case nothing is written, or the first error
+// returned by w.
+//
+// For C++ names the demangled name is written while it is being
+// printed, without building the whole string, which for some names
+// is very long.
+func ToWriter(w io.Writer, name string, options ...Option) error {
+	for _, o := range options {
+		if isMaxLength(o) {
+			// The string is short; let ToString
+			// truncate it.
+			s, err := ToString(name, options...)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, s)
+			return err
+		}
+	}
+
+	s, a, err := toStringOrAST(name, options)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		_, err = io.WriteString(w, s)
+		return err
+	}
+	return astToWriter(w, a, options)
+}
+
+// astToWriter writes the string that ASTToString returns to w.
+func astToWriter(w io.Writer, a AST, options []Option) error {
+	a, ps := newPrintState(a, options)
+	if a == nil {
+		return nil
+	}
+	ps.out = w
+	a.print(ps)
+	ps.flush()
+	return ps.outErr
+}
+
+// flush moves the output from ps.buf to ps.out.
+// After a write error, the output is discarded, and printing stops.
+func (ps *printState) flush() {
+	if ps.outErr == nil {
+		_, ps.outErr = ps.buf.WriteTo(ps.out)
+	}
+	ps.flushed += ps.buf.Len()
+	ps.buf.Reset()
+}
+
+// StringLength returns the length in bytes of the string that
+// ToString returns for a symbol name with the same options, or the
+// error that ToString returns. Like ToWriter, for C++ names this
+// does not build the whole string.
+func StringLength(name string, options ...Option) (int, error) {
+	var lw lengthWriter
+	if err := ToWriter(&lw, name, options...); err != nil {
+		return 0, err
+	}
+	return int(lw), nil
+}
+
+// lengthWriter is an io.Writer that counts the bytes written.
+type lengthWriter int
+
+func (lw *lengthWriter) Write(p []byte) (int, error) {
+	*lw += lengthWriter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demangle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// countWriter counts the calls to Write and WriteString.
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	return cw.Buffer.Write(p)
+}
+
+func (cw *countWriter) WriteString(s string) (int, error) {
+	cw.writes++
+	return cw.Buffer.WriteString(s)
+}
+
+// TestToWriter checks that ToWriter writes the string that ToString
+// returns for the names in the test data.
+func TestToWriter(t *testing.T) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	streamed := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "--") {
+			continue
+		}
+		for _, options := range [][]Option{nil, {NoParams}, {MaxLengthBytes(20)}} {
+			want, wantErr := ToString(line, options...)
+			var cw countWriter
+			gotErr := ToWriter(&cw, line, options...)
+			if got := cw.String(); got != want || (gotErr == nil) != (wantErr == nil) {
+				t.Errorf("%s %v: got %q, %v; want %q, %v", line, options, got, gotErr, want, wantErr)
+			}
+			if cw.writes > 1 {
+				streamed = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !streamed {
+		t.Error("no name was written in more than one piece")
+	}
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestToWriterError(t *testing.T) {
+	for _, name := range []string{"_ZN1A1fEv", "_RNvCs1234_4main4main"} {
+		if err := ToWriter(errWriter{}, name); err != errWrite {
+			t.Errorf("ToWriter(%s) = %v, want %v", name, err, errWrite)
+		}
+	}
+	if err := ToWriter(errWriter{}, "_Z"); err == nil || err == errWrite {
+		t.Errorf("ToWriter(_Z) = %v, want demangling error", err)
+	}
+}
+
+func TestStringLength(t *testing.T) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "--") {
+			continue
+		}
+		for _, options := range [][]Option{nil, {NoParams}, {MaxLength(6)}} {
+			s, wantErr := ToString(line, options...)
+			got, gotErr := StringLength(line, options...)
+			if (gotErr == nil) != (wantErr == nil) || (wantErr == nil && got != len(s)) {
+				t.Errorf("%s %v: got %d, %v; want %d, %v", line, options, got, gotErr, len(s), wantErr)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}